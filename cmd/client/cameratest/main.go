@@ -6,6 +6,7 @@ import (
     "time"
 
     "Ceres/pkg/camera"
+    "Ceres/pkg/engine"
     "Ceres/pkg/graphics"
     "Ceres/pkg/input"
     ceresmath "Ceres/pkg/math"
@@ -33,7 +34,7 @@ func main() {
         log.Fatal(err)
     }
 
-    cam := camera.NewCamera(ceresmath.NewVector3(0, 2, 5))
+    cam := camera.NewCamera(ceresmath.NewVector3d(0, 2, 5))
 
     inputHandler := input.NewInputHandler(window.GetHandle())
     inputHandler.SetCursorMode(glfw.CursorDisabled) // Capture cursor for FPS controls
@@ -64,41 +65,42 @@ func main() {
     fmt.Println("  Mouse - Look around")
     fmt.Println("  ESC - Exit")
 
-    lastFrame := time.Now()
     showControls := true
+    var deltaTime float32
+
+    app := engine.AppFuncs{
+        OnUpdate: func(dt float32) {
+            deltaTime = dt
+            processInput(inputHandler, cam, deltaTime, window)
+        },
+        OnRender: func(alpha float32) {
+            window.Clear()
+
+            projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 100.0)
+            view := cam.GetViewMatrix()
+
+            shader.Use()
+            shader.SetMat4("projection", projection.ToPtr())
+            shader.SetMat4("view", view.ToPtr())
+
+            gl.BindVertexArray(vao)
+            for _, cubePos := range cubes {
+                model := ceresmath.TranslateVec(cubePos)
+                shader.SetMat4("model", model.ToPtr())
+                gl.DrawArrays(gl.TRIANGLES, 0, 36)
+            }
+            gl.BindVertexArray(0)
+
+            if showControls && time.Now().Unix()%5 == 0 {
+                fmt.Printf("\rPos: (%.1f, %.1f, %.1f) | Yaw: %.0f° | Pitch: %.0f° | FPS: %.0f",
+                    cam.Position.X, cam.Position.Y, cam.Position.Z,
+                    cam.Yaw, cam.Pitch, 1.0/deltaTime)
+            }
+        },
+    }
 
-    for !window.ShouldClose() {
-        currentFrame := time.Now()
-        deltaTime := float32(currentFrame.Sub(lastFrame).Seconds())
-        lastFrame = currentFrame
-
-        processInput(inputHandler, cam, deltaTime, window)
-
-        window.Clear()
-
-        projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 100.0)
-        view := cam.GetViewMatrix()
-
-        shader.Use()
-        shader.SetMat4("projection", projection.ToPtr())
-        shader.SetMat4("view", view.ToPtr())
-
-        gl.BindVertexArray(vao)
-        for _, cubePos := range cubes {
-            model := ceresmath.TranslateVec(cubePos)
-            shader.SetMat4("model", model.ToPtr())
-            gl.DrawArrays(gl.TRIANGLES, 0, 36)
-        }
-        gl.BindVertexArray(0)
-
-        if showControls && int(currentFrame.Unix())%5 == 0 {
-            fmt.Printf("\rPos: (%.1f, %.1f, %.1f) | Yaw: %.0f° | Pitch: %.0f° | FPS: %.0f",
-                cam.Position.X, cam.Position.Y, cam.Position.Z,
-                cam.Yaw, cam.Pitch, 1.0/deltaTime)
-        }
-
-        window.SwapBuffers()
-        window.PollEvents()
+    if err := engine.NewEngine(app, window).Run(); err != nil {
+        log.Fatal(err)
     }
 
     gl.DeleteVertexArrays(1, &vao)
@@ -7,10 +7,12 @@ import (
 
 	"Ceres/pkg/camera"
 	"Ceres/pkg/chunk"
+	"Ceres/pkg/engine"
 	"Ceres/pkg/graphics"
 	"Ceres/pkg/input"
 	ceresmath "Ceres/pkg/math"
 	"Ceres/pkg/mesh"
+	"Ceres/pkg/sky"
 	"Ceres/pkg/voxel"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -35,8 +37,8 @@ func main() {
         log.Fatal(err)
     }
 
-    cam := camera.NewCamera(ceresmath.NewVector3(chunk.ChunkSize*1.5, chunk.ChunkSize, chunk.ChunkSize*2))
-    cam.LookAt(ceresmath.NewVector3(0, chunk.ChunkSize/2, 0))
+    cam := camera.NewCamera(ceresmath.NewVector3d(chunk.ChunkSize*1.5, chunk.ChunkSize, chunk.ChunkSize*2))
+    cam.LookAt(ceresmath.NewVector3d(0, chunk.ChunkSize/2, 0))
 
     inputHandler := input.NewInputHandler(window.GetHandle())
     inputHandler.SetCursorMode(glfw.CursorDisabled)
@@ -61,52 +63,58 @@ func main() {
     fmt.Println("  F1 - Toggle wireframe mode")
     fmt.Println("  ESC - Exit")
 
-    lastFrame := time.Now()
+    var deltaTime float32
     renderMode := graphics.RenderModeSolid
     voxelCount := 0
 
-    for !window.ShouldClose() {
-        currentFrame := time.Now()
-        deltaTime := float32(currentFrame.Sub(lastFrame).Seconds())
-        lastFrame = currentFrame
+    // The scene is lit by the noon sun and cleared to the sky's horizon
+    skyState := sky.AtTime(sky.Noon)
+    window.SetClearColor(skyState.HorizonColor)
 
-        if processInput(inputHandler, cam, deltaTime, window, &renderMode, cubeRenderer) {
-            break
-        }
-
-        window.Clear()
-
-        projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 500.0)
-        view := cam.GetViewMatrix()
-
-        shader.Use()
-        shader.SetMat4("projection", projection.ToPtr())
-        shader.SetMat4("view", view.ToPtr())
-
-        shader.SetVec3("lightPos", 100.0, 100.0, 100.0)
-        shader.SetVec3("viewPos", cam.Position.X, cam.Position.Y, cam.Position.Z)
-        shader.SetVec3("lightColor", 1.0, 1.0, 1.0)
-        shader.SetInt("useTexture", 0)
-
-        voxelCount = renderChunks(chunkManager, shader, cubeRenderer)
-
-        if int(currentFrame.Unix())%2 == 0 {
-            stats := chunkManager.GetStats()
-            modeStr := "Solid"
-            switch renderMode {
-            case graphics.RenderModeWireframe:
-                modeStr = "Wireframe"
-            case graphics.RenderModeBoth:
-                modeStr = "Both"
+    var eng *engine.Engine
+    app := engine.AppFuncs{
+        OnUpdate: func(dt float32) {
+            deltaTime = dt
+            if processInput(inputHandler, cam, deltaTime, window, &renderMode, cubeRenderer) {
+                eng.Stop()
             }
+        },
+        OnRender: func(alpha float32) {
+            window.Clear()
+
+            projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 500.0)
+            view := cam.GetViewMatrix()
+
+            shader.Use()
+            shader.SetMat4("projection", projection.ToPtr())
+            shader.SetMat4("view", view.ToPtr())
+
+            graphics.SetPointLightUniforms(shader, skyState)
+            shader.SetVec3("viewPos", float32(cam.Position.X), float32(cam.Position.Y), float32(cam.Position.Z))
+            shader.SetInt("useTexture", 0)
+
+            voxelCount = renderChunks(chunkManager, shader, cubeRenderer)
+
+            if int(time.Now().Unix())%2 == 0 {
+                stats := chunkManager.GetStats()
+                modeStr := "Solid"
+                switch renderMode {
+                case graphics.RenderModeWireframe:
+                    modeStr = "Wireframe"
+                case graphics.RenderModeBoth:
+                    modeStr = "Both"
+                }
 
-            fmt.Printf("\rPos: (%.0f, %.0f, %.0f) | Chunks: %d | Voxels: %d | Mode: %s | FPS: %.0f    ",
-                cam.Position.X, cam.Position.Y, cam.Position.Z,
-                stats.LoadedChunks, voxelCount, modeStr, 1.0/deltaTime)
-        }
+                fmt.Printf("\rPos: (%.0f, %.0f, %.0f) | Chunks: %d | Voxels: %d | Mode: %s | FPS: %.0f    ",
+                    cam.Position.X, cam.Position.Y, cam.Position.Z,
+                    stats.LoadedChunks, voxelCount, modeStr, 1.0/deltaTime)
+            }
+        },
+    }
 
-        window.SwapBuffers()
-        window.PollEvents()
+    eng = engine.NewEngine(app, window)
+    if err := eng.Run(); err != nil {
+        log.Fatal(err)
     }
 
     fmt.Println("\n✓ Chunk system demo completed")
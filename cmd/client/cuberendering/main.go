@@ -6,10 +6,12 @@ import (
     "time"
 
     "Ceres/pkg/camera"
+    "Ceres/pkg/engine"
     "Ceres/pkg/graphics"
     "Ceres/pkg/input"
     ceresmath "Ceres/pkg/math"
     "Ceres/pkg/mesh"
+    "Ceres/pkg/sky"
 
     "github.com/go-gl/glfw/v3.3/glfw"
 )
@@ -33,7 +35,7 @@ func main() {
         log.Fatal(err)
     }
 
-    cam := camera.NewCamera(ceresmath.NewVector3(0, 2, 5))
+    cam := camera.NewCamera(ceresmath.NewVector3d(0, 2, 5))
 
     inputHandler := input.NewInputHandler(window.GetHandle())
     inputHandler.SetCursorMode(glfw.CursorDisabled)
@@ -62,59 +64,65 @@ func main() {
     fmt.Println("  F3 - Toggle both modes")
     fmt.Println("  ESC - Exit")
 
-    lastFrame := time.Now()
+    var deltaTime float32
     renderMode := graphics.RenderModeSolid
 
-    for !window.ShouldClose() {
-        currentFrame := time.Now()
-        deltaTime := float32(currentFrame.Sub(lastFrame).Seconds())
-        lastFrame = currentFrame
+    // The scene is lit by the noon sun and cleared to the sky's horizon
+    skyState := sky.AtTime(sky.Noon)
+    window.SetClearColor(skyState.HorizonColor)
 
-        if processInput(inputHandler, cam, deltaTime, window, &renderMode, cubeRenderer) {
-            break
-        }
-
-        window.Clear()
-
-        projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 100.0)
-        view := cam.GetViewMatrix()
+    var eng *engine.Engine
+    app := engine.AppFuncs{
+        OnUpdate: func(dt float32) {
+            deltaTime = dt
+            if processInput(inputHandler, cam, deltaTime, window, &renderMode, cubeRenderer) {
+                eng.Stop()
+            }
+        },
+        OnRender: func(alpha float32) {
+            window.Clear()
 
-        shader.Use()
-        shader.SetMat4("projection", projection.ToPtr())
-        shader.SetMat4("view", view.ToPtr())
+            projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 100.0)
+            view := cam.GetViewMatrix()
 
-        shader.SetVec3("lightPos", 10.0, 10.0, 10.0)
-        shader.SetVec3("viewPos", cam.Position.X, cam.Position.Y, cam.Position.Z)
-        shader.SetVec3("lightColor", 1.0, 1.0, 1.0)
-        shader.SetInt("useTexture", 0)
+            shader.Use()
+            shader.SetMat4("projection", projection.ToPtr())
+            shader.SetMat4("view", view.ToPtr())
 
-        for i, pos := range cubePositions {
-            model := ceresmath.TranslateVec(pos)
+            graphics.SetPointLightUniforms(shader, skyState)
+            shader.SetVec3("viewPos", float32(cam.Position.X), float32(cam.Position.Y), float32(cam.Position.Z))
+            shader.SetInt("useTexture", 0)
 
-            hue := float32(i) / float32(len(cubePositions))
-            r, g, b := hsvToRgb(hue, 0.8, 0.9)
-            shader.SetVec3("objectColor", r, g, b)
+            for i, pos := range cubePositions {
+                model := ceresmath.TranslateVec(pos)
 
-            shader.SetMat4("model", model.ToPtr())
-            cubeRenderer.Render()
-        }
+                hue := float32(i) / float32(len(cubePositions))
+                r, g, b := hsvToRgb(hue, 0.8, 0.9)
+                shader.SetVec3("objectColor", r, g, b)
 
-        if int(currentFrame.Unix())%2 == 0 {
-            modeStr := "Solid"
-            switch renderMode {
-            case graphics.RenderModeWireframe:
-                modeStr = "Wireframe"
-            case graphics.RenderModeBoth:
-                modeStr = "Both"
+                shader.SetMat4("model", model.ToPtr())
+                cubeRenderer.Render()
             }
 
-            fmt.Printf("\rPos: (%.1f, %.1f, %.1f) | Mode: %s | FPS: %.0f    ",
-                cam.Position.X, cam.Position.Y, cam.Position.Z,
-                modeStr, 1.0/deltaTime)
-        }
+            if int(time.Now().Unix())%2 == 0 {
+                modeStr := "Solid"
+                switch renderMode {
+                case graphics.RenderModeWireframe:
+                    modeStr = "Wireframe"
+                case graphics.RenderModeBoth:
+                    modeStr = "Both"
+                }
+
+                fmt.Printf("\rPos: (%.1f, %.1f, %.1f) | Mode: %s | FPS: %.0f    ",
+                    cam.Position.X, cam.Position.Y, cam.Position.Z,
+                    modeStr, 1.0/deltaTime)
+            }
+        },
+    }
 
-        window.SwapBuffers()
-        window.PollEvents()
+    eng = engine.NewEngine(app, window)
+    if err := eng.Run(); err != nil {
+        log.Fatal(err)
     }
 
     fmt.Println("\n✓ Cube rendering test completed")
@@ -6,10 +6,12 @@ import (
 	"time"
 
 	"Ceres/pkg/camera"
+	"Ceres/pkg/engine"
 	"Ceres/pkg/graphics"
 	"Ceres/pkg/input"
 	ceresmath "Ceres/pkg/math"
 	"Ceres/pkg/mesh"
+	"Ceres/pkg/sky"
 	"Ceres/pkg/voxel"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -39,8 +41,8 @@ func main() {
 		log.Fatal(err)
 	}
 
-	cam := camera.NewCamera(ceresmath.NewVector3(15, 10, 15))
-	cam.LookAt(ceresmath.NewVector3(0, 0, 0))
+	cam := camera.NewCamera(ceresmath.NewVector3d(15, 10, 15))
+	cam.LookAt(ceresmath.NewVector3d(0, 0, 0))
 
 	inputHandler := input.NewInputHandler(window.GetHandle())
 	inputHandler.SetCursorMode(glfw.CursorDisabled)
@@ -48,7 +50,7 @@ func main() {
 	worldMesh := buildTestWorld()
 	defer worldMesh.Delete()
 
-	lastFrame := time.Now()
+	var deltaTime float32
 	frameCount := 0
 	fpsTimer := time.Now()
 
@@ -61,44 +63,50 @@ func main() {
 	fmt.Println()
 	printMeshStats(worldMesh)
 
-	for !window.ShouldClose() {
-		currentFrame := time.Now()
-		deltaTime := float32(currentFrame.Sub(lastFrame).Seconds())
-		lastFrame = currentFrame
+	// The scene is lit by the noon sun and cleared to the sky's horizon
+	skyState := sky.AtTime(sky.Noon)
+	window.SetClearColor(skyState.HorizonColor)
 
-		if processInput(inputHandler, cam, deltaTime, window) {
-			break
-		}
+	var eng *engine.Engine
+	app := engine.AppFuncs{
+		OnUpdate: func(dt float32) {
+			deltaTime = dt
+			if processInput(inputHandler, cam, deltaTime, window) {
+				eng.Stop()
+			}
+		},
+		OnRender: func(alpha float32) {
+			window.Clear()
+
+			projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 100.0)
+			view := cam.GetViewMatrix()
+			model := ceresmath.Identity()
+
+			shader.Use()
+			shader.SetMat4("projection", projection.ToPtr())
+			shader.SetMat4("view", view.ToPtr())
+			shader.SetMat4("model", model.ToPtr())
+			graphics.SetPointLightUniforms(shader, skyState)
+			shader.SetVec3("viewPos", float32(cam.Position.X), float32(cam.Position.Y), float32(cam.Position.Z))
+			shader.SetFloat("ambientStrength", 0.3)
+			shader.SetInt("useTexture", 0)
+
+			worldMesh.Draw()
+
+			frameCount++
+			if time.Since(fpsTimer) >= time.Second {
+				fmt.Printf("\rFPS: %d | Vertices: %d | Triangles: %d | Faces Culled: ~%.1f%%",
+					frameCount, worldMesh.GetVertexCount(), worldMesh.GetTriangleCount(),
+					calculateCullingPercentage(worldMesh.GetTriangleCount()))
+				frameCount = 0
+				fpsTimer = time.Now()
+			}
+		},
+	}
 
-		window.Clear()
-
-		projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 100.0)
-		view := cam.GetViewMatrix()
-		model := ceresmath.Identity()
-
-		shader.Use()
-		shader.SetMat4("projection", projection.ToPtr())
-		shader.SetMat4("view", view.ToPtr())
-		shader.SetMat4("model", model.ToPtr())
-		shader.SetVec3("lightPos", 20.0, 20.0, 20.0)
-		shader.SetVec3("viewPos", cam.Position.X, cam.Position.Y, cam.Position.Z)
-		shader.SetVec3("lightColor", 1.0, 1.0, 1.0)
-		shader.SetFloat("ambientStrength", 0.3)
-		shader.SetInt("useTexture", 0)
-
-		worldMesh.Draw()
-
-		window.SwapBuffers()
-		glfw.PollEvents()
-
-		frameCount++
-		if time.Since(fpsTimer) >= time.Second {
-			fmt.Printf("\rFPS: %d | Vertices: %d | Triangles: %d | Faces Culled: ~%.1f%%",
-				frameCount, worldMesh.GetVertexCount(), worldMesh.GetTriangleCount(),
-				calculateCullingPercentage(worldMesh.GetTriangleCount()))
-			frameCount = 0
-			fpsTimer = time.Now()
-		}
+	eng = engine.NewEngine(app, window)
+	if err := eng.Run(); err != nil {
+		log.Fatal(err)
 	}
 	fmt.Println()
 }
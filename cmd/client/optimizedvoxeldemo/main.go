@@ -7,9 +7,12 @@ import (
 
 	"Ceres/pkg/camera"
 	"Ceres/pkg/chunk"
+	"Ceres/pkg/engine"
 	"Ceres/pkg/graphics"
 	"Ceres/pkg/input"
 	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/shadow"
+	"Ceres/pkg/sky"
 	"Ceres/pkg/voxel"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -29,13 +32,25 @@ func main() {
 		log.Fatal(err)
 	}
 
-	shader, err := shaderManager.GetShader("basic")
+	shader, err := shaderManager.GetShader("chunk")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	cam := camera.NewCamera(ceresmath.NewVector3(chunk.ChunkSize*1.5, chunk.ChunkSize, chunk.ChunkSize*2))
-	cam.LookAt(ceresmath.NewVector3(0, chunk.ChunkSize/2, 0))
+	skyRenderer, err := graphics.NewSkyRenderer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer skyRenderer.Delete()
+
+	shadowRenderer, err := graphics.NewShadowRenderer(shadow.DefaultConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shadowRenderer.Delete()
+
+	cam := camera.NewCamera(ceresmath.NewVector3d(chunk.ChunkSize*1.5, chunk.ChunkSize, chunk.ChunkSize*2))
+	cam.LookAt(ceresmath.NewVector3d(0, chunk.ChunkSize/2, 0))
 
 	inputHandler := input.NewInputHandler(window.GetHandle())
 	inputHandler.SetCursorMode(glfw.CursorDisabled)
@@ -73,45 +88,56 @@ func main() {
 	fmt.Println("  Mouse - Look around")
 	fmt.Println("  ESC - Exit")
 
-	lastFrame := time.Now()
+	var deltaTime float32
 
-	for !window.ShouldClose() {
-		currentFrame := time.Now()
-		deltaTime := float32(currentFrame.Sub(lastFrame).Seconds())
-		lastFrame = currentFrame
+	// A day passes in two minutes, starting in the morning
+	const ticksPerSecond = sky.TicksPerDay / 120
+	worldTime := float64(sky.Sunrise + sky.TicksPerDay/24)
+	renderDistance := int32(500 / chunk.ChunkSize)
 
-		if processInput(inputHandler, cam, deltaTime, window) {
-			break
-		}
+	var eng *engine.Engine
+	app := engine.AppFuncs{
+		OnUpdate: func(dt float32) {
+			deltaTime = dt
+			worldTime += float64(dt) * ticksPerSecond
+			if processInput(inputHandler, cam, deltaTime, window) {
+				eng.Stop()
+			}
+		},
+		OnRender: func(alpha float32) {
+			window.Clear()
 
-		window.Clear()
+			projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 500.0)
+			view := cam.GetRelativeViewMatrix()
 
-		projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 500.0)
-		view := cam.GetViewMatrix()
+			state := sky.AtTime(uint64(worldTime))
+			shadowRenderer.Render(chunkRenderer, cam, window.GetAspectRatio(), 0.1, state.LightDirection)
+			skyRenderer.Render(view, projection, state)
 
-		shader.Use()
-		shader.SetMat4("model", ceresmath.Identity().ToPtr())
-		shader.SetMat4("projection", projection.ToPtr())
-		shader.SetMat4("view", view.ToPtr())
+			shader.Use()
+			shader.SetMat4("projection", projection.ToPtr())
+			shader.SetMat4("view", view.ToPtr())
 
-		shader.SetVec3("lightPos", 100.0, 100.0, 100.0)
-		shader.SetVec3("viewPos", cam.Position.X, cam.Position.Y, cam.Position.Z)
-		shader.SetVec3("lightColor", 1.0, 1.0, 1.0)
-		shader.SetInt("useTexture", 0)
-		shader.SetInt("useVertexColor", 1)
+			// Fog hides the chunks at the edge of the render distance
+			graphics.SetSkyUniforms(shader, state, sky.NewFog(state, float32(renderDistance*chunk.ChunkSize)))
+			shadowRenderer.SetUniforms(shader, cam, 1)
 
-		chunkRenderer.RenderAll()
+			frustum := cam.GetFrustum(window.GetAspectRatio(), 0.1, 500.0)
+			chunkRenderer.RenderVisible(shader, cam, frustum, renderDistance)
 
-		renderedChunks, renderedFaces := chunkRenderer.GetStats()
+			renderedChunks, renderedFaces := chunkRenderer.GetStats()
 
-		if int(currentFrame.Unix())%2 == 0 {
-			fmt.Printf("\rPos: (%.0f, %.0f, %.0f) | Chunks: %d | Faces: %d | FPS: %.0f    ",
-				cam.Position.X, cam.Position.Y, cam.Position.Z,
-				renderedChunks, renderedFaces, 1.0/deltaTime)
-		}
+			if int(time.Now().Unix())%2 == 0 {
+				fmt.Printf("\rPos: (%.0f, %.0f, %.0f) | Chunks: %d | Faces: %d | FPS: %.0f    ",
+					cam.Position.X, cam.Position.Y, cam.Position.Z,
+					renderedChunks, renderedFaces, 1.0/deltaTime)
+			}
+		},
+	}
 
-		window.SwapBuffers()
-		window.PollEvents()
+	eng = engine.NewEngine(app, window)
+	if err := eng.Run(); err != nil {
+		log.Fatal(err)
 	}
 
 	fmt.Println("\n✓ Step 10: Optimized voxel rendering completed")
@@ -7,6 +7,7 @@ import (
 
 	"Ceres/pkg/graphics"
 	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/sky"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -115,6 +116,10 @@ func main() {
 
 	rotation := float32(0)
 
+	// The scene is lit by the noon sun and cleared to the sky's horizon
+	skyState := sky.AtTime(sky.Noon)
+	window.SetClearColor(skyState.HorizonColor)
+
 	for !window.ShouldClose() {
 		window.Clear()
 
@@ -130,9 +135,8 @@ func main() {
 		shader.SetMat4("view", view.ToPtr())
 		shader.SetMat4("projection", projection.ToPtr())
 
-		shader.SetVec3("lightPos", 5.0, 5.0, 5.0)
+		graphics.SetPointLightUniforms(shader, skyState)
 		shader.SetVec3("viewPos", 3.0, 3.0, 3.0)
-		shader.SetVec3("lightColor", 1.0, 1.0, 1.0)
 		shader.SetVec3("objectColor", 0.2, 0.7, 1.0)
 		shader.SetInt("useTexture", 0)
 
@@ -6,10 +6,12 @@ import (
     "time"
 
     "Ceres/pkg/camera"
+    "Ceres/pkg/engine"
     "Ceres/pkg/graphics"
     "Ceres/pkg/input"
     ceresmath "Ceres/pkg/math"
     "Ceres/pkg/mesh"
+    "Ceres/pkg/sky"
     "Ceres/pkg/voxel"
 
     "github.com/go-gl/glfw/v3.3/glfw"
@@ -34,8 +36,8 @@ func main() {
         log.Fatal(err)
     }
 
-    cam := camera.NewCamera(ceresmath.NewVector3(8, 6, 12))
-    cam.LookAt(ceresmath.NewVector3(0, 0, 0))
+    cam := camera.NewCamera(ceresmath.NewVector3d(8, 6, 12))
+    cam.LookAt(ceresmath.NewVector3d(0, 0, 0))
 
     inputHandler := input.NewInputHandler(window.GetHandle())
     inputHandler.SetCursorMode(glfw.CursorDisabled)
@@ -56,52 +58,58 @@ func main() {
     fmt.Println("  F1 - Toggle wireframe mode")
     fmt.Println("  ESC - Exit")
 
-    lastFrame := time.Now()
+    var deltaTime float32
     renderMode := graphics.RenderModeSolid
 
-    for !window.ShouldClose() {
-        currentFrame := time.Now()
-        deltaTime := float32(currentFrame.Sub(lastFrame).Seconds())
-        lastFrame = currentFrame
+    // The scene is lit by the noon sun and cleared to the sky's horizon
+    skyState := sky.AtTime(sky.Noon)
+    window.SetClearColor(skyState.HorizonColor)
 
-        if processInput(inputHandler, cam, deltaTime, window, &renderMode, cubeRenderer) {
-            break
-        }
-
-        window.Clear()
-
-        projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 100.0)
-        view := cam.GetViewMatrix()
-
-        shader.Use()
-        shader.SetMat4("projection", projection.ToPtr())
-        shader.SetMat4("view", view.ToPtr())
-
-        // Lighting uniforms
-        shader.SetVec3("lightPos", 10.0, 15.0, 10.0)
-        shader.SetVec3("viewPos", cam.Position.X, cam.Position.Y, cam.Position.Z)
-        shader.SetVec3("lightColor", 1.0, 1.0, 1.0)
-        shader.SetInt("useTexture", 0)
-
-        // Render all voxels
-        renderVoxelWorld(voxelWorld, shader, cubeRenderer)
-
-        if int(currentFrame.Unix())%2 == 0 {
-            modeStr := "Solid"
-            switch renderMode {
-            case graphics.RenderModeWireframe:
-                modeStr = "Wireframe"
-            case graphics.RenderModeBoth:
-                modeStr = "Both"
+    var eng *engine.Engine
+    app := engine.AppFuncs{
+        OnUpdate: func(dt float32) {
+            deltaTime = dt
+            if processInput(inputHandler, cam, deltaTime, window, &renderMode, cubeRenderer) {
+                eng.Stop()
             }
+        },
+        OnRender: func(alpha float32) {
+            window.Clear()
+
+            projection := cam.GetProjectionMatrix(window.GetAspectRatio(), 0.1, 100.0)
+            view := cam.GetViewMatrix()
+
+            shader.Use()
+            shader.SetMat4("projection", projection.ToPtr())
+            shader.SetMat4("view", view.ToPtr())
+
+            // Lighting uniforms
+            graphics.SetPointLightUniforms(shader, skyState)
+            shader.SetVec3("viewPos", float32(cam.Position.X), float32(cam.Position.Y), float32(cam.Position.Z))
+            shader.SetInt("useTexture", 0)
+
+            // Render all voxels
+            renderVoxelWorld(voxelWorld, shader, cubeRenderer)
+
+            if int(time.Now().Unix())%2 == 0 {
+                modeStr := "Solid"
+                switch renderMode {
+                case graphics.RenderModeWireframe:
+                    modeStr = "Wireframe"
+                case graphics.RenderModeBoth:
+                    modeStr = "Both"
+                }
+
+                fmt.Printf("\rPos: (%.1f, %.1f, %.1f) | Voxels: %d | Mode: %s | FPS: %.0f    ",
+                    cam.Position.X, cam.Position.Y, cam.Position.Z,
+                    len(voxelWorld), modeStr, 1.0/deltaTime)
+            }
+        },
+    }
 
-            fmt.Printf("\rPos: (%.1f, %.1f, %.1f) | Voxels: %d | Mode: %s | FPS: %.0f    ",
-                cam.Position.X, cam.Position.Y, cam.Position.Z,
-                len(voxelWorld), modeStr, 1.0/deltaTime)
-        }
-
-        window.SwapBuffers()
-        window.PollEvents()
+    eng = engine.NewEngine(app, window)
+    if err := eng.Run(); err != nil {
+        log.Fatal(err)
     }
 
     fmt.Println("\n✓ Voxel data structure demo completed")
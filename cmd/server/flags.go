@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strconv"
+)
+
+// int32Flag adapts an int32 to flag.Value
+type int32Flag struct {
+	value *int32
+}
+
+func (f int32Flag) String() string {
+	if f.value == nil {
+		return "0"
+	}
+	return strconv.FormatInt(int64(*f.value), 10)
+}
+
+func (f int32Flag) Set(s string) error {
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return err
+	}
+	*f.value = int32(v)
+	return nil
+}
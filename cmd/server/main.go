@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"Ceres/pkg/engine"
+	"Ceres/pkg/server"
+)
+
+func main() {
+	config := server.DefaultConfig("world")
+
+	flag.StringVar(&config.Dir, "world", config.Dir, "world directory")
+	flag.Int64Var(&config.Seed, "seed", config.Seed, "seed for new worlds")
+	flag.TextVar(&config.MeshMode, "mesh", config.MeshMode, "chunk meshing of new worlds, blocky or smooth")
+	flag.StringVar(&config.Address, "addr", config.Address, "listen address, empty to disable networking")
+	flag.IntVar(&config.TickRate, "tps", config.TickRate, "simulation ticks per second")
+	flag.Var(int32Flag{&config.SpawnRadius}, "radius", "spawn area radius in chunks")
+	flag.DurationVar(&config.AutosaveInterval, "autosave", config.AutosaveInterval, "autosave interval, 0 to disable")
+	flag.Parse()
+
+	srv := server.NewServer(config)
+	eng := engine.NewEngine(srv, nil)
+	eng.FixedTimestep = srv.FixedTimestep()
+	eng.MinFrameTime = eng.FixedTimestep
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Println("Shutting down...")
+		eng.Stop()
+	}()
+
+	log.Printf("Starting server for world %q", config.Dir)
+	if err := eng.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
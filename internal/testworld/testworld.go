@@ -0,0 +1,30 @@
+// Package testworld builds the voxel fixtures that tests across the engine
+// share, such as a floor to stand mobs, fluids and falling blocks on.
+package testworld
+
+import (
+	"Ceres/pkg/voxel"
+)
+
+// Writer receives the voxels a fixture places. *chunk.ChunkManager satisfies
+// it; the package does not import chunk so that chunk's own tests can use it.
+type Writer interface {
+	SetVoxel(pos voxel.VoxelPosition, v voxel.Voxel)
+}
+
+// FillBox sets every voxel from min to max, inclusive, to v
+func FillBox(w Writer, min, max voxel.VoxelPosition, v voxel.Voxel) {
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			for z := min.Z; z <= max.Z; z++ {
+				w.SetVoxel(voxel.NewVoxelPosition(x, y, z), v)
+			}
+		}
+	}
+}
+
+// Floor lays a one voxel thick layer of voxelType at height y, spanning from
+// lo to hi, inclusive, on both X and Z
+func Floor(w Writer, y, lo, hi int32, voxelType voxel.VoxelType) {
+	FillBox(w, voxel.NewVoxelPosition(lo, y, lo), voxel.NewVoxelPosition(hi, y, hi), voxel.NewVoxel(voxelType))
+}
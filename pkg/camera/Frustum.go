@@ -4,19 +4,79 @@ import (
 	ceresmath "Ceres/pkg/math"
 )
 
+// Frustum is the volume a camera sees, bounded by six planes facing inwards
 type Frustum struct {
 	Planes [6]Plane
 }
 
+// Plane holds the points p with Normal·p + Distance = 0; points in front of
+// the plane have a positive distance
 type Plane struct {
-	Normal ceresmath.Vector3
+	Normal   ceresmath.Vector3
 	Distance float32
 }
 
+// NewFrustum extracts the frustum planes of a combined projection and view
+// matrix, in the space the view matrix transforms from
+func NewFrustum(viewProjection ceresmath.Matrix4) Frustum {
+	m := viewProjection.Mat4
+	rows := [4][4]float32{}
+	for i := 0; i < 4; i++ {
+		row := m.Row(i)
+		rows[i] = [4]float32{row[0], row[1], row[2], row[3]}
+	}
+
+	var f Frustum
+	for i := 0; i < 3; i++ {
+		f.Planes[i*2] = newPlane(rows[3], rows[i], 1)
+		f.Planes[i*2+1] = newPlane(rows[3], rows[i], -1)
+	}
+	return f
+}
+
+func newPlane(w, row [4]float32, sign float32) Plane {
+	normal := ceresmath.Vector3{X: w[0] + sign*row[0], Y: w[1] + sign*row[1], Z: w[2] + sign*row[2]}
+	distance := w[3] + sign*row[3]
+
+	length := normal.Length()
+	if length == 0 {
+		return Plane{Normal: normal, Distance: distance}
+	}
+	return Plane{Normal: normal.Div(length), Distance: distance / length}
+}
+
+// SignedDistance returns how far a point lies in front of the plane
+func (p Plane) SignedDistance(point ceresmath.Vector3) float32 {
+	return p.Normal.Dot(point) + p.Distance
+}
+
 func (f *Frustum) ContainsPoint(point ceresmath.Vector3) bool {
+	for _, plane := range f.Planes {
+		if plane.SignedDistance(point) < 0 {
+			return false
+		}
+	}
 	return true
 }
 
+// ContainsAABB reports whether a box may be inside the frustum. Boxes near a
+// corner of the frustum can be reported as inside when they are not.
 func (f *Frustum) ContainsAABB(min, max ceresmath.Vector3) bool {
+	for _, plane := range f.Planes {
+		// The corner furthest along the plane normal
+		corner := min
+		if plane.Normal.X > 0 {
+			corner.X = max.X
+		}
+		if plane.Normal.Y > 0 {
+			corner.Y = max.Y
+		}
+		if plane.Normal.Z > 0 {
+			corner.Z = max.Z
+		}
+		if plane.SignedDistance(corner) < 0 {
+			return false
+		}
+	}
 	return true
-}
\ No newline at end of file
+}
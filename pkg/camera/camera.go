@@ -7,7 +7,9 @@ import (
 )
 
 type Camera struct {
-	Position ceresmath.Vector3
+	// Position is kept in double precision so the camera moves smoothly far
+	// from the origin; see GetRelativeViewMatrix
+	Position ceresmath.Vector3d
 
 	// Orientation
 	Yaw float32
@@ -32,7 +34,7 @@ type Camera struct {
 	MaxPitch float32
 }
 
-func NewCamera(position ceresmath.Vector3) *Camera {
+func NewCamera(position ceresmath.Vector3d) *Camera {
 	c := &Camera{
 		Position: position,
 		Yaw:              -90.0,
@@ -50,8 +52,25 @@ func NewCamera(position ceresmath.Vector3) *Camera {
 	return c
 }
 
+// GetViewMatrix returns the view matrix in world space. It loses precision
+// far from the origin; large worlds should render relative to the camera.
 func (c *Camera) GetViewMatrix() ceresmath.Matrix4 {
-	return ceresmath.LookAt(c.Position, c.Position.Add(c.Front), c.Up)
+	position := c.Position.ToVector3()
+	return ceresmath.LookAt(position, position.Add(c.Front), c.Up)
+}
+
+// GetRelativeViewMatrix returns the view matrix for camera-relative rendering:
+// it only rotates, as positions are given relative to the camera, see
+// RelativePosition
+func (c *Camera) GetRelativeViewMatrix() ceresmath.Matrix4 {
+	return ceresmath.LookAt(ceresmath.Zero(), c.Front, c.Up)
+}
+
+// RelativePosition returns a world position relative to the camera. The
+// subtraction is done in double precision, so nearby positions are exact
+// however far the camera is from the origin.
+func (c *Camera) RelativePosition(world ceresmath.Vector3d) ceresmath.Vector3 {
+	return world.RelativeTo(c.Position)
 }
 
 func (c *Camera) GetProjectionMatrix(aspectRatio, near, far float32) ceresmath.Matrix4 {
@@ -63,17 +82,17 @@ func (c *Camera) ProcessKeyboard(direction Direction, deltaTime float32) {
 
 	switch direction {
 	case Forward:
-		c.Position = c.Position.Add(c.Front.Mul(velocity))
+		c.Position = c.Position.Offset(c.Front.Mul(velocity))
 	case Backward:
-		c.Position = c.Position.Sub(c.Front.Mul(velocity))
+		c.Position = c.Position.Offset(c.Front.Mul(-velocity))
 	case Left:
-		c.Position = c.Position.Sub(c.Right.Mul(velocity))
+		c.Position = c.Position.Offset(c.Right.Mul(-velocity))
 	case Right:
-		c.Position = c.Position.Add(c.Right.Mul(velocity))
+		c.Position = c.Position.Offset(c.Right.Mul(velocity))
 	case Up:
-		c.Position = c.Position.Add(c.WorldUp.Mul(velocity))
+		c.Position = c.Position.Offset(c.WorldUp.Mul(velocity))
 	case Down:
-		c.Position = c.Position.Sub(c.WorldUp.Mul(velocity))
+		c.Position = c.Position.Offset(c.WorldUp.Mul(-velocity))
 	}
 }
 
@@ -123,11 +142,14 @@ func (c *Camera) updateCameraVectors() {
 	c.Up = c.Right.Cross(c.Front).Normalize()
 }
 
+// GetFrustum returns the camera's frustum relative to the camera, matching
+// GetRelativeViewMatrix; test positions from RelativePosition against it
 func (c *Camera) GetFrustum(aspectRatio, near, far float32) Frustum {
-	return Frustum{}
+	viewProjection := c.GetProjectionMatrix(aspectRatio, near, far).Mul(c.GetRelativeViewMatrix())
+	return NewFrustum(viewProjection)
 }
 
-func (c *Camera) SetPosition(position ceresmath.Vector3) {
+func (c *Camera) SetPosition(position ceresmath.Vector3d) {
     c.Position = position
 }
 
@@ -141,8 +163,8 @@ func (c *Camera) SetPitch(pitch float32) {
     c.updateCameraVectors()
 }
 
-func (c *Camera) LookAt(target ceresmath.Vector3) {
-    direction := target.Sub(c.Position).Normalize()
+func (c *Camera) LookAt(target ceresmath.Vector3d) {
+    direction := c.RelativePosition(target).Normalize()
     
     c.Yaw = ceresmath.Rad2Deg(float32(math.Atan2(float64(direction.Z), float64(direction.X))))
     c.Pitch = ceresmath.Rad2Deg(float32(math.Asin(float64(direction.Y))))
@@ -0,0 +1,55 @@
+package camera
+
+import (
+	"testing"
+
+	ceresmath "Ceres/pkg/math"
+)
+
+func TestRelativeViewFarFromOrigin(t *testing.T) {
+	for _, far := range []float64{10_000_000, -10_000_000} {
+		cam := NewCamera(ceresmath.NewVector3d(far+0.25, 70.5, far-0.75))
+		target := ceresmath.NewVector3d(far+0.25, 70.5, far-3.25)
+		cam.LookAt(target)
+
+		// The target is 2.5 blocks straight ahead, down -Z in view space
+		got := cam.GetRelativeViewMatrix().MulVec(cam.RelativePosition(target))
+		if !ceresmath.ApproxEqual(got.X, 0) || !ceresmath.ApproxEqual(got.Y, 0) || !ceresmath.ApproxEqual(got.Z, -2.5) {
+			t.Errorf("Expected the target at (0, 0, -2.5) in view space at %f, got %v", far, got)
+		}
+
+		// Moving a little still moves the camera
+		before := cam.Position
+		cam.ProcessKeyboard(Forward, 0.01)
+		if moved := cam.Position.Distance(before); moved < 0.049 || moved > 0.051 {
+			t.Errorf("Expected the camera to move 0.05 blocks at %f, moved %f", far, moved)
+		}
+	}
+}
+
+func TestFrustumCullsBoxesOutsideView(t *testing.T) {
+	cam := NewCamera(ceresmath.NewVector3d(10_000_000, 64, -10_000_000))
+	cam.LookAt(ceresmath.NewVector3d(10_000_010, 64, -10_000_000))
+	frustum := cam.GetFrustum(16.0/9.0, 0.1, 100)
+
+	box := func(world ceresmath.Vector3d) bool {
+		min := cam.RelativePosition(world)
+		return frustum.ContainsAABB(min, min.Add(ceresmath.One()))
+	}
+
+	if !box(ceresmath.NewVector3d(10_000_020, 64, -10_000_000)) {
+		t.Error("Expected a box ahead of the camera to be inside")
+	}
+	if box(ceresmath.NewVector3d(9_999_980, 64, -10_000_000)) {
+		t.Error("Expected a box behind the camera to be outside")
+	}
+	if box(ceresmath.NewVector3d(10_000_200, 64, -10_000_000)) {
+		t.Error("Expected a box beyond the far plane to be outside")
+	}
+	if box(ceresmath.NewVector3d(10_000_005, 64, -10_000_060)) {
+		t.Error("Expected a box far to the side to be outside")
+	}
+	if !frustum.ContainsPoint(ceresmath.Vector3{X: 5}) {
+		t.Error("Expected a point ahead of the camera to be inside")
+	}
+}
@@ -15,9 +15,18 @@ type Chunk struct {
 
 	neighbors [6]*Chunk
 
-	isDirty    bool
-	isModified bool
-	isEmpty    bool
+	tintSource TintSource
+
+	// scheduled and fluidScheduled map local voxel indices to the tick they
+	// are due, see chunk_ticks.go
+	scheduled      map[int]uint64
+	fluidScheduled map[int]uint64
+
+	// dirtySections holds the sections whose mesh is out of date, see
+	// chunk_section.go
+	dirtySections SectionMask
+	isModified    bool
+	isEmpty       bool
 
 	mutex sync.RWMutex
 }
@@ -29,10 +38,10 @@ type ChunkPosition struct {
 // NewChunk creates a new empty chunk at the given position
 func NewChunk(position ChunkPosition) *Chunk {
 	chunk := &Chunk{
-		Position:   position,
-		isDirty:    true,
-		isEmpty:    true,
-		isModified: false,
+		Position:      position,
+		dirtySections: AllSections,
+		isEmpty:       true,
+		isModified:    false,
 	}
 
 	// Initialize with air
@@ -56,8 +65,13 @@ func (c *Chunk) GetVoxel(x, y, z int32) voxel.Voxel {
 }
 
 func (c *Chunk) SetVoxel(x, y, z int32, v voxel.Voxel) {
+	c.replaceVoxel(x, y, z, v)
+}
+
+// replaceVoxel sets a voxel and returns the voxel it replaced and whether it changed
+func (c *Chunk) replaceVoxel(x, y, z int32, v voxel.Voxel) (voxel.Voxel, bool) {
 	if !isValidLocalCoord(x, y, z) {
-		return
+		return voxel.NewVoxel(voxel.VoxelTypeAir), false
 	}
 
 	c.mutex.Lock()
@@ -66,18 +80,57 @@ func (c *Chunk) SetVoxel(x, y, z int32, v voxel.Voxel) {
 	index := localToIndex(x, y, z)
 	oldVoxel := c.voxels[index]
 
-	if oldVoxel.Type != v.Type {
-		c.voxels[index] = v
-		c.isDirty = true
-		c.isModified = true
+	if oldVoxel == v {
+		return oldVoxel, false
+	}
 
-		// Update isEmpty flag
-		if !v.IsAir() {
-			c.isEmpty = false
+	c.voxels[index] = v
+	c.dirtySections |= sectionsAround(x, y, z)
+	c.isModified = true
+
+	// Update isEmpty flag
+	if !v.IsAir() {
+		c.isEmpty = false
+	} else {
+		c.checkIfEmpty()
+	}
+
+	return oldVoxel, true
+}
+
+// setVoxels applies writes that all fall inside this chunk under a single
+// lock and returns the voxels that changed, in write order
+func (c *Chunk) setVoxels(writes []VoxelWrite) []VoxelChange {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var changes []VoxelChange
+	cleared := false
+	for _, write := range writes {
+		x, y, z := VoxelToLocalPosition(write.Position)
+		index := localToIndex(x, y, z)
+		if c.voxels[index] == write.Voxel {
+			continue
+		}
+
+		changes = append(changes, VoxelChange{Position: write.Position, Old: c.voxels[index], New: write.Voxel})
+		c.voxels[index] = write.Voxel
+		c.dirtySections |= sectionsAround(x, y, z)
+		if write.Voxel.IsAir() {
+			cleared = true
 		} else {
-			c.checkIfEmpty()
+			c.isEmpty = false
 		}
 	}
+
+	if len(changes) > 0 {
+		c.isModified = true
+	}
+	if cleared {
+		c.checkIfEmpty()
+	}
+
+	return changes
 }
 
 func (c *Chunk) GetVoxelSafe(x, y, z int32) voxel.Voxel {
@@ -90,24 +143,26 @@ func (c *Chunk) GetVoxelSafe(x, y, z int32) voxel.Voxel {
 	var neighborIndex int
 	var nx, ny, nz int32
 
+	// Only the first out of range axis is resolved here; the neighbour
+	// resolves the rest, so diagonal lookups also work
 	if x < 0 {
 		neighborIndex = int(voxel.VoxelFaceLeft)
-		nx, ny, nz = ChunkSize-1, y, z
+		nx, ny, nz = x+ChunkSize, y, z
 	} else if x >= ChunkSize {
 		neighborIndex = int(voxel.VoxelFaceRight)
-		nx, ny, nz = 0, y, z
+		nx, ny, nz = x-ChunkSize, y, z
 	} else if y < 0 {
 		neighborIndex = int(voxel.VoxelFaceBottom)
-		nx, ny, nz = x, ChunkSize-1, z
+		nx, ny, nz = x, y+ChunkSize, z
 	} else if y >= ChunkSize {
 		neighborIndex = int(voxel.VoxelFaceTop)
-		nx, ny, nz = x, 0, z
+		nx, ny, nz = x, y-ChunkSize, z
 	} else if z < 0 {
 		neighborIndex = int(voxel.VoxelFaceBack)
-		nx, ny, nz = x, y, ChunkSize-1
+		nx, ny, nz = x, y, z+ChunkSize
 	} else {
 		neighborIndex = int(voxel.VoxelFaceFront)
-		nx, ny, nz = x, y, 0
+		nx, ny, nz = x, y, z-ChunkSize
 	}
 
 	c.mutex.RLock()
@@ -115,7 +170,7 @@ func (c *Chunk) GetVoxelSafe(x, y, z int32) voxel.Voxel {
 	c.mutex.RUnlock()
 
 	if neighbor != nil {
-		return neighbor.GetVoxel(nx, ny, nz)
+		return neighbor.GetVoxelSafe(nx, ny, nz)
 	}
 
 	return voxel.NewVoxel(voxel.VoxelTypeAir)
@@ -126,7 +181,7 @@ func (c *Chunk) SetNeighbor(face voxel.VoxelFace, neighbor *Chunk) {
 	defer c.mutex.Unlock()
 
 	c.neighbors[face] = neighbor
-	c.isDirty = true
+	c.dirtySections |= sectionsOnFace(face)
 }
 
 func (c *Chunk) GetNeighbor(face voxel.VoxelFace) *Chunk {
@@ -136,18 +191,65 @@ func (c *Chunk) GetNeighbor(face voxel.VoxelFace) *Chunk {
 	return c.neighbors[face]
 }
 
+// SetTintSource sets the tint applied to tinted voxel types when meshing
+func (c *Chunk) SetTintSource(tintSource TintSource) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.tintSource = tintSource
+	c.dirtySections = AllSections
+}
+
+func (c *Chunk) GetTintSource() TintSource {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.tintSource
+}
+
 func (c *Chunk) IsDirty() bool {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
-	return c.isDirty
+	return c.dirtySections != 0
 }
 
+// SetDirty marks every section dirty, or none
 func (c *Chunk) SetDirty(dirty bool) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.isDirty = dirty
+	if dirty {
+		c.dirtySections = AllSections
+	} else {
+		c.dirtySections = 0
+	}
+}
+
+// IsModified reports whether the chunk changed since it was created, loaded
+// or last saved
+func (c *Chunk) IsModified() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.isModified
+}
+
+func (c *Chunk) SetModified(modified bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.isModified = modified
+}
+
+// takeModified clears the modified flag and returns its previous value
+func (c *Chunk) takeModified() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	modified := c.isModified
+	c.isModified = false
+	return modified
 }
 
 func (c *Chunk) IsEmpty() bool {
@@ -177,16 +279,12 @@ func (c *Chunk) Fill(voxelType voxel.VoxelType) {
 	}
 
 	c.isEmpty = voxelType == voxel.VoxelTypeAir
-	c.isDirty = true
+	c.dirtySections = AllSections
 	c.isModified = true
 }
 
 func (c *Chunk) GetWorldPosition() voxel.VoxelPosition {
-	return voxel.NewVoxelPosition(
-		c.Position.X*ChunkSize,
-		c.Position.Y*ChunkSize,
-		c.Position.Z*ChunkSize,
-	)
+	return c.Position.GetWorldPosition()
 }
 
 func isValidLocalCoord(x, y, z int32) bool {
@@ -0,0 +1,144 @@
+package chunk
+
+import (
+	"math"
+	"sort"
+
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+const (
+	// explosionRayGrid is the number of ray targets along each edge of the
+	// cube whose surface the explosion rays point at
+	explosionRayGrid = 16
+	// explosionStep is the distance between samples along a ray
+	explosionStep = 0.3
+	// explosionFalloff is the intensity lost per step in open air
+	explosionFalloff = 0.225
+)
+
+// Explode casts rays outward from center whose intensity starts at power and
+// is weakened by distance and by the blast resistance of every block they
+// pass through. Blocks a ray reaches with intensity left are removed in one
+// batched edit. It returns the destroyed blocks, ordered by position.
+// Unloaded chunks stop rays and are never created.
+func (cm *ChunkManager) Explode(center ceresmath.Vector3, power float32) []VoxelChange {
+	destroyed := make(map[voxel.VoxelPosition]voxel.Voxel)
+	reader := chunkReader{cm: cm}
+
+	const last = explosionRayGrid - 1
+	for i := 0; i <= last; i++ {
+		for j := 0; j <= last; j++ {
+			for k := 0; k <= last; k++ {
+				if i != 0 && i != last && j != 0 && j != last && k != 0 && k != last {
+					continue
+				}
+
+				direction := ceresmath.Vector3{
+					X: float32(i)/last*2 - 1,
+					Y: float32(j)/last*2 - 1,
+					Z: float32(k)/last*2 - 1,
+				}.Normalize().Mul(explosionStep)
+
+				position := center
+				for intensity := power; intensity > 0; intensity -= explosionFalloff {
+					voxelPos := voxel.NewVoxelPosition(
+						int32(math.Floor(float64(position.X))),
+						int32(math.Floor(float64(position.Y))),
+						int32(math.Floor(float64(position.Z))),
+					)
+
+					v, loaded := reader.get(voxelPos)
+					if !loaded {
+						break
+					}
+					if !v.IsAir() {
+						props, _ := voxel.GetVoxelProperties(v.Type)
+						intensity -= (props.BlastResistance + explosionStep) * explosionStep
+						if intensity > 0 {
+							destroyed[voxelPos] = v
+						}
+					}
+
+					position = position.Add(direction)
+				}
+			}
+		}
+	}
+
+	return cm.removeVoxels(destroyed)
+}
+
+// CarveSphere removes every block whose centre lies within radius of the
+// centre of the center voxel, regardless of blast resistance, in one batched
+// edit. It returns the removed blocks, ordered by position.
+func (cm *ChunkManager) CarveSphere(center voxel.VoxelPosition, radius float32) []VoxelChange {
+	removed := make(map[voxel.VoxelPosition]voxel.Voxel)
+	reader := chunkReader{cm: cm}
+
+	r := int32(math.Ceil(float64(radius)))
+	radiusSq := radius * radius
+	for dx := -r; dx <= r; dx++ {
+		for dy := -r; dy <= r; dy++ {
+			for dz := -r; dz <= r; dz++ {
+				if float32(dx*dx+dy*dy+dz*dz) > radiusSq {
+					continue
+				}
+
+				pos := center.Add(voxel.NewVoxelPosition(dx, dy, dz))
+				if v, loaded := reader.get(pos); loaded && !v.IsAir() {
+					removed[pos] = v
+				}
+			}
+		}
+	}
+
+	return cm.removeVoxels(removed)
+}
+
+// removeVoxels replaces the voxels with air in one batch and returns the changes
+func (cm *ChunkManager) removeVoxels(voxels map[voxel.VoxelPosition]voxel.Voxel) []VoxelChange {
+	changes := make([]VoxelChange, 0, len(voxels))
+	for pos, v := range voxels {
+		changes = append(changes, VoxelChange{Position: pos, Old: v, New: voxel.NewVoxel(voxel.VoxelTypeAir)})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i].Position, changes[j].Position
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.X < b.X
+	})
+
+	writes := make([]VoxelWrite, len(changes))
+	for i, change := range changes {
+		writes[i] = VoxelWrite{Position: change.Position, Voxel: change.New}
+	}
+	cm.SetVoxels(writes)
+
+	return changes
+}
+
+// chunkReader reads voxels without creating chunks, remembering the last
+// chunk looked up since consecutive reads usually hit the same one
+type chunkReader struct {
+	cm    *ChunkManager
+	chunk *Chunk
+}
+
+func (cr *chunkReader) get(pos voxel.VoxelPosition) (voxel.Voxel, bool) {
+	chunkPos := VoxelToChunkPosition(pos)
+	if cr.chunk == nil || cr.chunk.Position != chunkPos {
+		cr.chunk = cr.cm.GetChunkIfExists(chunkPos)
+		if cr.chunk == nil {
+			return voxel.Voxel{}, false
+		}
+	}
+
+	x, y, z := VoxelToLocalPosition(pos)
+	return cr.chunk.GetVoxel(x, y, z), true
+}
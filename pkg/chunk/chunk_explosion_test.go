@@ -0,0 +1,89 @@
+package chunk
+
+import (
+	"testing"
+
+	"Ceres/internal/testworld"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+func TestExplodeRespectsBlastResistance(t *testing.T) {
+	cm := NewChunkManager()
+	stone := voxel.NewVoxelPosition(12, 10, 10)
+	dirt := voxel.NewVoxelPosition(8, 10, 10)
+	cm.SetVoxel(stone, voxel.NewVoxel(voxel.VoxelTypeStone))
+	cm.SetVoxel(dirt, voxel.NewVoxel(voxel.VoxelTypeDirt))
+
+	changes := 0
+	cm.AddVoxelChangeListener(func(change VoxelChange) { changes++ })
+
+	destroyed := cm.Explode(ceresmath.Vector3{X: 10.5, Y: 10.5, Z: 10.5}, 2)
+
+	if len(destroyed) != 1 || destroyed[0].Position != dirt || destroyed[0].Old.Type != voxel.VoxelTypeDirt {
+		t.Fatalf("Expected only the dirt block to be destroyed, got %v", destroyed)
+	}
+	if !cm.GetVoxel(dirt).IsAir() || cm.GetVoxel(stone).Type != voxel.VoxelTypeStone {
+		t.Error("Expected the dirt to be removed and the stone to survive")
+	}
+	if changes != 1 {
+		t.Errorf("Expected 1 change notification, got %d", changes)
+	}
+}
+
+func TestExplodeIsBoundedAndBatched(t *testing.T) {
+	cm := NewChunkManager()
+	testworld.FillBox(cm, voxel.NewVoxelPosition(-12, -12, -12), voxel.NewVoxelPosition(12, 12, 12), voxel.NewVoxel(voxel.VoxelTypeDirt))
+	for _, c := range cm.GetLoadedChunks() {
+		c.SetDirty(false)
+	}
+
+	destroyed := cm.Explode(ceresmath.Vector3{X: 0.5, Y: 0.5, Z: 0.5}, 4)
+	if len(destroyed) == 0 {
+		t.Fatal("Expected blocks to be destroyed")
+	}
+
+	// Rays lose at least explosionFalloff per step, which bounds their reach
+	reach := float32(4/explosionFalloff*explosionStep) + 1
+	for i, change := range destroyed {
+		p := change.Position
+		d := ceresmath.Vector3{X: float32(p.X), Y: float32(p.Y), Z: float32(p.Z)}.Length()
+		if d > reach {
+			t.Errorf("Destroyed block %v beyond the blast reach", p)
+		}
+		if i > 0 && destroyed[i-1].Position == p {
+			t.Errorf("Block %v reported twice", p)
+		}
+		if !cm.GetVoxel(p).IsAir() {
+			t.Errorf("Expected %v to be removed", p)
+		}
+	}
+
+	if dirty := cm.GetStats().DirtyChunks; dirty != 8 {
+		t.Errorf("Expected the 8 chunks around the origin to be dirty, got %d", dirty)
+	}
+}
+
+func TestExplodeDoesNotCreateChunks(t *testing.T) {
+	cm := NewChunkManager()
+	cm.SetVoxel(voxel.NewVoxelPosition(1, 1, 1), voxel.NewVoxel(voxel.VoxelTypeDirt))
+
+	cm.Explode(ceresmath.Vector3{X: 1.5, Y: 1.5, Z: 1.5}, 8)
+
+	if len(cm.GetLoadedChunks()) != 1 {
+		t.Errorf("Expected 1 loaded chunk, got %d", len(cm.GetLoadedChunks()))
+	}
+}
+
+func TestCarveSphere(t *testing.T) {
+	cm := NewChunkManager()
+	testworld.FillBox(cm, voxel.NewVoxelPosition(0, 0, 0), voxel.NewVoxelPosition(10, 10, 10), voxel.NewVoxel(voxel.VoxelTypeBrick))
+
+	removed := cm.CarveSphere(voxel.NewVoxelPosition(5, 5, 5), 1)
+	if len(removed) != 7 {
+		t.Errorf("Expected 7 removed blocks, got %d", len(removed))
+	}
+	if cm.GetVoxel(voxel.NewVoxelPosition(6, 6, 5)).Type != voxel.VoxelTypeBrick {
+		t.Error("Expected blocks outside the radius to remain")
+	}
+}
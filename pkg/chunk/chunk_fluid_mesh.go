@@ -0,0 +1,106 @@
+package chunk
+
+import (
+	"math"
+
+	"Ceres/pkg/voxel"
+)
+
+// addFluidVoxels meshes the fluid voxels from min, inclusive, to max, exclusive
+func (s *ChunkSnapshot) addFluidVoxels(mesh *ChunkMesh, min, max [3]int32) {
+	for x := min[0]; x < max[0]; x++ {
+		for y := min[1]; y < max[1]; y++ {
+			for z := min[2]; z < max[2]; z++ {
+				if v := s.GetVoxel(x, y, z); s.props.IsFluid(v) {
+					s.addFluidVoxel(mesh, x, y, z, v)
+				}
+			}
+		}
+	}
+}
+
+// addFluidVoxel meshes a fluid voxel whose top surface slopes towards
+// neighbouring columns with lower fluid levels
+func (s *ChunkSnapshot) addFluidVoxel(mesh *ChunkMesh, x, y, z int32, v voxel.Voxel) {
+	var heights [2][2]float32
+	for cx := int32(0); cx < 2; cx++ {
+		for cz := int32(0); cz < 2; cz++ {
+			heights[cx][cz] = s.fluidCornerHeight(x+cx, y, z+cz, v.Type)
+		}
+	}
+
+	position := s.GetWorldPosition().Add(voxel.NewVoxelPosition(x, y, z))
+	color := s.getVoxelColor(position, v.Type)
+	above := s.GetVoxel(x, y+1, z)
+	full := heights[0][0] == 1 && heights[1][0] == 1 && heights[1][1] == 1 && heights[0][1] == 1
+
+	for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+		if face == voxel.VoxelFaceTop {
+			if above.Type == v.Type || (full && s.props.IsOpaque(above)) {
+				continue
+			}
+		} else if !s.isFaceVisible(x, y, z, face) {
+			continue
+		}
+
+		vertices := getFaceVertices(face)
+		var positions [4][3]float32
+		var uvs [4][2]float32
+		for i, vertex := range vertices {
+			height := vertex[1]
+			if height == 1 {
+				height = heights[int(vertex[0])][int(vertex[2])]
+			}
+			positions[i] = [3]float32{
+				float32(x) + vertex[0],
+				float32(y) + height,
+				float32(z) + vertex[2],
+			}
+			uvs[i] = [2]float32{vertex[3], vertex[4]}
+		}
+
+		normal := voxel.GetFaceNormal(face)
+		faceNormal := [3]float32{normal.X, normal.Y, normal.Z}
+		if face == voxel.VoxelFaceTop {
+			faceNormal = fluidSurfaceNormal(heights)
+		}
+
+		mesh.addVertexQuad(positions, uvs, face, v.Type, faceNormal, color, unoccluded)
+	}
+}
+
+// fluidCornerHeight returns the surface height at the corner shared by the
+// four columns around (x, z), averaged over the columns holding the fluid.
+// A corner touching fluid that continues upwards is full height.
+func (s *ChunkSnapshot) fluidCornerHeight(x, y, z int32, fluidType voxel.VoxelType) float32 {
+	total, count := float32(0), 0
+	for dx := int32(-1); dx <= 0; dx++ {
+		for dz := int32(-1); dz <= 0; dz++ {
+			neighbor := s.GetVoxel(x+dx, y, z+dz)
+			if neighbor.Type != fluidType {
+				continue
+			}
+			if s.GetVoxel(x+dx, y+1, z+dz).Type == fluidType {
+				return 1
+			}
+			total += neighbor.FluidHeight()
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0
+	}
+	return total / float32(count)
+}
+
+// fluidSurfaceNormal returns the normal of a top surface with the given
+// corner heights, from the cross product of its diagonals
+func fluidSurfaceNormal(heights [2][2]float32) [3]float32 {
+	nx := (heights[0][1] - heights[1][0]) - (heights[1][1] - heights[0][0])
+	ny := float32(2)
+	nz := -((heights[0][1] - heights[1][0]) + (heights[1][1] - heights[0][0]))
+
+	length := float32(math.Sqrt(float64(nx*nx + ny*ny + nz*nz)))
+	return [3]float32{nx / length, ny / length, nz / length}
+}
@@ -0,0 +1,112 @@
+package chunk
+
+import (
+	"Ceres/pkg/voxel"
+)
+
+// greedyFaceAxes returns the axis along the face normal followed by the two
+// axes spanning the face plane (0 = X, 1 = Y, 2 = Z)
+func greedyFaceAxes(face voxel.VoxelFace) (d, u, v int) {
+	switch face {
+	case voxel.VoxelFaceTop, voxel.VoxelFaceBottom:
+		return 1, 0, 2
+	case voxel.VoxelFaceLeft, voxel.VoxelFaceRight:
+		return 0, 2, 1
+	default:
+		return 2, 0, 1
+	}
+}
+
+// buildGreedyMesh merges visible faces of the same voxel type lying in the
+// same slice into rectangles, one quad per rectangle. Tinted types take the
+// tint of the quad's first voxel. Packed meshes only merge faces with the same
+// ambient occlusion. Fluids and shaped blocks are meshed per voxel.
+// Only voxels from min, inclusive, to max, exclusive, are meshed.
+func (s *ChunkSnapshot) buildGreedyMesh(mesh *ChunkMesh, min, max [3]int32) *ChunkMesh {
+	if s.IsEmpty() {
+		return mesh
+	}
+
+	worldPos := s.GetWorldPosition()
+	// mask holds the voxel type of each visible face in the low byte and, for
+	// packed meshes, its packed ambient occlusion above it; zero means no face
+	var mask [ChunkSize * ChunkSize]uint32
+
+	for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+		d, u, v := greedyFaceAxes(face)
+
+		for slice := min[d]; slice < max[d]; slice++ {
+			empty := true
+			for j := min[v]; j < max[v]; j++ {
+				for i := min[u]; i < max[u]; i++ {
+					var local [3]int32
+					local[d], local[u], local[v] = slice, i, j
+
+					mask[i+j*ChunkSize] = 0
+					current := s.GetVoxel(local[0], local[1], local[2])
+					if current.IsAir() || s.props.IsFluid(current) || s.props.Shape(current) != voxel.ShapeCube || !s.isFaceVisible(local[0], local[1], local[2], face) {
+						continue
+					}
+					key := uint32(current.Type)
+					if mesh.Format == VertexFormatPacked {
+						key |= packAO(s.faceAO(local[0], local[1], local[2], face)) << 8
+					}
+					mask[i+j*ChunkSize] = key
+					empty = false
+				}
+			}
+			if empty {
+				continue
+			}
+
+			for j := min[v]; j < max[v]; j++ {
+				for i := min[u]; i < max[u]; {
+					key := mask[i+j*ChunkSize]
+					if key == 0 {
+						i++
+						continue
+					}
+
+					width := int32(1)
+					for i+width < max[u] && mask[i+width+j*ChunkSize] == key {
+						width++
+					}
+
+					height := int32(1)
+				grow:
+					for j+height < max[v] {
+						for k := int32(0); k < width; k++ {
+							if mask[i+k+(j+height)*ChunkSize] != key {
+								break grow
+							}
+						}
+						height++
+					}
+
+					for h := int32(0); h < height; h++ {
+						for k := int32(0); k < width; k++ {
+							mask[i+k+(j+h)*ChunkSize] = 0
+						}
+					}
+
+					var origin, extent [3]int32
+					origin[d], origin[u], origin[v] = slice, i, j
+					extent[d], extent[u], extent[v] = 1, width, height
+
+					voxelType := voxel.VoxelType(key & 0xff)
+					position := worldPos.Add(voxel.NewVoxelPosition(origin[0], origin[1], origin[2]))
+					color := s.getVoxelColor(position, voxelType)
+					extentPos := voxel.NewVoxelPosition(extent[0], extent[1], extent[2])
+					mesh.addColoredQuad(position, extentPos, face, voxelType, color, unpackAO(key>>8))
+
+					i += width
+				}
+			}
+		}
+	}
+
+	s.addFluidVoxels(mesh, min, max)
+	s.addShapedVoxels(mesh, min, max)
+
+	return mesh
+}
@@ -7,10 +7,39 @@ import (
 	"Ceres/pkg/voxel"
 )
 
+// VoxelWrite is a single voxel change at a world position
+type VoxelWrite struct {
+	Position voxel.VoxelPosition
+	Voxel    voxel.Voxel
+}
+
+// VoxelChange describes a voxel that changed through the manager
+type VoxelChange struct {
+	Position voxel.VoxelPosition
+	Old      voxel.Voxel
+	New      voxel.Voxel
+}
+
+// VoxelChangeListener is called after a voxel changes through SetVoxel or
+// SetVoxels, outside of any chunk lock
+type VoxelChangeListener func(change VoxelChange)
+
+// ChunkLoadListener is called after a chunk is inserted or finishes
+// generating, as its voxels appear without going through SetVoxel
+type ChunkLoadListener func(pos ChunkPosition)
+
 type ChunkManager struct {
 	chunks map[ChunkPosition]*Chunk
 	mutex  sync.RWMutex
 
+	tintSource    TintSource
+	listeners     []VoxelChangeListener
+	loadListeners []ChunkLoadListener
+
+	// Generation state, see chunk_pending.go
+	generated map[ChunkPosition]bool
+	pending   map[ChunkPosition][]VoxelWrite
+
 	// Statistics
 	totalChunks  int
 	loadedChunks int
@@ -19,7 +48,9 @@ type ChunkManager struct {
 
 func NewChunkManager() *ChunkManager {
 	return &ChunkManager{
-		chunks: make(map[ChunkPosition]*Chunk),
+		chunks:    make(map[ChunkPosition]*Chunk),
+		generated: make(map[ChunkPosition]bool),
+		pending:   make(map[ChunkPosition][]VoxelWrite),
 	}
 }
 
@@ -44,6 +75,7 @@ func (cm *ChunkManager) CreateChunk(pos ChunkPosition) *Chunk {
 	}
 
 	chunk := NewChunk(pos)
+	chunk.tintSource = cm.tintSource
 	cm.chunks[pos] = chunk
 	cm.totalChunks++
 	cm.loadedChunks++
@@ -53,6 +85,34 @@ func (cm *ChunkManager) CreateChunk(pos ChunkPosition) *Chunk {
 	return chunk
 }
 
+// InsertChunk adds a chunk created elsewhere, such as one loaded from disk,
+// replacing any chunk at the same position and linking it to its neighbours
+func (cm *ChunkManager) InsertChunk(chunk *Chunk) {
+	cm.mutex.Lock()
+	if _, exists := cm.chunks[chunk.Position]; !exists {
+		cm.totalChunks++
+		cm.loadedChunks++
+	}
+
+	chunk.SetTintSource(cm.tintSource)
+	cm.chunks[chunk.Position] = chunk
+	cm.setupNeighbors(chunk)
+	cm.mutex.Unlock()
+
+	cm.notifyChunkLoaded(chunk.Position)
+}
+
+// SetTintSource sets the tint source of every loaded and future chunk
+func (cm *ChunkManager) SetTintSource(tintSource TintSource) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.tintSource = tintSource
+	for _, chunk := range cm.chunks {
+		chunk.SetTintSource(tintSource)
+	}
+}
+
 func (cm *ChunkManager) setupNeighbors(chunk *Chunk) {
 	for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
 		neighborPos := chunk.Position.GetNeighborPosition(face)
@@ -79,47 +139,127 @@ func (cm *ChunkManager) SetVoxel(voxelPos voxel.VoxelPosition, v voxel.Voxel) {
 	chunk := cm.GetChunk(chunkPos)
 
 	x, y, z := VoxelToLocalPosition(voxelPos)
-	chunk.SetVoxel(x, y, z, v)
+	old, changed := chunk.replaceVoxel(x, y, z, v)
+	if !changed {
+		return
+	}
 
-	cm.markAdjacentChunksDirty(voxelPos)
+	cm.markNeighborSectionsDirty(neighborSections(voxelPos))
+	cm.notifyListeners([]VoxelChange{{Position: voxelPos, Old: old, New: v}})
 }
 
-func (cm *ChunkManager) markAdjacentChunksDirty(voxelPos voxel.VoxelPosition) {
-	x, y, z := VoxelToLocalPosition(voxelPos)
-	chunkPos := VoxelToChunkPosition(voxelPos)
-
-	if x == 0 {
-		if neighbor := cm.GetChunkIfExists(chunkPos.GetNeighborPosition(voxel.VoxelFaceLeft)); neighbor != nil {
-			neighbor.SetDirty(true)
+// SetVoxels applies a batch of writes, locking each affected chunk once and
+// marking the sections around the changes dirty once per chunk
+func (cm *ChunkManager) SetVoxels(writes []VoxelWrite) {
+	var order []ChunkPosition
+	byChunk := make(map[ChunkPosition][]VoxelWrite)
+	for _, write := range writes {
+		chunkPos := VoxelToChunkPosition(write.Position)
+		if _, seen := byChunk[chunkPos]; !seen {
+			order = append(order, chunkPos)
 		}
+		byChunk[chunkPos] = append(byChunk[chunkPos], write)
 	}
-	if x == ChunkSize-1 {
-		if neighbor := cm.GetChunkIfExists(chunkPos.GetNeighborPosition(voxel.VoxelFaceRight)); neighbor != nil {
-			neighbor.SetDirty(true)
+
+	var changes []VoxelChange
+	borders := make(map[ChunkPosition]SectionMask)
+	for _, chunkPos := range order {
+		chunkChanges := cm.GetChunk(chunkPos).setVoxels(byChunk[chunkPos])
+		for _, change := range chunkChanges {
+			for neighborPos, sections := range neighborSections(change.Position) {
+				borders[neighborPos] |= sections
+			}
 		}
+		changes = append(changes, chunkChanges...)
 	}
-	if y == 0 {
-		if neighbor := cm.GetChunkIfExists(chunkPos.GetNeighborPosition(voxel.VoxelFaceBottom)); neighbor != nil {
-			neighbor.SetDirty(true)
-		}
+
+	cm.markNeighborSectionsDirty(borders)
+	cm.notifyListeners(changes)
+}
+
+// AddVoxelChangeListener registers a listener for voxel changes
+func (cm *ChunkManager) AddVoxelChangeListener(listener VoxelChangeListener) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.listeners = append(cm.listeners, listener)
+}
+
+// AddChunkLoadListener registers a listener for inserted and generated chunks
+func (cm *ChunkManager) AddChunkLoadListener(listener ChunkLoadListener) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.loadListeners = append(cm.loadListeners, listener)
+}
+
+func (cm *ChunkManager) notifyChunkLoaded(pos ChunkPosition) {
+	cm.mutex.RLock()
+	listeners := cm.loadListeners
+	cm.mutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener(pos)
 	}
-	if y == ChunkSize-1 {
-		if neighbor := cm.GetChunkIfExists(chunkPos.GetNeighborPosition(voxel.VoxelFaceTop)); neighbor != nil {
-			neighbor.SetDirty(true)
-		}
+}
+
+func (cm *ChunkManager) notifyListeners(changes []VoxelChange) {
+	if len(changes) == 0 {
+		return
 	}
-	if z == 0 {
-		if neighbor := cm.GetChunkIfExists(chunkPos.GetNeighborPosition(voxel.VoxelFaceBack)); neighbor != nil {
-			neighbor.SetDirty(true)
+
+	cm.mutex.RLock()
+	listeners := cm.listeners
+	cm.mutex.RUnlock()
+
+	for _, change := range changes {
+		for _, listener := range listeners {
+			listener(change)
 		}
 	}
-	if z == ChunkSize-1 {
-		if neighbor := cm.GetChunkIfExists(chunkPos.GetNeighborPosition(voxel.VoxelFaceFront)); neighbor != nil {
-			neighbor.SetDirty(true)
+}
+
+// markNeighborSectionsDirty marks sections of loaded chunks dirty, see
+// neighborSections
+func (cm *ChunkManager) markNeighborSectionsDirty(sections map[ChunkPosition]SectionMask) {
+	for neighborPos, mask := range sections {
+		if neighbor := cm.GetChunkIfExists(neighborPos); neighbor != nil {
+			neighbor.MarkSectionsDirty(mask)
 		}
 	}
 }
 
+// GetVoxelIfLoaded returns the voxel at a world position and whether its
+// chunk is loaded, without creating the chunk
+func (cm *ChunkManager) GetVoxelIfLoaded(voxelPos voxel.VoxelPosition) (voxel.Voxel, bool) {
+	chunk := cm.GetChunkIfExists(VoxelToChunkPosition(voxelPos))
+	if chunk == nil {
+		return voxel.NewVoxel(voxel.VoxelTypeAir), false
+	}
+
+	x, y, z := VoxelToLocalPosition(voxelPos)
+	return chunk.GetVoxel(x, y, z), true
+}
+
+// CollisionBoxes returns the model boxes of the voxel at a world position,
+// relative to the voxel, and whether its chunk is loaded. Neighbours in
+// unloaded chunks count as air.
+func (cm *ChunkManager) CollisionBoxes(voxelPos voxel.VoxelPosition) ([]voxel.Box, bool) {
+	v, loaded := cm.GetVoxelIfLoaded(voxelPos)
+	if !loaded {
+		return nil, false
+	}
+	return voxel.CurrentPropertyTable().Boxes(v, cm.neighborFunc(voxelPos)), true
+}
+
+// neighborFunc looks up the neighbours of a world position in loaded chunks
+func (cm *ChunkManager) neighborFunc(voxelPos voxel.VoxelPosition) voxel.NeighborFunc {
+	return func(face voxel.VoxelFace) voxel.Voxel {
+		v, _ := cm.GetVoxelIfLoaded(voxelPos.Add(voxel.GetFaceOffset(face)))
+		return v
+	}
+}
+
 func (cm *ChunkManager) GetChunkIfExists(pos ChunkPosition) *Chunk {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
@@ -140,6 +280,7 @@ func (cm *ChunkManager) UnloadChunk(pos ChunkPosition) {
 		}
 
 		delete(cm.chunks, pos)
+		delete(cm.generated, pos)
 		cm.loadedChunks--
 	}
 }
@@ -4,51 +4,111 @@ import (
 	"Ceres/pkg/voxel"
 )
 
+// ChunkMesh holds the triangles of a chunk. Vertex positions are relative to
+// Origin, so they stay small and exact in float32 however far the chunk is
+// from the world origin; the renderer adds Origin back, relative to the camera.
 type ChunkMesh struct {
+	// Format selects whether vertices are stored in Vertices or Packed
+	Format VertexFormat
+
 	Vertices []float32
 
+	// Packed holds two words per vertex for VertexFormatPacked, see PackVertex
+	Packed []uint32
+	Origin voxel.VoxelPosition
+
 	Indices []uint32
 
 	VertexCount int
 
 	IndexCount int
 
-	VAO uint32
-	VBO uint32
-	EBO uint32
+	// Connectivity is computed with the mesh for occlusion culling, see
+	// VisibilityGraph
+	Connectivity FaceConnectivity
 }
 
-func NewChunkMesh() *ChunkMesh {
+// NewChunkMesh creates an empty mesh with vertices relative to origin
+func NewChunkMesh(origin voxel.VoxelPosition) *ChunkMesh {
 	return &ChunkMesh{
 		Vertices: make([]float32, 0, 4096),
+		Origin:   origin,
 		Indices:  make([]uint32, 0, 4096),
 	}
 }
 
+// NewPackedChunkMesh creates an empty packed mesh for the chunk whose
+// minimum corner is at origin
+func NewPackedChunkMesh(origin voxel.VoxelPosition) *ChunkMesh {
+	return &ChunkMesh{
+		Format:  VertexFormatPacked,
+		Packed:  make([]uint32, 0, 1024),
+		Origin:  origin,
+		Indices: make([]uint32, 0, 4096),
+	}
+}
+
 func (cm *ChunkMesh) AddFace(position voxel.VoxelPosition, face voxel.VoxelFace, voxelType voxel.VoxelType) {
+	cm.AddQuad(position, voxel.NewVoxelPosition(1, 1, 1), face, voxelType)
+}
+
+// AddQuad adds a face spanning extent voxels along each axis of the face plane.
+// Position is in world space and the extent along the face normal must be 1.
+// Texture coordinates repeat once per voxel.
+func (cm *ChunkMesh) AddQuad(position, extent voxel.VoxelPosition, face voxel.VoxelFace, voxelType voxel.VoxelType) {
+	cm.addColoredQuad(position, extent, face, voxelType, getVoxelTypeColor(voxelType), unoccluded)
+}
+
+// addColoredQuad adds an axis-aligned face. Float meshes use the colour,
+// packed meshes the type and per-corner ambient occlusion.
+func (cm *ChunkMesh) addColoredQuad(position, extent voxel.VoxelPosition, face voxel.VoxelFace, voxelType voxel.VoxelType, color [3]float32, ao [4]uint8) {
 	normal := voxel.GetFaceNormal(face)
 	vertices := getFaceVertices(face)
+	uScale, vScale := getFaceUVScale(face, extent)
+	// Subtracting in integers keeps far away positions exact
+	position = position.Sub(cm.Origin)
+
+	var positions [4][3]float32
+	var uvs [4][2]float32
+	for i := 0; i < 4; i++ {
+		positions[i] = [3]float32{
+			float32(position.X) + vertices[i][0]*float32(extent.X),
+			float32(position.Y) + vertices[i][1]*float32(extent.Y),
+			float32(position.Z) + vertices[i][2]*float32(extent.Z),
+		}
+		uvs[i] = [2]float32{vertices[i][3] * uScale, vertices[i][4] * vScale}
+	}
+
+	cm.addVertexQuad(positions, uvs, face, voxelType, [3]float32{normal.X, normal.Y, normal.Z}, color, ao)
+}
 
-	color := getVoxelTypeColor(voxelType)
+// addVertexQuad adds a quad from explicit corner positions relative to the
+// mesh origin, for faces that are not axis aligned such as sloped fluid surfaces. Packed meshes keep only
+// the face, so sloped quads take the normal of the face they belong to.
+func (cm *ChunkMesh) addVertexQuad(positions [4][3]float32, uvs [4][2]float32, face voxel.VoxelFace, voxelType voxel.VoxelType, normal, color [3]float32, ao [4]uint8) {
+	if cm.Format == VertexFormatPacked {
+		cm.addPackedQuad(positions, uvs, face, voxelType, ao)
+		return
+	}
 
 	baseIndex := uint32(len(cm.Vertices) / 11)
 
 	for i := 0; i < 4; i++ {
 		cm.Vertices = append(cm.Vertices,
-			float32(position.X)+vertices[i][0],
-			float32(position.Y)+vertices[i][1],
-			float32(position.Z)+vertices[i][2],
+			positions[i][0],
+			positions[i][1],
+			positions[i][2],
 		)
 
 		cm.Vertices = append(cm.Vertices,
-			float32(normal.X),
-			float32(normal.Y),
-			float32(normal.Z),
+			normal[0],
+			normal[1],
+			normal[2],
 		)
 
 		cm.Vertices = append(cm.Vertices,
-			vertices[i][3],
-			vertices[i][4],
+			uvs[i][0],
+			uvs[i][1],
 		)
 
 		cm.Vertices = append(cm.Vertices,
@@ -69,6 +129,7 @@ func (cm *ChunkMesh) AddFace(position voxel.VoxelPosition, face voxel.VoxelFace,
 
 func (cm *ChunkMesh) Clear() {
 	cm.Vertices = cm.Vertices[:0]
+	cm.Packed = cm.Packed[:0]
 	cm.Indices = cm.Indices[:0]
 	cm.VertexCount = 0
 	cm.IndexCount = 0
@@ -127,70 +188,140 @@ func getFaceVertices(face voxel.VoxelFace) [4][5]float32 {
 	}
 }
 
-func getVoxelTypeColor(voxelType voxel.VoxelType) [3]float32 {
-	switch voxelType {
-	case voxel.VoxelTypeStone:
-		return [3]float32{0.5, 0.5, 0.5} // Gray
-	case voxel.VoxelTypeDirt:
-		return [3]float32{0.55, 0.35, 0.2} // Brown
-	case voxel.VoxelTypeGrass:
-		return [3]float32{0.2, 0.8, 0.2} // Green
-	case voxel.VoxelTypeSand:
-		return [3]float32{0.95, 0.9, 0.6} // Sand yellow
-	case voxel.VoxelTypeWater:
-		return [3]float32{0.2, 0.4, 0.9} // Blue
-	case voxel.VoxelTypeWood:
-		return [3]float32{0.6, 0.4, 0.2} // Wood brown
-	case voxel.VoxelTypeLeaves:
-		return [3]float32{0.15, 0.6, 0.15} // Dark green
-	case voxel.VoxelTypeGlass:
-		return [3]float32{0.7, 0.9, 1.0} // Light blue
-	case voxel.VoxelTypeBrick:
-		return [3]float32{0.7, 0.3, 0.2} // Red brick
+// getFaceUVScale returns how far the U and V texture coordinates of a face
+// stretch for a quad of the given extent
+func getFaceUVScale(face voxel.VoxelFace, extent voxel.VoxelPosition) (float32, float32) {
+	switch face {
+	case voxel.VoxelFaceTop, voxel.VoxelFaceBottom:
+		return float32(extent.X), float32(extent.Z)
+	case voxel.VoxelFaceLeft, voxel.VoxelFaceRight:
+		return float32(extent.Z), float32(extent.Y)
 	default:
-		return [3]float32{1.0, 1.0, 1.0} // White
+		return float32(extent.X), float32(extent.Y)
 	}
 }
 
+func getVoxelTypeColor(voxelType voxel.VoxelType) [3]float32 {
+	if props, exists := voxel.GetVoxelProperties(voxelType); exists {
+		return props.Color
+	}
+	return [3]float32{1.0, 1.0, 1.0} // White
+}
+
+// TintSource supplies colour multipliers for tinted voxel types, such as a
+// biome map colouring grass
+type TintSource interface {
+	TintAt(pos voxel.VoxelPosition) [3]float32
+}
+
+// getVoxelColor returns the colour of a voxel at a world position, applying
+// the chunk's tint source to tinted types
+func (s *ChunkSnapshot) getVoxelColor(position voxel.VoxelPosition, voxelType voxel.VoxelType) [3]float32 {
+	props, exists := s.props.Get(voxelType)
+	if !exists {
+		return [3]float32{1.0, 1.0, 1.0}
+	}
+
+	if !props.Tinted || s.tintSource == nil {
+		return props.Color
+	}
+
+	tint := s.tintSource.TintAt(position)
+	color := props.Color
+	for i := range color {
+		color[i] *= tint[i]
+		if color[i] > 1 {
+			color[i] = 1
+		}
+	}
+	return color
+}
+
+// GenerateMesh clears the dirty flag and builds the chunk mesh. Edits made
+// while the mesh is built mark the chunk dirty again.
 func (c *Chunk) GenerateMesh() *ChunkMesh {
-	mesh := NewChunkMesh()
+	c.SetDirty(false)
+	return c.BuildMesh(false)
+}
+
+// BuildMesh builds the chunk mesh without clearing the dirty flag.
+// With greedy set, adjacent faces of the same type are merged into larger quads.
+func (c *Chunk) BuildMesh(greedy bool) *ChunkMesh {
+	return c.Snapshot().BuildMesh(greedy)
+}
+
+// BuildMesh builds the mesh of the snapshot's chunk, see Chunk.BuildMesh
+func (s *ChunkSnapshot) BuildMesh(greedy bool) *ChunkMesh {
+	return s.buildMesh(NewChunkMesh(s.GetWorldPosition()), greedy)
+}
 
-	if c.IsEmpty() {
+// buildMesh adds the chunk's faces to an empty mesh of either vertex format
+func (s *ChunkSnapshot) buildMesh(mesh *ChunkMesh, greedy bool) *ChunkMesh {
+	mesh.Connectivity = s.ComputeConnectivity()
+	return s.buildRegion(mesh, [3]int32{}, [3]int32{ChunkSize, ChunkSize, ChunkSize}, greedy)
+}
+
+// buildRegion adds the faces of the voxels from min, inclusive, to max,
+// exclusive, to a mesh
+func (s *ChunkSnapshot) buildRegion(mesh *ChunkMesh, min, max [3]int32, greedy bool) *ChunkMesh {
+	if greedy {
+		return s.buildGreedyMesh(mesh, min, max)
+	}
+
+	if s.IsEmpty() {
 		return mesh
 	}
 
-	for x := int32(0); x < ChunkSize; x++ {
-		for y := int32(0); y < ChunkSize; y++ {
-			for z := int32(0); z < ChunkSize; z++ {
-				currentVoxel := c.GetVoxel(x, y, z)
+	for x := min[0]; x < max[0]; x++ {
+		for y := min[1]; y < max[1]; y++ {
+			for z := min[2]; z < max[2]; z++ {
+				currentVoxel := s.GetVoxel(x, y, z)
 
 				if currentVoxel.IsAir() {
 					continue
 				}
+				if s.props.IsFluid(currentVoxel) {
+					s.addFluidVoxel(mesh, x, y, z, currentVoxel)
+					continue
+				}
+				if s.props.Shape(currentVoxel) != voxel.ShapeCube {
+					s.addShapedVoxel(mesh, x, y, z, currentVoxel)
+					continue
+				}
 
 				for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
-					if c.isFaceVisible(x, y, z, face) {
-						position := c.GetWorldPosition().Add(voxel.NewVoxelPosition(x, y, z))
-						mesh.AddFace(position, face, currentVoxel.Type)
+					if s.isFaceVisible(x, y, z, face) {
+						position := s.GetWorldPosition().Add(voxel.NewVoxelPosition(x, y, z))
+						color := s.getVoxelColor(position, currentVoxel.Type)
+						ao := unoccluded
+						if mesh.Format == VertexFormatPacked {
+							ao = s.faceAO(x, y, z, face)
+						}
+						mesh.addColoredQuad(position, voxel.NewVoxelPosition(1, 1, 1), face, currentVoxel.Type, color, ao)
 					}
 				}
 			}
 		}
 	}
 
-	c.SetDirty(false)
-
 	return mesh
 }
 
-func (c *Chunk) isFaceVisible(x, y, z int32, face voxel.VoxelFace) bool {
+// isFaceVisible reports whether a face on the boundary of a voxel can be seen,
+// which it cannot when the neighbour covers the whole face
+func (s *ChunkSnapshot) isFaceVisible(x, y, z int32, face voxel.VoxelFace) bool {
 	offset := voxel.GetFaceOffset(face)
 
 	nx := x + offset.X
 	ny := y + offset.Y
 	nz := z + offset.Z
 
-	neighbor := c.GetVoxelSafe(nx, ny, nz)
+	neighbor := s.GetVoxel(nx, ny, nz)
+
+	// Faces between voxels of the same fluid are inside the body of fluid
+	if s.props.IsFluid(neighbor) && neighbor.Type == s.GetVoxel(x, y, z).Type {
+		return false
+	}
 
-	return neighbor.IsAir() || neighbor.IsTransparent()
+	return !s.props.Hides(neighbor, getOppositeFace(face))
 }
@@ -0,0 +1,41 @@
+package chunk
+
+import (
+	"testing"
+)
+
+func TestMeshIsStableFarFromOrigin(t *testing.T) {
+	near := terrainChunk(NewChunkPosition(0, 0, 0))
+	for _, greedy := range []bool{false, true} {
+		want := near.BuildMesh(greedy)
+		wantPacked := near.BuildPackedMesh(greedy)
+
+		// Chunks around 10 million voxels out, where float32 world
+		// coordinates can only represent whole voxels
+		for _, far := range []int32{312500, -312500} {
+			c := terrainChunk(NewChunkPosition(far, 2, -far))
+			mesh := c.BuildMesh(greedy)
+			if mesh.Origin != c.GetWorldPosition() {
+				t.Errorf("Expected the mesh origin at %v, got %v", c.GetWorldPosition(), mesh.Origin)
+			}
+			if len(mesh.Vertices) != len(want.Vertices) {
+				t.Fatalf("Expected %d floats at chunk %d, got %d", len(want.Vertices), far, len(mesh.Vertices))
+			}
+			for i := range want.Vertices {
+				if mesh.Vertices[i] != want.Vertices[i] {
+					t.Fatalf("Expected float %d at chunk %d to be %f, got %f", i, far, want.Vertices[i], mesh.Vertices[i])
+				}
+			}
+
+			packed := c.BuildPackedMesh(greedy)
+			if len(packed.Packed) != len(wantPacked.Packed) {
+				t.Fatalf("Expected %d packed words at chunk %d, got %d", len(wantPacked.Packed), far, len(packed.Packed))
+			}
+			for i := range wantPacked.Packed {
+				if packed.Packed[i] != wantPacked.Packed[i] {
+					t.Fatalf("Expected packed word %d at chunk %d to match the chunk at the origin", i, far)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package chunk
+
+import (
+	"math"
+
+	"Ceres/pkg/voxel"
+)
+
+// VertexFormat selects how a ChunkMesh stores its vertices
+type VertexFormat uint8
+
+const (
+	// VertexFormatFloat stores 11 floats per vertex: position, normal, UV and colour
+	VertexFormatFloat VertexFormat = iota
+	// VertexFormatPacked stores two uint32 words per vertex, see PackVertex
+	VertexFormatPacked
+)
+
+const (
+	// PackedWordsPerVertex is the number of uint32 words in a packed vertex
+	PackedWordsPerVertex = 2
+	// PackedPositionScale is the number of position steps per voxel, enough for
+	// fractional fluid heights
+	PackedPositionScale = 16
+	// MaxAO is the ambient occlusion of a corner with nothing around it
+	MaxAO = 3
+	// MaxLight is the brightest packed light level
+	MaxLight = 15
+)
+
+// Packed vertex layout. The first word holds the chunk-relative position in
+// 10 bits per axis; the second holds the attributes.
+const (
+	packedPositionBits = 10
+	packedPositionMask = 1<<packedPositionBits - 1
+
+	packedFaceShift  = 0
+	packedFaceMask   = 0x7
+	packedAOShift    = 3
+	packedAOMask     = 0x3
+	packedLightShift = 5
+	packedLightMask  = 0xf
+	packedUShift     = 9
+	packedVShift     = 15
+	packedUVMask     = 0x3f
+	packedTypeShift  = 21
+	packedTypeMask   = 0xff
+)
+
+// unoccluded is the ambient occlusion of a face with no neighbours
+var unoccluded = [4]uint8{MaxAO, MaxAO, MaxAO, MaxAO}
+
+// PackedVertex is one vertex in the packed format
+type PackedVertex [PackedWordsPerVertex]uint32
+
+// VertexData is an unpacked vertex. Position is relative to the chunk origin
+// and is rounded to 1/PackedPositionScale of a voxel, from 0 to ChunkSize.
+// U and V range from 0 to 63, AO from 0 to MaxAO and Light from 0 to MaxLight;
+// larger values are truncated to their bits.
+type VertexData struct {
+	Position [3]float32
+	Face     voxel.VoxelFace
+	U, V     uint8
+	AO       uint8
+	Light    uint8
+	Type     voxel.VoxelType
+}
+
+// PackVertex packs a vertex into two words
+func PackVertex(d VertexData) PackedVertex {
+	var position uint32
+	for axis := 0; axis < 3; axis++ {
+		step := uint32(math.Round(float64(d.Position[axis] * PackedPositionScale)))
+		position |= (step & packedPositionMask) << (axis * packedPositionBits)
+	}
+
+	attributes := uint32(d.Face)&packedFaceMask<<packedFaceShift |
+		uint32(d.AO)&packedAOMask<<packedAOShift |
+		uint32(d.Light)&packedLightMask<<packedLightShift |
+		uint32(d.U)&packedUVMask<<packedUShift |
+		uint32(d.V)&packedUVMask<<packedVShift |
+		uint32(d.Type)&packedTypeMask<<packedTypeShift
+
+	return PackedVertex{position, attributes}
+}
+
+// Unpack returns the vertex's fields
+func (p PackedVertex) Unpack() VertexData {
+	var d VertexData
+	for axis := 0; axis < 3; axis++ {
+		step := p[0] >> (axis * packedPositionBits) & packedPositionMask
+		d.Position[axis] = float32(step) / PackedPositionScale
+	}
+
+	d.Face = voxel.VoxelFace(p[1] >> packedFaceShift & packedFaceMask)
+	d.AO = uint8(p[1] >> packedAOShift & packedAOMask)
+	d.Light = uint8(p[1] >> packedLightShift & packedLightMask)
+	d.U = uint8(p[1] >> packedUShift & packedUVMask)
+	d.V = uint8(p[1] >> packedVShift & packedUVMask)
+	d.Type = voxel.VoxelType(p[1] >> packedTypeShift & packedTypeMask)
+	return d
+}
+
+// PackedVertex returns the i-th vertex of a packed mesh
+func (cm *ChunkMesh) PackedVertex(i int) PackedVertex {
+	return PackedVertex{cm.Packed[i*PackedWordsPerVertex], cm.Packed[i*PackedWordsPerVertex+1]}
+}
+
+// SizeInBytes returns the memory used by the mesh's vertices and indices
+func (cm *ChunkMesh) SizeInBytes() int {
+	return len(cm.Vertices)*4 + len(cm.Packed)*4 + len(cm.Indices)*4
+}
+
+// BuildPackedMesh builds the chunk mesh in the packed vertex format, with
+// per-corner ambient occlusion, without clearing the dirty flag
+func (c *Chunk) BuildPackedMesh(greedy bool) *ChunkMesh {
+	return c.Snapshot().BuildPackedMesh(greedy)
+}
+
+// BuildPackedMesh builds the packed mesh of the snapshot's chunk
+func (s *ChunkSnapshot) BuildPackedMesh(greedy bool) *ChunkMesh {
+	return s.buildMesh(NewPackedChunkMesh(s.GetWorldPosition()), greedy)
+}
+
+// addPackedQuad appends a quad in the packed format. The quad is split along
+// the diagonal with the brighter corners so occlusion interpolates evenly.
+func (cm *ChunkMesh) addPackedQuad(positions [4][3]float32, uvs [4][2]float32, face voxel.VoxelFace, voxelType voxel.VoxelType, ao [4]uint8) {
+	baseIndex := uint32(len(cm.Packed) / PackedWordsPerVertex)
+
+	for i := 0; i < 4; i++ {
+		vertex := PackVertex(VertexData{
+			Position: positions[i],
+			Face:     face,
+			U:        uint8(math.Round(float64(uvs[i][0]))),
+			V:        uint8(math.Round(float64(uvs[i][1]))),
+			AO:       ao[i],
+			Light:    MaxLight,
+			Type:     voxelType,
+		})
+		cm.Packed = append(cm.Packed, vertex[0], vertex[1])
+	}
+
+	if int(ao[0])+int(ao[2]) < int(ao[1])+int(ao[3]) {
+		cm.Indices = append(cm.Indices,
+			baseIndex+1, baseIndex+2, baseIndex+3,
+			baseIndex+3, baseIndex+0, baseIndex+1,
+		)
+	} else {
+		cm.Indices = append(cm.Indices,
+			baseIndex+0, baseIndex+1, baseIndex+2,
+			baseIndex+2, baseIndex+3, baseIndex+0,
+		)
+	}
+
+	cm.VertexCount += 4
+	cm.IndexCount += 6
+}
+
+// faceAO returns the ambient occlusion of each corner of a voxel face, in the
+// order of getFaceVertices, from the opaque voxels in front of the face
+func (s *ChunkSnapshot) faceAO(x, y, z int32, face voxel.VoxelFace) [4]uint8 {
+	_, u, v := greedyFaceAxes(face)
+	offset := voxel.GetFaceOffset(face)
+	front := [3]int32{x + offset.X, y + offset.Y, z + offset.Z}
+
+	var ao [4]uint8
+	for i, vertex := range getFaceVertices(face) {
+		side1, side2 := front, front
+		side1[u] += int32(vertex[u])*2 - 1
+		side2[v] += int32(vertex[v])*2 - 1
+		corner := side1
+		corner[v] = side2[v]
+
+		ao[i] = vertexAO(s.occludes(side1), s.occludes(side2), s.occludes(corner))
+	}
+	return ao
+}
+
+// occludes reports whether a voxel, possibly in a neighbouring chunk, darkens
+// the corners next to it
+func (s *ChunkSnapshot) occludes(pos [3]int32) bool {
+	return s.props.IsOpaque(s.GetVoxel(pos[0], pos[1], pos[2]))
+}
+
+// vertexAO returns a corner's ambient occlusion from its three neighbours; a
+// corner between two sides is fully dark whatever the corner voxel is
+func vertexAO(side1, side2, corner bool) uint8 {
+	if side1 && side2 {
+		return 0
+	}
+	n := uint8(0)
+	for _, occluded := range []bool{side1, side2, corner} {
+		if occluded {
+			n++
+		}
+	}
+	return MaxAO - n
+}
+
+// packAO packs the ambient occlusion of four corners into 8 bits
+func packAO(ao [4]uint8) uint32 {
+	var packed uint32
+	for i, value := range ao {
+		packed |= uint32(value) << (i * 2)
+	}
+	return packed
+}
+
+// unpackAO reverses packAO
+func unpackAO(packed uint32) [4]uint8 {
+	var ao [4]uint8
+	for i := range ao {
+		ao[i] = uint8(packed >> (i * 2) & 0x3)
+	}
+	return ao
+}
@@ -0,0 +1,239 @@
+package chunk
+
+import (
+	"math"
+	"testing"
+
+	"Ceres/pkg/voxel"
+)
+
+// terrainChunk returns a chunk of rolling hills of mixed types with a tunnel
+// through them, a typical chunk to mesh
+func terrainChunk(position ChunkPosition) *Chunk {
+	c := NewChunk(position)
+	for x := int32(0); x < ChunkSize; x++ {
+		for z := int32(0); z < ChunkSize; z++ {
+			height := int32(16 + 6*math.Sin(float64(x)/5) + 4*math.Cos(float64(z)/7))
+			for y := int32(0); y <= height; y++ {
+				voxelType := voxel.VoxelTypeStone
+				if y == height {
+					voxelType = voxel.VoxelTypeGrass
+				} else if y > height-3 {
+					voxelType = voxel.VoxelTypeDirt
+				}
+				if y >= 6 && y <= 8 && z >= 12 && z <= 14 {
+					continue
+				}
+				c.SetVoxel(x, y, z, voxel.NewVoxel(voxelType))
+			}
+		}
+	}
+	return c
+}
+
+func TestPackVertexRoundTrip(t *testing.T) {
+	tests := []VertexData{
+		{},
+		{Position: [3]float32{32, 32, 32}, Face: voxel.VoxelFaceBack, U: 63, V: 63, AO: MaxAO, Light: MaxLight, Type: 255},
+		{Position: [3]float32{1.5, 17.0625, 0.25}, Face: voxel.VoxelFaceTop, U: 32, V: 1, AO: 1, Light: 7, Type: voxel.VoxelTypeWater},
+		{Position: [3]float32{31, 0, 9}, Face: voxel.VoxelFaceRight, U: 0, V: 12, AO: 2, Light: 0, Type: voxel.VoxelTypeGlass},
+	}
+	for _, want := range tests {
+		if got := PackVertex(want).Unpack(); got != want {
+			t.Errorf("Expected %+v after a round trip, got %+v", want, got)
+		}
+	}
+
+	// Positions are rounded to the nearest step
+	got := PackVertex(VertexData{Position: [3]float32{0.03, 0.04, 31.97}}).Unpack()
+	if got.Position != [3]float32{0, 0.0625, 32} {
+		t.Errorf("Expected positions rounded to 1/16, got %v", got.Position)
+	}
+}
+
+func TestPackVertexFieldsDoNotOverlap(t *testing.T) {
+	full := PackVertex(VertexData{
+		Position: [3]float32{63.9375, 63.9375, 63.9375},
+		Face:     7, U: 63, V: 63, AO: 3, Light: 15, Type: 255,
+	})
+	for i, field := range []VertexData{
+		{Position: [3]float32{63.9375}},
+		{Position: [3]float32{0, 63.9375}},
+		{Position: [3]float32{0, 0, 63.9375}},
+		{Face: 7}, {AO: 3}, {Light: 15}, {U: 63}, {V: 63}, {Type: 255},
+	} {
+		packed := PackVertex(field)
+		for word := range packed {
+			if packed[word]&^full[word] != 0 {
+				t.Errorf("Expected field %d to stay within the layout, got %032b", i, packed[word])
+			}
+		}
+		for j, other := range []VertexData{{Face: 7}, {AO: 3}, {Light: 15}, {U: 63}, {V: 63}, {Type: 255}} {
+			if field == other {
+				continue
+			}
+			if PackVertex(other)[1]&packed[1] != 0 {
+				t.Errorf("Expected fields %d and %d not to overlap", i, j)
+			}
+		}
+	}
+}
+
+func TestPackedMeshMatchesFloatMesh(t *testing.T) {
+	c := terrainChunk(NewChunkPosition(-2, 1, 3))
+	origin := c.GetWorldPosition()
+
+	for _, greedy := range []bool{false, true} {
+		floats := c.BuildMesh(greedy)
+		packed := c.BuildPackedMesh(greedy)
+		if floats.Origin != origin || packed.Origin != origin {
+			t.Fatalf("Expected both meshes relative to %v, got %v and %v", origin, floats.Origin, packed.Origin)
+		}
+		if greedy {
+			// Faces only merge when their occlusion matches, so there are
+			// more packed quads but never fewer
+			if packed.VertexCount < floats.VertexCount {
+				t.Errorf("Expected at least %d greedy vertices, got %d", floats.VertexCount, packed.VertexCount)
+			}
+			continue
+		}
+
+		if packed.VertexCount != floats.VertexCount || packed.IndexCount != floats.IndexCount {
+			t.Fatalf("Expected %d vertices and %d indices, got %d and %d",
+				floats.VertexCount, floats.IndexCount, packed.VertexCount, packed.IndexCount)
+		}
+		if len(packed.Vertices) != 0 || len(packed.Packed) != packed.VertexCount*PackedWordsPerVertex {
+			t.Fatalf("Expected only packed vertex data, got %d floats and %d words", len(packed.Vertices), len(packed.Packed))
+		}
+
+		for i := 0; i < packed.VertexCount; i++ {
+			d := packed.PackedVertex(i).Unpack()
+			f := floats.Vertices[i*11 : i*11+11]
+			if d.Position != [3]float32{f[0], f[1], f[2]} {
+				t.Fatalf("Expected vertex %d at %v, got %v", i, f[:3], d.Position)
+			}
+			normal := voxel.GetFaceNormal(d.Face)
+			if [3]float32{normal.X, normal.Y, normal.Z} != [3]float32{f[3], f[4], f[5]} {
+				t.Fatalf("Expected vertex %d to face %v, got face %d", i, f[3:6], d.Face)
+			}
+			if float32(d.U) != f[6] || float32(d.V) != f[7] {
+				t.Fatalf("Expected vertex %d to have UV %v, got %d,%d", i, f[6:8], d.U, d.V)
+			}
+			if getVoxelTypeColor(d.Type) != [3]float32{f[8], f[9], f[10]} {
+				t.Fatalf("Expected vertex %d to have the colour of its type %v", i, d.Type)
+			}
+			if d.Light != MaxLight {
+				t.Fatalf("Expected full light, got %d", d.Light)
+			}
+		}
+	}
+}
+
+func TestPackedMeshAmbientOcclusion(t *testing.T) {
+	c := NewChunk(NewChunkPosition(0, 0, 0))
+	stone := voxel.NewVoxel(voxel.VoxelTypeStone)
+	c.SetVoxel(5, 5, 5, stone)
+	// A wall along -X beside the top face, and a block on its +X+Z diagonal
+	for z := int32(4); z <= 6; z++ {
+		c.SetVoxel(4, 6, z, stone)
+	}
+	c.SetVoxel(6, 6, 6, stone)
+
+	mesh := c.BuildPackedMesh(false)
+
+	want := map[[3]float32]uint8{
+		{5, 6, 5}: 1, // beside the wall
+		{5, 6, 6}: 1, // beside the wall
+		{6, 6, 5}: MaxAO,
+		{6, 6, 6}: 2, // under the diagonal block
+	}
+	found := 0
+	for i := 0; i < mesh.VertexCount; i++ {
+		d := mesh.PackedVertex(i).Unpack()
+		if d.Face != voxel.VoxelFaceTop || d.Type != voxel.VoxelTypeStone || d.Position[1] != 6 {
+			continue
+		}
+		if ao, ok := want[d.Position]; ok {
+			found++
+			if d.AO != ao {
+				t.Errorf("Expected AO %d at %v, got %d", ao, d.Position, d.AO)
+			}
+		}
+	}
+	if found != len(want) {
+		t.Errorf("Expected the top face of the floor block, found %d of its corners", found)
+	}
+
+	if got := vertexAO(true, true, false); got != 0 {
+		t.Errorf("Expected a corner between two sides to be fully dark, got %d", got)
+	}
+}
+
+func TestPackedGreedyMeshMergesEqualOcclusion(t *testing.T) {
+	c := NewChunk(NewChunkPosition(0, 0, 0))
+	for x := int32(0); x < ChunkSize; x++ {
+		for z := int32(0); z < ChunkSize; z++ {
+			c.SetVoxel(x, 0, z, voxel.NewVoxel(voxel.VoxelTypeStone))
+		}
+	}
+
+	// An open floor has the same occlusion everywhere and merges into one quad
+	if top := countFaces(c.BuildPackedMesh(true), voxel.VoxelFaceTop); top != 1 {
+		t.Errorf("Expected a single top quad for an open floor, got %d", top)
+	}
+
+	// A pillar darkens the floor around it, splitting the quad
+	c.SetVoxel(10, 1, 10, voxel.NewVoxel(voxel.VoxelTypeStone))
+	floor := countFaces(c.BuildPackedMesh(true), voxel.VoxelFaceTop)
+	if unshaded := countFaces(c.BuildMesh(true), voxel.VoxelFaceTop); floor <= unshaded {
+		t.Errorf("Expected occlusion to split the floor into more than %d quads, got %d", unshaded, floor)
+	}
+}
+
+// countFaces returns the number of quads of a mesh facing one way
+func countFaces(mesh *ChunkMesh, face voxel.VoxelFace) int {
+	n := 0
+	for i := 0; i < mesh.VertexCount; i += 4 {
+		if mesh.Format == VertexFormatPacked {
+			if mesh.PackedVertex(i).Unpack().Face == face {
+				n++
+			}
+			continue
+		}
+		normal := voxel.GetFaceNormal(face)
+		if mesh.Vertices[i*11+3] == normal.X && mesh.Vertices[i*11+4] == normal.Y && mesh.Vertices[i*11+5] == normal.Z {
+			n++
+		}
+	}
+	return n
+}
+
+func benchmarkChunkMesh(b *testing.B, build func(c *Chunk) *ChunkMesh) {
+	c := terrainChunk(NewChunkPosition(0, 0, 0))
+	var mesh *ChunkMesh
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mesh = build(c)
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(mesh.SizeInBytes()), "bytes/chunk")
+	b.ReportMetric(float64(mesh.SizeInBytes()-mesh.IndexCount*4)/float64(mesh.VertexCount), "bytes/vertex")
+}
+
+func BenchmarkChunkMeshFloat(b *testing.B) {
+	benchmarkChunkMesh(b, func(c *Chunk) *ChunkMesh { return c.BuildMesh(false) })
+}
+
+func BenchmarkChunkMeshPacked(b *testing.B) {
+	benchmarkChunkMesh(b, func(c *Chunk) *ChunkMesh { return c.BuildPackedMesh(false) })
+}
+
+func BenchmarkChunkMeshFloatGreedy(b *testing.B) {
+	benchmarkChunkMesh(b, func(c *Chunk) *ChunkMesh { return c.BuildMesh(true) })
+}
+
+func BenchmarkChunkMeshPackedGreedy(b *testing.B) {
+	benchmarkChunkMesh(b, func(c *Chunk) *ChunkMesh { return c.BuildPackedMesh(true) })
+}
@@ -0,0 +1,101 @@
+package chunk
+
+import (
+	"sort"
+
+	"Ceres/pkg/voxel"
+)
+
+// QueueWrite queues a write into a chunk that has not finished generating, so
+// that features crossing chunk borders do not force-create empty neighbours.
+// It returns false without queueing when the chunk is already generated, in
+// which case the caller should write the voxel directly.
+func (cm *ChunkManager) QueueWrite(pos voxel.VoxelPosition, v voxel.Voxel) bool {
+	chunkPos := VoxelToChunkPosition(pos)
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.generated[chunkPos] {
+		return false
+	}
+
+	cm.pending[chunkPos] = append(cm.pending[chunkPos], VoxelWrite{Position: pos, Voxel: v})
+	return true
+}
+
+// FinishGeneration marks the chunk at pos as generated and returns the writes
+// that were queued for it. Later calls to QueueWrite for the chunk return false.
+// Chunk load listeners are notified.
+func (cm *ChunkManager) FinishGeneration(pos ChunkPosition) []VoxelWrite {
+	cm.mutex.Lock()
+	writes := cm.pending[pos]
+	delete(cm.pending, pos)
+	cm.generated[pos] = true
+	cm.mutex.Unlock()
+
+	cm.notifyChunkLoaded(pos)
+	return writes
+}
+
+// IsGenerated reports whether the chunk at pos has finished generating
+func (cm *ChunkManager) IsGenerated(pos ChunkPosition) bool {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	return cm.generated[pos]
+}
+
+// PendingWriteCount returns the number of writes queued for chunks that have
+// not been generated yet
+func (cm *ChunkManager) PendingWriteCount() int {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	count := 0
+	for _, writes := range cm.pending {
+		count += len(writes)
+	}
+
+	return count
+}
+
+// PendingWrites returns a copy of the writes queued for chunks that have not
+// been generated, ordered by position, so they can be saved with the world
+func (cm *ChunkManager) PendingWrites() []VoxelWrite {
+	cm.mutex.RLock()
+	var writes []VoxelWrite
+	for _, chunkWrites := range cm.pending {
+		writes = append(writes, chunkWrites...)
+	}
+	cm.mutex.RUnlock()
+
+	// Writes to the same voxel keep their queued order
+	sort.SliceStable(writes, func(i, j int) bool {
+		return lessVoxelPosition(writes[i].Position, writes[j].Position)
+	})
+	return writes
+}
+
+// RestorePendingWrites queues writes saved from PendingWrites by an earlier
+// run. Writes for chunks that have already been generated are returned
+// instead, for the caller to apply.
+func (cm *ChunkManager) RestorePendingWrites(writes []VoxelWrite) []VoxelWrite {
+	var generated []VoxelWrite
+	for _, write := range writes {
+		if !cm.QueueWrite(write.Position, write.Voxel) {
+			generated = append(generated, write)
+		}
+	}
+	return generated
+}
+
+func lessVoxelPosition(a, b voxel.VoxelPosition) bool {
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.Z < b.Z
+}
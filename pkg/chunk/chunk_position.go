@@ -67,6 +67,15 @@ func (cp ChunkPosition) GetNeighborPosition(face voxel.VoxelFace) ChunkPosition
 	}
 }
 
+// GetWorldPosition returns the world position of the chunk's minimum corner
+func (cp ChunkPosition) GetWorldPosition() voxel.VoxelPosition {
+	return voxel.NewVoxelPosition(
+		cp.X*ChunkSize,
+		cp.Y*ChunkSize,
+		cp.Z*ChunkSize,
+	)
+}
+
 func floorDiv(a, b int32) int32 {
 	if a < 0 {
 		return (a - b + 1) / b
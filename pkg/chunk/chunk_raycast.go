@@ -0,0 +1,124 @@
+package chunk
+
+import (
+	"math"
+
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+// RaycastHit describes the first voxel a ray hits
+type RaycastHit struct {
+	Position voxel.VoxelPosition
+	// Face is the face of the hit box the ray entered through
+	Face     voxel.VoxelFace
+	Distance float64
+	Voxel    voxel.Voxel
+}
+
+// Raycast walks the voxels along a ray and returns the first one whose
+// selection boxes it hits within maxDistance. Slabs, stairs and fences are
+// hit only where their model is, and plants by a box around them. The walk
+// stops at chunks that are not loaded.
+func (cm *ChunkManager) Raycast(origin ceresmath.Vector3d, direction ceresmath.Vector3, maxDistance float64) (RaycastHit, bool) {
+	dir := [3]float64{float64(direction.X), float64(direction.Y), float64(direction.Z)}
+	length := math.Sqrt(dir[0]*dir[0] + dir[1]*dir[1] + dir[2]*dir[2])
+	if length == 0 {
+		return RaycastHit{}, false
+	}
+	for i := range dir {
+		dir[i] /= length
+	}
+
+	start := [3]float64{origin.X, origin.Y, origin.Z}
+	var cell, step [3]int32
+	var next, delta [3]float64
+	for i := range cell {
+		cell[i] = int32(math.Floor(start[i]))
+		switch {
+		case dir[i] > 0:
+			step[i] = 1
+			next[i] = (float64(cell[i]) + 1 - start[i]) / dir[i]
+			delta[i] = 1 / dir[i]
+		case dir[i] < 0:
+			step[i] = -1
+			next[i] = (float64(cell[i]) - start[i]) / dir[i]
+			delta[i] = -1 / dir[i]
+		default:
+			next[i] = math.Inf(1)
+			delta[i] = math.Inf(1)
+		}
+	}
+
+	props := voxel.CurrentPropertyTable()
+	for {
+		pos := voxel.NewVoxelPosition(cell[0], cell[1], cell[2])
+		v, loaded := cm.GetVoxelIfLoaded(pos)
+		if !loaded {
+			return RaycastHit{}, false
+		}
+
+		// Intersect in cell-local coordinates to keep precision far from the origin
+		var local [3]float64
+		for i := range local {
+			local[i] = start[i] - float64(cell[i])
+		}
+		bestDistance, bestFace := math.Inf(1), voxel.VoxelFaceTop
+		for _, box := range props.SelectionBoxes(v, cm.neighborFunc(pos)) {
+			if distance, face, ok := rayBox(local, dir, box); ok && distance < bestDistance {
+				bestDistance, bestFace = distance, face
+			}
+		}
+		if bestDistance <= maxDistance {
+			return RaycastHit{Position: pos, Face: bestFace, Distance: bestDistance, Voxel: v}, true
+		}
+
+		axis := 0
+		if next[1] < next[axis] {
+			axis = 1
+		}
+		if next[2] < next[axis] {
+			axis = 2
+		}
+		if next[axis] > maxDistance {
+			return RaycastHit{}, false
+		}
+		cell[axis] += step[axis]
+		next[axis] += delta[axis]
+	}
+}
+
+// rayBox intersects a ray with a box using the slab method, returning the
+// distance along the ray to where it enters the box and the face it enters
+// through. A ray starting inside the box hits it at distance 0.
+func rayBox(origin, dir [3]float64, box voxel.Box) (float64, voxel.VoxelFace, bool) {
+	negative := [3]voxel.VoxelFace{voxel.VoxelFaceLeft, voxel.VoxelFaceBottom, voxel.VoxelFaceBack}
+	positive := [3]voxel.VoxelFace{voxel.VoxelFaceRight, voxel.VoxelFaceTop, voxel.VoxelFaceFront}
+
+	enter, exit := 0.0, math.Inf(1)
+	face := voxel.VoxelFaceTop
+	for i := range origin {
+		min, max := float64(box.Min[i]), float64(box.Max[i])
+		if dir[i] == 0 {
+			if origin[i] < min || origin[i] > max {
+				return 0, 0, false
+			}
+			continue
+		}
+
+		near, far := (min-origin[i])/dir[i], (max-origin[i])/dir[i]
+		nearFace := negative[i]
+		if dir[i] < 0 {
+			near, far = far, near
+			nearFace = positive[i]
+		}
+		if near > enter {
+			enter, face = near, nearFace
+		}
+		exit = math.Min(exit, far)
+		if enter > exit {
+			return 0, 0, false
+		}
+	}
+	return enter, face, true
+}
@@ -0,0 +1,41 @@
+package chunk
+
+import (
+	"math"
+	"testing"
+
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+func TestRaycastHitsModelBoxes(t *testing.T) {
+	cm := NewChunkManager()
+	cm.InsertChunk(NewChunk(NewChunkPosition(0, 0, 0)))
+	cm.SetVoxel(voxel.NewVoxelPosition(4, 4, 4), voxel.NewVoxel(voxel.VoxelTypeStoneSlab))
+	cm.SetVoxel(voxel.NewVoxelPosition(8, 4, 4), voxel.NewVoxel(voxel.VoxelTypeFlower))
+
+	// Straight down onto the slab's top, half way up its voxel
+	hit, ok := cm.Raycast(ceresmath.NewVector3d(4.5, 10, 4.5), ceresmath.Down(), 20)
+	if !ok || hit.Position != voxel.NewVoxelPosition(4, 4, 4) || hit.Face != voxel.VoxelFaceTop {
+		t.Fatalf("Expected to hit the top of the slab, got %+v", hit)
+	}
+	if math.Abs(hit.Distance-5.5) > 1e-9 {
+		t.Errorf("Expected a distance of 5.5, got %f", hit.Distance)
+	}
+
+	// Over the slab the ray goes on to the flower's selection box
+	hit, ok = cm.Raycast(ceresmath.NewVector3d(0.5, 4.75, 4.5), ceresmath.Right(), 20)
+	if !ok || hit.Voxel.Type != voxel.VoxelTypeFlower || hit.Face != voxel.VoxelFaceLeft {
+		t.Fatalf("Expected to hit the left of the flower, got %+v", hit)
+	}
+	if math.Abs(hit.Distance-7.625) > 1e-9 {
+		t.Errorf("Expected a distance of 7.625, got %f", hit.Distance)
+	}
+
+	if _, ok := cm.Raycast(ceresmath.NewVector3d(0.5, 4.75, 4.5), ceresmath.Right(), 7); ok {
+		t.Error("Expected no hit beyond the maximum distance")
+	}
+	if _, ok := cm.Raycast(ceresmath.NewVector3d(0.5, 20, 4.5), ceresmath.Up(), 100); ok {
+		t.Error("Expected the ray to stop at unloaded chunks")
+	}
+}
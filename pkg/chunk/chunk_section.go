@@ -0,0 +1,175 @@
+package chunk
+
+import (
+	"math/bits"
+
+	"Ceres/pkg/voxel"
+)
+
+// SectionSize is the edge length of the cubic sections a chunk is divided
+// into for per-section work such as random ticks and remeshing
+const SectionSize = 16
+
+// SectionsPerAxis is the number of sections along each axis of a chunk
+const SectionsPerAxis = ChunkSize / SectionSize
+
+// SectionCount is the number of sections in a chunk
+const SectionCount = SectionsPerAxis * SectionsPerAxis * SectionsPerAxis
+
+// SectionMask holds one bit per section of a chunk, see SectionIndex
+type SectionMask uint64
+
+// AllSections has the bit of every section set
+const AllSections SectionMask = 1<<SectionCount - 1
+
+// Has reports whether the section's bit is set
+func (m SectionMask) Has(section int) bool {
+	return m&(1<<section) != 0
+}
+
+// Count returns the number of sections set
+func (m SectionMask) Count() int {
+	return bits.OnesCount64(uint64(m))
+}
+
+// SectionIndex returns the section holding a local voxel position
+func SectionIndex(x, y, z int32) int {
+	return int(x/SectionSize + y/SectionSize*SectionsPerAxis + z/SectionSize*SectionsPerAxis*SectionsPerAxis)
+}
+
+// SectionBounds returns the local voxel range of a section, min inclusive and
+// max exclusive
+func SectionBounds(section int) (min, max [3]int32) {
+	min = [3]int32{
+		int32(section%SectionsPerAxis) * SectionSize,
+		int32(section/SectionsPerAxis%SectionsPerAxis) * SectionSize,
+		int32(section/(SectionsPerAxis*SectionsPerAxis)) * SectionSize,
+	}
+	for axis := range max {
+		max[axis] = min[axis] + SectionSize
+	}
+	return min, max
+}
+
+// sectionsAround returns the sections within one voxel of a local position,
+// diagonals included, since a voxel changes the faces and ambient occlusion
+// of every voxel touching it
+func sectionsAround(x, y, z int32) SectionMask {
+	var mask SectionMask
+	for dx := int32(-1); dx <= 1; dx++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for dz := int32(-1); dz <= 1; dz++ {
+				if isValidLocalCoord(x+dx, y+dy, z+dz) {
+					mask |= 1 << SectionIndex(x+dx, y+dy, z+dz)
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// sectionsOnFace returns the sections touching one face of the chunk
+func sectionsOnFace(face voxel.VoxelFace) SectionMask {
+	var mask SectionMask
+	for section := 0; section < SectionCount; section++ {
+		min, max := SectionBounds(section)
+		onFace := false
+		switch face {
+		case voxel.VoxelFaceTop:
+			onFace = max[1] == ChunkSize
+		case voxel.VoxelFaceBottom:
+			onFace = min[1] == 0
+		case voxel.VoxelFaceLeft:
+			onFace = min[0] == 0
+		case voxel.VoxelFaceRight:
+			onFace = max[0] == ChunkSize
+		case voxel.VoxelFaceFront:
+			onFace = max[2] == ChunkSize
+		case voxel.VoxelFaceBack:
+			onFace = min[2] == 0
+		}
+		if onFace {
+			mask |= 1 << section
+		}
+	}
+	return mask
+}
+
+// neighborSections returns, for each chunk other than the voxel's own, the
+// sections within one voxel of a world position. This includes chunks that
+// only share an edge or corner with the voxel's chunk, whose ambient
+// occlusion it can change.
+func neighborSections(voxelPos voxel.VoxelPosition) map[ChunkPosition]SectionMask {
+	chunkPos := VoxelToChunkPosition(voxelPos)
+	x, y, z := VoxelToLocalPosition(voxelPos)
+	if x > 0 && x < ChunkSize-1 && y > 0 && y < ChunkSize-1 && z > 0 && z < ChunkSize-1 {
+		return nil
+	}
+
+	sections := make(map[ChunkPosition]SectionMask)
+	for dx := int32(-1); dx <= 1; dx++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for dz := int32(-1); dz <= 1; dz++ {
+				pos := voxelPos.Add(voxel.NewVoxelPosition(dx, dy, dz))
+				neighborPos := VoxelToChunkPosition(pos)
+				if neighborPos == chunkPos {
+					continue
+				}
+				sections[neighborPos] |= 1 << SectionIndex(VoxelToLocalPosition(pos))
+			}
+		}
+	}
+	return sections
+}
+
+// DirtySections returns the sections whose mesh is out of date
+func (c *Chunk) DirtySections() SectionMask {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.dirtySections
+}
+
+// MarkSectionsDirty marks sections as needing a new mesh
+func (c *Chunk) MarkSectionsDirty(sections SectionMask) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.dirtySections |= sections
+}
+
+// TakeDirtySections clears the dirty sections and returns them. Sections
+// changed while their meshes are rebuilt are marked dirty again.
+func (c *Chunk) TakeDirtySections() SectionMask {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	sections := c.dirtySections
+	c.dirtySections = 0
+	return sections
+}
+
+// BuildSectionMesh builds the mesh of the faces of one section's voxels, with
+// vertices relative to the chunk origin like BuildMesh. Unlike BuildMesh it
+// leaves Connectivity unset; see ComputeConnectivity. To build several
+// sections, build them from one Snapshot.
+func (c *Chunk) BuildSectionMesh(section int, greedy bool) *ChunkMesh {
+	return c.Snapshot().BuildSectionMesh(section, greedy)
+}
+
+// BuildPackedSectionMesh is BuildSectionMesh in the packed vertex format
+func (c *Chunk) BuildPackedSectionMesh(section int, greedy bool) *ChunkMesh {
+	return c.Snapshot().BuildPackedSectionMesh(section, greedy)
+}
+
+// BuildSectionMesh builds the mesh of one section of the snapshot's chunk
+func (s *ChunkSnapshot) BuildSectionMesh(section int, greedy bool) *ChunkMesh {
+	min, max := SectionBounds(section)
+	return s.buildRegion(NewChunkMesh(s.GetWorldPosition()), min, max, greedy)
+}
+
+// BuildPackedSectionMesh is BuildSectionMesh in the packed vertex format
+func (s *ChunkSnapshot) BuildPackedSectionMesh(section int, greedy bool) *ChunkMesh {
+	min, max := SectionBounds(section)
+	return s.buildRegion(NewPackedChunkMesh(s.GetWorldPosition()), min, max, greedy)
+}
@@ -0,0 +1,228 @@
+package chunk
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"testing"
+
+	"Ceres/pkg/voxel"
+)
+
+// cleanTerrain returns a manager holding a 3x3x3 block of terrain chunks
+// around the origin with no dirty sections
+func cleanTerrain() *ChunkManager {
+	cm := NewChunkManager()
+	for x := int32(-1); x <= 1; x++ {
+		for y := int32(-1); y <= 1; y++ {
+			for z := int32(-1); z <= 1; z++ {
+				cm.InsertChunk(terrainChunk(NewChunkPosition(x, y, z)))
+			}
+		}
+	}
+	for _, c := range cm.GetLoadedChunks() {
+		c.SetDirty(false)
+	}
+	return cm
+}
+
+// dirtySections returns the dirty sections of every chunk that has any
+func dirtySections(cm *ChunkManager) map[ChunkPosition]SectionMask {
+	dirty := make(map[ChunkPosition]SectionMask)
+	for _, c := range cm.GetLoadedChunks() {
+		if sections := c.DirtySections(); sections != 0 {
+			dirty[c.Position] = sections
+		}
+	}
+	return dirty
+}
+
+func TestSectionBounds(t *testing.T) {
+	for section := 0; section < SectionCount; section++ {
+		min, max := SectionBounds(section)
+		if got := SectionIndex(min[0], min[1], min[2]); got != section {
+			t.Errorf("Expected the minimum corner of section %d to be in it, got %d", section, got)
+		}
+		if got := SectionIndex(max[0]-1, max[1]-1, max[2]-1); got != section {
+			t.Errorf("Expected the maximum corner of section %d to be in it, got %d", section, got)
+		}
+	}
+}
+
+func TestSetVoxelMarksNearbySections(t *testing.T) {
+	stone := voxel.NewVoxel(voxel.VoxelTypeStone)
+	origin := NewChunkPosition(0, 0, 0)
+
+	// Inside a section only that section is remeshed
+	cm := cleanTerrain()
+	cm.SetVoxel(voxel.NewVoxelPosition(8, 28, 8), stone)
+	want := map[ChunkPosition]SectionMask{origin: 1 << SectionIndex(8, 28, 8)}
+	if got := dirtySections(cm); !maps.Equal(got, want) {
+		t.Errorf("Expected %v to be dirty, got %v", want, got)
+	}
+
+	// Next to a section border both sections are
+	cm = cleanTerrain()
+	cm.SetVoxel(voxel.NewVoxelPosition(SectionSize, 28, 8), stone)
+	want = map[ChunkPosition]SectionMask{origin: 1<<SectionIndex(SectionSize-1, 28, 8) | 1<<SectionIndex(SectionSize, 28, 8)}
+	if got := dirtySections(cm); !maps.Equal(got, want) {
+		t.Errorf("Expected %v to be dirty, got %v", want, got)
+	}
+
+	// At a chunk corner the chunks sharing only an edge or corner are too
+	cm = cleanTerrain()
+	cm.SetVoxel(voxel.NewVoxelPosition(0, 0, 0), voxel.NewVoxel(voxel.VoxelTypeAir))
+	got := dirtySections(cm)
+	if len(got) != 8 {
+		t.Errorf("Expected the 8 chunks around the corner to be dirty, got %v", got)
+	}
+	for pos, sections := range got {
+		if sections.Count() != 1 {
+			t.Errorf("Expected one dirty section in chunk %v, got %b", pos, sections)
+		}
+	}
+	if !got[NewChunkPosition(-1, -1, -1)].Has(SectionIndex(ChunkSize-1, ChunkSize-1, ChunkSize-1)) {
+		t.Error("Expected the diagonal chunk's corner section to be dirty")
+	}
+
+	// Writes that change nothing mark nothing
+	cm = cleanTerrain()
+	cm.SetVoxel(voxel.NewVoxelPosition(0, 0, 0), cm.GetVoxel(voxel.NewVoxelPosition(0, 0, 0)))
+	cm.SetVoxels([]VoxelWrite{{Position: voxel.NewVoxelPosition(40, 28, 40), Voxel: voxel.NewVoxel(voxel.VoxelTypeAir)}})
+	if got := dirtySections(cm); len(got) != 0 {
+		t.Errorf("Expected no dirty sections, got %v", got)
+	}
+}
+
+func TestSetVoxelsMarksNeighborSectionsInBatch(t *testing.T) {
+	cm := cleanTerrain()
+	cm.SetVoxels([]VoxelWrite{
+		{Position: voxel.NewVoxelPosition(-1, 28, 8), Voxel: voxel.NewVoxel(voxel.VoxelTypeBrick)},
+		{Position: voxel.NewVoxelPosition(8, 28, 8), Voxel: voxel.NewVoxel(voxel.VoxelTypeBrick)},
+	})
+
+	got := dirtySections(cm)
+	if !got[NewChunkPosition(0, 0, 0)].Has(SectionIndex(0, 28, 8)) {
+		t.Error("Expected the border section of a chunk in the same batch to be dirty")
+	}
+	if !got[NewChunkPosition(-1, 0, 0)].Has(SectionIndex(ChunkSize-1, 28, 8)) {
+		t.Error("Expected the written section to be dirty")
+	}
+}
+
+func TestTakeDirtySections(t *testing.T) {
+	c := NewChunk(NewChunkPosition(0, 0, 0))
+	if got := c.TakeDirtySections(); got != AllSections {
+		t.Errorf("Expected a new chunk to have every section dirty, got %b", got)
+	}
+	if c.IsDirty() {
+		t.Error("Expected the chunk to be clean after taking its dirty sections")
+	}
+
+	c.SetNeighbor(voxel.VoxelFaceTop, NewChunk(NewChunkPosition(0, 1, 0)))
+	if got, want := c.TakeDirtySections(), sectionsOnFace(voxel.VoxelFaceTop); got != want || got.Count() != SectionCount/SectionsPerAxis {
+		t.Errorf("Expected a new neighbour to dirty the sections on its face %b, got %b", want, got)
+	}
+}
+
+func TestSectionMeshesMatchChunkMesh(t *testing.T) {
+	c := terrainChunk(NewChunkPosition(2, -1, 3))
+	c.SetVoxel(4, 30, 4, voxel.NewVoxel(voxel.VoxelTypeWater))
+
+	want := c.BuildPackedMesh(false)
+	var wantVertices, gotVertices []PackedVertex
+	for i := 0; i < want.VertexCount; i++ {
+		wantVertices = append(wantVertices, want.PackedVertex(i))
+	}
+	for section := 0; section < SectionCount; section++ {
+		mesh := c.BuildPackedSectionMesh(section, false)
+		if mesh.Origin != want.Origin {
+			t.Errorf("Expected section meshes to share the chunk origin, got %v", mesh.Origin)
+		}
+		for i := 0; i < mesh.VertexCount; i++ {
+			gotVertices = append(gotVertices, mesh.PackedVertex(i))
+		}
+	}
+
+	compare := func(a, b PackedVertex) int {
+		if c := cmp.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a[1], b[1])
+	}
+	slices.SortFunc(wantVertices, compare)
+	slices.SortFunc(gotVertices, compare)
+	if !slices.Equal(gotVertices, wantVertices) {
+		t.Errorf("Expected the section meshes to hold the chunk mesh's %d vertices, got %d", len(wantVertices), len(gotVertices))
+	}
+
+	// Greedy quads stop at section borders but cover the same faces
+	for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+		wantFaces := countFaces(c.BuildMesh(false), face)
+		greedyFaces := 0
+		for section := 0; section < SectionCount; section++ {
+			mesh := c.BuildSectionMesh(section, true)
+			greedyFaces += countFaces(mesh, face)
+			if section == 0 && mesh.Connectivity != 0 {
+				t.Error("Expected section meshes to leave connectivity unset")
+			}
+		}
+		if greedyFaces == 0 || greedyFaces > wantFaces {
+			t.Errorf("Expected between 1 and %d greedy quads on face %d, got %d", wantFaces, face, greedyFaces)
+		}
+	}
+}
+
+// benchmarkBlockEdit measures the time from a single block change to new
+// meshes for everything it touched, as a renderer would rebuild them before
+// uploading, toggling a block at the given position
+func benchmarkBlockEdit(b *testing.B, pos voxel.VoxelPosition, remesh func(c *Chunk) int) {
+	cm := cleanTerrain()
+	blocks := [2]voxel.Voxel{voxel.NewVoxel(voxel.VoxelTypeBrick), voxel.NewVoxel(voxel.VoxelTypeAir)}
+	meshes := 0
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cm.SetVoxel(pos, blocks[i%2])
+		for _, c := range cm.GetDirtyChunks() {
+			meshes += remesh(c)
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(meshes)/float64(b.N), "meshes/op")
+}
+
+func remeshChunk(c *Chunk) int {
+	c.BuildPackedMesh(false)
+	c.SetDirty(false)
+	return 1
+}
+
+func remeshSections(c *Chunk) int {
+	sections := c.TakeDirtySections()
+	snapshot := c.Snapshot()
+	snapshot.ComputeConnectivity()
+	for section := 0; section < SectionCount; section++ {
+		if sections.Has(section) {
+			snapshot.BuildPackedSectionMesh(section, false)
+		}
+	}
+	return sections.Count()
+}
+
+func BenchmarkBlockEditRemeshChunk(b *testing.B) {
+	benchmarkBlockEdit(b, voxel.NewVoxelPosition(8, 24, 8), remeshChunk)
+}
+
+func BenchmarkBlockEditRemeshSections(b *testing.B) {
+	benchmarkBlockEdit(b, voxel.NewVoxelPosition(8, 24, 8), remeshSections)
+}
+
+func BenchmarkBlockEditCornerRemeshChunk(b *testing.B) {
+	benchmarkBlockEdit(b, voxel.NewVoxelPosition(0, 0, 0), remeshChunk)
+}
+
+func BenchmarkBlockEditCornerRemeshSections(b *testing.B) {
+	benchmarkBlockEdit(b, voxel.NewVoxelPosition(0, 0, 0), remeshSections)
+}
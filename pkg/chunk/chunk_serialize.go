@@ -0,0 +1,209 @@
+package chunk
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"Ceres/pkg/voxel"
+)
+
+// chunkFormatVersion is written at the start of every encoded chunk. Version
+// 2 added fluid ticks; version 1 chunks still decode.
+const chunkFormatVersion uint8 = 2
+
+// ErrUnsupportedChunkVersion is returned when decoding a chunk written by an
+// incompatible format version
+var ErrUnsupportedChunkVersion = errors.New("unsupported chunk format version")
+
+// Encode writes the chunk's position, voxels and scheduled block and fluid
+// ticks. Voxels are
+// stored as a palette of distinct voxels followed by run-length encoded
+// palette indices, and everything after the header is DEFLATE compressed.
+func (c *Chunk) Encode(w io.Writer) error {
+	c.mutex.RLock()
+	palette, runs := c.paletteRuns()
+	c.mutex.RUnlock()
+	ticks, fluidTicks := c.ScheduledTicks(), c.FluidTicks()
+
+	header := []any{chunkFormatVersion, c.Position.X, c.Position.Y, c.Position.Z}
+	for _, field := range header {
+		if err := binary.Write(w, binary.LittleEndian, field); err != nil {
+			return fmt.Errorf("failed to write chunk header: %w", err)
+		}
+	}
+
+	var body bytes.Buffer
+	binary.Write(&body, binary.LittleEndian, uint16(len(palette)))
+	for _, v := range palette {
+		body.WriteByte(byte(v.Type))
+		body.WriteByte(v.State)
+	}
+
+	binary.Write(&body, binary.LittleEndian, uint32(len(runs)))
+	for _, run := range runs {
+		binary.Write(&body, binary.LittleEndian, run)
+	}
+
+	writeTicks(&body, ticks)
+	writeTicks(&body, fluidTicks)
+
+	fw, err := flate.NewWriter(w, flate.DefaultCompression)
+	if err != nil {
+		return fmt.Errorf("failed to create compressor: %w", err)
+	}
+	if _, err := fw.Write(body.Bytes()); err != nil {
+		return fmt.Errorf("failed to write chunk data: %w", err)
+	}
+	if err := fw.Close(); err != nil {
+		return fmt.Errorf("failed to write chunk data: %w", err)
+	}
+
+	return nil
+}
+
+// voxelRun is a run of identical voxels in index order
+type voxelRun struct {
+	Length  uint16
+	Palette uint16
+}
+
+// paletteRuns returns the distinct voxels of the chunk in first-seen order
+// and the voxel array as runs of palette indices. The caller holds the lock.
+func (c *Chunk) paletteRuns() ([]voxel.Voxel, []voxelRun) {
+	var palette []voxel.Voxel
+	indices := make(map[voxel.Voxel]uint16)
+	var runs []voxelRun
+
+	for i, v := range c.voxels {
+		index, ok := indices[v]
+		if !ok {
+			index = uint16(len(palette))
+			indices[v] = index
+			palette = append(palette, v)
+		}
+
+		if i > 0 && runs[len(runs)-1].Palette == index && runs[len(runs)-1].Length < ^uint16(0) {
+			runs[len(runs)-1].Length++
+			continue
+		}
+		runs = append(runs, voxelRun{Length: 1, Palette: index})
+	}
+
+	return palette, runs
+}
+
+func writeTicks(body *bytes.Buffer, ticks []ScheduledTick) {
+	binary.Write(body, binary.LittleEndian, uint32(len(ticks)))
+	for _, tick := range ticks {
+		x, y, z := VoxelToLocalPosition(tick.Position)
+		binary.Write(body, binary.LittleEndian, uint16(localToIndex(x, y, z)))
+		binary.Write(body, binary.LittleEndian, tick.Due)
+	}
+}
+
+// DecodeChunk reads a chunk written by Encode. The returned chunk has no
+// neighbours; add it to a manager with InsertChunk.
+func DecodeChunk(r io.Reader) (*Chunk, error) {
+	br := bufio.NewReader(r)
+
+	var version uint8
+	if err := binary.Read(br, binary.LittleEndian, &version); err != nil {
+		return nil, fmt.Errorf("failed to read chunk header: %w", err)
+	}
+	if version < 1 || version > chunkFormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedChunkVersion, version)
+	}
+
+	var pos ChunkPosition
+	if err := binary.Read(br, binary.LittleEndian, &pos); err != nil {
+		return nil, fmt.Errorf("failed to read chunk header: %w", err)
+	}
+
+	fr := flate.NewReader(br)
+	defer fr.Close()
+
+	c := NewChunk(pos)
+	if err := c.decodeBody(fr, version); err != nil {
+		return nil, fmt.Errorf("failed to read chunk %v: %w", pos, err)
+	}
+
+	return c, nil
+}
+
+func (c *Chunk) decodeBody(r io.Reader, version uint8) error {
+	var paletteSize uint16
+	if err := binary.Read(r, binary.LittleEndian, &paletteSize); err != nil {
+		return err
+	}
+	palette := make([]voxel.Voxel, paletteSize)
+	for i := range palette {
+		var entry [2]uint8
+		if err := binary.Read(r, binary.LittleEndian, &entry); err != nil {
+			return err
+		}
+		palette[i] = voxel.Voxel{Type: voxel.VoxelType(entry[0]), State: entry[1]}
+	}
+
+	var runCount uint32
+	if err := binary.Read(r, binary.LittleEndian, &runCount); err != nil {
+		return err
+	}
+	index := 0
+	for i := uint32(0); i < runCount; i++ {
+		var run voxelRun
+		if err := binary.Read(r, binary.LittleEndian, &run); err != nil {
+			return err
+		}
+		if int(run.Palette) >= len(palette) || index+int(run.Length) > len(c.voxels) {
+			return errors.New("voxel data out of range")
+		}
+		for j := 0; j < int(run.Length); j++ {
+			c.voxels[index] = palette[run.Palette]
+			index++
+		}
+	}
+	if index != len(c.voxels) {
+		return fmt.Errorf("expected %d voxels, got %d", len(c.voxels), index)
+	}
+
+	if err := c.readTicks(r, &c.scheduled); err != nil {
+		return err
+	}
+	if version >= 2 {
+		if err := c.readTicks(r, &c.fluidScheduled); err != nil {
+			return err
+		}
+	}
+
+	c.checkIfEmpty()
+	return nil
+}
+
+func (c *Chunk) readTicks(r io.Reader, scheduled *map[int]uint64) error {
+	var tickCount uint32
+	if err := binary.Read(r, binary.LittleEndian, &tickCount); err != nil {
+		return err
+	}
+	for i := uint32(0); i < tickCount; i++ {
+		var tick struct {
+			Index uint16
+			Due   uint64
+		}
+		if err := binary.Read(r, binary.LittleEndian, &tick); err != nil {
+			return err
+		}
+		if int(tick.Index) >= len(c.voxels) {
+			return errors.New("scheduled tick out of range")
+		}
+		if *scheduled == nil {
+			*scheduled = make(map[int]uint64)
+		}
+		(*scheduled)[int(tick.Index)] = tick.Due
+	}
+	return nil
+}
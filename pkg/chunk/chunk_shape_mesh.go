@@ -0,0 +1,121 @@
+package chunk
+
+import (
+	"Ceres/pkg/voxel"
+)
+
+// crossInset keeps the quads of cross-shaped plants inside the voxel, on the
+// packed format's position grid
+const crossInset = 2.0 / PackedPositionScale
+
+// addShapedVoxels meshes the voxels from min, inclusive, to max, exclusive,
+// whose shape is not a cube
+func (s *ChunkSnapshot) addShapedVoxels(mesh *ChunkMesh, min, max [3]int32) {
+	for x := min[0]; x < max[0]; x++ {
+		for y := min[1]; y < max[1]; y++ {
+			for z := min[2]; z < max[2]; z++ {
+				v := s.GetVoxel(x, y, z)
+				if !v.IsAir() && !s.props.IsFluid(v) && s.props.Shape(v) != voxel.ShapeCube {
+					s.addShapedVoxel(mesh, x, y, z, v)
+				}
+			}
+		}
+	}
+}
+
+// addShapedVoxel meshes the boxes of a voxel's model, or its crossed quads.
+// Box faces on the voxel's boundary are culled like cube faces; faces inside
+// the voxel are culled where another box of the model covers them.
+func (s *ChunkSnapshot) addShapedVoxel(mesh *ChunkMesh, x, y, z int32, v voxel.Voxel) {
+	position := s.GetWorldPosition().Add(voxel.NewVoxelPosition(x, y, z))
+	color := s.getVoxelColor(position, v.Type)
+
+	if s.props.Shape(v) == voxel.ShapeCross {
+		s.addCross(mesh, x, y, z, v, color)
+		return
+	}
+
+	neighbor := func(face voxel.VoxelFace) voxel.Voxel {
+		offset := voxel.GetFaceOffset(face)
+		return s.GetVoxel(x+offset.X, y+offset.Y, z+offset.Z)
+	}
+	boxes := s.props.Boxes(v, neighbor)
+
+	for i, box := range boxes {
+		min, max := box.Min, box.Max
+		for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+			d, u, w := greedyFaceAxes(face)
+			positive := face == voxel.VoxelFaceTop || face == voxel.VoxelFaceRight || face == voxel.VoxelFaceFront
+			onBoundary := (positive && max[d] == 1) || (!positive && min[d] == 0)
+			if onBoundary && !s.isFaceVisible(x, y, z, face) {
+				continue
+			}
+			if boxFaceCovered(boxes, i, face) {
+				continue
+			}
+
+			vertices := getFaceVertices(face)
+			var positions [4][3]float32
+			var uvs [4][2]float32
+			for j, vertex := range vertices {
+				for axis := 0; axis < 3; axis++ {
+					positions[j][axis] = min[axis] + vertex[axis]*(max[axis]-min[axis])
+				}
+				positions[j][0] += float32(x)
+				positions[j][1] += float32(y)
+				positions[j][2] += float32(z)
+				uvs[j] = [2]float32{vertex[3] * (max[u] - min[u]), vertex[4] * (max[w] - min[w])}
+			}
+
+			normal := voxel.GetFaceNormal(face)
+			mesh.addVertexQuad(positions, uvs, face, v.Type, [3]float32{normal.X, normal.Y, normal.Z}, color, unoccluded)
+		}
+	}
+}
+
+// boxFaceCovered reports whether a face of boxes[i] lies against another box
+// of the same model that covers all of it
+func boxFaceCovered(boxes []voxel.Box, i int, face voxel.VoxelFace) bool {
+	d, u, w := greedyFaceAxes(face)
+	positive := face == voxel.VoxelFaceTop || face == voxel.VoxelFaceRight || face == voxel.VoxelFaceFront
+	min, max := boxes[i].Min, boxes[i].Max
+
+	for j, other := range boxes {
+		if j == i {
+			continue
+		}
+		oMin, oMax := other.Min, other.Max
+		touching := (positive && oMin[d] == max[d]) || (!positive && oMax[d] == min[d])
+		if touching && oMin[u] <= min[u] && oMax[u] >= max[u] && oMin[w] <= min[w] && oMax[w] >= max[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// addCross adds two quads along the voxel's diagonals, each with a front and
+// a back side so they show from every direction. They are lit as top faces.
+func (s *ChunkSnapshot) addCross(mesh *ChunkMesh, x, y, z int32, v voxel.Voxel, color [3]float32) {
+	const lo, hi = crossInset, 1 - crossInset
+	fx, fy, fz := float32(x), float32(y), float32(z)
+	diagonals := [2][2][2]float32{
+		{{lo, lo}, {hi, hi}},
+		{{lo, hi}, {hi, lo}},
+	}
+
+	uvs := [4][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	up := [3]float32{0, 1, 0}
+	for _, diagonal := range diagonals {
+		a, b := diagonal[0], diagonal[1]
+		front := [4][3]float32{
+			{fx + a[0], fy, fz + a[1]},
+			{fx + b[0], fy, fz + b[1]},
+			{fx + b[0], fy + 1, fz + b[1]},
+			{fx + a[0], fy + 1, fz + a[1]},
+		}
+		back := [4][3]float32{front[1], front[0], front[3], front[2]}
+
+		mesh.addVertexQuad(front, uvs, voxel.VoxelFaceTop, v.Type, up, color, unoccluded)
+		mesh.addVertexQuad(back, uvs, voxel.VoxelFaceTop, v.Type, up, color, unoccluded)
+	}
+}
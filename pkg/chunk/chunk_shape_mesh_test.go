@@ -0,0 +1,55 @@
+package chunk
+
+import (
+	"testing"
+
+	"Ceres/pkg/voxel"
+)
+
+func TestSlabDoesNotHideNeighborSide(t *testing.T) {
+	c := NewChunk(NewChunkPosition(0, 0, 0))
+	c.SetVoxel(5, 5, 5, voxel.NewVoxel(voxel.VoxelTypeStone))
+	c.SetVoxel(6, 5, 5, voxel.NewVoxel(voxel.VoxelTypeStoneSlab))
+	c.SetVoxel(5, 6, 5, voxel.NewVoxel(voxel.VoxelTypeStoneSlab))
+
+	mesh := c.BuildMesh(false)
+
+	// The stone's right side shows beside the slab, the slab's left side is hidden
+	if got := countFaces(mesh, voxel.VoxelFaceRight); got != 3 {
+		t.Errorf("Expected 3 right faces, got %d", got)
+	}
+	if got := countFaces(mesh, voxel.VoxelFaceLeft); got != 2 {
+		t.Errorf("Expected 2 left faces, got %d", got)
+	}
+	// The slab on top hides the stone's top face
+	if got := countFaces(mesh, voxel.VoxelFaceTop); got != 2 {
+		t.Errorf("Expected the tops of both slabs only, got %d", got)
+	}
+}
+
+func TestStairsMeshHasNoInternalFaces(t *testing.T) {
+	c := NewChunk(NewChunkPosition(0, 0, 0))
+	c.SetVoxel(5, 5, 5, voxel.NewVoxel(voxel.VoxelTypeStoneStairs))
+
+	mesh := c.BuildMesh(false)
+	if got := countFaces(mesh, voxel.VoxelFaceBottom); got != 1 {
+		t.Errorf("Expected the step's bottom to be culled against the slab, got %d bottom faces", got)
+	}
+	if got := countFaces(mesh, voxel.VoxelFaceTop); got != 2 {
+		t.Errorf("Expected the tops of the slab and step, got %d", got)
+	}
+}
+
+func TestCrossMesh(t *testing.T) {
+	c := NewChunk(NewChunkPosition(0, 0, 0))
+	c.SetVoxel(5, 4, 5, voxel.NewVoxel(voxel.VoxelTypeGrass))
+	c.SetVoxel(5, 5, 5, voxel.NewVoxel(voxel.VoxelTypeFlower))
+
+	for _, greedy := range []bool{false, true} {
+		mesh := c.BuildPackedMesh(greedy)
+		// Six faces of grass, whose top shows through the flower, and four quads
+		if got := mesh.VertexCount / 4; got != 6+4 {
+			t.Errorf("Expected 10 quads with greedy=%v, got %d", greedy, got)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package chunk
+
+import (
+	"fmt"
+	"math"
+
+	"Ceres/pkg/voxel"
+)
+
+// MeshMode selects how a world's chunks are meshed
+type MeshMode uint8
+
+const (
+	// MeshModeBlocky draws every voxel as its block model
+	MeshModeBlocky MeshMode = iota
+	// MeshModeSmooth draws solid terrain as a smooth isosurface, see
+	// ChunkSnapshot.BuildSmoothMesh
+	MeshModeSmooth
+)
+
+func (m MeshMode) String() string {
+	switch m {
+	case MeshModeBlocky:
+		return "blocky"
+	case MeshModeSmooth:
+		return "smooth"
+	default:
+		return fmt.Sprintf("MeshMode(%d)", uint8(m))
+	}
+}
+
+// MarshalText stores the mode by name, as in world metadata
+func (m MeshMode) MarshalText() ([]byte, error) {
+	if m > MeshModeSmooth {
+		return nil, fmt.Errorf("unknown mesh mode %d", uint8(m))
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText parses a mode name
+func (m *MeshMode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "blocky":
+		*m = MeshModeBlocky
+	case "smooth":
+		*m = MeshModeSmooth
+	default:
+		return fmt.Errorf("unknown mesh mode %q", text)
+	}
+	return nil
+}
+
+// smoothIsoLevel is the density the smooth surface passes through
+const smoothIsoLevel = 0.5
+
+// cellCorners are the offsets of a cell's eight samples, indexed by bit 0
+// for X, bit 1 for Y and bit 2 for Z
+var cellCorners = [8][3]int32{
+	{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0},
+	{0, 0, 1}, {1, 0, 1}, {0, 1, 1}, {1, 1, 1},
+}
+
+// cellEdges are the pairs of corners joined by each of a cell's twelve edges
+var cellEdges = [12][2]int{
+	{0, 1}, {2, 3}, {4, 5}, {6, 7},
+	{0, 2}, {1, 3}, {4, 6}, {5, 7},
+	{0, 4}, {1, 5}, {2, 6}, {3, 7},
+}
+
+// density returns the density of a voxel for smooth meshing: 1 for solid
+// cubes and 0 for anything else, which the mesher leaves to its own model
+func (s *ChunkSnapshot) density(v voxel.Voxel) float32 {
+	if v.IsAir() || s.props.IsFluid(v) || s.props.Shape(v) != voxel.ShapeCube {
+		return 0
+	}
+	return 1
+}
+
+// BuildSmoothMesh builds a smooth isosurface around the chunk's solid voxels
+// with naive surface nets. Samples sit at voxel centres and each cell between
+// eight samples that the surface crosses gets one vertex, at the mean of the
+// crossings on its edges, with a normal from the density gradient. A chunk
+// meshes the sample edges starting inside it, and cell vertices depend only on
+// the cell's samples, so meshes of neighbouring chunks meet without seams.
+// Fluids and non-cube blocks keep their usual meshes.
+//
+// Smooth normals do not fit the packed format, so smooth meshes always use
+// VertexFormatFloat.
+func (s *ChunkSnapshot) BuildSmoothMesh() *ChunkMesh {
+	mesh := NewChunkMesh(s.GetWorldPosition())
+	mesh.Connectivity = s.ComputeConnectivity()
+	return s.buildSmoothRegion(mesh, [3]int32{}, [3]int32{ChunkSize, ChunkSize, ChunkSize})
+}
+
+// BuildSmoothSectionMesh builds the smooth mesh of one section of the
+// snapshot's chunk, see BuildSectionMesh
+func (s *ChunkSnapshot) BuildSmoothSectionMesh(section int) *ChunkMesh {
+	min, max := SectionBounds(section)
+	return s.buildSmoothRegion(NewChunkMesh(s.GetWorldPosition()), min, max)
+}
+
+// BuildSmoothMesh is ChunkSnapshot.BuildSmoothMesh on a snapshot of the chunk
+func (c *Chunk) BuildSmoothMesh() *ChunkMesh {
+	return c.Snapshot().BuildSmoothMesh()
+}
+
+// smoothRegion holds the densities and cell vertices needed to mesh the sample
+// edges starting from min, inclusive, to max, exclusive. Those edges touch the
+// cells from min-1 to max-1 and the samples from min-1 to max.
+type smoothRegion struct {
+	s    *ChunkSnapshot
+	mesh *ChunkMesh
+
+	min  [3]int32
+	size [3]int32
+
+	densities []float32
+	vertices  []int32
+}
+
+func (s *ChunkSnapshot) buildSmoothRegion(mesh *ChunkMesh, min, max [3]int32) *ChunkMesh {
+	r := &smoothRegion{s: s, mesh: mesh}
+	for axis := range min {
+		r.min[axis] = min[axis] - 1
+		r.size[axis] = max[axis] - min[axis] + 2
+	}
+
+	r.densities = make([]float32, r.size[0]*r.size[1]*r.size[2])
+	r.vertices = make([]int32, len(r.densities))
+	for i := range r.densities {
+		x, y, z := r.position(i)
+		r.densities[i] = s.density(s.GetVoxel(x, y, z))
+		r.vertices[i] = -1
+	}
+
+	for x := min[0]; x < max[0]; x++ {
+		for y := min[1]; y < max[1]; y++ {
+			for z := min[2]; z < max[2]; z++ {
+				r.addEdgeQuads([3]int32{x, y, z})
+			}
+		}
+	}
+
+	s.addFluidVoxels(mesh, min, max)
+	s.addShapedVoxels(mesh, min, max)
+	return mesh
+}
+
+// index returns the index of a local position in the region's arrays
+func (r *smoothRegion) index(p [3]int32) int {
+	return int((p[0] - r.min[0]) + (p[1]-r.min[1])*r.size[0] + (p[2]-r.min[2])*r.size[0]*r.size[1])
+}
+
+// position returns the local position at an index of the region's arrays
+func (r *smoothRegion) position(i int) (int32, int32, int32) {
+	index := int32(i)
+	return r.min[0] + index%r.size[0], r.min[1] + index/r.size[0]%r.size[1], r.min[2] + index/(r.size[0]*r.size[1])
+}
+
+func (r *smoothRegion) densityAt(p [3]int32) float32 {
+	return r.densities[r.index(p)]
+}
+
+// addEdgeQuads adds a quad for each edge from sample p in the positive
+// directions that the surface crosses, joining the vertices of the four
+// cells around the edge and facing from the solid sample to the empty one
+func (r *smoothRegion) addEdgeQuads(p [3]int32) {
+	inside := r.densityAt(p) >= smoothIsoLevel
+	for a := 0; a < 3; a++ {
+		q := p
+		q[a]++
+		if (r.densityAt(q) >= smoothIsoLevel) == inside {
+			continue
+		}
+
+		u, v := (a+1)%3, (a+2)%3
+		cells := [4][3]int32{p, p, p, p}
+		cells[0][u]--
+		cells[0][v]--
+		cells[1][v]--
+		cells[3][u]--
+
+		var quad [4]uint32
+		for i, cell := range cells {
+			quad[i] = r.cellVertex(cell)
+		}
+		// The corners run counter-clockwise seen from +a
+		if !inside {
+			quad[1], quad[3] = quad[3], quad[1]
+		}
+		r.mesh.Indices = append(r.mesh.Indices,
+			quad[0], quad[1], quad[2],
+			quad[2], quad[3], quad[0],
+		)
+		r.mesh.IndexCount += 6
+	}
+}
+
+// cellVertex returns the index of the vertex of the cell whose minimum sample
+// is c, adding the vertex the first time the cell is used
+func (r *smoothRegion) cellVertex(c [3]int32) uint32 {
+	i := r.index(c)
+	if r.vertices[i] >= 0 {
+		return uint32(r.vertices[i])
+	}
+
+	var corners [8]float32
+	for j, offset := range cellCorners {
+		corners[j] = r.densityAt([3]int32{c[0] + offset[0], c[1] + offset[1], c[2] + offset[2]})
+	}
+
+	var sum [3]float32
+	crossings := 0
+	for _, edge := range cellEdges {
+		d0, d1 := corners[edge[0]], corners[edge[1]]
+		if (d0 >= smoothIsoLevel) == (d1 >= smoothIsoLevel) {
+			continue
+		}
+		t := (smoothIsoLevel - d0) / (d1 - d0)
+		from, to := cellCorners[edge[0]], cellCorners[edge[1]]
+		for axis := range sum {
+			sum[axis] += float32(from[axis]) + t*float32(to[axis]-from[axis])
+		}
+		crossings++
+	}
+
+	// Samples sit at voxel centres, half a voxel into the cell's first voxel
+	var position [3]float32
+	for axis := range position {
+		position[axis] = float32(c[axis]) + 0.5 + sum[axis]/float32(crossings)
+	}
+
+	// Density rises into the terrain, so the normal points down its gradient
+	var gradient [3]float32
+	for j, offset := range cellCorners {
+		for axis := range gradient {
+			if offset[axis] == 1 {
+				gradient[axis] += corners[j]
+			} else {
+				gradient[axis] -= corners[j]
+			}
+		}
+	}
+	normal := [3]float32{0, 1, 0}
+	if length := float32(math.Sqrt(float64(gradient[0]*gradient[0] + gradient[1]*gradient[1] + gradient[2]*gradient[2]))); length > 0 {
+		normal = [3]float32{-gradient[0] / length, -gradient[1] / length, -gradient[2] / length}
+	}
+
+	r.vertices[i] = int32(r.mesh.VertexCount)
+	r.mesh.addSmoothVertex(position, normal, r.cellColor(c, corners))
+	return uint32(r.vertices[i])
+}
+
+// cellColor returns the colour of a cell's topmost solid voxel, so grassy
+// slopes show grass rather than the dirt beneath
+func (r *smoothRegion) cellColor(c [3]int32, corners [8]float32) [3]float32 {
+	for _, j := range [8]int{2, 3, 6, 7, 0, 1, 4, 5} {
+		if corners[j] < smoothIsoLevel {
+			continue
+		}
+		offset := cellCorners[j]
+		x, y, z := c[0]+offset[0], c[1]+offset[1], c[2]+offset[2]
+		position := r.s.GetWorldPosition().Add(voxel.NewVoxelPosition(x, y, z))
+		return r.s.getVoxelColor(position, r.s.GetVoxel(x, y, z).Type)
+	}
+	return [3]float32{1, 1, 1}
+}
+
+// addSmoothVertex adds a single float vertex, textured by projecting its
+// position along the normal's main axis
+func (cm *ChunkMesh) addSmoothVertex(position, normal, color [3]float32) {
+	uv := [2]float32{position[0], position[2]}
+	ax, ay, az := math.Abs(float64(normal[0])), math.Abs(float64(normal[1])), math.Abs(float64(normal[2]))
+	if ax > ay && ax > az {
+		uv = [2]float32{position[2], position[1]}
+	} else if az > ay {
+		uv = [2]float32{position[0], position[1]}
+	}
+
+	cm.Vertices = append(cm.Vertices,
+		position[0], position[1], position[2],
+		normal[0], normal[1], normal[2],
+		uv[0], uv[1],
+		color[0], color[1], color[2],
+	)
+	cm.VertexCount++
+}
@@ -0,0 +1,143 @@
+package chunk
+
+import (
+	"math"
+	"testing"
+
+	"Ceres/pkg/voxel"
+)
+
+// smoothVertex returns the position of a float vertex in world space, rounded
+// so that the same point meshed by different chunks compares equal
+func smoothVertex(mesh *ChunkMesh, i uint32) [3]float64 {
+	var p [3]float64
+	origin := [3]int32{mesh.Origin.X, mesh.Origin.Y, mesh.Origin.Z}
+	for axis := range p {
+		p[axis] = math.Round((float64(origin[axis])+float64(mesh.Vertices[int(i)*11+axis]))*1e4) / 1e4
+	}
+	return p
+}
+
+func TestSmoothMeshFlatGround(t *testing.T) {
+	c := NewChunk(NewChunkPosition(0, 0, 0))
+	for x := int32(0); x < ChunkSize; x++ {
+		for z := int32(0); z < ChunkSize; z++ {
+			for y := int32(0); y <= 10; y++ {
+				c.SetVoxel(x, y, z, voxel.NewVoxel(voxel.VoxelTypeStone))
+			}
+		}
+	}
+
+	mesh := c.BuildSmoothMesh()
+	if mesh.Format != VertexFormatFloat || mesh.IsEmpty() {
+		t.Fatal("Expected a float mesh with triangles")
+	}
+	for i := 0; i < mesh.VertexCount; i++ {
+		vertex := mesh.Vertices[i*11 : i*11+11]
+		// Away from the chunk's sides, where it is open, the ground is flat
+		if vertex[0] < 1 || vertex[0] > ChunkSize-1 || vertex[2] < 1 || vertex[2] > ChunkSize-1 {
+			continue
+		}
+		if vertex[1] != 11 || vertex[3] != 0 || vertex[4] != 1 || vertex[5] != 0 {
+			t.Fatalf("Expected vertices at y=11 facing up, got %v", vertex[:6])
+		}
+	}
+}
+
+func TestSmoothMeshIsSeamlessAcrossChunks(t *testing.T) {
+	// A ball around a chunk corner, meshed by the eight chunks it spans
+	cm := NewChunkManager()
+	for x := int32(-1); x <= 1; x++ {
+		for y := int32(-1); y <= 1; y++ {
+			for z := int32(-1); z <= 1; z++ {
+				cm.InsertChunk(NewChunk(NewChunkPosition(x, y, z)))
+			}
+		}
+	}
+	var writes []VoxelWrite
+	for x := int32(-10); x < 10; x++ {
+		for y := int32(-10); y < 10; y++ {
+			for z := int32(-10); z < 10; z++ {
+				if x*x+y*y+z*z < 81 {
+					writes = append(writes, VoxelWrite{Position: voxel.NewVoxelPosition(x, y, z), Voxel: voxel.NewVoxel(voxel.VoxelTypeStone)})
+				}
+			}
+		}
+	}
+	cm.SetVoxels(writes)
+
+	// On a closed surface every edge is used once in each direction
+	edges := make(map[[2][3]float64]int)
+	volume := 0.0
+	for _, c := range cm.GetLoadedChunks() {
+		mesh := c.BuildSmoothMesh()
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			var p [3][3]float64
+			for j := range p {
+				p[j] = smoothVertex(mesh, mesh.Indices[i+j])
+			}
+			for j := range p {
+				edges[[2][3]float64{p[j], p[(j+1)%3]}]++
+				edges[[2][3]float64{p[(j+1)%3], p[j]}]--
+			}
+			// Outward facing triangles add up to a positive volume
+			volume += (p[0][0]*(p[1][1]*p[2][2]-p[1][2]*p[2][1]) +
+				p[0][1]*(p[1][2]*p[2][0]-p[1][0]*p[2][2]) +
+				p[0][2]*(p[1][0]*p[2][1]-p[1][1]*p[2][0])) / 6
+		}
+
+		for i := 0; i < mesh.VertexCount; i++ {
+			p := smoothVertex(mesh, uint32(i))
+			normal := mesh.Vertices[i*11+3 : i*11+6]
+			// Samples sit at voxel centres, so the ball's centre is at -0.5
+			if float32(p[0]+0.5)*normal[0]+float32(p[1]+0.5)*normal[1]+float32(p[2]+0.5)*normal[2] <= 0 {
+				t.Fatalf("Expected normals to point out of the ball, got %v at %v", normal, p)
+			}
+		}
+	}
+
+	if want := 4.0 / 3 * math.Pi * 9 * 9 * 9; volume < want*0.8 || volume > want*1.2 {
+		t.Errorf("Expected the ball to enclose about %.0f, got %.0f", want, volume)
+	}
+	for edge, balance := range edges {
+		if balance != 0 {
+			t.Fatalf("Expected the chunk meshes to close up, edge %v is open", edge)
+		}
+	}
+}
+
+func TestSmoothSectionMeshesMatchChunkMesh(t *testing.T) {
+	c := terrainChunk(NewChunkPosition(1, 0, -2))
+	want := c.BuildSmoothMesh()
+
+	snapshot := c.Snapshot()
+	indices := 0
+	for section := 0; section < SectionCount; section++ {
+		indices += snapshot.BuildSmoothSectionMesh(section).IndexCount
+	}
+	if indices != want.IndexCount {
+		t.Errorf("Expected the section meshes to have the chunk mesh's %d indices, got %d", want.IndexCount, indices)
+	}
+}
+
+func TestMeshModeText(t *testing.T) {
+	for _, mode := range []MeshMode{MeshModeBlocky, MeshModeSmooth} {
+		text, err := mode.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed MeshMode
+		if err := parsed.UnmarshalText(text); err != nil || parsed != mode {
+			t.Errorf("Expected %v to round trip, got %v, %v", mode, parsed, err)
+		}
+	}
+
+	var mode MeshMode
+	if err := mode.UnmarshalText([]byte("voxelly")); err == nil {
+		t.Error("Expected an unknown mode to fail")
+	}
+}
+
+func BenchmarkChunkMeshSmooth(b *testing.B) {
+	benchmarkChunkMesh(b, func(c *Chunk) *ChunkMesh { return c.BuildSmoothMesh() })
+}
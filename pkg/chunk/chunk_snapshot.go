@@ -0,0 +1,147 @@
+package chunk
+
+import (
+	"Ceres/pkg/voxel"
+)
+
+// PaddedSize is the edge length of a snapshot: the chunk plus a one voxel
+// border on every side
+const PaddedSize = ChunkSize + 2
+
+// ChunkSnapshot is an immutable copy of a chunk and the one voxel border
+// around it, taken from its neighbours, including those sharing only an edge
+// or corner. It holds everything meshing reads, voxel properties included,
+// so meshes can be built from it without locks while the chunk keeps changing.
+type ChunkSnapshot struct {
+	Position ChunkPosition
+
+	voxels [PaddedSize * PaddedSize * PaddedSize]voxel.Voxel
+	props  *voxel.PropertyTable
+
+	tintSource TintSource
+	isEmpty    bool
+}
+
+// Snapshot copies the chunk and its border. Each chunk involved is locked once
+// while its voxels are copied; border voxels of missing neighbours are air.
+func (c *Chunk) Snapshot() *ChunkSnapshot {
+	s := &ChunkSnapshot{Position: c.Position, props: voxel.CurrentPropertyTable()}
+
+	c.mutex.RLock()
+	for z := int32(0); z < ChunkSize; z++ {
+		for y := int32(0); y < ChunkSize; y++ {
+			from := localToIndex(0, y, z)
+			copy(s.voxels[paddedIndex(0, y, z):], c.voxels[from:from+ChunkSize])
+		}
+	}
+	s.tintSource = c.tintSource
+	s.isEmpty = c.isEmpty
+	c.mutex.RUnlock()
+
+	for dx := int32(-1); dx <= 1; dx++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for dz := int32(-1); dz <= 1; dz++ {
+				if dx == 0 && dy == 0 && dz == 0 {
+					continue
+				}
+				if neighbor := c.neighborAt(dx, dy, dz); neighbor != nil {
+					neighbor.copyBorder(s, dx, dy, dz)
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+// neighborAt returns the chunk at an offset of up to one chunk on each axis,
+// reached through face neighbours in the same axis order as GetVoxelSafe
+func (c *Chunk) neighborAt(dx, dy, dz int32) *Chunk {
+	steps := [3]struct {
+		d         int32
+		neg, plus voxel.VoxelFace
+	}{
+		{dx, voxel.VoxelFaceLeft, voxel.VoxelFaceRight},
+		{dy, voxel.VoxelFaceBottom, voxel.VoxelFaceTop},
+		{dz, voxel.VoxelFaceBack, voxel.VoxelFaceFront},
+	}
+
+	neighbor := c
+	for _, step := range steps {
+		if step.d == 0 {
+			continue
+		}
+		face := step.plus
+		if step.d < 0 {
+			face = step.neg
+		}
+		if neighbor = neighbor.GetNeighbor(face); neighbor == nil {
+			return nil
+		}
+	}
+	return neighbor
+}
+
+// copyBorder copies the part of this chunk lying in the border of a snapshot
+// of the chunk at the opposite offset
+func (c *Chunk) copyBorder(s *ChunkSnapshot, dx, dy, dz int32) {
+	var min, max [3]int32
+	for axis, d := range [3]int32{dx, dy, dz} {
+		switch d {
+		case -1:
+			min[axis], max[axis] = ChunkSize-1, ChunkSize
+		case 0:
+			min[axis], max[axis] = 0, ChunkSize
+		case 1:
+			min[axis], max[axis] = 0, 1
+		}
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for z := min[2]; z < max[2]; z++ {
+		for y := min[1]; y < max[1]; y++ {
+			for x := min[0]; x < max[0]; x++ {
+				s.voxels[paddedIndex(x+dx*ChunkSize, y+dy*ChunkSize, z+dz*ChunkSize)] = c.voxels[localToIndex(x, y, z)]
+			}
+		}
+	}
+}
+
+// GetVoxel returns the voxel at a local position, from -1 to ChunkSize on
+// each axis; positions beyond the border are air
+func (s *ChunkSnapshot) GetVoxel(x, y, z int32) voxel.Voxel {
+	if x < -1 || x > ChunkSize || y < -1 || y > ChunkSize || z < -1 || z > ChunkSize {
+		return voxel.NewVoxel(voxel.VoxelTypeAir)
+	}
+	return s.voxels[paddedIndex(x, y, z)]
+}
+
+// IsEmpty reports whether the chunk held only air, whatever its border
+func (s *ChunkSnapshot) IsEmpty() bool {
+	return s.isEmpty
+}
+
+func (s *ChunkSnapshot) GetWorldPosition() voxel.VoxelPosition {
+	return s.Position.GetWorldPosition()
+}
+
+// ComputeConnectivity is Chunk.ComputeConnectivity on the snapshot
+func (s *ChunkSnapshot) ComputeConnectivity() FaceConnectivity {
+	if s.isEmpty {
+		return AllFacesConnected
+	}
+
+	var open [ChunkSize * ChunkSize * ChunkSize]bool
+	for i := range open {
+		x, y, z := indexToLocal(i)
+		open[i] = !s.props.IsOpaque(s.voxels[paddedIndex(x, y, z)])
+	}
+	return floodConnectivity(&open)
+}
+
+// paddedIndex returns the index of a local position in a snapshot
+func paddedIndex(x, y, z int32) int {
+	return int((x + 1) + (y+1)*PaddedSize + (z+1)*PaddedSize*PaddedSize)
+}
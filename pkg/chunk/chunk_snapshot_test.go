@@ -0,0 +1,98 @@
+package chunk
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"Ceres/pkg/voxel"
+)
+
+func TestSnapshotCopiesBorder(t *testing.T) {
+	cm := cleanTerrain()
+	cm.SetVoxel(voxel.NewVoxelPosition(-1, -1, -1), voxel.NewVoxel(voxel.VoxelTypeGlass))
+	cm.SetVoxel(voxel.NewVoxelPosition(ChunkSize, 5, ChunkSize), voxel.NewVoxel(voxel.VoxelTypeWater))
+
+	s := cm.GetChunk(NewChunkPosition(0, 0, 0)).Snapshot()
+	for x := int32(-1); x <= ChunkSize; x++ {
+		for y := int32(-1); y <= ChunkSize; y++ {
+			for z := int32(-1); z <= ChunkSize; z++ {
+				want := cm.GetVoxel(voxel.NewVoxelPosition(x, y, z))
+				if got := s.GetVoxel(x, y, z); got != want {
+					t.Fatalf("Expected %v at %d,%d,%d, got %v", want, x, y, z, got)
+				}
+			}
+		}
+	}
+
+	if got := s.GetVoxel(-2, 0, 0); !got.IsAir() {
+		t.Errorf("Expected air beyond the border, got %v", got)
+	}
+
+	// Without neighbours the border is air
+	lone := terrainChunk(NewChunkPosition(0, 0, 0)).Snapshot()
+	if got := lone.GetVoxel(-1, 0, 0); !got.IsAir() {
+		t.Errorf("Expected air where there is no neighbour, got %v", got)
+	}
+}
+
+func TestSnapshotIsUnaffectedByLaterEdits(t *testing.T) {
+	cm := cleanTerrain()
+	c := cm.GetChunk(NewChunkPosition(0, 0, 0))
+	s := c.Snapshot()
+	want := s.BuildPackedMesh(false)
+
+	cm.SetVoxel(voxel.NewVoxelPosition(4, 4, 4), voxel.NewVoxel(voxel.VoxelTypeAir))
+	cm.SetVoxel(voxel.NewVoxelPosition(-1, 20, 3), voxel.NewVoxel(voxel.VoxelTypeBrick))
+
+	if !s.GetVoxel(4, 4, 4).IsOpaque() || s.GetVoxel(-1, 20, 3).Type == voxel.VoxelTypeBrick {
+		t.Error("Expected the snapshot to keep the voxels it was taken with")
+	}
+	if got := s.BuildPackedMesh(false); !slices.Equal(got.Packed, want.Packed) {
+		t.Error("Expected the snapshot to mesh the same after the chunk changed")
+	}
+	if got := c.BuildPackedMesh(false); slices.Equal(got.Packed, want.Packed) {
+		t.Error("Expected a new snapshot to see the edits")
+	}
+}
+
+func TestMeshingConcurrentWithEdits(t *testing.T) {
+	cm := cleanTerrain()
+	c := cm.GetChunk(NewChunkPosition(0, 0, 0))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := int32(0); i < 2000; i++ {
+			pos := voxel.NewVoxelPosition(i%(ChunkSize+2)-1, 20+i%7, i%(ChunkSize+2)-1)
+			cm.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelType(1+i%3)))
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		c.TakeDirtySections()
+		s := c.Snapshot()
+		s.ComputeConnectivity()
+		for section := 0; section < SectionCount; section++ {
+			s.BuildPackedSectionMesh(section, i%2 == 0)
+		}
+	}
+	wg.Wait()
+
+	// Edits made while meshing leave their sections dirty
+	c.TakeDirtySections()
+	cm.SetVoxel(voxel.NewVoxelPosition(3, 20, 3), voxel.NewVoxel(voxel.VoxelTypeAir))
+	if !c.IsDirty() {
+		t.Error("Expected an edit after taking the dirty sections to mark the chunk dirty")
+	}
+}
+
+func BenchmarkChunkSnapshot(b *testing.B) {
+	c := cleanTerrain().GetChunk(NewChunkPosition(0, 0, 0))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Snapshot()
+	}
+}
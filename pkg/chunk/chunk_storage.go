@@ -0,0 +1,179 @@
+package chunk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"Ceres/pkg/voxel"
+)
+
+// pendingFile holds the writes queued for ungenerated chunks, see
+// Storage.SavePending
+const pendingFile = "pending.dat"
+
+// pendingFormatVersion is written at the start of the pending writes file
+const pendingFormatVersion uint8 = 1
+
+// Storage keeps chunks on disk as one file per chunk in a directory
+type Storage struct {
+	Dir string
+}
+
+// NewStorage creates a storage in dir, creating the directory if needed
+func NewStorage(dir string) (*Storage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create chunk directory: %w", err)
+	}
+	return &Storage{Dir: dir}, nil
+}
+
+func (s *Storage) path(pos ChunkPosition) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("c.%d.%d.%d.chunk", pos.X, pos.Y, pos.Z))
+}
+
+// Exists reports whether a chunk has been saved at pos
+func (s *Storage) Exists(pos ChunkPosition) bool {
+	_, err := os.Stat(s.path(pos))
+	return err == nil
+}
+
+// Save writes the chunk, replacing any previous file atomically
+func (s *Storage) Save(c *Chunk) error {
+	var buf bytes.Buffer
+	if err := c.Encode(&buf); err != nil {
+		return fmt.Errorf("failed to encode chunk %v: %w", c.Position, err)
+	}
+
+	path := s.path(c.Position)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write chunk %v: %w", c.Position, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write chunk %v: %w", c.Position, err)
+	}
+	return nil
+}
+
+// Load reads the chunk at pos. The error wraps os.ErrNotExist when the chunk
+// was never saved.
+func (s *Storage) Load(pos ChunkPosition) (*Chunk, error) {
+	f, err := os.Open(s.path(pos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chunk %v: %w", pos, err)
+	}
+	defer f.Close()
+
+	c, err := DecodeChunk(f)
+	if err != nil {
+		return nil, err
+	}
+	if c.Position != pos {
+		return nil, fmt.Errorf("chunk file for %v contains chunk %v", pos, c.Position)
+	}
+	return c, nil
+}
+
+// SavePending writes the decoration writes queued for chunks that have not
+// been generated yet, from ChunkManager.PendingWrites, replacing any saved
+// before. Without them, features crossing into chunks generated after a
+// restart would be cut off at the border.
+func (s *Storage) SavePending(writes []VoxelWrite) error {
+	path := filepath.Join(s.Dir, pendingFile)
+	if len(writes) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove pending writes: %w", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(pendingFormatVersion)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(writes)))
+	for _, write := range writes {
+		binary.Write(&buf, binary.LittleEndian, write.Position)
+		buf.WriteByte(byte(write.Voxel.Type))
+		buf.WriteByte(write.Voxel.State)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write pending writes: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write pending writes: %w", err)
+	}
+	return nil
+}
+
+// LoadPending reads the writes saved by SavePending, for
+// ChunkManager.RestorePendingWrites. A world without any has none.
+func (s *Storage) LoadPending() ([]VoxelWrite, error) {
+	f, err := os.Open(filepath.Join(s.Dir, pendingFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pending writes: %w", err)
+	}
+	defer f.Close()
+
+	writes, err := decodePending(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending writes: %w", err)
+	}
+	return writes, nil
+}
+
+func decodePending(r io.Reader) ([]VoxelWrite, error) {
+	var header struct {
+		Version uint8
+		Count   uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.Version != pendingFormatVersion {
+		return nil, fmt.Errorf("unsupported pending writes version %d", header.Version)
+	}
+
+	var writes []VoxelWrite
+	for i := uint32(0); i < header.Count; i++ {
+		var entry struct {
+			Position voxel.VoxelPosition
+			Type     uint8
+			State    uint8
+		}
+		if err := binary.Read(r, binary.LittleEndian, &entry); err != nil {
+			return nil, err
+		}
+		writes = append(writes, VoxelWrite{
+			Position: entry.Position,
+			Voxel:    voxel.Voxel{Type: voxel.VoxelType(entry.Type), State: entry.State},
+		})
+	}
+	return writes, nil
+}
+
+// SaveModified saves every loaded chunk that changed since it was last saved
+// and returns the number of chunks written
+func (cm *ChunkManager) SaveModified(s *Storage) (int, error) {
+	saved := 0
+	for _, c := range cm.GetLoadedChunks() {
+		if !c.takeModified() {
+			continue
+		}
+		if err := s.Save(c); err != nil {
+			c.SetModified(true)
+			return saved, err
+		}
+		saved++
+	}
+	return saved, nil
+}
@@ -0,0 +1,123 @@
+package chunk
+
+import (
+	"sort"
+
+	"Ceres/pkg/voxel"
+)
+
+// ScheduledTick is a block update due at an absolute world tick
+type ScheduledTick struct {
+	Position voxel.VoxelPosition
+	Due      uint64
+}
+
+// ScheduleTick schedules an update of the local voxel at the given absolute
+// tick. Scheduled ticks are stored with the chunk so they survive saving and
+// loading. An earlier pending tick for the same voxel is kept.
+func (c *Chunk) ScheduleTick(x, y, z int32, due uint64) {
+	c.scheduleIn(&c.scheduled, x, y, z, due)
+}
+
+// TakeDueTicks removes and returns the ticks due at or before now, ordered by
+// due tick and then position
+func (c *Chunk) TakeDueTicks(now uint64) []ScheduledTick {
+	return c.takeDueIn(c.scheduled, now)
+}
+
+// ScheduledTicks returns every pending tick of the chunk, ordered by due tick
+// and then position
+func (c *Chunk) ScheduledTicks() []ScheduledTick {
+	return c.ticksIn(c.scheduled)
+}
+
+// ScheduleFluidTick schedules a fluid update like ScheduleTick. Fluid updates
+// are kept apart from block ticks, so the fluid simulator and the tick
+// scheduler's handlers never run each other's updates.
+func (c *Chunk) ScheduleFluidTick(x, y, z int32, due uint64) {
+	c.scheduleIn(&c.fluidScheduled, x, y, z, due)
+}
+
+// TakeDueFluidTicks is TakeDueTicks for fluid updates
+func (c *Chunk) TakeDueFluidTicks(now uint64) []ScheduledTick {
+	return c.takeDueIn(c.fluidScheduled, now)
+}
+
+// FluidTicks is ScheduledTicks for fluid updates
+func (c *Chunk) FluidTicks() []ScheduledTick {
+	return c.ticksIn(c.fluidScheduled)
+}
+
+func (c *Chunk) scheduleIn(scheduled *map[int]uint64, x, y, z int32, due uint64) {
+	if !isValidLocalCoord(x, y, z) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if *scheduled == nil {
+		*scheduled = make(map[int]uint64)
+	}
+
+	index := localToIndex(x, y, z)
+	if existing, ok := (*scheduled)[index]; ok && existing <= due {
+		return
+	}
+	(*scheduled)[index] = due
+	c.isModified = true
+}
+
+func (c *Chunk) takeDueIn(scheduled map[int]uint64, now uint64) []ScheduledTick {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var due []ScheduledTick
+	for index, at := range scheduled {
+		if at > now {
+			continue
+		}
+		due = append(due, c.scheduledTick(index, at))
+		delete(scheduled, index)
+	}
+
+	sortScheduledTicks(due)
+	return due
+}
+
+func (c *Chunk) ticksIn(scheduled map[int]uint64) []ScheduledTick {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	ticks := make([]ScheduledTick, 0, len(scheduled))
+	for index, at := range scheduled {
+		ticks = append(ticks, c.scheduledTick(index, at))
+	}
+
+	sortScheduledTicks(ticks)
+	return ticks
+}
+
+func (c *Chunk) scheduledTick(index int, due uint64) ScheduledTick {
+	x, y, z := indexToLocal(index)
+	return ScheduledTick{
+		Position: c.GetWorldPosition().Add(voxel.NewVoxelPosition(x, y, z)),
+		Due:      due,
+	}
+}
+
+func sortScheduledTicks(ticks []ScheduledTick) {
+	sort.Slice(ticks, func(i, j int) bool {
+		a, b := ticks[i], ticks[j]
+		if a.Due != b.Due {
+			return a.Due < b.Due
+		}
+		if a.Position.Y != b.Position.Y {
+			return a.Position.Y < b.Position.Y
+		}
+		if a.Position.Z != b.Position.Z {
+			return a.Position.Z < b.Position.Z
+		}
+		return a.Position.X < b.Position.X
+	})
+}
@@ -0,0 +1,230 @@
+package chunk
+
+import (
+	"Ceres/pkg/voxel"
+)
+
+// FaceConnectivity records which pairs of a chunk's six faces are connected
+// through non-opaque voxels, so that something seen through one face may be
+// seen through the other. Bit a*6+b is set when faces a and b are connected.
+type FaceConnectivity uint64
+
+// AllFacesConnected is the connectivity of a chunk with no opaque voxels
+const AllFacesConnected FaceConnectivity = 1<<36 - 1
+
+func faceBit(a, b voxel.VoxelFace) FaceConnectivity {
+	return 1 << (uint(a)*6 + uint(b))
+}
+
+// Connected reports whether faces a and b are connected
+func (fc FaceConnectivity) Connected(a, b voxel.VoxelFace) bool {
+	return fc&faceBit(a, b) != 0
+}
+
+func (fc *FaceConnectivity) connect(a, b voxel.VoxelFace) {
+	*fc |= faceBit(a, b) | faceBit(b, a)
+}
+
+// ComputeConnectivity flood fills the chunk's non-opaque voxels and connects
+// every pair of faces touched by the same region
+func (c *Chunk) ComputeConnectivity() FaceConnectivity {
+	if c.IsEmpty() {
+		return AllFacesConnected
+	}
+
+	var open [ChunkSize * ChunkSize * ChunkSize]bool
+	props := voxel.CurrentPropertyTable()
+	c.mutex.RLock()
+	for i, v := range c.voxels {
+		open[i] = !props.IsOpaque(v)
+	}
+	c.mutex.RUnlock()
+
+	return floodConnectivity(&open)
+}
+
+// floodConnectivity connects the faces touched by each region of open voxels,
+// indexed like Chunk voxels
+func floodConnectivity(open *[ChunkSize * ChunkSize * ChunkSize]bool) FaceConnectivity {
+	var visited [ChunkSize * ChunkSize * ChunkSize]bool
+	var connectivity FaceConnectivity
+	queue := make([]int, 0, 1024)
+	for start := range open {
+		if !open[start] || visited[start] {
+			continue
+		}
+
+		var touched uint8
+		visited[start] = true
+		queue = append(queue[:0], start)
+		for len(queue) > 0 {
+			index := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			x, y, z := indexToLocal(index)
+			touched |= boundaryFaces(x, y, z)
+
+			for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+				offset := voxel.GetFaceOffset(face)
+				nx, ny, nz := x+offset.X, y+offset.Y, z+offset.Z
+				if !isValidLocalCoord(nx, ny, nz) {
+					continue
+				}
+				next := localToIndex(nx, ny, nz)
+				if open[next] && !visited[next] {
+					visited[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		for a := voxel.VoxelFaceTop; a <= voxel.VoxelFaceBack; a++ {
+			for b := a; b <= voxel.VoxelFaceBack; b++ {
+				if touched&(1<<a) != 0 && touched&(1<<b) != 0 {
+					connectivity.connect(a, b)
+				}
+			}
+		}
+	}
+
+	return connectivity
+}
+
+// boundaryFaces returns a bit per chunk face the local voxel lies on
+func boundaryFaces(x, y, z int32) uint8 {
+	var faces uint8
+	if y == ChunkSize-1 {
+		faces |= 1 << voxel.VoxelFaceTop
+	}
+	if y == 0 {
+		faces |= 1 << voxel.VoxelFaceBottom
+	}
+	if x == 0 {
+		faces |= 1 << voxel.VoxelFaceLeft
+	}
+	if x == ChunkSize-1 {
+		faces |= 1 << voxel.VoxelFaceRight
+	}
+	if z == ChunkSize-1 {
+		faces |= 1 << voxel.VoxelFaceFront
+	}
+	if z == 0 {
+		faces |= 1 << voxel.VoxelFaceBack
+	}
+	return faces
+}
+
+// VisibilityGraph holds the face connectivity of meshed chunks and finds the
+// chunks that may be visible from the camera. Chunks without an entry, such
+// as unloaded or never meshed ones, count as open air.
+type VisibilityGraph struct {
+	connectivity map[ChunkPosition]FaceConnectivity
+}
+
+func NewVisibilityGraph() *VisibilityGraph {
+	return &VisibilityGraph{
+		connectivity: make(map[ChunkPosition]FaceConnectivity),
+	}
+}
+
+// Set records a chunk's connectivity, normally from ChunkMesh.Connectivity
+func (g *VisibilityGraph) Set(pos ChunkPosition, connectivity FaceConnectivity) {
+	g.connectivity[pos] = connectivity
+}
+
+// Remove forgets a chunk, which then counts as open air
+func (g *VisibilityGraph) Remove(pos ChunkPosition) {
+	delete(g.connectivity, pos)
+}
+
+// Clear forgets every chunk
+func (g *VisibilityGraph) Clear() {
+	clear(g.connectivity)
+}
+
+// Connectivity returns a chunk's connectivity
+func (g *VisibilityGraph) Connectivity(pos ChunkPosition) FaceConnectivity {
+	if connectivity, exists := g.connectivity[pos]; exists {
+		return connectivity
+	}
+	return AllFacesConnected
+}
+
+// visibilityStep is a chunk reached by the search, the face it was entered
+// through and the directions travelled to get there
+type visibilityStep struct {
+	pos        ChunkPosition
+	from       voxel.VoxelFace
+	directions uint8
+}
+
+// Visible returns the chunks that may be seen from the camera's chunk, in
+// breadth-first order starting with the camera's chunk. The search leaves a
+// chunk only through faces connected to the one it came in by, never turns
+// back towards the camera, and skips chunks more than maxDistance chunks away
+// or for which inView, when set, returns false. Chunks hidden behind solid
+// terrain are never reached.
+func (g *VisibilityGraph) Visible(camera ChunkPosition, maxDistance int32, inView func(pos ChunkPosition) bool) []ChunkPosition {
+	visited := map[ChunkPosition]bool{camera: true}
+	visible := []ChunkPosition{camera}
+
+	var queue []visibilityStep
+	enqueue := func(from visibilityStep, face voxel.VoxelFace) {
+		offset := voxel.GetFaceOffset(face)
+		next := NewChunkPosition(from.pos.X+offset.X, from.pos.Y+offset.Y, from.pos.Z+offset.Z)
+		if visited[next] || chebyshevDistance(camera, next) > maxDistance {
+			return
+		}
+		if inView != nil && !inView(next) {
+			return
+		}
+		visited[next] = true
+		queue = append(queue, visibilityStep{
+			pos:        next,
+			from:       getOppositeFace(face),
+			directions: from.directions | 1<<face,
+		})
+	}
+
+	// The camera can look out through every face of its own chunk
+	start := visibilityStep{pos: camera}
+	for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+		enqueue(start, face)
+	}
+
+	for len(queue) > 0 {
+		step := queue[0]
+		queue = queue[1:]
+		visible = append(visible, step.pos)
+
+		connectivity := g.Connectivity(step.pos)
+		for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+			if step.directions&(1<<getOppositeFace(face)) != 0 {
+				continue
+			}
+			if !connectivity.Connected(step.from, face) {
+				continue
+			}
+			enqueue(step, face)
+		}
+	}
+
+	return visible
+}
+
+func chebyshevDistance(a, b ChunkPosition) int32 {
+	d := abs32(a.X - b.X)
+	if dy := abs32(a.Y - b.Y); dy > d {
+		d = dy
+	}
+	if dz := abs32(a.Z - b.Z); dz > d {
+		d = dz
+	}
+	return d
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,138 @@
+package chunk
+
+import (
+	"testing"
+
+	"Ceres/pkg/voxel"
+)
+
+// solidChunk returns a chunk filled with stone except where carve returns true
+func solidChunk(position ChunkPosition, carve func(x, y, z int32) bool) *Chunk {
+	c := NewChunk(position)
+	for x := int32(0); x < ChunkSize; x++ {
+		for y := int32(0); y < ChunkSize; y++ {
+			for z := int32(0); z < ChunkSize; z++ {
+				if carve == nil || !carve(x, y, z) {
+					c.SetVoxel(x, y, z, voxel.NewVoxel(voxel.VoxelTypeStone))
+				}
+			}
+		}
+	}
+	return c
+}
+
+func TestComputeConnectivity(t *testing.T) {
+	top, bottom := voxel.VoxelFaceTop, voxel.VoxelFaceBottom
+	left, right := voxel.VoxelFaceLeft, voxel.VoxelFaceRight
+	front, back := voxel.VoxelFaceFront, voxel.VoxelFaceBack
+
+	if got := NewChunk(NewChunkPosition(0, 0, 0)).ComputeConnectivity(); got != AllFacesConnected {
+		t.Errorf("Expected an empty chunk to connect every face, got %b", got)
+	}
+	if got := solidChunk(NewChunkPosition(0, 0, 0), nil).ComputeConnectivity(); got != 0 {
+		t.Errorf("Expected a solid chunk to connect nothing, got %b", got)
+	}
+
+	// A sealed cave touches no face
+	cave := solidChunk(NewChunkPosition(0, 0, 0), func(x, y, z int32) bool {
+		return x > 4 && x < 28 && y > 4 && y < 28 && z > 4 && z < 28
+	})
+	if got := cave.ComputeConnectivity(); got != 0 {
+		t.Errorf("Expected a sealed cave to connect nothing, got %b", got)
+	}
+
+	// A tunnel along X and, separately, a glass-walled shaft along Y
+	tunnels := solidChunk(NewChunkPosition(0, 0, 0), func(x, y, z int32) bool {
+		return (y == 4 && z == 4) || (x == 20 && z == 20)
+	})
+	for y := int32(0); y < ChunkSize; y++ {
+		tunnels.SetVoxel(21, y, 20, voxel.NewVoxel(voxel.VoxelTypeGlass))
+	}
+	got := tunnels.ComputeConnectivity()
+	if !got.Connected(left, right) || !got.Connected(right, left) {
+		t.Error("Expected the tunnel to connect the left and right faces")
+	}
+	if !got.Connected(top, bottom) {
+		t.Error("Expected the shaft to connect the top and bottom faces")
+	}
+	for _, pair := range [][2]voxel.VoxelFace{{left, top}, {right, bottom}, {front, back}, {top, front}} {
+		if got.Connected(pair[0], pair[1]) {
+			t.Errorf("Expected faces %d and %d not to be connected", pair[0], pair[1])
+		}
+	}
+}
+
+// undergroundGraph is a 5x3x5 block of chunks: open air at y=0, a solid
+// layer at y=-1 and caves open on every side at y=-2
+func undergroundGraph() *VisibilityGraph {
+	g := NewVisibilityGraph()
+	for x := int32(-2); x <= 2; x++ {
+		for z := int32(-2); z <= 2; z++ {
+			g.Set(NewChunkPosition(x, 0, z), AllFacesConnected)
+			g.Set(NewChunkPosition(x, -1, z), 0)
+			g.Set(NewChunkPosition(x, -2, z), AllFacesConnected)
+		}
+	}
+	return g
+}
+
+func visibleSet(positions []ChunkPosition) map[ChunkPosition]bool {
+	set := make(map[ChunkPosition]bool)
+	for _, pos := range positions {
+		set[pos] = true
+	}
+	return set
+}
+
+func TestVisibilitySkipsCavesBehindSolidTerrain(t *testing.T) {
+	g := undergroundGraph()
+	camera := NewChunkPosition(0, 0, 0)
+
+	visible := visibleSet(g.Visible(camera, 2, nil))
+	if !visible[camera] || !visible[NewChunkPosition(2, 0, -2)] {
+		t.Error("Expected the camera's chunk and the open chunks around it to be visible")
+	}
+	if !visible[NewChunkPosition(1, -1, 1)] {
+		t.Error("Expected the solid layer itself to be visible")
+	}
+	for pos := range visible {
+		if pos.Y == -2 {
+			t.Errorf("Expected caves behind the solid layer to be hidden, got %v", pos)
+		}
+	}
+
+	// A shaft through the solid layer opens up the caves beneath it
+	shaft := solidChunk(NewChunkPosition(0, -1, 0), func(x, y, z int32) bool {
+		return x == 16 && z == 16
+	})
+	g.Set(shaft.Position, shaft.BuildMesh(false).Connectivity)
+
+	visible = visibleSet(g.Visible(camera, 2, nil))
+	for _, pos := range []ChunkPosition{{0, -2, 0}, {1, -2, 0}, {2, -2, -1}} {
+		if !visible[pos] {
+			t.Errorf("Expected cave chunk %v to be visible down the shaft", pos)
+		}
+	}
+	if visible[NewChunkPosition(0, -3, 0)] {
+		t.Error("Expected chunks beyond maxDistance to be skipped")
+	}
+}
+
+func TestVisibilityRespectsView(t *testing.T) {
+	g := undergroundGraph()
+	camera := NewChunkPosition(0, 0, 0)
+
+	// Looking towards +X only
+	visible := g.Visible(camera, 2, func(pos ChunkPosition) bool { return pos.X >= 0 })
+	if len(visible) == 0 || visible[0] != camera {
+		t.Fatalf("Expected the camera's chunk first, got %v", visible)
+	}
+	for _, pos := range visible {
+		if pos.X < 0 {
+			t.Errorf("Expected chunks outside the view to be skipped, got %v", pos)
+		}
+	}
+	if !visibleSet(visible)[NewChunkPosition(2, 0, 2)] {
+		t.Error("Expected chunks inside the view to be visible")
+	}
+}
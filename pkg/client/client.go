@@ -0,0 +1,316 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+	"Ceres/pkg/engine"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/protocol"
+	"Ceres/pkg/voxel"
+)
+
+// inboxSize is how many received packets may wait for Update
+const inboxSize = 4096
+
+// inputRedundancy is how many of the newest unacknowledged inputs each input
+// packet repeats, so a lost packet rarely loses an input
+const inputRedundancy = 4
+
+// RemoteEntity is an entity replicated from the server. Position is the
+// newest known position; InterpolatedPosition gives a smooth one to draw.
+type RemoteEntity struct {
+	ID       uint64
+	Kind     string
+	Position ceresmath.Vector3d
+	Velocity ceresmath.Vector3
+
+	samples []entitySample
+}
+
+// Client is a connection to a server. Packets are read in the background and
+// applied to the local world when the game loop calls Update, so the world
+// only changes on the caller's goroutine.
+type Client struct {
+	conn    protocol.Transport
+	welcome protocol.Welcome
+
+	chunks     *chunk.ChunkManager
+	entities   map[uint64]*RemoteEntity
+	predictor  *Predictor
+	serverTick uint64
+
+	// time is the world clock at timeTick
+	time     uint64
+	timeTick uint64
+
+	edits         map[uint32]pendingEdit
+	editsAt       map[voxel.VoxelPosition]int
+	nextEdit      uint32
+	editListeners []EditRejectedListener
+
+	inbox   chan protocol.Packet
+	done    chan struct{}
+	closing chan struct{}
+	once    sync.Once
+
+	mutex  sync.Mutex
+	err    error
+	reason string
+}
+
+// Dial connects to a server over TCP
+func Dial(address, name string) (*Client, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+
+	c, err := Connect(conn, name)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Connect performs the handshake over an existing connection
+func Connect(conn net.Conn, name string) (*Client, error) {
+	return ConnectTransport(protocol.NewConn(conn), name)
+}
+
+// ConnectTransport performs the handshake over any packet transport, such as
+// one end of a protocol.SimulatedLink
+func ConnectTransport(conn protocol.Transport, name string) (*Client, error) {
+	welcome, err := protocol.ClientHandshake(conn, name)
+	if err != nil {
+		return nil, err
+	}
+
+	timestep := welcome.Timestep
+	if timestep <= 0 {
+		timestep = engine.DefaultFixedTimestep
+	}
+
+	c := &Client{
+		conn:       conn,
+		welcome:    *welcome,
+		chunks:     chunk.NewChunkManager(),
+		entities:   make(map[uint64]*RemoteEntity),
+		serverTick: welcome.Tick,
+		time:       welcome.Time,
+		timeTick:   welcome.Tick,
+		edits:      make(map[uint32]pendingEdit),
+		editsAt:    make(map[voxel.VoxelPosition]int),
+		inbox:      make(chan protocol.Packet, inboxSize),
+		done:       make(chan struct{}),
+		closing:    make(chan struct{}),
+	}
+	c.predictor = NewPredictor(c.chunks, ecs.PlayerAt(welcome.Spawn), float32(timestep.Seconds()))
+	go c.readLoop()
+
+	return c, nil
+}
+
+// Chunks returns the client's copy of the world
+func (c *Client) Chunks() *chunk.ChunkManager {
+	return c.chunks
+}
+
+// PlayerID returns the ID of the entity representing this client on the server
+func (c *Client) PlayerID() uint64 {
+	return c.welcome.PlayerID
+}
+
+// Welcome returns the server's handshake reply
+func (c *Client) Welcome() protocol.Welcome {
+	return c.welcome
+}
+
+// WorldTime returns the world clock at the newest server tick seen, see
+// sky.TimeOfDay
+func (c *Client) WorldTime() uint64 {
+	return c.time + c.serverTick - c.timeTick
+}
+
+// Entity returns a replicated entity by ID
+func (c *Client) Entity(id uint64) (RemoteEntity, bool) {
+	entity, ok := c.entities[id]
+	if !ok {
+		return RemoteEntity{}, false
+	}
+	return *entity, true
+}
+
+// Entities returns every replicated entity, ordered by ID
+func (c *Client) Entities() []RemoteEntity {
+	entities := make([]RemoteEntity, 0, len(c.entities))
+	for _, entity := range c.entities {
+		entities = append(entities, *entity)
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].ID < entities[j].ID })
+	return entities
+}
+
+// SendPosition moves the player's collider centre directly, bypassing
+// movement physics. Only spectators may; the server's next snapshot moves
+// other players back.
+func (c *Client) SendPosition(position ceresmath.Vector3d) error {
+	c.predictor.Reset(ecs.PlayerState{Position: position})
+	return c.conn.Send(&protocol.PlayerPosition{Position: position})
+}
+
+// SendInput predicts one tick of movement locally and sends the input to the
+// server. Call it once per fixed update, after Update.
+func (c *Client) SendInput(input ecs.PlayerInput) error {
+	c.predictor.Predict(input)
+
+	pending := c.predictor.Pending()
+	if len(pending) > inputRedundancy {
+		pending = pending[len(pending)-inputRedundancy:]
+	}
+	return c.conn.Send(&protocol.PlayerInput{Inputs: append([]ecs.PlayerInput(nil), pending...)})
+}
+
+// Predictor returns the local player's predictor
+func (c *Client) Predictor() *Predictor {
+	return c.predictor
+}
+
+// PlayerState returns the predicted state of the local player
+func (c *Client) PlayerState() ecs.PlayerState {
+	return c.predictor.State()
+}
+
+// Update applies every packet received since the last call and returns how
+// many were applied
+func (c *Client) Update() (int, error) {
+	applied := 0
+	for {
+		select {
+		case p := <-c.inbox:
+			if err := c.apply(p); err != nil {
+				return applied, err
+			}
+			applied++
+		default:
+			return applied, nil
+		}
+	}
+}
+
+func (c *Client) apply(p protocol.Packet) error {
+	switch p := p.(type) {
+	case *protocol.ChunkData:
+		decoded, err := p.Chunk()
+		if err != nil {
+			return fmt.Errorf("failed to apply chunk: %w", err)
+		}
+		c.chunks.InsertChunk(decoded)
+	case *protocol.UnloadChunk:
+		c.chunks.UnloadChunk(p.Position)
+	case *protocol.VoxelDelta:
+		c.applyDelta(p.Changes)
+	case *protocol.EntitySpawn:
+		entity := &RemoteEntity{ID: p.ID, Kind: p.Kind, Position: p.Position}
+		entity.addSample(p.Tick, p.Position)
+		c.entities[p.ID] = entity
+		c.observeTick(p.Tick)
+	case *protocol.EntityMove:
+		if entity, ok := c.entities[p.ID]; ok {
+			entity.Position = p.Position
+			entity.Velocity = p.Velocity
+			entity.addSample(p.Tick, p.Position)
+		}
+		c.observeTick(p.Tick)
+	case *protocol.PlayerSnapshot:
+		c.predictor.Reconcile(p.LastInput, p.State)
+		c.observeTick(p.Tick)
+	case *protocol.EntityDespawn:
+		delete(c.entities, p.ID)
+	case *protocol.EditResult:
+		c.resolveEdit(p)
+	case *protocol.TimeUpdate:
+		c.time, c.timeTick = p.Time, p.Tick
+		c.observeTick(p.Tick)
+	}
+	return nil
+}
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+
+	for {
+		p, err := c.conn.Receive()
+		if err != nil {
+			c.setError(err)
+			return
+		}
+
+		switch p := p.(type) {
+		case *protocol.KeepAlive:
+			if err := c.conn.Send(p); err != nil {
+				c.setError(err)
+				return
+			}
+		case *protocol.Disconnect:
+			c.mutex.Lock()
+			c.reason = p.Reason
+			c.mutex.Unlock()
+			c.conn.Close()
+			return
+		default:
+			select {
+			case c.inbox <- p:
+			case <-c.closing:
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) setError(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// Done is closed when the connection ends
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error that ended the connection, if any
+func (c *Client) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+// DisconnectReason returns the reason the server gave for disconnecting, if any
+func (c *Client) DisconnectReason() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.reason
+}
+
+// Close tells the server the client is leaving and closes the connection
+func (c *Client) Close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.closing)
+		c.conn.Send(&protocol.Disconnect{Reason: "client closed"})
+		err = c.conn.Close()
+		<-c.done
+	})
+	return err
+}
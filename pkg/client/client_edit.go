@@ -0,0 +1,101 @@
+package client
+
+import (
+	"errors"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/protocol"
+	"Ceres/pkg/voxel"
+)
+
+// ErrChunkNotLoaded is returned for edits in chunks the client has not received
+var ErrChunkNotLoaded = errors.New("chunk not loaded")
+
+// EditRejection describes an edit the server refused and the client rolled back
+type EditRejection struct {
+	Position voxel.VoxelPosition
+	Voxel    voxel.Voxel
+	Status   protocol.EditStatus
+}
+
+// EditRejectedListener is called from Update when the server rejects an edit
+type EditRejectedListener func(rejection EditRejection)
+
+// pendingEdit is an edit applied locally and waiting for the server's verdict
+type pendingEdit struct {
+	position voxel.VoxelPosition
+	voxel    voxel.Voxel
+}
+
+// SetVoxel changes a voxel at once and asks the server to make the same
+// change. If the server rejects the edit, the voxel is rolled back to the
+// server's voxel and the edit rejected listeners are called.
+func (c *Client) SetVoxel(pos voxel.VoxelPosition, v voxel.Voxel) error {
+	if c.chunks.GetChunkIfExists(chunk.VoxelToChunkPosition(pos)) == nil {
+		return ErrChunkNotLoaded
+	}
+
+	expected := c.chunks.GetVoxel(pos)
+	c.chunks.SetVoxel(pos, v)
+
+	c.nextEdit++
+	c.edits[c.nextEdit] = pendingEdit{position: pos, voxel: v}
+	c.editsAt[pos]++
+
+	return c.conn.Send(&protocol.EditRequest{ID: c.nextEdit, Position: pos, Expected: expected, Voxel: v})
+}
+
+// PendingEdits returns the number of edits waiting for the server
+func (c *Client) PendingEdits() int {
+	return len(c.edits)
+}
+
+// AddEditRejectedListener registers a listener for rejected edits
+func (c *Client) AddEditRejectedListener(listener EditRejectedListener) {
+	c.editListeners = append(c.editListeners, listener)
+}
+
+// resolveEdit applies the server's verdict on an edit. Once the last pending
+// edit at a position resolves, the position takes the server's voxel, which
+// rolls back a rejected edit and any delta skipped while it was pending.
+func (c *Client) resolveEdit(result *protocol.EditResult) {
+	edit, ok := c.edits[result.ID]
+	if !ok {
+		return
+	}
+	delete(c.edits, result.ID)
+
+	c.editsAt[edit.position]--
+	if c.editsAt[edit.position] == 0 {
+		delete(c.editsAt, edit.position)
+		loaded := c.chunks.GetChunkIfExists(chunk.VoxelToChunkPosition(edit.position)) != nil
+		if loaded && result.Status != protocol.EditUnloaded {
+			c.chunks.SetVoxel(edit.position, result.Voxel)
+		}
+	}
+
+	if result.Status == protocol.EditAccepted {
+		return
+	}
+	rejection := EditRejection{Position: edit.position, Voxel: edit.voxel, Status: result.Status}
+	for _, listener := range c.editListeners {
+		listener(rejection)
+	}
+}
+
+// applyDelta applies voxel changes from the server, except at positions with
+// pending edits, which the edit results settle
+func (c *Client) applyDelta(changes []chunk.VoxelWrite) {
+	if len(c.editsAt) == 0 {
+		c.chunks.SetVoxels(changes)
+		return
+	}
+
+	writes := make([]chunk.VoxelWrite, 0, len(changes))
+	for _, change := range changes {
+		if c.editsAt[change.Position] == 0 {
+			writes = append(writes, change)
+		}
+	}
+	c.chunks.SetVoxels(writes)
+}
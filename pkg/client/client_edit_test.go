@@ -0,0 +1,217 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"Ceres/pkg/ecs"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/protocol"
+	"Ceres/pkg/server"
+	"Ceres/pkg/voxel"
+)
+
+// editHarness connects clients over links with a little latency and streams
+// the spawn area to all of them
+func editHarness(t *testing.T, configure func(config *server.Config), names ...string) (*harness, []*Client, []*protocol.SimulatedLink) {
+	h := newHarness(t, func(config *server.Config) {
+		config.SpawnRadius = 1
+		if configure != nil {
+			configure(config)
+		}
+	})
+
+	var clients []*Client
+	var links []*protocol.SimulatedLink
+	for _, name := range names {
+		c, link := h.connectLink(name, protocol.LinkConfig{Latency: 50 * time.Millisecond})
+		clients = append(clients, c)
+		links = append(links, link)
+	}
+
+	loaded := func() bool {
+		for _, c := range clients {
+			if loadedChunks(c) < 18 {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i < 40 && !loaded(); i++ {
+		h.stepAll(clients, links)
+	}
+	if !loaded() {
+		t.Fatal("Expected the spawn area to stream to every client")
+	}
+
+	return h, clients, links
+}
+
+// settleEdits ticks until no client has pending edits
+func (h *harness) settleEdits(clients []*Client, links []*protocol.SimulatedLink) {
+	h.t.Helper()
+
+	pending := func() int {
+		n := 0
+		for _, c := range clients {
+			n += c.PendingEdits()
+		}
+		return n
+	}
+	for i := 0; i < 20 && pending() > 0; i++ {
+		h.stepAll(clients, links)
+	}
+	if n := pending(); n > 0 {
+		h.t.Fatalf("Expected every edit to be resolved, %d pending", n)
+	}
+	// One more tick delivers the deltas sent with the last results
+	h.stepAll(clients, links)
+}
+
+// nearSpawn returns a voxel beside the spawn point at head height
+func nearSpawn(c *Client, dx int32) voxel.VoxelPosition {
+	spawn := c.Welcome().Spawn
+	return voxel.NewVoxelPosition(int32(spawn.X)+dx, int32(spawn.Y)+1, int32(spawn.Z))
+}
+
+func TestConcurrentEditsOnSameVoxel(t *testing.T) {
+	h, clients, links := editHarness(t, nil, "a", "b")
+	a, b := clients[0], clients[1]
+
+	var rejections []EditRejection
+	b.AddEditRejectedListener(func(rejection EditRejection) {
+		rejections = append(rejections, rejection)
+	})
+	a.AddEditRejectedListener(func(rejection EditRejection) {
+		t.Errorf("Expected a's edit to be accepted, got %v", rejection.Status)
+	})
+
+	target := nearSpawn(a, 2)
+	h.srv.SetVoxel(target, voxel.NewVoxel(voxel.VoxelTypeAir))
+	h.stepAll(clients, links)
+	h.stepAll(clients, links)
+
+	brick, glass := voxel.NewVoxel(voxel.VoxelTypeBrick), voxel.NewVoxel(voxel.VoxelTypeGlass)
+	if err := a.SetVoxel(target, brick); err != nil {
+		t.Fatalf("Expected a's edit to send, got %v", err)
+	}
+	if err := b.SetVoxel(target, glass); err != nil {
+		t.Fatalf("Expected b's edit to send, got %v", err)
+	}
+
+	// Both clients see their own edit before the server answers
+	if a.Chunks().GetVoxel(target) != brick || b.Chunks().GetVoxel(target) != glass {
+		t.Error("Expected edits to apply locally at once")
+	}
+
+	h.settleEdits(clients, links)
+
+	// a joined first, so its edit is applied first and b's conflicts with it
+	if got := h.srv.Chunks().GetVoxel(target); got != brick {
+		t.Errorf("Expected the server to keep a's brick, got %v", got.Type)
+	}
+	for _, c := range clients {
+		if got := c.Chunks().GetVoxel(target); got != brick {
+			t.Errorf("Expected every client to end with a's brick, got %v", got.Type)
+		}
+	}
+	if len(rejections) != 1 || rejections[0].Status != protocol.EditConflict || rejections[0].Voxel != glass {
+		t.Errorf("Expected b's glass to be rejected as a conflict, got %+v", rejections)
+	}
+}
+
+func TestTeleportingDoesNotExtendReach(t *testing.T) {
+	h, clients, links := editHarness(t, nil, "cheater")
+	c := clients[0]
+
+	var statuses []protocol.EditStatus
+	c.AddEditRejectedListener(func(rejection EditRejection) {
+		statuses = append(statuses, rejection.Status)
+	})
+
+	// Room to stand beside a voxel far out of reach of spawn
+	far := nearSpawn(c, 20)
+	spawn := c.Welcome().Spawn
+	for y := int32(spawn.Y) - 1; y <= int32(spawn.Y)+2; y++ {
+		h.srv.SetVoxel(voxel.NewVoxelPosition(far.X-2, y, far.Z), voxel.NewVoxel(voxel.VoxelTypeAir))
+	}
+	beside := ecs.PlayerAt(ceresmath.Vector3d{X: float64(far.X) - 1.5, Y: spawn.Y, Z: float64(far.Z) + 0.5}).Position
+	before := h.srv.Chunks().GetVoxel(far)
+
+	brick := voxel.NewVoxel(voxel.VoxelTypeBrick)
+	for _, spectator := range []bool{false, true} {
+		h.srv.SetSpectator(ecs.Entity(c.PlayerID()), spectator)
+		if err := c.SendPosition(beside); err != nil {
+			t.Fatalf("Expected the position to send, got %v", err)
+		}
+		if err := c.SetVoxel(far, brick); err != nil {
+			t.Fatalf("Expected the edit to send, got %v", err)
+		}
+		h.settleEdits(clients, links)
+	}
+
+	if serverPosition(h, c) != beside {
+		t.Errorf("Expected the spectator to be moved beside the voxel, got %+v", serverPosition(h, c))
+	}
+	if len(statuses) != 2 || statuses[0] != protocol.EditOutOfReach || statuses[1] != protocol.EditForbidden {
+		t.Errorf("Expected out of reach and forbidden rejections, got %v", statuses)
+	}
+	if got := h.srv.Chunks().GetVoxel(far); got != before {
+		t.Errorf("Expected the server to keep %v, got %v", before.Type, got.Type)
+	}
+}
+
+func TestRejectedEditsRollBack(t *testing.T) {
+	protected := func(config *server.Config) {
+		config.ProtectedRegions = []server.Region{{
+			Min: voxel.NewVoxelPosition(-3, -64, 2),
+			Max: voxel.NewVoxelPosition(3, 128, 4),
+		}}
+	}
+	h, clients, links := editHarness(t, protected, "editor", "observer")
+	editor, observer := clients[0], clients[1]
+
+	var statuses []protocol.EditStatus
+	editor.AddEditRejectedListener(func(rejection EditRejection) {
+		statuses = append(statuses, rejection.Status)
+	})
+
+	far := nearSpawn(editor, 20)
+	inside := voxel.NewVoxelPosition(nearSpawn(editor, 0).X, nearSpawn(editor, 0).Y, 3)
+	allowed := nearSpawn(editor, -2)
+	before := map[voxel.VoxelPosition]voxel.Voxel{
+		far:    editor.Chunks().GetVoxel(far),
+		inside: editor.Chunks().GetVoxel(inside),
+	}
+
+	brick := voxel.NewVoxel(voxel.VoxelTypeBrick)
+	for _, pos := range []voxel.VoxelPosition{far, inside, allowed} {
+		if err := editor.SetVoxel(pos, brick); err != nil {
+			t.Fatalf("Expected edit at %v to send, got %v", pos, err)
+		}
+	}
+	h.settleEdits(clients, links)
+
+	if len(statuses) != 2 || statuses[0] != protocol.EditOutOfReach || statuses[1] != protocol.EditProtected {
+		t.Errorf("Expected out of reach and protected rejections, got %v", statuses)
+	}
+	for pos, v := range before {
+		if got := editor.Chunks().GetVoxel(pos); got != v {
+			t.Errorf("Expected rejected edit at %v to roll back to %v, got %v", pos, v.Type, got.Type)
+		}
+		if got := h.srv.Chunks().GetVoxel(pos); got != v {
+			t.Errorf("Expected the server to keep %v at %v, got %v", v.Type, pos, got.Type)
+		}
+	}
+
+	// The accepted edit is broadcast to the other client
+	if got := observer.Chunks().GetVoxel(allowed); got != brick {
+		t.Errorf("Expected the observer to receive the accepted edit, got %v", got.Type)
+	}
+
+	unloaded := voxel.NewVoxelPosition(500, 40, 500)
+	if err := editor.SetVoxel(unloaded, brick); !errors.Is(err, ErrChunkNotLoaded) {
+		t.Errorf("Expected ErrChunkNotLoaded, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	ceresmath "Ceres/pkg/math"
+)
+
+// InterpolationDelay is how many ticks behind the newest server tick remote
+// entities are drawn, so a later position is usually known to move towards
+const InterpolationDelay = 2
+
+// maxEntitySamples limits the position history kept per entity
+const maxEntitySamples = 32
+
+// entitySample is an entity's position at a server tick
+type entitySample struct {
+	tick     uint64
+	position ceresmath.Vector3d
+}
+
+// addSample records a position from the server. The server only sends moves,
+// so after a pause the previous position is repeated one tick earlier to keep
+// the entity still until it started moving.
+func (e *RemoteEntity) addSample(tick uint64, position ceresmath.Vector3d) {
+	if n := len(e.samples); n > 0 {
+		last := e.samples[n-1]
+		if tick <= last.tick {
+			e.samples[n-1].position = position
+			return
+		}
+		if tick-last.tick > 1 {
+			e.samples = append(e.samples, entitySample{tick: tick - 1, position: last.position})
+		}
+	}
+
+	e.samples = append(e.samples, entitySample{tick: tick, position: position})
+	if excess := len(e.samples) - maxEntitySamples; excess > 0 {
+		e.samples = append(e.samples[:0], e.samples[excess:]...)
+	}
+}
+
+// positionAt interpolates the entity's position at a fractional server tick,
+// holding the first or last known position outside the recorded history
+func (e *RemoteEntity) positionAt(renderTick float64) ceresmath.Vector3d {
+	if len(e.samples) == 0 {
+		return e.Position
+	}
+	if renderTick <= float64(e.samples[0].tick) {
+		return e.samples[0].position
+	}
+
+	for i := 1; i < len(e.samples); i++ {
+		from, to := e.samples[i-1], e.samples[i]
+		if renderTick <= float64(to.tick) {
+			t := (renderTick - float64(from.tick)) / float64(to.tick-from.tick)
+			return from.position.Lerp(to.position, t)
+		}
+	}
+	return e.samples[len(e.samples)-1].position
+}
+
+// ServerTick returns the newest server tick the client has heard of
+func (c *Client) ServerTick() uint64 {
+	return c.serverTick
+}
+
+// RenderTick returns the fractional server tick to draw remote entities at,
+// InterpolationDelay ticks behind the newest one. Alpha is the fraction of a
+// tick since the last update, as passed to Render.
+func (c *Client) RenderTick(alpha float32) float64 {
+	return float64(c.serverTick) + float64(alpha) - InterpolationDelay
+}
+
+// InterpolatedPosition returns where to draw a remote entity at renderTick
+func (c *Client) InterpolatedPosition(id uint64, renderTick float64) (ceresmath.Vector3d, bool) {
+	entity, ok := c.entities[id]
+	if !ok {
+		return ceresmath.Vector3d{}, false
+	}
+	return entity.positionAt(renderTick), true
+}
+
+func (c *Client) observeTick(tick uint64) {
+	if tick > c.serverTick {
+		c.serverTick = tick
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+)
+
+// Predictor runs the local player ahead of the server. Inputs are applied
+// immediately with the same movement code the server uses and kept until the
+// server acknowledges them. When a snapshot arrives the predictor rewinds to
+// the server's state and replays the inputs the server has not seen yet.
+type Predictor struct {
+	chunks *chunk.ChunkManager
+	dt     float32
+
+	state    ecs.PlayerState
+	pending  []ecs.PlayerInput
+	sequence uint32
+	acked    uint32
+
+	correction float32
+}
+
+// NewPredictor creates a predictor starting at state, stepping dt seconds per input
+func NewPredictor(cm *chunk.ChunkManager, state ecs.PlayerState, dt float32) *Predictor {
+	return &Predictor{
+		chunks: cm,
+		dt:     dt,
+		state:  state,
+	}
+}
+
+// State returns the predicted player state
+func (p *Predictor) State() ecs.PlayerState {
+	return p.state
+}
+
+// Predict numbers the input, applies it locally and returns it for sending
+func (p *Predictor) Predict(input ecs.PlayerInput) ecs.PlayerInput {
+	p.sequence++
+	input.Sequence = p.sequence
+
+	p.state = ecs.StepPlayer(p.chunks, p.state, input, p.dt)
+	p.pending = append(p.pending, input)
+	return input
+}
+
+// Pending returns the inputs the server has not acknowledged, oldest first
+func (p *Predictor) Pending() []ecs.PlayerInput {
+	return p.pending
+}
+
+// Reconcile replaces the predicted state with the server's state after
+// lastInput and replays the newer inputs on top of it. Snapshots older than
+// one already reconciled are ignored.
+func (p *Predictor) Reconcile(lastInput uint32, server ecs.PlayerState) {
+	if lastInput < p.acked {
+		return
+	}
+	p.acked = lastInput
+
+	i := 0
+	for i < len(p.pending) && p.pending[i].Sequence <= lastInput {
+		i++
+	}
+	p.pending = append(p.pending[:0], p.pending[i:]...)
+
+	predicted := p.state
+	p.state = server
+	for _, input := range p.pending {
+		p.state = ecs.StepPlayer(p.chunks, p.state, input, p.dt)
+	}
+	p.correction = float32(p.state.Position.Distance(predicted.Position))
+}
+
+// Reset moves the player without physics, such as after a teleport, and
+// forgets the inputs predicted from the old position
+func (p *Predictor) Reset(state ecs.PlayerState) {
+	p.state = state
+	p.pending = p.pending[:0]
+}
+
+// Correction returns how far the last reconciliation moved the player. It is
+// zero while the prediction agrees with the server.
+func (p *Predictor) Correction() float32 {
+	return p.correction
+}
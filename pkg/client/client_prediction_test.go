@@ -0,0 +1,269 @@
+package client
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"Ceres/internal/testworld"
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/protocol"
+	"Ceres/pkg/server"
+	"Ceres/pkg/voxel"
+)
+
+// step runs one tick in lockstep: the client predicts and sends input (when
+// given), the link advances one tick, the server simulates it and the client
+// applies whatever has arrived
+func (h *harness) step(c *Client, link *protocol.SimulatedLink, input *ecs.PlayerInput) {
+	h.t.Helper()
+
+	if input != nil {
+		if err := c.SendInput(*input); err != nil {
+			h.t.Fatalf("Expected input to send, got %v", err)
+		}
+	}
+	h.stepAll([]*Client{c}, []*protocol.SimulatedLink{link})
+}
+
+// stepAll runs one tick in lockstep for several clients, each on its own link
+func (h *harness) stepAll(clients []*Client, links []*protocol.SimulatedLink) {
+	h.t.Helper()
+
+	for _, link := range links {
+		link.Advance(h.srv.FixedTimestep())
+		link.WaitIdle()
+	}
+	h.clock.Advance(h.srv.FixedTimestep())
+	h.eng.Frame()
+	h.srv.Flush()
+	for _, link := range links {
+		link.WaitIdle()
+	}
+
+	for _, c := range clients {
+		if _, err := c.Update(); err != nil {
+			h.t.Fatalf("Expected updates to apply, got %v", err)
+		}
+	}
+}
+
+func serverPosition(h *harness, c *Client) ceresmath.Vector3d {
+	transform, _ := ecs.Get[ecs.Transform](h.srv.Entities(), ecs.Entity(c.PlayerID()))
+	return transform.Position
+}
+
+// settle stops sending input until the client has received the server's
+// state after its last input
+func (h *harness) settle(c *Client, link *protocol.SimulatedLink) {
+	for i := 0; i < 40 && len(c.Predictor().Pending()) > 0; i++ {
+		h.step(c, link, nil)
+	}
+	if pending := len(c.Predictor().Pending()); pending > 0 {
+		h.t.Fatalf("Expected every input to be acknowledged, %d pending", pending)
+	}
+}
+
+// prepareWalk clears a flat corridor east of spawn, so nothing stops a walk
+// along it, and waits for the client to load and settle
+func (h *harness) prepareWalk(c *Client, link *protocol.SimulatedLink) {
+	spawn := c.Welcome().Spawn
+	floor := int32(spawn.Y) - 1
+	for x := int32(0); x < 12; x++ {
+		h.srv.SetVoxel(voxel.NewVoxelPosition(x, floor, 0), voxel.NewVoxel(voxel.VoxelTypeStone))
+		for y := floor + 1; y <= floor+3; y++ {
+			h.srv.SetVoxel(voxel.NewVoxelPosition(x, y, 0), voxel.NewVoxel(voxel.VoxelTypeAir))
+		}
+	}
+
+	idle := &ecs.PlayerInput{}
+	for i := 0; i < 40 && loadedChunks(c) < 18; i++ {
+		h.step(c, link, idle)
+	}
+	for i := 0; i < 10; i++ {
+		h.step(c, link, idle)
+	}
+	h.settle(c, link)
+}
+
+func newPredictionHarness(t *testing.T) *harness {
+	return newHarness(t, func(config *server.Config) {
+		config.SpawnRadius = 1
+	})
+}
+
+func TestPredictionRunsAheadOfServer(t *testing.T) {
+	h := newPredictionHarness(t)
+	c, link := h.connectLink("walker", protocol.LinkConfig{Latency: 100 * time.Millisecond})
+
+	h.prepareWalk(c, link)
+
+	start := serverPosition(h, c)
+	if c.PlayerState().Position != start {
+		t.Fatalf("Expected client to start at the server position %+v, got %+v", start, c.PlayerState().Position)
+	}
+
+	walk := &ecs.PlayerInput{Forward: 1}
+	h.step(c, link, walk)
+	if c.PlayerState().Position.X <= start.X {
+		t.Error("Expected the client to move before the server acknowledged the input")
+	}
+	if serverPosition(h, c) != start {
+		t.Error("Expected the server not to have the input yet")
+	}
+
+	for i := 0; i < 19; i++ {
+		h.step(c, link, walk)
+		if correction := c.Predictor().Correction(); correction != 0 {
+			t.Fatalf("Expected prediction to match the server, corrected by %f", correction)
+		}
+	}
+	h.settle(c, link)
+
+	if got, want := c.PlayerState().Position, serverPosition(h, c); got != want {
+		t.Errorf("Expected client to end at the server position %+v, got %+v", want, got)
+	}
+	if moved, want := serverPosition(h, c).X-start.X, float64(20*ecs.PlayerWalkSpeed*float32(0.05)); math.Abs(moved-want) > 1e-3 {
+		t.Errorf("Expected the server to move the player %f blocks, moved %f", want, moved)
+	}
+}
+
+func TestFloodedInputsDoNotSpeedUpPlayer(t *testing.T) {
+	h := newPredictionHarness(t)
+	c, link := h.connectLink("flooder", protocol.LinkConfig{Latency: 50 * time.Millisecond})
+	h.prepareWalk(c, link)
+
+	// Eight inputs a tick, each walking a full tick's distance
+	step := float64(ecs.PlayerWalkSpeed * float32(h.srv.FixedTimestep().Seconds()))
+	start := serverPosition(h, c)
+	previous := start
+	for tick := 0; tick < 20; tick++ {
+		for i := 0; i < 8; i++ {
+			if err := c.SendInput(ecs.PlayerInput{Forward: 1}); err != nil {
+				t.Fatalf("Expected input to send, got %v", err)
+			}
+		}
+		h.step(c, link, nil)
+
+		// The idle ticks before the flood saved a few inputs for jitter,
+		// which the first tick may spend
+		position := serverPosition(h, c)
+		if moved := position.X - previous.X; tick > 0 && moved > step+1e-6 {
+			t.Fatalf("Expected at most one input's movement per tick, %f blocks, moved %f on tick %d", step, moved, tick)
+		}
+		previous = position
+	}
+
+	walked := previous.X - start.X
+	if walked > 23*step+1e-6 {
+		t.Errorf("Expected flooding not to speed the player up, walked %f in 20 ticks", walked)
+	}
+	if walked < 15*step {
+		t.Errorf("Expected the player to keep walking at walking speed, walked %f", walked)
+	}
+}
+
+// lossyWalk walks a player around over a lossy link and returns its final
+// predicted state and the number of inputs the link dropped
+func lossyWalk(t *testing.T) (ecs.PlayerState, int) {
+	h := newPredictionHarness(t)
+	config := protocol.LinkConfig{Latency: 100 * time.Millisecond, Jitter: 50 * time.Millisecond, Loss: 0.25, Seed: 3}
+	c, link := h.connectLink("walker", config)
+
+	for i := 0; i < 60; i++ {
+		input := ecs.PlayerInput{Forward: 1, Yaw: float32(i * 6), Jump: i%15 == 0}
+		h.step(c, link, &input)
+	}
+
+	// Once the network recovers the client converges on the server's state
+	link.SetConfig(protocol.LinkConfig{Latency: 100 * time.Millisecond})
+	idle := &ecs.PlayerInput{}
+	for i := 0; i < 5; i++ {
+		h.step(c, link, idle)
+	}
+	h.settle(c, link)
+
+	if got, want := c.PlayerState().Position, serverPosition(h, c); got != want {
+		t.Errorf("Expected client to converge on the server position %+v, got %+v", want, got)
+	}
+	return c.PlayerState(), link.Client().Dropped()
+}
+
+func TestReconciliationOverLossyLink(t *testing.T) {
+	first, dropped := lossyWalk(t)
+	if dropped == 0 {
+		t.Error("Expected the link to drop some inputs")
+	}
+
+	second, droppedAgain := lossyWalk(t)
+	if first != second || dropped != droppedAgain {
+		t.Errorf("Expected the same seed to give the same run, got %+v (%d dropped) and %+v (%d dropped)",
+			first, dropped, second, droppedAgain)
+	}
+}
+
+func TestPredictorReplaysUnacknowledgedInputs(t *testing.T) {
+	cm := chunk.NewChunkManager()
+	testworld.Floor(cm, 0, 0, chunk.ChunkSize-1, voxel.VoxelTypeStone)
+
+	dt := float32(1.0 / 20)
+	start := ecs.PlayerState{Position: ceresmath.Vector3d{X: 8.5, Y: 1.9, Z: 8.5}, OnGround: true}
+	p := NewPredictor(cm, start, dt)
+	for i := 0; i < 3; i++ {
+		if input := p.Predict(ecs.PlayerInput{Forward: 1}); input.Sequence != uint32(i+1) {
+			t.Errorf("Expected sequence %d, got %d", i+1, input.Sequence)
+		}
+	}
+
+	// The server applied the first input but pushed the player sideways
+	server := ecs.StepPlayer(cm, start, ecs.PlayerInput{Forward: 1}, dt)
+	server.Position.Z += 1
+	p.Reconcile(1, server)
+
+	want := server
+	for i := 0; i < 2; i++ {
+		want = ecs.StepPlayer(cm, want, ecs.PlayerInput{Forward: 1}, dt)
+	}
+	if p.State() != want {
+		t.Errorf("Expected %+v after replaying two inputs, got %+v", want, p.State())
+	}
+	if len(p.Pending()) != 2 || p.Pending()[0].Sequence != 2 {
+		t.Errorf("Expected inputs 2 and 3 to be pending, got %+v", p.Pending())
+	}
+	if math.Abs(float64(p.Correction()-1)) > 1e-4 {
+		t.Errorf("Expected a correction of 1 block, got %f", p.Correction())
+	}
+
+	// A snapshot older than the last one reconciled is ignored
+	p.Reconcile(0, start)
+	if p.State() != want {
+		t.Error("Expected a stale snapshot to be ignored")
+	}
+}
+
+func TestRemoteEntityInterpolation(t *testing.T) {
+	entity := &RemoteEntity{}
+	entity.addSample(10, ceresmath.Vector3d{X: 0})
+	entity.addSample(11, ceresmath.Vector3d{X: 2})
+	entity.addSample(20, ceresmath.Vector3d{X: 4})
+
+	tests := []struct {
+		tick float64
+		want float64
+	}{
+		{5, 0},
+		{10.5, 1},
+		{10.75, 1.5},
+		// The entity stood still after tick 11 until just before tick 20
+		{15, 2},
+		{19.5, 3},
+		{25, 4},
+	}
+	for _, tt := range tests {
+		if got := entity.positionAt(tt.tick); math.Abs(got.X-tt.want) > 1e-5 {
+			t.Errorf("Expected x=%f at tick %f, got %f", tt.want, tt.tick, got.X)
+		}
+	}
+}
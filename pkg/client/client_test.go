@@ -0,0 +1,321 @@
+package client
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+	"Ceres/pkg/engine"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/protocol"
+	"Ceres/pkg/server"
+	"Ceres/pkg/sky"
+	"Ceres/pkg/voxel"
+)
+
+// maxPumpTicks bounds how long pump waits. It counts server ticks rather
+// than wall-clock time, so slow machines and the race detector do not make
+// tests time out.
+const maxPumpTicks = 2000
+
+// harness runs a server on a fake clock and connects clients over simulated
+// links, so each tick can wait until the clients have received what it sent
+type harness struct {
+	t     *testing.T
+	srv   *server.Server
+	eng   *engine.Engine
+	clock *engine.FakeClock
+	links map[*Client]*protocol.SimulatedLink
+}
+
+func newHarness(t *testing.T, configure func(config *server.Config)) *harness {
+	config := server.DefaultConfig(t.TempDir())
+	config.Seed = 42
+	config.Address = ""
+	config.SpawnRadius = 0
+	config.MaxChunkY = 1
+	config.ViewDistance = 1
+	config.ChunksPerTick = 4
+	if configure != nil {
+		configure(&config)
+	}
+
+	h := &harness{
+		t:     t,
+		srv:   server.NewServer(config),
+		clock: engine.NewFakeClock(),
+		links: make(map[*Client]*protocol.SimulatedLink),
+	}
+	h.eng = engine.NewEngine(h.srv, nil)
+	h.eng.SetClock(h.clock)
+	h.eng.FixedTimestep = h.srv.FixedTimestep()
+	if err := h.eng.Start(); err != nil {
+		t.Fatalf("Expected server to start, got %v", err)
+	}
+	t.Cleanup(h.eng.Shutdown)
+
+	return h
+}
+
+// connect connects a client over a link without latency or loss
+func (h *harness) connect(name string) *Client {
+	c, _ := h.connectLink(name, protocol.LinkConfig{})
+	return c
+}
+
+// connectLink connects a client over a simulated link. The handshake runs
+// without latency, then the link switches to config.
+func (h *harness) connectLink(name string, config protocol.LinkConfig) (*Client, *protocol.SimulatedLink) {
+	link := protocol.NewSimulatedLink(protocol.LinkConfig{Seed: config.Seed})
+	h.srv.ConnectTransport(link.Server())
+
+	c, err := ConnectTransport(link.Client(), name)
+	if err != nil {
+		h.t.Fatalf("Expected %s to connect, got %v", name, err)
+	}
+	h.t.Cleanup(func() { c.Close() })
+
+	link.SetConfig(config)
+	h.links[c] = link
+	return c, link
+}
+
+// pump runs ticks in lockstep, see stepAll, until cond holds
+func (h *harness) pump(clients []*Client, cond func() bool) {
+	h.t.Helper()
+
+	var links []*protocol.SimulatedLink
+	for _, c := range clients {
+		links = append(links, h.links[c])
+	}
+
+	for tick := 0; tick < maxPumpTicks; tick++ {
+		h.stepAll(clients, links)
+		if cond() {
+			return
+		}
+	}
+	h.t.Fatalf("Expected clients to catch up within %d ticks (%d connected)", maxPumpTicks, h.srv.ClientCount())
+}
+
+func loadedChunks(c *Client) int {
+	return c.Chunks().GetStats().LoadedChunks
+}
+
+func TestChunkStreamingWithMultipleClients(t *testing.T) {
+	h := newHarness(t, nil)
+	a, b := h.connect("a"), h.connect("b")
+
+	// 3x3 chunks around spawn, two chunks tall
+	h.pump([]*Client{a, b}, func() bool {
+		return loadedChunks(a) == 18 && loadedChunks(b) == 18
+	})
+
+	spawn := a.Welcome().Spawn
+	below := voxel.NewVoxelPosition(0, int32(spawn.Y)-1, 0)
+	if a.Chunks().GetVoxel(below) != h.srv.Chunks().GetVoxel(below) {
+		t.Error("Expected streamed chunks to match the server")
+	}
+
+	// b walks ten chunks east and only keeps chunks around its new position
+	far := ceresmath.Vector3d{X: 10*chunk.ChunkSize + 0.5, Y: spawn.Y, Z: 0.5}
+	h.srv.Teleport(ecs.Entity(b.PlayerID()), far)
+	h.pump([]*Client{a, b}, func() bool {
+		if loadedChunks(b) != 18 {
+			return false
+		}
+		for _, c := range b.Chunks().GetLoadedChunks() {
+			if c.Position.X < 9 {
+				return false
+			}
+		}
+		return true
+	})
+
+	if loadedChunks(a) != 18 {
+		t.Errorf("Expected a to keep its 18 chunks, got %d", loadedChunks(a))
+	}
+}
+
+func TestVoxelDeltasReachInterestedClients(t *testing.T) {
+	h := newHarness(t, nil)
+	a, b := h.connect("a"), h.connect("b")
+
+	spawn := a.Welcome().Spawn
+	h.srv.Teleport(ecs.Entity(b.PlayerID()), ceresmath.Vector3d{X: -10 * chunk.ChunkSize, Y: spawn.Y})
+	h.pump([]*Client{a, b}, func() bool {
+		return loadedChunks(a) == 18 && loadedChunks(b) == 18 &&
+			b.Chunks().GetChunkIfExists(chunk.NewChunkPosition(0, 0, 0)) == nil
+	})
+
+	pos := voxel.NewVoxelPosition(2, int32(spawn.Y)+2, 2)
+	h.srv.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelTypeBrick))
+
+	h.pump([]*Client{a, b}, func() bool {
+		return a.Chunks().GetVoxel(pos).Type == voxel.VoxelTypeBrick
+	})
+	if b.Chunks().GetChunkIfExists(chunk.VoxelToChunkPosition(pos)) != nil {
+		t.Error("Expected the distant client not to receive the chunk")
+	}
+}
+
+func TestOnlySpectatorsMoveDirectly(t *testing.T) {
+	h := newHarness(t, nil)
+	a := h.connect("a")
+	h.pump([]*Client{a}, func() bool { return loadedChunks(a) == 18 })
+
+	player := ecs.Entity(a.PlayerID())
+	start := serverPosition(h, a)
+	air := start.Add(ceresmath.Vector3d{X: 3, Y: 6})
+	rock := ceresmath.Vector3d{X: 0.5, Y: 2, Z: 0.5}
+
+	ticks := 0
+	settle := func() {
+		ticks = 0
+		h.pump([]*Client{a}, func() bool { ticks++; return ticks >= 5 })
+	}
+
+	// A plain player cannot teleport, and is sent back where it was
+	a.SendPosition(air)
+	settle()
+	if got := serverPosition(h, a); got != start {
+		t.Errorf("Expected the server to ignore a plain player's position, moved to %+v", got)
+	}
+	if got := a.PlayerState().Position; got != start {
+		t.Errorf("Expected the client to be corrected back to %+v, got %+v", start, got)
+	}
+
+	// Spectators can, but not into solid rock or out of the world
+	h.srv.SetSpectator(player, true)
+	for _, invalid := range []ceresmath.Vector3d{rock, {X: 0.5, Y: 1e6, Z: 0.5}, {X: math.NaN()}} {
+		a.SendPosition(invalid)
+		settle()
+		if got := serverPosition(h, a); got != start {
+			t.Errorf("Expected the server to reject a teleport to %+v, moved to %+v", invalid, got)
+		}
+	}
+
+	a.SendPosition(air)
+	h.pump([]*Client{a}, func() bool { return serverPosition(h, a) == air })
+}
+
+func TestWorldTimeFollowsServer(t *testing.T) {
+	h := newHarness(t, nil)
+	a := h.connect("a")
+	if a.WorldTime() != a.Welcome().Time {
+		t.Errorf("Expected the welcome time %d, got %d", a.Welcome().Time, a.WorldTime())
+	}
+
+	setAt := sky.TicksPerDay + sky.Sunset
+	h.srv.SetTime(setAt)
+	h.pump([]*Client{a}, func() bool {
+		return a.WorldTime() >= setAt
+	})
+	if got, want := a.WorldTime(), h.srv.Time(); got > want || got < setAt {
+		t.Errorf("Expected the client clock between %d and %d, got %d", setAt, want, got)
+	}
+}
+
+func TestEntityReplication(t *testing.T) {
+	h := newHarness(t, nil)
+	a, b := h.connect("a"), h.connect("b")
+
+	h.pump([]*Client{a, b}, func() bool {
+		_, ok := a.Entity(b.PlayerID())
+		return ok
+	})
+	if _, ok := a.Entity(a.PlayerID()); ok {
+		t.Error("Expected a client not to receive its own player entity")
+	}
+	if entity, _ := a.Entity(b.PlayerID()); entity.Kind != server.PlayerKind {
+		t.Errorf("Expected kind %q, got %q", server.PlayerKind, entity.Kind)
+	}
+
+	moved := b.Welcome().Spawn.Add(ceresmath.Vector3d{X: 3})
+	h.srv.Teleport(ecs.Entity(b.PlayerID()), moved)
+	h.pump([]*Client{a, b}, func() bool {
+		entity, _ := a.Entity(b.PlayerID())
+		return entity.Position == moved
+	})
+
+	b.Close()
+	h.pump([]*Client{a}, func() bool {
+		_, ok := a.Entity(b.PlayerID())
+		return !ok
+	})
+}
+
+func TestKeepAliveDropsSilentClients(t *testing.T) {
+	h := newHarness(t, func(config *server.Config) {
+		config.KeepAliveInterval = config.KeepAliveInterval / 100
+		config.Timeout = 300 * time.Millisecond
+	})
+	active := h.connect("active")
+	h.pump([]*Client{active}, func() bool { return loadedChunks(active) == 18 })
+
+	// Deadlines are real on a pipe, unlike on a simulated link
+	serverEnd, clientEnd := net.Pipe()
+	defer clientEnd.Close()
+	h.srv.Connect(serverEnd)
+	if _, err := protocol.ClientHandshake(protocol.NewConn(clientEnd), "silent"); err != nil {
+		t.Fatalf("Expected handshake to succeed, got %v", err)
+	}
+
+	h.pump([]*Client{active}, func() bool { return h.srv.ClientCount() == 1 })
+	ticks := 0
+	h.pump([]*Client{active}, func() bool { ticks++; return ticks >= 20 })
+
+	select {
+	case <-active.Done():
+		t.Errorf("Expected the active client to stay connected, got %v", active.Err())
+	default:
+	}
+}
+
+func TestHandshakeRejectsOtherVersions(t *testing.T) {
+	h := newHarness(t, nil)
+
+	serverEnd, clientEnd := net.Pipe()
+	defer clientEnd.Close()
+	h.srv.Connect(serverEnd)
+
+	conn := protocol.NewConn(clientEnd)
+	conn.Send(&protocol.Hello{Version: protocol.Version + 1, Name: "future"})
+
+	p, err := conn.Receive()
+	if err != nil {
+		t.Fatalf("Expected a disconnect packet, got %v", err)
+	}
+	if _, ok := p.(*protocol.Disconnect); !ok {
+		t.Errorf("Expected Disconnect, got %T", p)
+	}
+}
+
+func TestDialOverLoopback(t *testing.T) {
+	h := newHarness(t, func(config *server.Config) {
+		config.Address = "127.0.0.1:0"
+	})
+
+	c, err := Dial(h.srv.Addr().String(), "remote")
+	if err != nil {
+		t.Fatalf("Expected to dial the server, got %v", err)
+	}
+	defer c.Close()
+
+	// Every tick streams more chunks until all 18 are sent, so a packet is
+	// always on its way while the client waits for one
+	for loadedChunks(c) < 18 {
+		h.stepAll(nil, nil)
+		select {
+		case p := <-c.inbox:
+			if err := c.apply(p); err != nil {
+				t.Fatalf("Expected updates to apply, got %v", err)
+			}
+		case <-c.Done():
+			t.Fatalf("Expected to stay connected, got %v", c.Err())
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package ecs
+
+import (
+	ceresmath "Ceres/pkg/math"
+)
+
+// AABB is an axis-aligned bounding box in world coordinates
+type AABB struct {
+	Min, Max ceresmath.Vector3d
+}
+
+// NewAABB creates a box from its centre and half extents
+func NewAABB(center ceresmath.Vector3d, halfExtents ceresmath.Vector3) AABB {
+	extents := ceresmath.FromVector3(halfExtents)
+	return AABB{
+		Min: center.Sub(extents),
+		Max: center.Add(extents),
+	}
+}
+
+// Center returns the centre of the box
+func (b AABB) Center() ceresmath.Vector3d {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Translate returns the box moved by offset
+func (b AABB) Translate(offset ceresmath.Vector3d) AABB {
+	return AABB{Min: b.Min.Add(offset), Max: b.Max.Add(offset)}
+}
+
+// Intersects reports whether two boxes overlap
+func (b AABB) Intersects(other AABB) bool {
+	return b.Min.X < other.Max.X && b.Max.X > other.Min.X &&
+		b.Min.Y < other.Max.Y && b.Max.Y > other.Min.Y &&
+		b.Min.Z < other.Max.Z && b.Max.Z > other.Min.Z
+}
+
+// DistanceSquared returns the squared distance from a point to the box
+func (b AABB) DistanceSquared(point ceresmath.Vector3d) float64 {
+	closest := point.Clamp(b.Min, b.Max)
+	return closest.DistanceSquared(point)
+}
+
+func axis(v ceresmath.Vector3d, a int) float64 {
+	switch a {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	default:
+		return v.Z
+	}
+}
+
+func setAxis(v *ceresmath.Vector3d, a int, value float64) {
+	switch a {
+	case 0:
+		v.X = value
+	case 1:
+		v.Y = value
+	default:
+		v.Z = value
+	}
+}
@@ -0,0 +1,146 @@
+package ecs
+
+import (
+	"math"
+
+	"Ceres/pkg/chunk"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+// collisionEpsilon keeps boxes from counting as overlapping a voxel they only touch
+const collisionEpsilon = 1e-4
+
+// CollisionResult reports which axes were blocked during a move
+type CollisionResult struct {
+	Moved ceresmath.Vector3d
+	Hit   [3]bool
+}
+
+// IsSolidVoxel reports whether a voxel has collision boxes and so can block
+// entity movement
+func IsSolidVoxel(v voxel.Voxel) bool {
+	return len(voxel.CurrentPropertyTable().Boxes(v, nil)) > 0
+}
+
+// MoveAABB moves a box by delta through the world, stopping at the collision
+// boxes of the voxels' models. Axes are resolved one at a time (Y first, then
+// X and Z) so boxes slide along walls and floors. Voxels in unloaded chunks
+// count as full cubes.
+func MoveAABB(cm *chunk.ChunkManager, box AABB, delta ceresmath.Vector3) (AABB, CollisionResult) {
+	var result CollisionResult
+
+	for _, a := range [3]int{1, 0, 2} {
+		d := axis(ceresmath.FromVector3(delta), a)
+		if d == 0 {
+			continue
+		}
+
+		allowed, hit := sweepAxis(cm, box, a, d)
+		result.Hit[a] = hit
+		setAxis(&result.Moved, a, allowed)
+
+		var offset ceresmath.Vector3d
+		setAxis(&offset, a, allowed)
+		box = box.Translate(offset)
+	}
+
+	return box, result
+}
+
+// Overlaps reports whether a box overlaps the collision boxes of any voxel.
+// Voxels in unloaded chunks count as full cubes.
+func Overlaps(cm *chunk.ChunkManager, box AABB) bool {
+	xMin, xMax := cellRange(box.Min.X, box.Max.X)
+	yMin, yMax := cellRange(box.Min.Y, box.Max.Y)
+	zMin, zMax := cellRange(box.Min.Z, box.Max.Z)
+
+	overlaps := false
+	for y := yMin; y <= yMax && !overlaps; y++ {
+		slabBoxes(cm, 1, y, 2, zMin, zMax, 0, xMin, xMax, func(min, max [3]float64) {
+			for a := 0; a < 3; a++ {
+				if min[a] >= axis(box.Max, a)-collisionEpsilon || max[a] <= axis(box.Min, a)+collisionEpsilon {
+					return
+				}
+			}
+			overlaps = true
+		})
+	}
+	return overlaps
+}
+
+// sweepAxis returns how far the box can move along axis a, up to d. Cells are
+// visited in order along the move, and boxes inside the cell holding the
+// moving face count only if they lie ahead of it.
+func sweepAxis(cm *chunk.ChunkManager, box AABB, a int, d float64) (float64, bool) {
+	u, v := (a+1)%3, (a+2)%3
+	uMin, uMax := cellRange(axis(box.Min, u), axis(box.Max, u))
+	vMin, vMax := cellRange(axis(box.Min, v), axis(box.Max, v))
+
+	allowed, hit := d, false
+	overlaps := func(min, max [3]float64) bool {
+		return min[u] < axis(box.Max, u)-collisionEpsilon && max[u] > axis(box.Min, u)+collisionEpsilon &&
+			min[v] < axis(box.Max, v)-collisionEpsilon && max[v] > axis(box.Min, v)+collisionEpsilon
+	}
+
+	if d > 0 {
+		face := axis(box.Max, a)
+		first := floorCell(face - collisionEpsilon)
+		last := floorCell(face + d - collisionEpsilon)
+		for c := first; c <= last && !hit; c++ {
+			slabBoxes(cm, a, c, u, uMin, uMax, v, vMin, vMax, func(min, max [3]float64) {
+				if overlaps(min, max) && min[a] >= face-collisionEpsilon && min[a]-face < allowed {
+					allowed, hit = math.Max(0, min[a]-face), true
+				}
+			})
+		}
+		return allowed, hit
+	}
+
+	face := axis(box.Min, a)
+	first := floorCell(face + collisionEpsilon)
+	last := floorCell(face + d + collisionEpsilon)
+	for c := first; c >= last && !hit; c-- {
+		slabBoxes(cm, a, c, u, uMin, uMax, v, vMin, vMax, func(min, max [3]float64) {
+			if overlaps(min, max) && max[a] <= face+collisionEpsilon && max[a]-face > allowed {
+				allowed, hit = math.Min(0, max[a]-face), true
+			}
+		})
+	}
+	return allowed, hit
+}
+
+// slabBoxes calls visit with the world-space collision boxes of the voxels in
+// the slab at cell c along axis a, spanning the given cells on the other two
+// axes. Voxels in unloaded chunks are full cubes.
+func slabBoxes(cm *chunk.ChunkManager, a int, c int32, u int, uMin, uMax int32, v int, vMin, vMax int32, visit func(min, max [3]float64)) {
+	var cell [3]int32
+	cell[a] = c
+	for cu := uMin; cu <= uMax; cu++ {
+		for cv := vMin; cv <= vMax; cv++ {
+			cell[u], cell[v] = cu, cv
+			boxes, loaded := cm.CollisionBoxes(voxel.NewVoxelPosition(cell[0], cell[1], cell[2]))
+			if !loaded {
+				boxes = []voxel.Box{voxel.FullBox}
+			}
+
+			for _, box := range boxes {
+				var min, max [3]float64
+				for i := range cell {
+					min[i] = float64(cell[i]) + float64(box.Min[i])
+					max[i] = float64(cell[i]) + float64(box.Max[i])
+				}
+				visit(min, max)
+			}
+		}
+	}
+}
+
+// cellRange returns the voxel cells overlapped by the open interval (min, max)
+func cellRange(min, max float64) (int32, int32) {
+	return floorCell(min + collisionEpsilon), floorCell(max - collisionEpsilon)
+}
+
+func floorCell(v float64) int32 {
+	return int32(math.Floor(v))
+}
@@ -0,0 +1,41 @@
+package ecs
+
+import (
+	ceresmath "Ceres/pkg/math"
+)
+
+// Transform places an entity in the world; Position is the collider centre,
+// in double precision so entities far from the origin move smoothly
+type Transform = ceresmath.Transformd
+
+// Velocity is the entity's linear velocity in blocks per second
+type Velocity struct {
+	Linear ceresmath.Vector3
+}
+
+// Collider is an axis-aligned box around the entity position. The box does
+// not rotate with the entity.
+type Collider struct {
+	HalfExtents ceresmath.Vector3
+	Offset      ceresmath.Vector3
+}
+
+// Bounds returns the collider's box for an entity at position
+func (c Collider) Bounds(position ceresmath.Vector3d) AABB {
+	return NewAABB(position.Offset(c.Offset), c.HalfExtents)
+}
+
+// Renderable describes how to draw an entity. The renderer resolves Mesh to
+// its own resources, so this package stays free of graphics dependencies.
+type Renderable struct {
+	Mesh    string
+	Color   [3]float32
+	Visible bool
+}
+
+// PhysicsBody makes an entity subject to gravity. OnGround is set by the
+// physics system when the entity rests on a solid voxel.
+type PhysicsBody struct {
+	GravityScale float32
+	OnGround     bool
+}
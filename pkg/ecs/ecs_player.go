@@ -0,0 +1,106 @@
+package ecs
+
+import (
+	"math"
+
+	"Ceres/pkg/chunk"
+	ceresmath "Ceres/pkg/math"
+)
+
+const (
+	// PlayerWalkSpeed is the horizontal player speed in blocks per second
+	PlayerWalkSpeed = 4.3
+	// PlayerJumpSpeed is the upward velocity given by a jump
+	PlayerJumpSpeed = 8.5
+	// PlayerEyeHeight is the height of the player's eyes above its feet
+	PlayerEyeHeight = 1.62
+)
+
+// PlayerCollider is the box used for player movement
+var PlayerCollider = Collider{HalfExtents: ceresmath.Vector3{X: 0.3, Y: 0.9, Z: 0.3}}
+
+// PlayerInput is one tick of player controls. Forward and Strafe range from
+// -1 to 1 and are relative to Yaw, in degrees, using the camera's convention.
+// Inputs come from clients, so StepPlayer treats NaN and infinite values as 0.
+type PlayerInput struct {
+	Sequence uint32
+	Forward  float32
+	Strafe   float32
+	Yaw      float32
+	Jump     bool
+}
+
+// PlayerState is the simulated state of a player; Position is the collider centre
+type PlayerState struct {
+	Position ceresmath.Vector3d
+	Velocity ceresmath.Vector3
+	OnGround bool
+}
+
+// Eye returns the position of the player's eyes, where reach is measured from
+func (s PlayerState) Eye() ceresmath.Vector3d {
+	return s.Position.Offset(ceresmath.Vector3{Y: PlayerEyeHeight - PlayerCollider.HalfExtents.Y})
+}
+
+// PlayerAt returns a player at rest with its feet at the given point
+func PlayerAt(feet ceresmath.Vector3d) PlayerState {
+	return PlayerState{Position: feet.Offset(ceresmath.Vector3{Y: PlayerCollider.HalfExtents.Y})}
+}
+
+// StepPlayer advances a player by one input over dt. It depends only on its
+// arguments and the voxels around the player, so the client and server get
+// the same result for the same input and world.
+func StepPlayer(cm *chunk.ChunkManager, state PlayerState, input PlayerInput, dt float32) PlayerState {
+	yaw := ceresmath.Deg2Rad(finite(input.Yaw))
+	forward := ceresmath.Vector3{X: ceresmath.Cos(yaw), Z: ceresmath.Sin(yaw)}
+	right := ceresmath.Vector3{X: -forward.Z, Z: forward.X}
+
+	wish := forward.Mul(clampUnit(input.Forward)).Add(right.Mul(clampUnit(input.Strafe)))
+	if length := wish.Length(); length > 1 {
+		wish = wish.Mul(1 / length)
+	}
+
+	state.Velocity.X = wish.X * PlayerWalkSpeed
+	state.Velocity.Z = wish.Z * PlayerWalkSpeed
+	if input.Jump && state.OnGround {
+		state.Velocity.Y = PlayerJumpSpeed
+	}
+	state.Velocity.Y -= DefaultGravity * dt
+
+	delta := state.Velocity.Mul(dt)
+	_, result := MoveAABB(cm, PlayerCollider.Bounds(state.Position), delta)
+	state.Position = state.Position.Add(result.Moved)
+
+	if result.Hit[0] {
+		state.Velocity.X = 0
+	}
+	if result.Hit[2] {
+		state.Velocity.Z = 0
+	}
+	state.OnGround = result.Hit[1] && delta.Y < 0
+	if result.Hit[1] {
+		state.Velocity.Y = 0
+	}
+
+	return state
+}
+
+// clampUnit clamps v to [-1, 1], treating non-finite values as 0
+func clampUnit(v float32) float32 {
+	v = finite(v)
+	if v > 1 {
+		return 1
+	}
+	if v < -1 {
+		return -1
+	}
+	return v
+}
+
+// finite returns v, or 0 if it is NaN or infinite
+func finite(v float32) float32 {
+	if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,100 @@
+package ecs
+
+import (
+	"math"
+	"sort"
+
+	ceresmath "Ceres/pkg/math"
+)
+
+type cellKey struct {
+	X, Y, Z int32
+}
+
+// SpatialHash buckets entity boxes into a uniform grid for neighbour queries
+type SpatialHash struct {
+	CellSize float32
+
+	cells  map[cellKey][]Entity
+	bounds map[Entity]AABB
+}
+
+// NewSpatialHash creates a spatial hash with the given cell size
+func NewSpatialHash(cellSize float32) *SpatialHash {
+	return &SpatialHash{
+		CellSize: cellSize,
+		cells:    make(map[cellKey][]Entity),
+		bounds:   make(map[Entity]AABB),
+	}
+}
+
+// Clear removes every entity
+func (sh *SpatialHash) Clear() {
+	clear(sh.cells)
+	clear(sh.bounds)
+}
+
+// Insert adds an entity with its box. Entities are expected to be inserted
+// once per rebuild.
+func (sh *SpatialHash) Insert(e Entity, box AABB) {
+	sh.bounds[e] = box
+	sh.forEachCell(box, func(key cellKey) {
+		sh.cells[key] = append(sh.cells[key], e)
+	})
+}
+
+// QueryAABB returns the entities whose boxes overlap box, in ascending order
+func (sh *SpatialHash) QueryAABB(box AABB) []Entity {
+	return sh.query(box, func(other AABB) bool {
+		return other.Intersects(box)
+	})
+}
+
+// QueryRadius returns the entities whose boxes are within radius of center,
+// in ascending order
+func (sh *SpatialHash) QueryRadius(center ceresmath.Vector3d, radius float32) []Entity {
+	extent := ceresmath.Vector3{X: radius, Y: radius, Z: radius}
+	return sh.query(NewAABB(center, extent), func(other AABB) bool {
+		return other.DistanceSquared(center) <= float64(radius)*float64(radius)
+	})
+}
+
+func (sh *SpatialHash) query(box AABB, accept func(other AABB) bool) []Entity {
+	seen := make(map[Entity]bool)
+	var found []Entity
+
+	sh.forEachCell(box, func(key cellKey) {
+		for _, e := range sh.cells[key] {
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+			if accept(sh.bounds[e]) {
+				found = append(found, e)
+			}
+		}
+	})
+
+	sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+	return found
+}
+
+func (sh *SpatialHash) forEachCell(box AABB, fn func(key cellKey)) {
+	min, max := sh.cell(box.Min), sh.cell(box.Max)
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			for z := min.Z; z <= max.Z; z++ {
+				fn(cellKey{x, y, z})
+			}
+		}
+	}
+}
+
+func (sh *SpatialHash) cell(p ceresmath.Vector3d) cellKey {
+	size := float64(sh.CellSize)
+	return cellKey{
+		X: int32(math.Floor(p.X / size)),
+		Y: int32(math.Floor(p.Y / size)),
+		Z: int32(math.Floor(p.Z / size)),
+	}
+}
@@ -0,0 +1,124 @@
+package ecs
+
+import (
+	"reflect"
+)
+
+type componentStorage interface {
+	remove(e Entity)
+}
+
+// storage keeps components of one type densely packed for fast iteration
+type storage[T any] struct {
+	components []T
+	entities   []Entity
+	indices    map[Entity]int
+}
+
+func (s *storage[T]) remove(e Entity) {
+	i, ok := s.indices[e]
+	if !ok {
+		return
+	}
+
+	last := len(s.components) - 1
+	s.components[i] = s.components[last]
+	s.entities[i] = s.entities[last]
+	s.indices[s.entities[i]] = i
+
+	var zero T
+	s.components[last] = zero
+	s.components = s.components[:last]
+	s.entities = s.entities[:last]
+	delete(s.indices, e)
+}
+
+func storageFor[T any](w *World) *storage[T] {
+	key := reflect.TypeFor[T]()
+	if s, ok := w.storages[key]; ok {
+		return s.(*storage[T])
+	}
+
+	s := &storage[T]{indices: make(map[Entity]int)}
+	w.storages[key] = s
+	return s
+}
+
+// Add sets the component of type T on an entity, replacing any existing one.
+// Adding components to a dead entity does nothing.
+func Add[T any](w *World, e Entity, component T) {
+	if !w.IsAlive(e) {
+		return
+	}
+
+	s := storageFor[T](w)
+	if i, ok := s.indices[e]; ok {
+		s.components[i] = component
+		return
+	}
+
+	s.indices[e] = len(s.components)
+	s.components = append(s.components, component)
+	s.entities = append(s.entities, e)
+}
+
+// Get returns the entity's component of type T. The pointer stays valid
+// until a component of the same type is added or removed.
+func Get[T any](w *World, e Entity) (*T, bool) {
+	s := storageFor[T](w)
+	if i, ok := s.indices[e]; ok {
+		return &s.components[i], true
+	}
+	return nil, false
+}
+
+// Has reports whether the entity has a component of type T
+func Has[T any](w *World, e Entity) bool {
+	_, ok := storageFor[T](w).indices[e]
+	return ok
+}
+
+// Remove removes the entity's component of type T
+func Remove[T any](w *World, e Entity) {
+	storageFor[T](w).remove(e)
+}
+
+// Count returns the number of entities with a component of type T
+func Count[T any](w *World) int {
+	return len(storageFor[T](w).components)
+}
+
+// Each calls fn for every entity with a component of type T. Components of
+// type T must not be added or removed inside fn.
+func Each[T any](w *World, fn func(e Entity, c *T)) {
+	s := storageFor[T](w)
+	for i := range s.components {
+		fn(s.entities[i], &s.components[i])
+	}
+}
+
+// Each2 calls fn for every entity with components of both types A and B
+func Each2[A, B any](w *World, fn func(e Entity, a *A, b *B)) {
+	sa, sb := storageFor[A](w), storageFor[B](w)
+	for i := range sa.components {
+		e := sa.entities[i]
+		if j, ok := sb.indices[e]; ok {
+			fn(e, &sa.components[i], &sb.components[j])
+		}
+	}
+}
+
+// Each3 calls fn for every entity with components of types A, B and C
+func Each3[A, B, C any](w *World, fn func(e Entity, a *A, b *B, c *C)) {
+	sa, sb, sc := storageFor[A](w), storageFor[B](w), storageFor[C](w)
+	for i := range sa.components {
+		e := sa.entities[i]
+		j, ok := sb.indices[e]
+		if !ok {
+			continue
+		}
+		if k, ok := sc.indices[e]; ok {
+			fn(e, &sa.components[i], &sb.components[j], &sc.components[k])
+		}
+	}
+}
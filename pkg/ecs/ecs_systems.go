@@ -0,0 +1,74 @@
+package ecs
+
+import (
+	"Ceres/pkg/chunk"
+)
+
+// DefaultGravity is the downward acceleration in blocks per second squared
+const DefaultGravity = 28
+
+// PhysicsSystem integrates velocities, applies gravity to entities with a
+// PhysicsBody and, when Chunks is set, stops entities with a Collider at
+// solid voxels
+type PhysicsSystem struct {
+	Chunks  *chunk.ChunkManager
+	Gravity float32
+}
+
+// NewPhysicsSystem creates a physics system colliding with the chunk manager
+func NewPhysicsSystem(cm *chunk.ChunkManager) *PhysicsSystem {
+	return &PhysicsSystem{
+		Chunks:  cm,
+		Gravity: DefaultGravity,
+	}
+}
+
+func (ps *PhysicsSystem) Update(w *World, dt float32) {
+	Each2(w, func(e Entity, transform *Transform, velocity *Velocity) {
+		body, hasBody := Get[PhysicsBody](w, e)
+		if hasBody {
+			velocity.Linear.Y -= ps.Gravity * body.GravityScale * dt
+		}
+
+		delta := velocity.Linear.Mul(dt)
+		collider, hasCollider := Get[Collider](w, e)
+		if !hasCollider || ps.Chunks == nil {
+			transform.Position = transform.Position.Offset(delta)
+			return
+		}
+
+		_, result := MoveAABB(ps.Chunks, collider.Bounds(transform.Position), delta)
+		transform.Position = transform.Position.Add(result.Moved)
+
+		if result.Hit[0] {
+			velocity.Linear.X = 0
+		}
+		if result.Hit[2] {
+			velocity.Linear.Z = 0
+		}
+		if hasBody {
+			body.OnGround = result.Hit[1] && delta.Y < 0
+		}
+		if result.Hit[1] {
+			velocity.Linear.Y = 0
+		}
+	})
+}
+
+// SpatialHashSystem rebuilds a spatial hash from every entity with a
+// Transform and a Collider. Add it after the systems that move entities.
+type SpatialHashSystem struct {
+	Hash *SpatialHash
+}
+
+// NewSpatialHashSystem creates a spatial hash system with the given cell size
+func NewSpatialHashSystem(cellSize float32) *SpatialHashSystem {
+	return &SpatialHashSystem{Hash: NewSpatialHash(cellSize)}
+}
+
+func (shs *SpatialHashSystem) Update(w *World, dt float32) {
+	shs.Hash.Clear()
+	Each2(w, func(e Entity, transform *Transform, collider *Collider) {
+		shs.Hash.Insert(e, collider.Bounds(transform.Position))
+	})
+}
@@ -0,0 +1,284 @@
+package ecs
+
+import (
+	"math"
+	"testing"
+
+	"Ceres/internal/testworld"
+	"Ceres/pkg/chunk"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+func TestEntityGenerationReuse(t *testing.T) {
+	w := NewWorld()
+	first := w.CreateEntity()
+	Add(w, first, Velocity{})
+
+	w.Destroy(first)
+	if w.IsAlive(first) {
+		t.Error("Expected destroyed entity to be dead")
+	}
+	if Has[Velocity](w, first) {
+		t.Error("Expected components to be removed with the entity")
+	}
+
+	second := w.CreateEntity()
+	if second.index() != first.index() {
+		t.Errorf("Expected slot %d to be reused, got %d", first.index(), second.index())
+	}
+	if second == first || w.IsAlive(first) {
+		t.Error("Expected stale handle to stay dead after its slot is reused")
+	}
+	if _, ok := Get[Velocity](w, first); ok {
+		t.Error("Expected Get on a stale handle to fail")
+	}
+}
+
+func TestComponentQueries(t *testing.T) {
+	w := NewWorld()
+	moving := w.CreateEntity()
+	static := w.CreateEntity()
+
+	Add(w, moving, Transform{})
+	Add(w, moving, Velocity{Linear: ceresmath.Vector3{X: 1}})
+	Add(w, static, Transform{})
+
+	visited := 0
+	Each2(w, func(e Entity, transform *Transform, velocity *Velocity) {
+		visited++
+		transform.Position = transform.Position.Offset(velocity.Linear)
+	})
+	if visited != 1 {
+		t.Errorf("Expected 1 entity with Transform and Velocity, got %d", visited)
+	}
+
+	transform, _ := Get[Transform](w, moving)
+	if transform.Position.X != 1 {
+		t.Errorf("Expected component to be modified in place, got %v", transform.Position)
+	}
+
+	Remove[Velocity](w, moving)
+	if Count[Velocity](w) != 0 || Count[Transform](w) != 2 {
+		t.Errorf("Expected 0 velocities and 2 transforms, got %d and %d", Count[Velocity](w), Count[Transform](w))
+	}
+}
+
+func TestDestroyDuringUpdateIsDeferred(t *testing.T) {
+	w := NewWorld()
+	for i := 0; i < 4; i++ {
+		Add(w, w.CreateEntity(), Velocity{})
+	}
+
+	var order []string
+	w.AddSystem(SystemFunc(func(w *World, dt float32) {
+		order = append(order, "destroy")
+		Each(w, func(e Entity, v *Velocity) {
+			w.Destroy(e)
+			if !w.IsAlive(e) {
+				t.Error("Expected entity to stay alive until the system finishes")
+			}
+		})
+	}))
+	w.AddSystem(SystemFunc(func(w *World, dt float32) {
+		order = append(order, "count")
+		if w.EntityCount() != 0 {
+			t.Errorf("Expected destroyed entities to be flushed between systems, got %d", w.EntityCount())
+		}
+	}))
+
+	w.Update(0.05)
+
+	if len(order) != 2 || order[0] != "destroy" || order[1] != "count" {
+		t.Errorf("Expected systems to run in registration order, got %v", order)
+	}
+}
+
+func newFloorWorld(floorY int32) *chunk.ChunkManager {
+	cm := chunk.NewChunkManager()
+	testworld.Floor(cm, floorY, 0, chunk.ChunkSize-1, voxel.VoxelTypeStone)
+	return cm
+}
+
+func TestPhysicsEntityLandsOnFloor(t *testing.T) {
+	cm := newFloorWorld(4)
+	w := NewWorld()
+	w.AddSystem(NewPhysicsSystem(cm))
+
+	e := w.CreateEntity()
+	Add(w, e, Transform{Position: ceresmath.Vector3d{X: 8.5, Y: 12, Z: 8.5}})
+	Add(w, e, Velocity{})
+	Add(w, e, Collider{HalfExtents: ceresmath.Vector3{X: 0.3, Y: 0.9, Z: 0.3}})
+	Add(w, e, PhysicsBody{GravityScale: 1})
+
+	for i := 0; i < 200; i++ {
+		w.Update(1.0 / 60)
+	}
+
+	transform, _ := Get[Transform](w, e)
+	if math.Abs(float64(transform.Position.Y-5.9)) > 1e-3 {
+		t.Errorf("Expected entity to rest at y=5.9, got %f", transform.Position.Y)
+	}
+
+	body, _ := Get[PhysicsBody](w, e)
+	if !body.OnGround {
+		t.Error("Expected entity to be on the ground")
+	}
+}
+
+func TestPhysicsEntityCollidesWithBlockModels(t *testing.T) {
+	cm := newFloorWorld(4)
+	cm.SetVoxel(voxel.NewVoxelPosition(8, 5, 8), voxel.NewVoxel(voxel.VoxelTypeStoneSlab))
+	cm.SetVoxel(voxel.NewVoxelPosition(20, 5, 20), voxel.NewVoxel(voxel.VoxelTypeFlower))
+
+	box := NewAABB(ceresmath.Vector3d{X: 8.5, Y: 8, Z: 8.5}, ceresmath.Vector3{X: 0.3, Y: 0.9, Z: 0.3})
+	moved, result := MoveAABB(cm, box, ceresmath.Vector3{Y: -5})
+	if !result.Hit[1] || math.Abs(moved.Min.Y-5.5) > 1e-4 {
+		t.Errorf("Expected box to land on the slab at y=5.5, got %f", moved.Min.Y)
+	}
+
+	box = NewAABB(ceresmath.Vector3d{X: 20.5, Y: 8, Z: 20.5}, ceresmath.Vector3{X: 0.3, Y: 0.9, Z: 0.3})
+	moved, _ = MoveAABB(cm, box, ceresmath.Vector3{Y: -5})
+	if math.Abs(moved.Min.Y-5) > 1e-4 {
+		t.Errorf("Expected box to fall through the flower to y=5, got %f", moved.Min.Y)
+	}
+}
+
+func TestMoveAABBSlidesAlongWall(t *testing.T) {
+	cm := newFloorWorld(0)
+	for y := int32(1); y < 4; y++ {
+		for z := int32(0); z < chunk.ChunkSize; z++ {
+			cm.SetVoxel(voxel.NewVoxelPosition(10, y, z), voxel.NewVoxel(voxel.VoxelTypeStone))
+		}
+	}
+
+	box := NewAABB(ceresmath.Vector3d{X: 8.5, Y: 2, Z: 8.5}, ceresmath.Vector3{X: 0.3, Y: 0.9, Z: 0.3})
+	moved, result := MoveAABB(cm, box, ceresmath.Vector3{X: 2, Z: 1})
+
+	if !result.Hit[0] || result.Hit[2] {
+		t.Errorf("Expected only the X axis to be blocked, got %v", result.Hit)
+	}
+	if math.Abs(float64(moved.Max.X-10)) > 1e-4 {
+		t.Errorf("Expected box to stop against the wall at x=10, got %f", moved.Max.X)
+	}
+	if math.Abs(float64(moved.Center().Z-9.5)) > 1e-4 {
+		t.Errorf("Expected box to slide to z=9.5, got %f", moved.Center().Z)
+	}
+}
+
+func TestStepPlayerWalksAndJumps(t *testing.T) {
+	cm := newFloorWorld(0)
+	state := PlayerAt(ceresmath.Vector3d{X: 8.5, Y: 1, Z: 8.5})
+	floor := state.Position.Y
+	dt := float32(1.0 / 20)
+
+	// Yaw 0 faces +X, so walking forward only moves along X
+	for i := 0; i < 10; i++ {
+		state = StepPlayer(cm, state, PlayerInput{Forward: 1}, dt)
+	}
+	if math.Abs(state.Position.X-float64(8.5+10*PlayerWalkSpeed*dt)) > 1e-3 || math.Abs(float64(state.Position.Z-8.5)) > 1e-4 {
+		t.Errorf("Expected player to walk %f blocks along X, got %+v", 10*PlayerWalkSpeed*dt, state.Position)
+	}
+	if !state.OnGround || state.Position.Y != floor {
+		t.Errorf("Expected player to stay on the floor, got y=%f on ground %v", state.Position.Y, state.OnGround)
+	}
+
+	state = StepPlayer(cm, state, PlayerInput{Jump: true}, dt)
+	if state.OnGround || state.Position.Y <= floor {
+		t.Errorf("Expected jump to leave the ground, got y=%f", state.Position.Y)
+	}
+
+	// Holding jump in the air does nothing until the player lands again
+	velocity := state.Velocity.Y
+	state = StepPlayer(cm, state, PlayerInput{Jump: true}, dt)
+	if state.Velocity.Y >= velocity {
+		t.Errorf("Expected gravity to slow the jump, got %f after %f", state.Velocity.Y, velocity)
+	}
+}
+
+func TestStepPlayerIgnoresNonFiniteInput(t *testing.T) {
+	cm := newFloorWorld(0)
+	start := PlayerAt(ceresmath.Vector3d{X: 8.5, Y: 1, Z: 8.5})
+	dt := float32(1.0 / 20)
+	nan, inf := float32(math.NaN()), float32(math.Inf(1))
+
+	for _, input := range []PlayerInput{
+		{Forward: nan},
+		{Strafe: -inf},
+		{Forward: 1, Yaw: nan},
+		{Forward: inf, Strafe: nan, Yaw: -inf},
+	} {
+		state := start
+		for i := 0; i < 5; i++ {
+			state = StepPlayer(cm, state, input, dt)
+		}
+		p := state.Position
+		if math.IsNaN(p.X) || math.IsNaN(p.Y) || math.IsNaN(p.Z) || math.IsInf(p.X, 0) || math.IsInf(p.Z, 0) {
+			t.Fatalf("Expected input %+v to leave a finite position, got %+v", input, p)
+		}
+		if walked := math.Hypot(p.X-start.Position.X, p.Z-start.Position.Z); walked > float64(5*PlayerWalkSpeed*dt)+1e-3 {
+			t.Errorf("Expected input %+v to move at most walking speed, moved %f", input, walked)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	cm := newFloorWorld(0)
+	if !Overlaps(cm, PlayerCollider.Bounds(ceresmath.Vector3d{X: 8.5, Y: 0.5, Z: 8.5})) {
+		t.Error("Expected a player inside the floor to overlap it")
+	}
+	if Overlaps(cm, PlayerCollider.Bounds(PlayerAt(ceresmath.Vector3d{X: 8.5, Y: 1, Z: 8.5}).Position)) {
+		t.Error("Expected a player standing on the floor not to overlap it")
+	}
+}
+
+func TestStepPlayerFarFromOrigin(t *testing.T) {
+	dt := float32(1.0 / 20)
+	for _, far := range []int32{10_000_000, -10_000_000} {
+		cm := chunk.NewChunkManager()
+		testworld.Floor(cm, 0, far, far+chunk.ChunkSize-1, voxel.VoxelTypeStone)
+
+		start := PlayerAt(ceresmath.Vector3d{X: float64(far) + 8.5, Y: 1, Z: float64(far) + 8.5})
+		state := start
+		for i := 0; i < 10; i++ {
+			state = StepPlayer(cm, state, PlayerInput{Forward: 1}, dt)
+		}
+
+		// float32 positions have a step of one block at this distance, so a
+		// walk of a couple of blocks would be lost or rounded
+		if walked := state.Position.X - start.Position.X; math.Abs(walked-float64(10*PlayerWalkSpeed*dt)) > 1e-3 {
+			t.Errorf("Expected player at %d to walk %f blocks, walked %f", far, 10*PlayerWalkSpeed*dt, walked)
+		}
+		if state.Position.Y != start.Position.Y || !state.OnGround {
+			t.Errorf("Expected player at %d to stay on the floor at y=%f, got %f", far, start.Position.Y, state.Position.Y)
+		}
+	}
+}
+
+func TestSpatialHashQueries(t *testing.T) {
+	w := NewWorld()
+	system := NewSpatialHashSystem(4)
+	w.AddSystem(system)
+
+	half := ceresmath.Vector3{X: 0.5, Y: 0.5, Z: 0.5}
+	positions := []ceresmath.Vector3d{{X: 1}, {X: 3}, {X: 20}}
+	entities := make([]Entity, len(positions))
+	for i, p := range positions {
+		entities[i] = w.CreateEntity()
+		Add(w, entities[i], Transform{Position: p})
+		Add(w, entities[i], Collider{HalfExtents: half})
+	}
+
+	w.Update(0)
+
+	near := system.Hash.QueryRadius(ceresmath.Vector3d{}, 3)
+	if len(near) != 2 || near[0] != entities[0] || near[1] != entities[1] {
+		t.Errorf("Expected the two nearby entities, got %v", near)
+	}
+
+	box := NewAABB(ceresmath.Vector3d{X: 20}, half)
+	overlapping := system.Hash.QueryAABB(box)
+	if len(overlapping) != 1 || overlapping[0] != entities[2] {
+		t.Errorf("Expected only the far entity, got %v", overlapping)
+	}
+}
@@ -0,0 +1,130 @@
+package ecs
+
+import (
+	"reflect"
+)
+
+// Entity identifies an entity. The low 32 bits are a slot index and the high
+// 32 bits a generation, so handles to destroyed entities never match a new
+// entity reusing the slot.
+type Entity uint64
+
+// NoEntity is never returned by CreateEntity
+const NoEntity Entity = 0
+
+func newEntity(index, generation uint32) Entity {
+	return Entity(uint64(generation)<<32 | uint64(index))
+}
+
+func (e Entity) index() uint32 {
+	return uint32(e)
+}
+
+func (e Entity) generation() uint32 {
+	return uint32(e >> 32)
+}
+
+// System updates the world once per tick
+type System interface {
+	Update(w *World, dt float32)
+}
+
+// SystemFunc adapts a function to the System interface
+type SystemFunc func(w *World, dt float32)
+
+func (f SystemFunc) Update(w *World, dt float32) {
+	f(w, dt)
+}
+
+// World holds entities, their components and the systems that run on them
+type World struct {
+	generations []uint32
+	free        []uint32
+	alive       int
+
+	storages map[reflect.Type]componentStorage
+	systems  []System
+
+	updating  bool
+	destroyed []Entity
+}
+
+// NewWorld creates an empty world
+func NewWorld() *World {
+	return &World{
+		// Slot 0 is reserved so that NoEntity is never valid
+		generations: []uint32{1},
+		storages:    make(map[reflect.Type]componentStorage),
+	}
+}
+
+// CreateEntity creates a new entity without components
+func (w *World) CreateEntity() Entity {
+	w.alive++
+
+	if n := len(w.free); n > 0 {
+		index := w.free[n-1]
+		w.free = w.free[:n-1]
+		return newEntity(index, w.generations[index])
+	}
+
+	w.generations = append(w.generations, 1)
+	return newEntity(uint32(len(w.generations)-1), 1)
+}
+
+// IsAlive reports whether the entity exists
+func (w *World) IsAlive(e Entity) bool {
+	index := e.index()
+	return index != 0 && int(index) < len(w.generations) && w.generations[index] == e.generation()
+}
+
+// EntityCount returns the number of live entities
+func (w *World) EntityCount() int {
+	return w.alive
+}
+
+// Destroy removes an entity and all of its components. While systems are
+// running the entity is removed after the current system finishes, so
+// systems may destroy entities while iterating.
+func (w *World) Destroy(e Entity) {
+	if !w.IsAlive(e) {
+		return
+	}
+	if w.updating {
+		w.destroyed = append(w.destroyed, e)
+		return
+	}
+
+	for _, storage := range w.storages {
+		storage.remove(e)
+	}
+
+	index := e.index()
+	w.generations[index]++
+	w.free = append(w.free, index)
+	w.alive--
+}
+
+// AddSystem appends a system; systems run in the order they were added
+func (w *World) AddSystem(system System) {
+	w.systems = append(w.systems, system)
+}
+
+// Update runs every system once with the given time step
+func (w *World) Update(dt float32) {
+	for _, system := range w.systems {
+		w.updating = true
+		system.Update(w, dt)
+		w.updating = false
+
+		w.flushDestroyed()
+	}
+}
+
+func (w *World) flushDestroyed() {
+	destroyed := w.destroyed
+	w.destroyed = nil
+	for _, e := range destroyed {
+		w.Destroy(e)
+	}
+}
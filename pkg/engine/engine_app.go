@@ -0,0 +1,72 @@
+package engine
+
+// App is a game or demo driven by the engine loop
+type App interface {
+	// Init is called once before the first frame. Returning an error stops
+	// the engine before any frame runs.
+	Init(e *Engine) error
+
+	// Update is called once per frame with the real elapsed time, for input
+	// handling and camera movement
+	Update(dt float32)
+
+	// FixedUpdate advances the simulation by exactly the engine's fixed
+	// timestep. It may run several times in one frame, or not at all.
+	FixedUpdate(dt float32)
+
+	// Render draws the frame. Alpha is how far the current time lies between
+	// the last and the next fixed update, for interpolating simulated state.
+	Render(alpha float32)
+
+	// Shutdown is called once after the last frame
+	Shutdown()
+}
+
+// Window is the part of a window the engine loop needs. graphics.Window
+// implements it; headless engines run without one.
+type Window interface {
+	ShouldClose() bool
+	SwapBuffers()
+	PollEvents()
+}
+
+// AppFuncs adapts plain functions to the App interface. Nil functions are
+// skipped, so small demos only provide what they use.
+type AppFuncs struct {
+	OnInit        func(e *Engine) error
+	OnUpdate      func(dt float32)
+	OnFixedUpdate func(dt float32)
+	OnRender      func(alpha float32)
+	OnShutdown    func()
+}
+
+func (af AppFuncs) Init(e *Engine) error {
+	if af.OnInit == nil {
+		return nil
+	}
+	return af.OnInit(e)
+}
+
+func (af AppFuncs) Update(dt float32) {
+	if af.OnUpdate != nil {
+		af.OnUpdate(dt)
+	}
+}
+
+func (af AppFuncs) FixedUpdate(dt float32) {
+	if af.OnFixedUpdate != nil {
+		af.OnFixedUpdate(dt)
+	}
+}
+
+func (af AppFuncs) Render(alpha float32) {
+	if af.OnRender != nil {
+		af.OnRender(alpha)
+	}
+}
+
+func (af AppFuncs) Shutdown() {
+	if af.OnShutdown != nil {
+		af.OnShutdown()
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock supplies the current time to the engine loop
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// RealClock returns a clock reading the system time
+func RealClock() Clock {
+	return realClock{}
+}
+
+// FakeClock is a manually advanced clock for driving the engine in tests
+// and headless tools
+type FakeClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+// NewFakeClock creates a fake clock starting at the Unix epoch
+func NewFakeClock() *FakeClock {
+	return &FakeClock{now: time.Unix(0, 0)}
+}
+
+func (fc *FakeClock) Now() time.Time {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	return fc.now
+}
+
+// Advance moves the clock forward by d
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	fc.now = fc.now.Add(d)
+}
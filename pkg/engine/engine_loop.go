@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultFixedTimestep runs the simulation at 60 ticks per second
+	DefaultFixedTimestep = time.Second / 60
+	// DefaultMaxFrameTime caps how much time a single frame can account for,
+	// so a stall such as a breakpoint or window drag doesn't replay forever
+	DefaultMaxFrameTime = 250 * time.Millisecond
+	// DefaultMaxStepsPerFrame limits fixed updates per frame
+	DefaultMaxStepsPerFrame = 8
+)
+
+// Engine runs an App with a variable-rate render loop and a fixed-rate
+// simulation. When frames fall behind, fixed updates are capped at
+// MaxStepsPerFrame and the excess time is dropped rather than accumulated,
+// which keeps a slow simulation from falling into a spiral of death.
+type Engine struct {
+	FixedTimestep    time.Duration
+	MaxFrameTime     time.Duration
+	MaxStepsPerFrame int
+
+	// MinFrameTime makes Run sleep after fast frames, so headless engines
+	// without vsync don't spin a core
+	MinFrameTime time.Duration
+
+	app    App
+	window Window
+	clock  Clock
+
+	started     bool
+	running     atomic.Bool
+	lastFrame   time.Time
+	accumulator time.Duration
+	alpha       float32
+
+	paused       bool
+	pendingSteps int
+
+	frames  uint64
+	ticks   uint64
+	dropped time.Duration
+}
+
+// NewEngine creates an engine for app. The window may be nil to run headless.
+func NewEngine(app App, window Window) *Engine {
+	return &Engine{
+		FixedTimestep:    DefaultFixedTimestep,
+		MaxFrameTime:     DefaultMaxFrameTime,
+		MaxStepsPerFrame: DefaultMaxStepsPerFrame,
+		app:              app,
+		window:           window,
+		clock:            RealClock(),
+	}
+}
+
+// SetClock replaces the clock, for example with a FakeClock. It must be
+// called before Start.
+func (e *Engine) SetClock(clock Clock) {
+	e.clock = clock
+}
+
+// Run starts the app and runs frames until Stop is called or the window
+// closes, then shuts the app down
+func (e *Engine) Run() error {
+	if err := e.Start(); err != nil {
+		return err
+	}
+	defer e.Shutdown()
+
+	for e.Running() {
+		frameStart := time.Now()
+		e.Frame()
+
+		if rest := e.MinFrameTime - time.Since(frameStart); rest > 0 {
+			time.Sleep(rest)
+		}
+	}
+	return nil
+}
+
+// Start initialises the app. Run calls it; headless drivers call Start,
+// then Frame as often as they like, then Shutdown.
+func (e *Engine) Start() error {
+	if e.started {
+		return nil
+	}
+	if err := e.app.Init(e); err != nil {
+		return fmt.Errorf("failed to initialize app: %w", err)
+	}
+
+	e.started = true
+	e.running.Store(true)
+	e.lastFrame = e.clock.Now()
+	return nil
+}
+
+// Frame runs one iteration of the loop: Update, any due fixed updates and
+// Render, followed by presenting the window
+func (e *Engine) Frame() {
+	now := e.clock.Now()
+	frameTime := now.Sub(e.lastFrame)
+	e.lastFrame = now
+
+	if frameTime > e.MaxFrameTime {
+		e.dropped += frameTime - e.MaxFrameTime
+		frameTime = e.MaxFrameTime
+	}
+	if frameTime < 0 {
+		frameTime = 0
+	}
+
+	e.app.Update(float32(frameTime.Seconds()))
+
+	fixedDt := float32(e.FixedTimestep.Seconds())
+	if e.paused {
+		for ; e.pendingSteps > 0; e.pendingSteps-- {
+			e.fixedUpdate(fixedDt)
+		}
+	} else {
+		e.accumulator += frameTime
+		steps := 0
+		for e.accumulator >= e.FixedTimestep && steps < e.MaxStepsPerFrame {
+			e.fixedUpdate(fixedDt)
+			e.accumulator -= e.FixedTimestep
+			steps++
+		}
+		if e.accumulator >= e.FixedTimestep {
+			excess := e.accumulator - e.accumulator%e.FixedTimestep
+			e.dropped += excess
+			e.accumulator -= excess
+		}
+		e.alpha = float32(e.accumulator) / float32(e.FixedTimestep)
+	}
+
+	e.app.Render(e.alpha)
+	e.frames++
+
+	if e.window != nil {
+		e.window.SwapBuffers()
+		e.window.PollEvents()
+		if e.window.ShouldClose() {
+			e.running.Store(false)
+		}
+	}
+}
+
+func (e *Engine) fixedUpdate(dt float32) {
+	e.app.FixedUpdate(dt)
+	e.ticks++
+}
+
+// Shutdown shuts the app down once
+func (e *Engine) Shutdown() {
+	if !e.started {
+		return
+	}
+	e.app.Shutdown()
+	e.started = false
+	e.running.Store(false)
+}
+
+// Stop makes Run return after the current frame. It is safe to call from
+// other goroutines, such as a signal handler.
+func (e *Engine) Stop() {
+	e.running.Store(false)
+}
+
+// Running reports whether the engine has started and not been stopped
+func (e *Engine) Running() bool {
+	return e.running.Load()
+}
+
+// Pause freezes the simulation. Frames keep running Update and Render, and
+// the interpolation alpha holds its value.
+func (e *Engine) Pause() {
+	e.paused = true
+}
+
+// Resume continues a paused simulation without catching up the paused time
+func (e *Engine) Resume() {
+	e.paused = false
+	e.pendingSteps = 0
+	e.accumulator = 0
+}
+
+// Paused reports whether the simulation is paused
+func (e *Engine) Paused() bool {
+	return e.paused
+}
+
+// Step queues a single fixed update to run on the next frame while paused
+func (e *Engine) Step() {
+	if e.paused {
+		e.pendingSteps++
+	}
+}
+
+// Alpha returns the interpolation factor passed to the last Render
+func (e *Engine) Alpha() float32 {
+	return e.alpha
+}
+
+// Frames returns the number of frames run
+func (e *Engine) Frames() uint64 {
+	return e.frames
+}
+
+// Ticks returns the number of fixed updates run
+func (e *Engine) Ticks() uint64 {
+	return e.ticks
+}
+
+// DroppedTime returns the total simulation time skipped to stay real time
+func (e *Engine) DroppedTime() time.Duration {
+	return e.dropped
+}
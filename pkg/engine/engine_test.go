@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type recordingApp struct {
+	initErr error
+
+	updates      []float32
+	fixedUpdates int
+	alphas       []float32
+	shutdowns    int
+}
+
+func (ra *recordingApp) Init(e *Engine) error { return ra.initErr }
+func (ra *recordingApp) Update(dt float32)    { ra.updates = append(ra.updates, dt) }
+func (ra *recordingApp) FixedUpdate(dt float32) {
+	ra.fixedUpdates++
+}
+func (ra *recordingApp) Render(alpha float32) { ra.alphas = append(ra.alphas, alpha) }
+func (ra *recordingApp) Shutdown()            { ra.shutdowns++ }
+
+func newHeadless(t *testing.T) (*Engine, *recordingApp, *FakeClock) {
+	app := &recordingApp{}
+	clock := NewFakeClock()
+	e := NewEngine(app, nil)
+	e.SetClock(clock)
+	e.FixedTimestep = 10 * time.Millisecond
+	if err := e.Start(); err != nil {
+		t.Fatalf("Expected engine to start, got %v", err)
+	}
+	return e, app, clock
+}
+
+func approxEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func TestFixedStepsAndAlpha(t *testing.T) {
+	e, app, clock := newHeadless(t)
+
+	clock.Advance(25 * time.Millisecond)
+	e.Frame()
+
+	if app.fixedUpdates != 2 {
+		t.Errorf("Expected 2 fixed updates, got %d", app.fixedUpdates)
+	}
+	if !approxEqual(e.Alpha(), 0.5) || !approxEqual(app.alphas[0], 0.5) {
+		t.Errorf("Expected alpha 0.5, got %f", e.Alpha())
+	}
+	if !approxEqual(app.updates[0], 0.025) {
+		t.Errorf("Expected frame delta 0.025, got %f", app.updates[0])
+	}
+
+	// The leftover half step carries into the next frame
+	clock.Advance(5 * time.Millisecond)
+	e.Frame()
+	if app.fixedUpdates != 3 || !approxEqual(e.Alpha(), 0) {
+		t.Errorf("Expected 3 fixed updates and alpha 0, got %d and %f", app.fixedUpdates, e.Alpha())
+	}
+}
+
+func TestSpiralOfDeathProtection(t *testing.T) {
+	e, app, clock := newHeadless(t)
+	e.MaxStepsPerFrame = 4
+
+	clock.Advance(time.Hour)
+	e.Frame()
+
+	if app.fixedUpdates != 4 {
+		t.Errorf("Expected fixed updates capped at 4, got %d", app.fixedUpdates)
+	}
+	if e.DroppedTime() < time.Hour-time.Second {
+		t.Errorf("Expected the stall to be dropped, got %v", e.DroppedTime())
+	}
+	if e.Alpha() < 0 || e.Alpha() >= 1 {
+		t.Errorf("Expected alpha in [0, 1), got %f", e.Alpha())
+	}
+
+	// Normal frames after the stall run at the normal rate
+	clock.Advance(10 * time.Millisecond)
+	e.Frame()
+	if app.fixedUpdates != 5 {
+		t.Errorf("Expected one fixed update after recovering, got %d", app.fixedUpdates-4)
+	}
+}
+
+func TestPauseAndSingleStep(t *testing.T) {
+	e, app, clock := newHeadless(t)
+
+	e.Pause()
+	for i := 0; i < 3; i++ {
+		clock.Advance(20 * time.Millisecond)
+		e.Frame()
+	}
+	if app.fixedUpdates != 0 {
+		t.Errorf("Expected no fixed updates while paused, got %d", app.fixedUpdates)
+	}
+	if len(app.updates) != 3 || len(app.alphas) != 3 {
+		t.Errorf("Expected Update and Render to keep running while paused")
+	}
+
+	e.Step()
+	e.Step()
+	e.Frame()
+	if app.fixedUpdates != 2 {
+		t.Errorf("Expected 2 single steps, got %d", app.fixedUpdates)
+	}
+
+	e.Resume()
+	clock.Advance(10 * time.Millisecond)
+	e.Frame()
+	if app.fixedUpdates != 3 {
+		t.Errorf("Expected paused time not to be caught up, got %d fixed updates", app.fixedUpdates)
+	}
+}
+
+type fakeWindow struct {
+	framesLeft int
+	swaps      int
+}
+
+func (fw *fakeWindow) ShouldClose() bool { return fw.framesLeft <= 0 }
+func (fw *fakeWindow) SwapBuffers()      { fw.swaps++ }
+func (fw *fakeWindow) PollEvents()       { fw.framesLeft-- }
+
+func TestRunUntilWindowCloses(t *testing.T) {
+	app := &recordingApp{}
+	window := &fakeWindow{framesLeft: 3}
+	e := NewEngine(app, window)
+	e.SetClock(NewFakeClock())
+
+	if err := e.Run(); err != nil {
+		t.Fatalf("Expected Run to succeed, got %v", err)
+	}
+	if e.Frames() != 3 || window.swaps != 3 {
+		t.Errorf("Expected 3 frames, got %d frames and %d swaps", e.Frames(), window.swaps)
+	}
+	if app.shutdowns != 1 {
+		t.Errorf("Expected 1 shutdown, got %d", app.shutdowns)
+	}
+
+	failing := &recordingApp{initErr: errors.New("no gpu")}
+	if err := NewEngine(failing, nil).Run(); err == nil || failing.shutdowns != 0 {
+		t.Errorf("Expected init failure to stop the engine without shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package fluid
+
+import (
+	"sort"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+var horizontalFaces = [4]voxel.VoxelFace{
+	voxel.VoxelFaceLeft,
+	voxel.VoxelFaceRight,
+	voxel.VoxelFaceFront,
+	voxel.VoxelFaceBack,
+}
+
+// Simulator runs cellular-automaton fluid flow on a chunk manager. Positions
+// are updated on fluid ticks, which are stored with their chunks apart from
+// block ticks, so flowing fluid keeps flowing after the world is saved and
+// loaded. Each tick reads the world as it was at the start of the tick and
+// writes all changes as one batch so every affected chunk is locked and
+// re-dirtied once.
+type Simulator struct {
+	cm *chunk.ChunkManager
+
+	tick uint64
+}
+
+// NewSimulator creates a fluid simulator for the chunk manager
+func NewSimulator(cm *chunk.ChunkManager) *Simulator {
+	return &Simulator{cm: cm}
+}
+
+// CurrentTick returns the tick that runs next
+func (s *Simulator) CurrentTick() uint64 {
+	return s.tick
+}
+
+// SetTick sets the current tick, e.g. when restoring a saved world whose
+// chunks hold fluid ticks at absolute ticks
+func (s *Simulator) SetTick(tick uint64) {
+	s.tick = tick
+}
+
+// PendingUpdates returns the number of fluid ticks waiting in loaded chunks
+func (s *Simulator) PendingUpdates() int {
+	pending := 0
+	for _, c := range s.cm.GetLoadedChunks() {
+		pending += len(c.FluidTicks())
+	}
+	return pending
+}
+
+// Schedule updates pos after delay ticks. An earlier pending update for the
+// same position is kept. Positions in unloaded chunks are not updated.
+func (s *Simulator) Schedule(pos voxel.VoxelPosition, delay uint64) {
+	c := s.cm.GetChunkIfExists(chunk.VoxelToChunkPosition(pos))
+	if c == nil {
+		return
+	}
+
+	x, y, z := chunk.VoxelToLocalPosition(pos)
+	c.ScheduleFluidTick(x, y, z, s.tick+delay)
+}
+
+// NotifyChanged schedules the position and its neighbours after a block was
+// placed or removed outside the simulator, so nearby fluid reacts to it
+func (s *Simulator) NotifyChanged(pos voxel.VoxelPosition) {
+	current, _ := s.getVoxel(pos)
+	fluidType, _ := s.fluidAt(pos, current)
+	s.scheduleAround(pos, fluidType)
+}
+
+// Tick runs one simulation tick and returns the number of voxels changed
+func (s *Simulator) Tick() int {
+	var ready []voxel.VoxelPosition
+	for _, c := range s.cm.GetLoadedChunks() {
+		for _, due := range c.TakeDueFluidTicks(s.tick) {
+			ready = append(ready, due.Position)
+		}
+	}
+
+	// Sort chunk by chunk so runs are deterministic and batches stay local
+	sort.Slice(ready, func(i, j int) bool {
+		return lessChunkMajor(ready[i], ready[j])
+	})
+
+	var writes []chunk.VoxelWrite
+	var fluidTypes []voxel.VoxelType
+	for _, pos := range ready {
+		current, ok := s.getVoxel(pos)
+		if !ok {
+			continue
+		}
+
+		next, fluidType := s.nextState(pos, current)
+		if next != current {
+			writes = append(writes, chunk.VoxelWrite{Position: pos, Voxel: next})
+			fluidTypes = append(fluidTypes, fluidType)
+		}
+	}
+
+	s.cm.SetVoxels(writes)
+
+	s.tick++
+	for i, write := range writes {
+		s.scheduleAround(write.Position, fluidTypes[i])
+	}
+
+	return len(writes)
+}
+
+// scheduleAround schedules a position and its six neighbours using the tick
+// delay of the fluid involved
+func (s *Simulator) scheduleAround(pos voxel.VoxelPosition, fluidType voxel.VoxelType) {
+	delay := fluidTickDelay(fluidType)
+	s.Schedule(pos, delay)
+	for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+		s.Schedule(pos.Add(voxel.GetFaceOffset(face)), delay)
+	}
+}
+
+// nextState computes the voxel at pos after one update and the fluid type
+// that decided it
+func (s *Simulator) nextState(pos voxel.VoxelPosition, current voxel.Voxel) (voxel.Voxel, voxel.VoxelType) {
+	fluidType, ok := s.fluidAt(pos, current)
+	if !ok {
+		return current, current.Type
+	}
+	props, _ := voxel.GetVoxelProperties(fluidType)
+
+	if current.Type == fluidType && current.IsFluidSource() {
+		return current, fluidType
+	}
+
+	below, belowLoaded := s.getVoxel(pos.Add(voxel.GetFaceOffset(voxel.VoxelFaceBottom)))
+
+	if props.InfiniteSource {
+		sources := 0
+		for _, face := range horizontalFaces {
+			if n, ok := s.getVoxel(pos.Add(voxel.GetFaceOffset(face))); ok && n.Type == fluidType && n.IsFluidSource() {
+				sources++
+			}
+		}
+		supported := !belowLoaded || !below.IsAir() && (below.Type != fluidType || below.IsFluidSource())
+		if sources >= 2 && supported {
+			return voxel.NewVoxel(fluidType), fluidType
+		}
+	}
+
+	if above, ok := s.getVoxel(pos.Add(voxel.GetFaceOffset(voxel.VoxelFaceTop))); ok && above.Type == fluidType {
+		return voxel.NewFluidVoxel(fluidType, 0, true), fluidType
+	}
+
+	spread := props.FluidSpread
+	if spread > voxel.FluidMaxDistance {
+		spread = voxel.FluidMaxDistance
+	}
+
+	best := spread + 1
+	for _, face := range horizontalFaces {
+		neighborPos := pos.Add(voxel.GetFaceOffset(face))
+		n, ok := s.getVoxel(neighborPos)
+		if !ok || n.Type != fluidType || !s.spreadsSideways(neighborPos, fluidType) {
+			continue
+		}
+
+		distance := n.FluidDistance()
+		if n.IsFluidFalling() {
+			distance = 0
+		}
+		if distance+1 < best {
+			best = distance + 1
+		}
+	}
+
+	if best <= spread {
+		return voxel.NewFluidVoxel(fluidType, best, false), fluidType
+	}
+	return voxel.NewVoxel(voxel.VoxelTypeAir), fluidType
+}
+
+// fluidAt returns the fluid that may occupy pos: its own fluid, or for air
+// the first fluid found above or beside it
+func (s *Simulator) fluidAt(pos voxel.VoxelPosition, current voxel.Voxel) (voxel.VoxelType, bool) {
+	if current.IsFluid() {
+		return current.Type, true
+	}
+	if !current.IsAir() {
+		return current.Type, false
+	}
+
+	faces := []voxel.VoxelFace{voxel.VoxelFaceTop, horizontalFaces[0], horizontalFaces[1], horizontalFaces[2], horizontalFaces[3]}
+	for _, face := range faces {
+		if n, ok := s.getVoxel(pos.Add(voxel.GetFaceOffset(face))); ok && n.IsFluid() {
+			return n.Type, true
+		}
+	}
+
+	return current.Type, false
+}
+
+// spreadsSideways reports whether fluid at pos flows sideways, which it only
+// does when it cannot flow down
+func (s *Simulator) spreadsSideways(pos voxel.VoxelPosition, fluidType voxel.VoxelType) bool {
+	below, ok := s.getVoxel(pos.Add(voxel.GetFaceOffset(voxel.VoxelFaceBottom)))
+	if !ok {
+		return true
+	}
+	if below.IsAir() {
+		return false
+	}
+	return below.Type != fluidType || below.IsFluidSource()
+}
+
+// getVoxel reads a voxel without creating its chunk. Unloaded chunks report
+// false and are treated as solid, so fluid never flows into them.
+func (s *Simulator) getVoxel(pos voxel.VoxelPosition) (voxel.Voxel, bool) {
+	c := s.cm.GetChunkIfExists(chunk.VoxelToChunkPosition(pos))
+	if c == nil {
+		return voxel.Voxel{}, false
+	}
+
+	x, y, z := chunk.VoxelToLocalPosition(pos)
+	return c.GetVoxel(x, y, z), true
+}
+
+func fluidTickDelay(voxelType voxel.VoxelType) uint64 {
+	if props, ok := voxel.GetVoxelProperties(voxelType); ok && props.Fluid && props.FluidTickDelay > 0 {
+		return uint64(props.FluidTickDelay)
+	}
+	return 1
+}
+
+func lessChunkMajor(a, b voxel.VoxelPosition) bool {
+	ca, cb := chunk.VoxelToChunkPosition(a), chunk.VoxelToChunkPosition(b)
+	if ca != cb {
+		if ca.X != cb.X {
+			return ca.X < cb.X
+		}
+		if ca.Y != cb.Y {
+			return ca.Y < cb.Y
+		}
+		return ca.Z < cb.Z
+	}
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.Z < b.Z
+}
@@ -0,0 +1,230 @@
+package fluid
+
+import (
+	"testing"
+
+	"Ceres/internal/testworld"
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/tick"
+	"Ceres/pkg/voxel"
+)
+
+// newFloorWorld creates a stone floor at y = 0 spanning [-size, size] on X and Z
+func newFloorWorld(size int32) *chunk.ChunkManager {
+	cm := chunk.NewChunkManager()
+	testworld.Floor(cm, 0, -size, size, voxel.VoxelTypeStone)
+	return cm
+}
+
+func runUntilSettled(t *testing.T, s *Simulator) {
+	t.Helper()
+	for i := 0; i < 2000; i++ {
+		if s.PendingUpdates() == 0 {
+			return
+		}
+		s.Tick()
+	}
+	t.Fatal("Expected the fluid to settle")
+}
+
+func countWater(cm *chunk.ChunkManager, y, size int32) int {
+	count := 0
+	for x := -size; x <= size; x++ {
+		for z := -size; z <= size; z++ {
+			if cm.GetVoxel(voxel.NewVoxelPosition(x, y, z)).Type == voxel.VoxelTypeWater {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestWaterSpreadsAFiniteDistance(t *testing.T) {
+	cm := newFloorWorld(12)
+	s := NewSimulator(cm)
+
+	source := voxel.NewVoxelPosition(0, 1, 0)
+	cm.SetVoxel(source, voxel.NewVoxel(voxel.VoxelTypeWater))
+	s.NotifyChanged(source)
+	runUntilSettled(t, s)
+
+	// A diamond of radius 7 around the source
+	if count := countWater(cm, 1, 12); count != 113 {
+		t.Errorf("Expected 113 water voxels, got %d", count)
+	}
+
+	edge := cm.GetVoxel(voxel.NewVoxelPosition(7, 1, 0))
+	if edge.Type != voxel.VoxelTypeWater || edge.FluidDistance() != 7 {
+		t.Errorf("Expected water at distance 7 at the edge, got %s with state %d", edge.GetName(), edge.State)
+	}
+	if !cm.GetVoxel(voxel.NewVoxelPosition(8, 1, 0)).IsAir() {
+		t.Error("Expected no water beyond the spread distance")
+	}
+}
+
+func TestWaterFallsThenSpreads(t *testing.T) {
+	cm := newFloorWorld(12)
+	s := NewSimulator(cm)
+
+	source := voxel.NewVoxelPosition(0, 5, 0)
+	cm.SetVoxel(source, voxel.NewVoxel(voxel.VoxelTypeWater))
+	s.NotifyChanged(source)
+	runUntilSettled(t, s)
+
+	for y := int32(1); y < 5; y++ {
+		v := cm.GetVoxel(voxel.NewVoxelPosition(0, y, 0))
+		if v.Type != voxel.VoxelTypeWater || !v.IsFluidFalling() {
+			t.Fatalf("Expected falling water at y=%d, got %s with state %d", y, v.GetName(), v.State)
+		}
+	}
+	if !cm.GetVoxel(voxel.NewVoxelPosition(1, 5, 0)).IsAir() {
+		t.Error("Expected the source not to spread sideways while it can fall")
+	}
+	if cm.GetVoxel(voxel.NewVoxelPosition(3, 1, 0)).Type != voxel.VoxelTypeWater {
+		t.Error("Expected the water to spread once it lands")
+	}
+}
+
+func TestInfiniteSourceAndDrain(t *testing.T) {
+	cm := newFloorWorld(12)
+	s := NewSimulator(cm)
+
+	a, b := voxel.NewVoxelPosition(0, 1, 0), voxel.NewVoxelPosition(2, 1, 0)
+	cm.SetVoxel(a, voxel.NewVoxel(voxel.VoxelTypeWater))
+	cm.SetVoxel(b, voxel.NewVoxel(voxel.VoxelTypeWater))
+	s.NotifyChanged(a)
+	s.NotifyChanged(b)
+	runUntilSettled(t, s)
+
+	if !cm.GetVoxel(voxel.NewVoxelPosition(1, 1, 0)).IsFluidSource() {
+		t.Error("Expected water between two sources to become a source")
+	}
+
+	for _, pos := range []voxel.VoxelPosition{a, b, voxel.NewVoxelPosition(1, 1, 0)} {
+		cm.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelTypeAir))
+		s.NotifyChanged(pos)
+	}
+	runUntilSettled(t, s)
+
+	if count := countWater(cm, 1, 12); count != 0 {
+		t.Errorf("Expected flowing water to drain after removing its sources, got %d voxels", count)
+	}
+}
+
+func TestWaterDoesNotFlowIntoUnloadedChunks(t *testing.T) {
+	cm := chunk.NewChunkManager()
+	testworld.Floor(cm, 0, 0, chunk.ChunkSize-1, voxel.VoxelTypeStone)
+	s := NewSimulator(cm)
+
+	source := voxel.NewVoxelPosition(0, 1, 0)
+	cm.SetVoxel(source, voxel.NewVoxel(voxel.VoxelTypeWater))
+	s.NotifyChanged(source)
+	runUntilSettled(t, s)
+
+	if len(cm.GetLoadedChunks()) != 1 {
+		t.Errorf("Expected only 1 loaded chunk, got %d", len(cm.GetLoadedChunks()))
+	}
+}
+
+func TestFlowingWaterSlopes(t *testing.T) {
+	cm := newFloorWorld(12)
+	s := NewSimulator(cm)
+
+	source := voxel.NewVoxelPosition(4, 1, 4)
+	cm.SetVoxel(source, voxel.NewVoxel(voxel.VoxelTypeWater))
+	s.NotifyChanged(source)
+	runUntilSettled(t, s)
+
+	mesh := cm.GetChunk(chunk.NewChunkPosition(0, 0, 0)).BuildMesh(false)
+
+	sloped := false
+	for i := 0; i < len(mesh.Vertices); i += 11 {
+		y, ny := mesh.Vertices[i+1], mesh.Vertices[i+4]
+		if y > 1 && y < 2 && ny > 0 && ny < 1 {
+			sloped = true
+		}
+		if y > 2 {
+			t.Fatalf("Expected water surfaces below y=2, got a vertex at %f", y)
+		}
+	}
+	if !sloped {
+		t.Error("Expected sloped water surfaces")
+	}
+}
+
+func TestFlowingWaterKeepsFlowingAfterReload(t *testing.T) {
+	storage, err := chunk.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected storage, got %v", err)
+	}
+
+	cm := newFloorWorld(12)
+	s := NewSimulator(cm)
+	source := voxel.NewVoxelPosition(0, 1, 0)
+	cm.SetVoxel(source, voxel.NewVoxel(voxel.VoxelTypeWater))
+	s.NotifyChanged(source)
+	for i := uint64(0); i < 3*fluidTickDelay(voxel.VoxelTypeWater); i++ {
+		s.Tick()
+	}
+	if s.PendingUpdates() == 0 {
+		t.Fatal("Expected the water to still be flowing before saving")
+	}
+	for _, c := range cm.GetLoadedChunks() {
+		if err := storage.Save(c); err != nil {
+			t.Fatalf("Expected the chunk to save, got %v", err)
+		}
+	}
+
+	loaded := chunk.NewChunkManager()
+	for _, c := range cm.GetLoadedChunks() {
+		restored, err := storage.Load(c.Position)
+		if err != nil {
+			t.Fatalf("Expected the chunk to load, got %v", err)
+		}
+		loaded.InsertChunk(restored)
+	}
+	reloaded := NewSimulator(loaded)
+	reloaded.SetTick(s.CurrentTick())
+	if reloaded.PendingUpdates() == 0 {
+		t.Fatal("Expected the pending updates to be saved with the chunks")
+	}
+
+	runUntilSettled(t, reloaded)
+	runUntilSettled(t, s)
+	if count := countWater(loaded, 1, 12); count != 113 {
+		t.Errorf("Expected the reloaded water to spread to 113 voxels, got %d", count)
+	}
+	if before, after := countWater(cm, 1, 12), countWater(loaded, 1, 12); before != after {
+		t.Errorf("Expected the same water as without reloading, got %d and %d", before, after)
+	}
+}
+
+func TestFluidTicksStayApartFromBlockTicks(t *testing.T) {
+	cm := newFloorWorld(12)
+	s := NewSimulator(cm)
+	ticks := tick.NewScheduler(cm, 1)
+	ticks.RandomTicksPerSection = 0
+
+	var handled []voxel.VoxelPosition
+	ticks.RegisterScheduled(voxel.VoxelTypeAir, func(ctx *tick.Context, pos voxel.VoxelPosition, v voxel.Voxel) {
+		handled = append(handled, pos)
+	})
+
+	source := voxel.NewVoxelPosition(0, 1, 0)
+	cm.SetVoxel(source, voxel.NewVoxel(voxel.VoxelTypeWater))
+	s.NotifyChanged(source)
+	air := voxel.NewVoxelPosition(5, 6, 5)
+	ticks.Schedule(air, 2)
+
+	for i := 0; i < 2000 && s.PendingUpdates() > 0; i++ {
+		ticks.Tick()
+		s.Tick()
+	}
+
+	if len(handled) != 1 || handled[0] != air {
+		t.Errorf("Expected only the block tick to reach the air handler, got %v", handled)
+	}
+	if count := countWater(cm, 1, 12); count != 113 {
+		t.Errorf("Expected the water to spread to 113 voxels alongside block ticks, got %d", count)
+	}
+}
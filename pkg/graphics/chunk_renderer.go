@@ -1,13 +1,63 @@
 package graphics
 
 import (
+	"math"
+
 	"github.com/go-gl/gl/v4.1-core/gl"
 
+	"Ceres/pkg/camera"
 	"Ceres/pkg/chunk"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
 )
 
+// GPUMesh holds the OpenGL objects of an uploaded chunk mesh
+type GPUMesh struct {
+	VAO uint32
+	VBO uint32
+	EBO uint32
+
+	IndexCount int
+
+	// Format and Origin come from the uploaded mesh, whose vertices are
+	// relative to Origin
+	Format chunk.VertexFormat
+	Origin voxel.VoxelPosition
+}
+
+// chunkSections holds the uploaded meshes of a chunk's sections; sections
+// without faces have none
+type chunkSections [chunk.SectionCount]*GPUMesh
+
+func (s *chunkSections) isEmpty() bool {
+	for _, mesh := range s {
+		if mesh != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ChunkRenderer draws chunk meshes relative to the camera: each chunk's offset
+// from the camera is computed in double precision and passed to the shader, so
+// chunks far from the origin render without jitter. The view matrix must come
+// from Camera.GetRelativeViewMatrix, and other world positions given to the
+// shader, such as lights, must be relative to the camera too.
+//
+// Each chunk section has its own mesh, so an edit only rebuilds and
+// re-uploads the sections around it.
 type ChunkRenderer struct {
-	meshes map[chunk.ChunkPosition]*chunk.ChunkMesh
+	meshes     map[chunk.ChunkPosition]*chunkSections
+	visibility *chunk.VisibilityGraph
+
+	// Format is the vertex format chunks are meshed in. Float meshes are
+	// drawn with the "chunk" shader and packed meshes with the "packed"
+	// shader, after SetVoxelPalette; both are lit by SetSkyUniforms and
+	// shadowed by ShadowRenderer.SetUniforms.
+	Format chunk.VertexFormat
+	// MeshMode is the world's mesh mode. Smooth meshes are always in the float
+	// format, whatever Format is. Clear the renderer after changing it.
+	MeshMode chunk.MeshMode
 
 	renderedChunks int
 	renderedFaces  int
@@ -15,40 +65,102 @@ type ChunkRenderer struct {
 
 func NewChunkRenderer() *ChunkRenderer {
 	return &ChunkRenderer{
-		meshes: make(map[chunk.ChunkPosition]*chunk.ChunkMesh),
+		meshes:     make(map[chunk.ChunkPosition]*chunkSections),
+		visibility: chunk.NewVisibilityGraph(),
+	}
+}
+
+// SetVoxelPalette uploads the colour of every voxel type to the palette
+// uniform of the shader in use
+func SetVoxelPalette(shader *Shader) {
+	palette := make([][3]float32, 256)
+	for i := range palette {
+		palette[i] = [3]float32{1, 1, 1}
+		if props, exists := voxel.GetVoxelProperties(voxel.VoxelType(i)); exists {
+			palette[i] = props.Color
+		}
 	}
+	shader.SetVec3Array("palette", palette)
 }
 
+// UpdateChunkMesh rebuilds and uploads the meshes of the chunk's dirty
+// sections, keeping those of the other sections. The meshes are built from a
+// snapshot, so the chunk may be edited meanwhile.
 func (cr *ChunkRenderer) UpdateChunkMesh(c *chunk.Chunk) {
-	mesh := c.GenerateMesh()
+	dirty := c.TakeDirtySections()
+	if dirty == 0 {
+		return
+	}
+	snapshot := c.Snapshot()
+	cr.visibility.Set(c.Position, snapshot.ComputeConnectivity())
+
+	sections, exists := cr.meshes[c.Position]
+	if !exists {
+		sections = &chunkSections{}
+	}
 
-	if oldMesh, exists := cr.meshes[c.Position]; exists {
-		cr.DeleteMesh(oldMesh)
+	for section := 0; section < chunk.SectionCount; section++ {
+		if !dirty.Has(section) {
+			continue
+		}
+		if oldMesh := sections[section]; oldMesh != nil {
+			cr.DeleteMesh(oldMesh)
+			sections[section] = nil
+		}
+
+		var mesh *chunk.ChunkMesh
+		if cr.MeshMode == chunk.MeshModeSmooth {
+			mesh = snapshot.BuildSmoothSectionMesh(section)
+		} else if cr.Format == chunk.VertexFormatPacked {
+			mesh = snapshot.BuildPackedSectionMesh(section, false)
+		} else {
+			mesh = snapshot.BuildSectionMesh(section, false)
+		}
+		if !mesh.IsEmpty() {
+			sections[section] = cr.UploadMesh(mesh)
+		}
 	}
 
-	if mesh.IsEmpty() {
+	if sections.isEmpty() {
 		delete(cr.meshes, c.Position)
 		return
 	}
-
-	cr.UploadMesh(mesh)
-
-	cr.meshes[c.Position] = mesh
+	cr.meshes[c.Position] = sections
 }
 
-func (cr *ChunkRenderer) UploadMesh(mesh *chunk.ChunkMesh) {
-	gl.GenVertexArrays(1, &mesh.VAO)
-	gl.GenBuffers(1, &mesh.VBO)
-	gl.GenBuffers(1, &mesh.EBO)
+// UploadMesh copies a chunk mesh to the GPU. The CPU mesh is not referenced
+// afterwards and can be discarded.
+func (cr *ChunkRenderer) UploadMesh(mesh *chunk.ChunkMesh) *GPUMesh {
+	gpuMesh := &GPUMesh{IndexCount: mesh.IndexCount, Format: mesh.Format, Origin: mesh.Origin}
 
-	gl.BindVertexArray(mesh.VAO)
+	gl.GenVertexArrays(1, &gpuMesh.VAO)
+	gl.GenBuffers(1, &gpuMesh.VBO)
+	gl.GenBuffers(1, &gpuMesh.EBO)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, mesh.VBO)
-	gl.BufferData(gl.ARRAY_BUFFER, len(mesh.Vertices)*4, gl.Ptr(mesh.Vertices), gl.STATIC_DRAW)
+	gl.BindVertexArray(gpuMesh.VAO)
 
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, mesh.EBO)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, gpuMesh.EBO)
 	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(mesh.Indices)*4, gl.Ptr(mesh.Indices), gl.STATIC_DRAW)
 
+	gl.BindBuffer(gl.ARRAY_BUFFER, gpuMesh.VBO)
+	if mesh.Format == chunk.VertexFormatPacked {
+		gl.BufferData(gl.ARRAY_BUFFER, len(mesh.Packed)*4, gl.Ptr(mesh.Packed), gl.STATIC_DRAW)
+
+		// Integer attributes reach the shader as uint without conversion
+		stride := int32(chunk.PackedWordsPerVertex * 4)
+
+		gl.VertexAttribIPointer(0, 1, gl.UNSIGNED_INT, stride, gl.PtrOffset(0))
+		gl.EnableVertexAttribArray(0)
+
+		gl.VertexAttribIPointer(1, 1, gl.UNSIGNED_INT, stride, gl.PtrOffset(4))
+		gl.EnableVertexAttribArray(1)
+
+		gl.BindVertexArray(0)
+		return gpuMesh
+	}
+
+	gl.BufferData(gl.ARRAY_BUFFER, len(mesh.Vertices)*4, gl.Ptr(mesh.Vertices), gl.STATIC_DRAW)
+
 	stride := int32(11 * 4)
 
 	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, stride, gl.PtrOffset(0))
@@ -64,9 +176,11 @@ func (cr *ChunkRenderer) UploadMesh(mesh *chunk.ChunkMesh) {
 	gl.EnableVertexAttribArray(3)
 
 	gl.BindVertexArray(0)
+
+	return gpuMesh
 }
 
-func (cr *ChunkRenderer) DeleteMesh(mesh *chunk.ChunkMesh) {
+func (cr *ChunkRenderer) DeleteMesh(mesh *GPUMesh) {
 	if mesh.VAO != 0 {
 		gl.DeleteVertexArrays(1, &mesh.VAO)
 		mesh.VAO = 0
@@ -81,29 +195,101 @@ func (cr *ChunkRenderer) DeleteMesh(mesh *chunk.ChunkMesh) {
 	}
 }
 
-func (cr *ChunkRenderer) RenderChunk(chunkPos chunk.ChunkPosition) {
-	mesh, exists := cr.meshes[chunkPos]
-	if !exists || mesh.IsEmpty() {
+// RenderChunk draws a chunk with the shader in use, placing it relative to
+// the camera through the "model" matrix for float meshes or the "chunkOffset"
+// uniform for packed ones
+func (cr *ChunkRenderer) RenderChunk(shader *Shader, chunkPos chunk.ChunkPosition, cameraPos ceresmath.Vector3d) {
+	sections, exists := cr.meshes[chunkPos]
+	if !exists {
 		return
 	}
 
-	gl.BindVertexArray(mesh.VAO)
-	gl.DrawElements(gl.TRIANGLES, int32(mesh.IndexCount), gl.UNSIGNED_INT, nil)
+	// Every section mesh shares the chunk origin
+	offsetSet := false
+	for _, mesh := range sections {
+		if mesh == nil || mesh.IndexCount == 0 {
+			continue
+		}
+
+		if !offsetSet {
+			offset := ChunkOffset(mesh.Origin, cameraPos)
+			if mesh.Format == chunk.VertexFormatPacked {
+				shader.SetVec3("chunkOffset", offset.X, offset.Y, offset.Z)
+			} else {
+				shader.SetMat4("model", ceresmath.TranslateVec(offset).ToPtr())
+			}
+			offsetSet = true
+		}
+
+		gl.BindVertexArray(mesh.VAO)
+		gl.DrawElements(gl.TRIANGLES, int32(mesh.IndexCount), gl.UNSIGNED_INT, nil)
+		cr.renderedFaces += mesh.IndexCount / 3
+	}
 	gl.BindVertexArray(0)
-
-	cr.renderedFaces += mesh.IndexCount / 3
 }
 
-func (cr *ChunkRenderer) RenderAll() {
+// RenderAll draws every chunk with the shader in use, relative to the camera
+func (cr *ChunkRenderer) RenderAll(shader *Shader, cameraPos ceresmath.Vector3d) {
 	cr.renderedChunks = 0
 	cr.renderedFaces = 0
 
 	for chunkPos := range cr.meshes {
-		cr.RenderChunk(chunkPos)
+		cr.RenderChunk(shader, chunkPos, cameraPos)
 		cr.renderedChunks++
 	}
 }
 
+// RenderVisible draws the chunks that may be seen from the camera, skipping
+// chunks outside the frustum or hidden behind solid terrain, up to
+// maxDistance chunks away. The frustum must be relative to the camera, as
+// returned by Camera.GetFrustum.
+func (cr *ChunkRenderer) RenderVisible(shader *Shader, cam *camera.Camera, frustum camera.Frustum, maxDistance int32) {
+	cr.renderedChunks = 0
+	cr.renderedFaces = 0
+
+	cameraChunk := chunk.VoxelToChunkPosition(voxel.NewVoxelPosition(
+		int32(math.Floor(cam.Position.X)), int32(math.Floor(cam.Position.Y)), int32(math.Floor(cam.Position.Z)),
+	))
+	inView := func(pos chunk.ChunkPosition) bool {
+		min, max := chunkBounds(pos, cam.Position)
+		return frustum.ContainsAABB(min, max)
+	}
+
+	for _, chunkPos := range cr.visibility.Visible(cameraChunk, maxDistance, inView) {
+		if _, exists := cr.meshes[chunkPos]; !exists {
+			continue
+		}
+		cr.RenderChunk(shader, chunkPos, cam.Position)
+		cr.renderedChunks++
+	}
+}
+
+// RenderInFrustum draws every chunk inside a camera-relative frustum with the
+// shader in use, whether or not the camera can see it, as shadow casters are
+// drawn. It leaves the stats of the last RenderAll or RenderVisible alone.
+func (cr *ChunkRenderer) RenderInFrustum(shader *Shader, cameraPos ceresmath.Vector3d, frustum camera.Frustum) {
+	renderedFaces := cr.renderedFaces
+	for chunkPos := range cr.meshes {
+		if min, max := chunkBounds(chunkPos, cameraPos); frustum.ContainsAABB(min, max) {
+			cr.RenderChunk(shader, chunkPos, cameraPos)
+		}
+	}
+	cr.renderedFaces = renderedFaces
+}
+
+// chunkBounds returns the corners of a chunk relative to the camera
+func chunkBounds(pos chunk.ChunkPosition, cameraPos ceresmath.Vector3d) (min, max ceresmath.Vector3) {
+	min = ChunkOffset(voxel.NewVoxelPosition(pos.X*chunk.ChunkSize, pos.Y*chunk.ChunkSize, pos.Z*chunk.ChunkSize), cameraPos)
+	max = min.Add(ceresmath.Vector3{X: chunk.ChunkSize, Y: chunk.ChunkSize, Z: chunk.ChunkSize})
+	return min, max
+}
+
+// ChunkOffset returns where a mesh origin lies relative to the camera
+func ChunkOffset(origin voxel.VoxelPosition, cameraPos ceresmath.Vector3d) ceresmath.Vector3 {
+	world := ceresmath.Vector3d{X: float64(origin.X), Y: float64(origin.Y), Z: float64(origin.Z)}
+	return world.RelativeTo(cameraPos)
+}
+
 func (cr *ChunkRenderer) GetStats() (chunks, faces int) {
 	return cr.renderedChunks, cr.renderedFaces
 }
@@ -119,10 +305,15 @@ func (cr *ChunkRenderer) UpdateDirtyChunks(chunkManager *chunk.ChunkManager) int
 }
 
 func (cr *ChunkRenderer) Clear() {
-	for _, mesh := range cr.meshes {
-		cr.DeleteMesh(mesh)
+	for _, sections := range cr.meshes {
+		for _, mesh := range sections {
+			if mesh != nil {
+				cr.DeleteMesh(mesh)
+			}
+		}
 	}
-	cr.meshes = make(map[chunk.ChunkPosition]*chunk.ChunkMesh)
+	cr.meshes = make(map[chunk.ChunkPosition]*chunkSections)
+	cr.visibility.Clear()
 }
 
 func (cr *ChunkRenderer) GetMeshCount() int {
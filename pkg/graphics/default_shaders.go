@@ -133,3 +133,257 @@ void main()
     FragColor = vec4(vertexColor, 1.0);
 }
 `
+
+// PackedChunkVertexShader draws chunk meshes in chunk.VertexFormatPacked.
+// Positions are chunk-local and placed by the chunkOffset uniform, the chunk's
+// position relative to the camera, and colours come from the palette uniform,
+// indexed by voxel type. Lighting happens in camera-relative space.
+const PackedChunkVertexShader = `#version 410 core
+
+layout (location = 0) in uint aPosition;
+layout (location = 1) in uint aAttributes;
+
+out vec3 FragPos;
+out vec3 Normal;
+out vec2 TexCoord;
+out vec3 VertexColor;
+out float Occlusion;
+
+uniform mat4 view;
+uniform mat4 projection;
+uniform vec3 chunkOffset;
+uniform vec3 palette[256];
+
+const vec3 faceNormals[6] = vec3[6](
+    vec3(0.0, 1.0, 0.0),
+    vec3(0.0, -1.0, 0.0),
+    vec3(-1.0, 0.0, 0.0),
+    vec3(1.0, 0.0, 0.0),
+    vec3(0.0, 0.0, 1.0),
+    vec3(0.0, 0.0, -1.0)
+);
+
+const float aoCurve[4] = float[4](0.45, 0.65, 0.82, 1.0);
+
+void main()
+{
+    vec3 local = vec3(
+        float(aPosition & 0x3FFu),
+        float((aPosition >> 10u) & 0x3FFu),
+        float((aPosition >> 20u) & 0x3FFu)
+    ) / 16.0;
+
+    uint face = aAttributes & 0x7u;
+    uint ao = (aAttributes >> 3u) & 0x3u;
+    uint light = (aAttributes >> 5u) & 0xFu;
+    uint voxelType = (aAttributes >> 21u) & 0xFFu;
+
+    FragPos = chunkOffset + local;
+    Normal = faceNormals[min(face, 5u)];
+    TexCoord = vec2(float((aAttributes >> 9u) & 0x3Fu), float((aAttributes >> 15u) & 0x3Fu));
+    VertexColor = palette[voxelType] * (float(light) / 15.0);
+    Occlusion = aoCurve[ao];
+
+    gl_Position = projection * view * vec4(FragPos, 1.0);
+}
+`
+
+const PackedChunkFragmentShader = `#version 410 core
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 TexCoord;
+in vec3 VertexColor;
+in float Occlusion;
+
+out vec4 FragColor;
+` + chunkLighting + `
+void main()
+{
+    FragColor = vec4(shadeChunk(VertexColor, Normal, FragPos, Occlusion), 1.0);
+}
+`
+
+// ChunkFragmentShader lights float chunk meshes like the packed shader. It
+// pairs with BasicVertexShader, whose model matrix places chunks relative to
+// the camera.
+const ChunkFragmentShader = `#version 410 core
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 TexCoord;
+in vec3 VertexColor;
+
+out vec4 FragColor;
+` + chunkLighting + `
+void main()
+{
+    FragColor = vec4(shadeChunk(VertexColor, normalize(Normal), FragPos, 1.0), 1.0);
+}
+`
+
+// chunkLighting is the sky lighting shared by the chunk fragment shaders, set
+// by SetSkyUniforms, with the sun's shadows set by ShadowRenderer.SetUniforms.
+// Positions are relative to the camera, so the distance to a fragment is the
+// length of its position. With cascadeCount left at 0 nothing is shadowed.
+const chunkLighting = `
+uniform vec3 sunDirection;
+uniform vec3 sunColor;
+uniform vec3 ambientColor;
+uniform vec3 fogColor;
+uniform float fogStart;
+uniform float fogEnd;
+
+uniform sampler2DArrayShadow shadowMap;
+uniform mat4 cascadeViewProjection[4];
+uniform float cascadeFar[4];
+uniform float cascadeTexelSize[4];
+uniform int cascadeCount;
+uniform vec3 viewForward;
+
+// sunShadow returns how much of the sun reaches a fragment, filtering 3x3
+// shadow map texels so shadow edges are soft
+float sunShadow(vec3 normal, vec3 fragPos)
+{
+    if (cascadeCount == 0) {
+        return 1.0;
+    }
+
+    // Cascades are split by distance along the view
+    float depth = dot(fragPos, viewForward);
+    float shadowDistance = cascadeFar[cascadeCount - 1];
+    if (depth >= shadowDistance) {
+        return 1.0;
+    }
+    int cascade = 0;
+    while (cascade < cascadeCount - 1 && depth > cascadeFar[cascade]) {
+        cascade++;
+    }
+
+    // Looking up the map from just off the surface, further on slopes facing
+    // away from the sun, keeps surfaces from shadowing themselves
+    float slope = 1.0 - max(dot(normal, sunDirection), 0.0);
+    vec3 lookup = fragPos + normal * cascadeTexelSize[cascade] * (1.0 + 2.0 * slope);
+    vec4 lightClip = cascadeViewProjection[cascade] * vec4(lookup, 1.0);
+    vec3 coords = lightClip.xyz / lightClip.w * 0.5 + 0.5;
+
+    vec2 texel = 1.0 / vec2(textureSize(shadowMap, 0).xy);
+    float lit = 0.0;
+    for (int x = -1; x <= 1; x++) {
+        for (int y = -1; y <= 1; y++) {
+            lit += texture(shadowMap, vec4(coords.xy + vec2(x, y) * texel, float(cascade), coords.z));
+        }
+    }
+    lit /= 9.0;
+
+    // Shadows fade out towards the shadow distance rather than ending at a line
+    float fade = clamp((shadowDistance - depth) / (0.1 * shadowDistance), 0.0, 1.0);
+    return mix(1.0, lit, fade);
+}
+
+vec3 shadeChunk(vec3 albedo, vec3 normal, vec3 fragPos, float occlusion)
+{
+    vec3 ambient = ambientColor * occlusion;
+    float diff = max(dot(normal, sunDirection), 0.0);
+    if (diff > 0.0) {
+        diff *= sunShadow(normal, fragPos);
+    }
+    vec3 diffuse = diff * sunColor * mix(0.6, 1.0, occlusion);
+
+    float fog = clamp((length(fragPos) - fogStart) / max(fogEnd - fogStart, 0.001), 0.0, 1.0);
+    return mix((ambient + diffuse) * albedo, fogColor, fog);
+}
+`
+
+// ShadowVertexShader draws float chunk meshes into a shadow cascade, placed
+// relative to the camera by the model matrix like BasicVertexShader
+const ShadowVertexShader = `#version 410 core
+
+layout (location = 0) in vec3 aPos;
+
+uniform mat4 model;
+uniform mat4 lightViewProjection;
+
+void main()
+{
+    gl_Position = lightViewProjection * model * vec4(aPos, 1.0);
+}
+`
+
+// PackedShadowVertexShader draws packed chunk meshes into a shadow cascade,
+// decoding positions like PackedChunkVertexShader
+const PackedShadowVertexShader = `#version 410 core
+
+layout (location = 0) in uint aPosition;
+
+uniform mat4 lightViewProjection;
+uniform vec3 chunkOffset;
+
+void main()
+{
+    vec3 local = vec3(
+        float(aPosition & 0x3FFu),
+        float((aPosition >> 10u) & 0x3FFu),
+        float((aPosition >> 20u) & 0x3FFu)
+    ) / 16.0;
+
+    gl_Position = lightViewProjection * vec4(chunkOffset + local, 1.0);
+}
+`
+
+// ShadowFragmentShader writes nothing; shadow passes only keep depth
+const ShadowFragmentShader = `#version 410 core
+
+void main()
+{
+}
+`
+
+// SkyVertexShader covers the screen with one triangle, numbered by
+// gl_VertexID, at the far plane, and passes on the direction each pixel
+// looks in, unprojected with the inverse of the camera-relative view and
+// projection matrices
+const SkyVertexShader = `#version 410 core
+
+out vec3 ViewDirection;
+
+uniform mat4 inverseViewProjection;
+
+void main()
+{
+    vec2 corner = vec2(float((gl_VertexID << 1) & 2), float(gl_VertexID & 2)) * 2.0 - 1.0;
+    vec4 far = inverseViewProjection * vec4(corner, 1.0, 1.0);
+    ViewDirection = far.xyz / far.w;
+    gl_Position = vec4(corner, 1.0, 1.0);
+}
+`
+
+// SkyFragmentShader blends from the horizon colour to the zenith colour and
+// draws the sun and moon discs
+const SkyFragmentShader = `#version 410 core
+
+in vec3 ViewDirection;
+
+out vec4 FragColor;
+
+uniform vec3 zenithColor;
+uniform vec3 horizonColor;
+uniform vec3 sunDirection;
+uniform vec3 moonDirection;
+uniform float daylight;
+
+void main()
+{
+    vec3 dir = normalize(ViewDirection);
+    vec3 color = mix(horizonColor, zenithColor, sqrt(clamp(dir.y, 0.0, 1.0)));
+
+    float sun = dot(dir, sunDirection);
+    color += vec3(1.0, 0.9, 0.7) * smoothstep(0.9990, 0.9995, sun);
+    color += horizonColor * 0.4 * pow(max(sun, 0.0), 32.0);
+
+    float moon = smoothstep(0.9994, 0.9997, dot(dir, moonDirection));
+    color = mix(color, vec3(0.85, 0.87, 0.95), moon * (1.0 - daylight));
+
+    FragColor = vec4(color, 1.0);
+}
+`
@@ -99,6 +99,14 @@ func (s *Shader) SetVec3(name string, x, y, z float32) {
     gl.Uniform3f(s.GetUniformLocation(name), x, y, z)
 }
 
+// SetVec3Array sets a vec3 array uniform from its first element
+func (s *Shader) SetVec3Array(name string, values [][3]float32) {
+    if len(values) == 0 {
+        return
+    }
+    gl.Uniform3fv(s.GetUniformLocation(name), int32(len(values)), &values[0][0])
+}
+
 func (s *Shader) SetVec4(name string, x, y, z, w float32) {
     gl.Uniform4f(s.GetUniformLocation(name), x, y, z, w)
 }
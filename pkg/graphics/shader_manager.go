@@ -78,6 +78,14 @@ func (sm *ShaderManager) LoadDefaultShaders() error {
 		return err
 	}
 
+	if err := sm.LoadShader("packed", PackedChunkVertexShader, PackedChunkFragmentShader); err != nil {
+		return err
+	}
+
+	if err := sm.LoadShader("chunk", BasicVertexShader, ChunkFragmentShader); err != nil {
+		return err
+	}
+
 	fmt.Println("✓ All default shaders loaded")
 	return nil
 }
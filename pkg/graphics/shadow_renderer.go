@@ -0,0 +1,140 @@
+package graphics
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"Ceres/pkg/camera"
+	"Ceres/pkg/chunk"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/shadow"
+)
+
+// ShadowRenderer draws the shadows of the sun, or moon, as cascaded shadow
+// maps: a depth texture array with a layer per cascade, fitted to the camera
+// by shadow.Fit. Render the cascades before the world each frame, then light
+// the chunk shader with SetUniforms.
+type ShadowRenderer struct {
+	Config shadow.Config
+
+	shader       *Shader
+	packedShader *Shader
+
+	texture     uint32
+	framebuffer uint32
+
+	cascades []shadow.Cascade
+}
+
+func NewShadowRenderer(config shadow.Config) (*ShadowRenderer, error) {
+	config.Cascades = min(max(config.Cascades, 1), shadow.MaxCascades)
+
+	shader, err := NewShader(ShadowVertexShader, ShadowFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+	packedShader, err := NewShader(PackedShadowVertexShader, ShadowFragmentShader)
+	if err != nil {
+		shader.Delete()
+		return nil, err
+	}
+	sr := &ShadowRenderer{Config: config, shader: shader, packedShader: packedShader}
+
+	size := int32(config.Resolution)
+	gl.GenTextures(1, &sr.texture)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, sr.texture)
+	gl.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, gl.DEPTH_COMPONENT32F, size, size, int32(config.Cascades), 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	// Linear filtering of a comparison texture blends the four nearest
+	// depth tests, softening each PCF tap
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	// Beyond the edge of a cascade everything is lit
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	border := [4]float32{1, 1, 1, 1}
+	gl.TexParameterfv(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_BORDER_COLOR, &border[0])
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+
+	gl.GenFramebuffers(1, &sr.framebuffer)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, sr.framebuffer)
+	gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, sr.texture, 0, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		sr.Delete()
+		return nil, fmt.Errorf("shadow framebuffer incomplete: 0x%x", status)
+	}
+	return sr, nil
+}
+
+// Render fits the cascades to the camera and draws the chunks casting shadows
+// into each, for a light shining from lightDirection. near is the camera's
+// near plane. The framebuffer and viewport are restored afterwards.
+func (sr *ShadowRenderer) Render(chunks *ChunkRenderer, cam *camera.Camera, aspectRatio, near float32, lightDirection ceresmath.Vector3) {
+	sr.cascades = shadow.Fit(cam, aspectRatio, near, lightDirection, sr.Config)
+
+	// Smooth meshes are always floats, see ChunkRenderer.MeshMode
+	shader := sr.shader
+	if chunks.Format == chunk.VertexFormatPacked && chunks.MeshMode != chunk.MeshModeSmooth {
+		shader = sr.packedShader
+	}
+
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, sr.framebuffer)
+	gl.Viewport(0, 0, int32(sr.Config.Resolution), int32(sr.Config.Resolution))
+	// Pushing depths back a little keeps lit surfaces from shadowing
+	// themselves
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(1.5, 4)
+
+	shader.Use()
+	for i, cascade := range sr.cascades {
+		gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, sr.texture, 0, int32(i))
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+
+		shader.SetMat4("lightViewProjection", cascade.ViewProjection.ToPtr())
+		chunks.RenderInFrustum(shader, cam.Position, camera.NewFrustum(cascade.ViewProjection))
+	}
+
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+}
+
+// SetUniforms binds the cascades of the last Render to texture unit unit and
+// sets the shadow uniforms of the chunk shader in use, see chunkLighting
+func (sr *ShadowRenderer) SetUniforms(shader *Shader, cam *camera.Camera, unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, sr.texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	shader.SetInt("shadowMap", int32(unit))
+	shader.SetInt("cascadeCount", int32(len(sr.cascades)))
+	shader.SetVec3("viewForward", cam.Front.X, cam.Front.Y, cam.Front.Z)
+	for i, cascade := range sr.cascades {
+		shader.SetMat4(fmt.Sprintf("cascadeViewProjection[%d]", i), cascade.ViewProjection.ToPtr())
+		shader.SetFloat(fmt.Sprintf("cascadeFar[%d]", i), cascade.Far)
+		shader.SetFloat(fmt.Sprintf("cascadeTexelSize[%d]", i), cascade.TexelSize)
+	}
+}
+
+func (sr *ShadowRenderer) Delete() {
+	if sr.framebuffer != 0 {
+		gl.DeleteFramebuffers(1, &sr.framebuffer)
+		sr.framebuffer = 0
+	}
+	if sr.texture != 0 {
+		gl.DeleteTextures(1, &sr.texture)
+		sr.texture = 0
+	}
+	sr.shader.Delete()
+	sr.packedShader.Delete()
+}
@@ -0,0 +1,80 @@
+package graphics
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/sky"
+)
+
+// SkyRenderer draws the sky behind the world: a gradient from the horizon to
+// the zenith with the sun and moon. Draw it after clearing and before
+// anything else; it writes no depth.
+type SkyRenderer struct {
+	shader *Shader
+	// vao is empty, the sky's single triangle comes from gl_VertexID
+	vao uint32
+}
+
+func NewSkyRenderer() (*SkyRenderer, error) {
+	shader, err := NewShader(SkyVertexShader, SkyFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+
+	sr := &SkyRenderer{shader: shader}
+	gl.GenVertexArrays(1, &sr.vao)
+	return sr, nil
+}
+
+// Render draws the sky for the camera-relative view and projection matrices
+// that the world is drawn with
+func (sr *SkyRenderer) Render(view, projection ceresmath.Matrix4, s sky.State) {
+	sr.shader.Use()
+	sr.shader.SetMat4("inverseViewProjection", projection.Mul(view).Inverse().ToPtr())
+	sr.shader.SetVec3("zenithColor", s.ZenithColor[0], s.ZenithColor[1], s.ZenithColor[2])
+	sr.shader.SetVec3("horizonColor", s.HorizonColor[0], s.HorizonColor[1], s.HorizonColor[2])
+	sr.shader.SetVec3("sunDirection", s.SunDirection.X, s.SunDirection.Y, s.SunDirection.Z)
+	sr.shader.SetVec3("moonDirection", s.MoonDirection.X, s.MoonDirection.Y, s.MoonDirection.Z)
+	sr.shader.SetFloat("daylight", s.Daylight)
+
+	gl.DepthMask(false)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.BindVertexArray(sr.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.BindVertexArray(0)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthMask(true)
+}
+
+func (sr *SkyRenderer) Delete() {
+	if sr.vao != 0 {
+		gl.DeleteVertexArrays(1, &sr.vao)
+		sr.vao = 0
+	}
+	sr.shader.Delete()
+}
+
+// SetSkyUniforms sets the sun, ambient light and fog of the chunk shader in
+// use, see ChunkFragmentShader
+func SetSkyUniforms(shader *Shader, s sky.State, fog sky.Fog) {
+	shader.SetVec3("sunDirection", s.LightDirection.X, s.LightDirection.Y, s.LightDirection.Z)
+	shader.SetVec3("sunColor", s.LightColor[0], s.LightColor[1], s.LightColor[2])
+	shader.SetVec3("ambientColor", s.AmbientColor[0], s.AmbientColor[1], s.AmbientColor[2])
+	shader.SetVec3("fogColor", fog.Color[0], fog.Color[1], fog.Color[2])
+	shader.SetFloat("fogStart", fog.Start)
+	shader.SetFloat("fogEnd", fog.End)
+}
+
+// pointLightDistance is how far from the origin SetPointLightUniforms places
+// the light, far enough that its rays are nearly parallel over a demo scene
+const pointLightDistance = 1000
+
+// SetPointLightUniforms lights a shader with a point light, such as
+// BasicFragmentShader, from the sky. The light sits far away along the sun or
+// moon direction so it shades like the chunk shader's directional light.
+func SetPointLightUniforms(shader *Shader, s sky.State) {
+	pos := s.LightDirection.Mul(pointLightDistance)
+	shader.SetVec3("lightPos", pos.X, pos.Y, pos.Z)
+	shader.SetVec3("lightColor", s.LightColor[0], s.LightColor[1], s.LightColor[2])
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+
+	"Ceres/pkg/sky"
 )
 
 const (
@@ -75,7 +77,7 @@ func (w *Window) initializeOpenGLState() {
 
     gl.Enable(gl.MULTISAMPLE)
 
-    gl.ClearColor(0.53, 0.81, 0.92, 1.0)
+    w.SetClearColor(sky.AtTime(sky.Noon).HorizonColor)
 
     gl.Viewport(0, 0, int32(w.Width), int32(w.Height))
 
@@ -95,6 +97,12 @@ func (w *Window) ShouldClose() bool {
     return w.handle.ShouldClose()
 }
 
+// SetClearColor sets the colour Clear fills the screen with. Scenes without a
+// sky pass set it to the sky's horizon colour.
+func (w *Window) SetClearColor(color [3]float32) {
+    gl.ClearColor(color[0], color[1], color[2], 1.0)
+}
+
 func (w *Window) Clear() {
     gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 }
@@ -0,0 +1,319 @@
+package gravity
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// CollapseMode decides what happens to blocks no longer connected to the ground
+type CollapseMode int
+
+const (
+	// CollapseNone leaves floating structures in place; only gravity blocks fall
+	CollapseNone CollapseMode = iota
+	// CollapseFall makes unsupported islands fall as one piece
+	CollapseFall
+	// CollapseBreak removes unsupported islands, reporting each block to OnBreak
+	CollapseBreak
+)
+
+// FallingBody is a group of blocks falling together, such as a column of
+// sand or a collapsed island. Blocks holds the positions the blocks fell from.
+type FallingBody struct {
+	Blocks   []chunk.VoxelWrite
+	Distance float32
+	Velocity float32
+
+	drop int32
+}
+
+// BlockPosition returns the world-space minimum corner of block i for rendering
+func (fb *FallingBody) BlockPosition(i int) (x, y, z float32) {
+	p := fb.Blocks[i].Position
+	return float32(p.X), float32(p.Y) - fb.Distance, float32(p.Z)
+}
+
+// System makes gravity blocks fall when the block beneath them is removed
+// and optionally collapses structures that lose their connection to the
+// ground. It listens to changes made through the ChunkManager and reacts to
+// them on the next Update.
+type System struct {
+	// Gravity is the downward acceleration in blocks per second squared
+	Gravity float32
+	// MaxSpeed caps the falling speed in blocks per second
+	MaxSpeed float32
+
+	Collapse CollapseMode
+	// MaxIslandSize bounds the support flood fill; anything larger counts as supported
+	MaxIslandSize int
+	// GroundLevel is the height at or below which blocks are always supported
+	GroundLevel int32
+
+	OnLand  func(pos voxel.VoxelPosition, v voxel.Voxel)
+	OnBreak func(pos voxel.VoxelPosition, v voxel.Voxel)
+
+	cm     *chunk.ChunkManager
+	bodies []*FallingBody
+
+	mutex    sync.Mutex
+	queued   map[voxel.VoxelPosition]bool
+	applying bool
+}
+
+// NewSystem creates a gravity system and registers it with the chunk manager
+func NewSystem(cm *chunk.ChunkManager) *System {
+	s := &System{
+		Gravity:       32,
+		MaxSpeed:      40,
+		Collapse:      CollapseNone,
+		MaxIslandSize: 512,
+		GroundLevel:   math.MinInt32,
+		cm:            cm,
+		queued:        make(map[voxel.VoxelPosition]bool),
+	}
+	cm.AddVoxelChangeListener(s.onVoxelChanged)
+	return s
+}
+
+func (s *System) onVoxelChanged(change chunk.VoxelChange) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.applying {
+		s.queued[change.Position] = true
+	}
+}
+
+// Bodies returns the bodies that are currently falling
+func (s *System) Bodies() []*FallingBody {
+	return s.bodies
+}
+
+// Settled reports whether nothing is falling or waiting to be checked
+func (s *System) Settled() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.queued) == 0 && len(s.bodies) == 0
+}
+
+// Update checks the voxels changed since the last update and advances the
+// falling bodies by dt seconds
+func (s *System) Update(dt float32) {
+	s.mutex.Lock()
+	changed := make([]voxel.VoxelPosition, 0, len(s.queued))
+	for pos := range s.queued {
+		changed = append(changed, pos)
+	}
+	s.queued = make(map[voxel.VoxelPosition]bool)
+	s.mutex.Unlock()
+
+	sort.Slice(changed, func(i, j int) bool {
+		a, b := changed[i], changed[j]
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.X < b.X
+	})
+
+	visited := make(map[voxel.VoxelPosition]bool)
+	for _, pos := range changed {
+		s.checkColumn(pos)
+		s.checkColumn(pos.Add(voxel.NewVoxelPosition(0, 1, 0)))
+
+		if v, ok := s.getVoxel(pos); s.Collapse != CollapseNone && ok && !supports(v) {
+			for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+				s.checkIsland(pos.Add(voxel.GetFaceOffset(face)), visited)
+			}
+		}
+	}
+
+	remaining := s.bodies[:0]
+	for _, body := range s.bodies {
+		if !s.advance(body, dt) {
+			remaining = append(remaining, body)
+		}
+	}
+	s.bodies = remaining
+}
+
+// checkColumn starts a column of gravity blocks falling if the block at pos
+// is a gravity block with nothing beneath it
+func (s *System) checkColumn(pos voxel.VoxelPosition) {
+	v, ok := s.getVoxel(pos)
+	if !ok || !isGravityBlock(v) {
+		return
+	}
+	if below, ok := s.getVoxel(pos.Add(voxel.NewVoxelPosition(0, -1, 0))); !ok || supports(below) {
+		return
+	}
+
+	var blocks []chunk.VoxelWrite
+	for ok && isGravityBlock(v) {
+		blocks = append(blocks, chunk.VoxelWrite{Position: pos, Voxel: v})
+		pos = pos.Add(voxel.NewVoxelPosition(0, 1, 0))
+		v, ok = s.getVoxel(pos)
+	}
+
+	s.startFalling(blocks)
+}
+
+// checkIsland flood fills the solid blocks connected to start and collapses
+// them if the fill ends without reaching the ground
+func (s *System) checkIsland(start voxel.VoxelPosition, visited map[voxel.VoxelPosition]bool) {
+	if visited[start] {
+		return
+	}
+	if v, ok := s.getVoxel(start); !ok || !supports(v) {
+		return
+	}
+
+	island := []chunk.VoxelWrite{}
+	seen := map[voxel.VoxelPosition]bool{start: true}
+	queue := []voxel.VoxelPosition{start}
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+
+		v, ok := s.getVoxel(pos)
+		if !ok || pos.Y <= s.GroundLevel || len(island) >= s.MaxIslandSize {
+			// Supported: remember the blocks so other fills stop early
+			for p := range seen {
+				visited[p] = true
+			}
+			return
+		}
+		island = append(island, chunk.VoxelWrite{Position: pos, Voxel: v})
+
+		for face := voxel.VoxelFaceTop; face <= voxel.VoxelFaceBack; face++ {
+			next := pos.Add(voxel.GetFaceOffset(face))
+			if seen[next] {
+				continue
+			}
+			if n, ok := s.getVoxel(next); ok && !supports(n) {
+				continue
+			}
+			seen[next] = true
+			queue = append(queue, next)
+		}
+	}
+
+	for p := range seen {
+		visited[p] = true
+	}
+
+	if s.Collapse == CollapseBreak {
+		s.removeBlocks(island)
+		if s.OnBreak != nil {
+			for _, block := range island {
+				s.OnBreak(block.Position, block.Voxel)
+			}
+		}
+		return
+	}
+
+	s.startFalling(island)
+}
+
+func (s *System) startFalling(blocks []chunk.VoxelWrite) {
+	s.removeBlocks(blocks)
+	s.bodies = append(s.bodies, &FallingBody{Blocks: blocks})
+}
+
+// removeBlocks clears blocks in one batch without reacting to the change
+func (s *System) removeBlocks(blocks []chunk.VoxelWrite) {
+	writes := make([]chunk.VoxelWrite, len(blocks))
+	for i, block := range blocks {
+		writes[i] = chunk.VoxelWrite{Position: block.Position, Voxel: voxel.NewVoxel(voxel.VoxelTypeAir)}
+	}
+
+	s.mutex.Lock()
+	s.applying = true
+	s.mutex.Unlock()
+
+	s.cm.SetVoxels(writes)
+
+	s.mutex.Lock()
+	s.applying = false
+	s.mutex.Unlock()
+}
+
+// advance moves a body down and places it once it lands, reporting whether it landed
+func (s *System) advance(body *FallingBody, dt float32) bool {
+	body.Velocity = float32(math.Min(float64(body.Velocity+s.Gravity*dt), float64(s.MaxSpeed)))
+	body.Distance += body.Velocity * dt
+
+	for int32(math.Floor(float64(body.Distance))) > body.drop {
+		if !s.canDrop(body, body.drop+1) {
+			s.land(body)
+			return true
+		}
+		body.drop++
+	}
+
+	return false
+}
+
+func (s *System) canDrop(body *FallingBody, drop int32) bool {
+	for _, block := range body.Blocks {
+		target := block.Position.Add(voxel.NewVoxelPosition(0, -drop, 0))
+		if v, ok := s.getVoxel(target); !ok || supports(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// land re-solidifies a body at its current whole-block drop. Blocks whose
+// cell has been filled in the meantime break instead.
+func (s *System) land(body *FallingBody) {
+	body.Distance = float32(body.drop)
+
+	var writes []chunk.VoxelWrite
+	for _, block := range body.Blocks {
+		target := block.Position.Add(voxel.NewVoxelPosition(0, -body.drop, 0))
+		if v, ok := s.getVoxel(target); !ok || supports(v) {
+			if s.OnBreak != nil {
+				s.OnBreak(target, block.Voxel)
+			}
+			continue
+		}
+		writes = append(writes, chunk.VoxelWrite{Position: target, Voxel: block.Voxel})
+	}
+
+	s.cm.SetVoxels(writes)
+
+	if s.OnLand != nil {
+		for _, write := range writes {
+			s.OnLand(write.Position, write.Voxel)
+		}
+	}
+}
+
+// getVoxel reads a voxel without creating its chunk
+func (s *System) getVoxel(pos voxel.VoxelPosition) (voxel.Voxel, bool) {
+	c := s.cm.GetChunkIfExists(chunk.VoxelToChunkPosition(pos))
+	if c == nil {
+		return voxel.Voxel{}, false
+	}
+
+	x, y, z := chunk.VoxelToLocalPosition(pos)
+	return c.GetVoxel(x, y, z), true
+}
+
+// supports reports whether a voxel holds up what rests on it
+func supports(v voxel.Voxel) bool {
+	return !v.IsAir() && !v.IsFluid()
+}
+
+func isGravityBlock(v voxel.Voxel) bool {
+	props, exists := voxel.GetVoxelProperties(v.Type)
+	return exists && props.Gravity
+}
@@ -0,0 +1,109 @@
+package gravity
+
+import (
+	"testing"
+
+	"Ceres/internal/testworld"
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+func newFloorWorld() *chunk.ChunkManager {
+	cm := chunk.NewChunkManager()
+	testworld.Floor(cm, 0, 0, 15, voxel.VoxelTypeStone)
+	return cm
+}
+
+func runUntilSettled(t *testing.T, s *System) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		s.Update(1.0 / 20.0)
+		if s.Settled() {
+			return
+		}
+	}
+	t.Fatal("Expected falling blocks to settle")
+}
+
+func TestSandFallsWhenSupportIsRemoved(t *testing.T) {
+	cm := newFloorWorld()
+	cm.SetVoxel(voxel.NewVoxelPosition(4, 5, 4), voxel.NewVoxel(voxel.VoxelTypeDirt))
+	cm.SetVoxel(voxel.NewVoxelPosition(4, 6, 4), voxel.NewVoxel(voxel.VoxelTypeSand))
+	cm.SetVoxel(voxel.NewVoxelPosition(4, 7, 4), voxel.NewVoxel(voxel.VoxelTypeSand))
+
+	s := NewSystem(cm)
+	landed := 0
+	s.OnLand = func(pos voxel.VoxelPosition, v voxel.Voxel) { landed++ }
+
+	runUntilSettled(t, s)
+	if cm.GetVoxel(voxel.NewVoxelPosition(4, 6, 4)).Type != voxel.VoxelTypeSand {
+		t.Fatal("Expected supported sand to stay in place")
+	}
+
+	cm.SetVoxel(voxel.NewVoxelPosition(4, 5, 4), voxel.NewVoxel(voxel.VoxelTypeAir))
+	s.Update(1.0 / 20.0)
+	if len(s.Bodies()) != 1 || len(s.Bodies()[0].Blocks) != 2 {
+		t.Fatal("Expected the sand column to start falling as one body")
+	}
+	if !cm.GetVoxel(voxel.NewVoxelPosition(4, 7, 4)).IsAir() {
+		t.Error("Expected falling sand to leave the world while falling")
+	}
+
+	runUntilSettled(t, s)
+	for y, expected := range []voxel.VoxelType{voxel.VoxelTypeSand, voxel.VoxelTypeSand, voxel.VoxelTypeAir} {
+		if got := cm.GetVoxel(voxel.NewVoxelPosition(4, int32(y)+1, 4)).Type; got != expected {
+			t.Errorf("Expected %d at y=%d, got %d", expected, y+1, got)
+		}
+	}
+	if landed != 2 {
+		t.Errorf("Expected 2 landed blocks, got %d", landed)
+	}
+}
+
+func TestFloatingStructuresOnlyCollapseWhenEnabled(t *testing.T) {
+	build := func() *chunk.ChunkManager {
+		cm := newFloorWorld()
+		for y := int32(1); y <= 4; y++ {
+			cm.SetVoxel(voxel.NewVoxelPosition(2, y, 2), voxel.NewVoxel(voxel.VoxelTypeStone))
+		}
+		for x := int32(3); x <= 6; x++ {
+			cm.SetVoxel(voxel.NewVoxelPosition(x, 4, 2), voxel.NewVoxel(voxel.VoxelTypeBrick))
+		}
+		return cm
+	}
+
+	cm := build()
+	s := NewSystem(cm)
+	cm.SetVoxel(voxel.NewVoxelPosition(2, 2, 2), voxel.NewVoxel(voxel.VoxelTypeAir))
+	runUntilSettled(t, s)
+	if cm.GetVoxel(voxel.NewVoxelPosition(6, 4, 2)).Type != voxel.VoxelTypeBrick {
+		t.Error("Expected the bridge to float without collapse enabled")
+	}
+
+	cm = build()
+	s = NewSystem(cm)
+	s.Collapse = CollapseFall
+	cm.SetVoxel(voxel.NewVoxelPosition(2, 2, 2), voxel.NewVoxel(voxel.VoxelTypeAir))
+	runUntilSettled(t, s)
+	if !cm.GetVoxel(voxel.NewVoxelPosition(6, 4, 2)).IsAir() {
+		t.Error("Expected the bridge to fall")
+	}
+	// The island (stone at y=3..4 and the bridge) drops one block onto the stump
+	if cm.GetVoxel(voxel.NewVoxelPosition(6, 3, 2)).Type != voxel.VoxelTypeBrick {
+		t.Error("Expected the bridge to land as one piece")
+	}
+
+	cm = build()
+	s = NewSystem(cm)
+	s.Collapse = CollapseBreak
+	broken := 0
+	s.OnBreak = func(pos voxel.VoxelPosition, v voxel.Voxel) { broken++ }
+	cm.SetVoxel(voxel.NewVoxelPosition(2, 2, 2), voxel.NewVoxel(voxel.VoxelTypeAir))
+	runUntilSettled(t, s)
+	if broken != 6 {
+		t.Errorf("Expected 6 broken blocks, got %d", broken)
+	}
+	if cm.GetVoxel(voxel.NewVoxelPosition(2, 1, 2)).Type != voxel.VoxelTypeStone {
+		t.Error("Expected the grounded stump to remain")
+	}
+}
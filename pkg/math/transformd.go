@@ -0,0 +1,54 @@
+package math
+
+// Transformd is a Transform whose position is double precision, for objects
+// placed in world space far from the origin. Rotation and scale stay single
+// precision since they do not grow with distance.
+type Transformd struct {
+	Position Vector3d
+	Rotation Vector3
+	Scale    Vector3
+}
+
+func NewTransformd() Transformd {
+	return Transformd{
+		Position: Vector3d{},
+		Rotation: Zero(),
+		Scale:    One(),
+	}
+}
+
+// RelativeTo returns the transform with its position relative to origin in
+// single precision, such as a camera-relative model transform for rendering
+func (t Transformd) RelativeTo(origin Vector3d) Transform {
+	return Transform{
+		Position: t.Position.RelativeTo(origin),
+		Rotation: t.Rotation,
+		Scale:    t.Scale,
+	}
+}
+
+// Matrix returns the model matrix relative to the world origin. Far from the
+// origin the translation loses precision; render with RelativeTo instead.
+func (t Transformd) Matrix() Matrix4 {
+	return t.RelativeTo(Vector3d{}).Matrix()
+}
+
+func (t Transformd) Forward() Vector3 {
+	return t.RelativeTo(t.Position).Forward()
+}
+
+func (t Transformd) Right() Vector3 {
+	return t.RelativeTo(t.Position).Right()
+}
+
+func (t Transformd) Up() Vector3 {
+	return t.RelativeTo(t.Position).Up()
+}
+
+// LookAt turns the transform to face target. The direction is taken in double
+// precision, so it stays exact for nearby targets far from the origin.
+func (t *Transformd) LookAt(target Vector3d, worldUp Vector3) {
+	local := t.RelativeTo(t.Position)
+	local.LookAt(target.RelativeTo(t.Position), worldUp)
+	t.Rotation = local.Rotation
+}
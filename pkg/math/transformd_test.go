@@ -0,0 +1,47 @@
+package math
+
+import (
+	"testing"
+)
+
+func TestTransformdMatchesTransform(t *testing.T) {
+	td := NewTransformd()
+	td.Position = NewVector3d(3, -2, 5)
+	td.Rotation = NewVector3(0.3, Deg2Rad(90), 0)
+
+	single := NewTransform()
+	single.Position = td.Position.ToVector3()
+	single.Rotation = td.Rotation
+
+	for name, pair := range map[string][2]Vector3{
+		"forward": {td.Forward(), single.Forward()},
+		"right":   {td.Right(), single.Right()},
+		"up":      {td.Up(), single.Up()},
+	} {
+		if !ApproxEqual(pair[0].X, pair[1].X) || !ApproxEqual(pair[0].Y, pair[1].Y) || !ApproxEqual(pair[0].Z, pair[1].Z) {
+			t.Errorf("Expected %s %v, got %v", name, pair[1], pair[0])
+		}
+	}
+	if td.Matrix() != single.Matrix() {
+		t.Errorf("Expected matrix %v, got %v", single.Matrix(), td.Matrix())
+	}
+}
+
+func TestTransformdLookAtFarFromOrigin(t *testing.T) {
+	offset := Vector3{X: 0.5, Y: -0.25, Z: 0.125}
+	td := NewTransformd()
+	td.Position = NewVector3d(10_000_000.25, 64, -10_000_000.25)
+	td.LookAt(td.Position.Offset(offset), Up())
+
+	// Turning towards the same offset near the origin gives the same rotation
+	single := NewTransform()
+	single.LookAt(offset, Up())
+	if !ApproxEqual(td.Rotation.X, single.Rotation.X) || !ApproxEqual(td.Rotation.Y, single.Rotation.Y) {
+		t.Errorf("Expected rotation %v, got %v", single.Rotation, td.Rotation)
+	}
+
+	relative := td.RelativeTo(NewVector3d(10_000_000, 64, -10_000_000))
+	if !ApproxEqual(relative.Position.X, 0.25) || !ApproxEqual(relative.Position.Z, -0.25) {
+		t.Errorf("Expected position (0.25, 0, -0.25) relative to the origin, got %v", relative.Position)
+	}
+}
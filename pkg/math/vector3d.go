@@ -0,0 +1,93 @@
+package math
+
+import (
+	"math"
+)
+
+// Vector3d is a double-precision vector for world positions, which lose
+// precision in float32 far from the origin. Offsets and directions stay Vector3.
+type Vector3d struct {
+	X, Y, Z float64
+}
+
+func NewVector3d(x, y, z float64) Vector3d {
+	return Vector3d{X: x, Y: y, Z: z}
+}
+
+// FromVector3 widens a single-precision vector
+func FromVector3(v Vector3) Vector3d {
+	return Vector3d{X: float64(v.X), Y: float64(v.Y), Z: float64(v.Z)}
+}
+
+// ToVector3 narrows the vector to single precision
+func (v Vector3d) ToVector3() Vector3 {
+	return Vector3{X: float32(v.X), Y: float32(v.Y), Z: float32(v.Z)}
+}
+
+func (v Vector3d) Add(other Vector3d) Vector3d {
+	return Vector3d{
+		X: v.X + other.X,
+		Y: v.Y + other.Y,
+		Z: v.Z + other.Z,
+	}
+}
+
+func (v Vector3d) Sub(other Vector3d) Vector3d {
+	return Vector3d{
+		X: v.X - other.X,
+		Y: v.Y - other.Y,
+		Z: v.Z - other.Z,
+	}
+}
+
+func (v Vector3d) Mul(scalar float64) Vector3d {
+	return Vector3d{
+		X: v.X * scalar,
+		Y: v.Y * scalar,
+		Z: v.Z * scalar,
+	}
+}
+
+// Offset returns the position moved by a single-precision offset
+func (v Vector3d) Offset(offset Vector3) Vector3d {
+	return v.Add(FromVector3(offset))
+}
+
+// RelativeTo returns the position relative to origin in single precision. The
+// subtraction is done in double precision, so the result is exact to float32
+// precision near origin however far both are from the world origin.
+func (v Vector3d) RelativeTo(origin Vector3d) Vector3 {
+	return v.Sub(origin).ToVector3()
+}
+
+func (v Vector3d) Length() float64 {
+	return math.Sqrt(v.LengthSquared())
+}
+
+func (v Vector3d) LengthSquared() float64 {
+	return v.X*v.X + v.Y*v.Y + v.Z*v.Z
+}
+
+func (v Vector3d) Distance(other Vector3d) float64 {
+	return v.Sub(other).Length()
+}
+
+func (v Vector3d) DistanceSquared(other Vector3d) float64 {
+	return v.Sub(other).LengthSquared()
+}
+
+func (v Vector3d) Lerp(other Vector3d, t float64) Vector3d {
+	return Vector3d{
+		X: v.X + (other.X-v.X)*t,
+		Y: v.Y + (other.Y-v.Y)*t,
+		Z: v.Z + (other.Z-v.Z)*t,
+	}
+}
+
+func (v Vector3d) Clamp(min, max Vector3d) Vector3d {
+	return Vector3d{
+		math.Max(min.X, math.Min(v.X, max.X)),
+		math.Max(min.Y, math.Min(v.Y, max.Y)),
+		math.Max(min.Z, math.Min(v.Z, max.Z)),
+	}
+}
@@ -0,0 +1,35 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRelativeToIsExactFarFromOrigin(t *testing.T) {
+	for _, far := range []float64{10_000_000, -10_000_000} {
+		camera := Vector3d{X: far + 0.3, Y: 64.7, Z: -far - 0.6}
+		origin := Vector3d{X: far + 32, Y: 64, Z: -far}
+
+		got := origin.RelativeTo(camera)
+		want := Vector3{X: 31.7, Y: -0.7, Z: 0.6}
+		if !ApproxEqual(got.X, want.X) || !ApproxEqual(got.Y, want.Y) || !ApproxEqual(got.Z, want.Z) {
+			t.Errorf("Expected %v relative to the camera at %v, got %v", want, camera, got)
+		}
+
+		// The same subtraction in float32 rounds both positions to whole voxels
+		lossy := origin.ToVector3().Sub(camera.ToVector3())
+		if math.Abs(float64(lossy.X-want.X)) < 0.1 {
+			t.Errorf("Expected float32 positions to lose precision at %f, got %v", far, lossy)
+		}
+	}
+}
+
+func TestVector3dOffset(t *testing.T) {
+	p := NewVector3d(10_000_000, 0, -10_000_000)
+	for i := 0; i < 100; i++ {
+		p = p.Offset(Vector3{X: 0.01, Z: -0.01})
+	}
+	if math.Abs(p.X-10_000_001) > 1e-6 || math.Abs(p.Z+10_000_001) > 1e-6 {
+		t.Errorf("Expected small steps to accumulate far from the origin, got %+v", p)
+	}
+}
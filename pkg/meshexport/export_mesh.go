@@ -0,0 +1,193 @@
+package meshexport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// chunkVertexFloats is the number of float32s per vertex in a ChunkMesh
+const chunkVertexFloats = 11
+
+// Mesh is a flattened triangle mesh that the exporters write out
+type Mesh struct {
+	Positions [][3]float32
+	Normals   [][3]float32
+	UVs       [][2]float32
+	Colors    [][3]float32
+	Indices   []uint32
+}
+
+// FromChunkMeshes concatenates chunk meshes into a single exportable mesh,
+// moving each chunk's vertices from chunk-local to world positions. Packed
+// meshes are unpacked, taking normals from the face and colours from the voxel
+// type's palette colour, so biome tints and ambient occlusion are not exported.
+func FromChunkMeshes(meshes []*chunk.ChunkMesh) *Mesh {
+	m := &Mesh{}
+
+	for _, cm := range meshes {
+		if cm == nil {
+			continue
+		}
+
+		origin := [3]float32{float32(cm.Origin.X), float32(cm.Origin.Y), float32(cm.Origin.Z)}
+		base := uint32(len(m.Positions))
+		if cm.Format == chunk.VertexFormatPacked {
+			m.appendPacked(cm, origin)
+		} else {
+			m.appendFloat(cm, origin)
+		}
+		for _, index := range cm.Indices {
+			m.Indices = append(m.Indices, base+index)
+		}
+	}
+
+	return m
+}
+
+// appendFloat appends the vertices of a VertexFormatFloat mesh
+func (m *Mesh) appendFloat(cm *chunk.ChunkMesh, origin [3]float32) {
+	for i := 0; i+chunkVertexFloats <= len(cm.Vertices); i += chunkVertexFloats {
+		v := cm.Vertices[i : i+chunkVertexFloats]
+		m.Positions = append(m.Positions, [3]float32{origin[0] + v[0], origin[1] + v[1], origin[2] + v[2]})
+		m.Normals = append(m.Normals, [3]float32{v[3], v[4], v[5]})
+		m.UVs = append(m.UVs, [2]float32{v[6], v[7]})
+		m.Colors = append(m.Colors, [3]float32{v[8], v[9], v[10]})
+	}
+}
+
+// appendPacked appends the vertices of a VertexFormatPacked mesh
+func (m *Mesh) appendPacked(cm *chunk.ChunkMesh, origin [3]float32) {
+	for i := 0; i < len(cm.Packed)/chunk.PackedWordsPerVertex; i++ {
+		d := cm.PackedVertex(i).Unpack()
+		normal := voxel.GetFaceNormal(d.Face)
+		color := [3]float32{1, 1, 1}
+		if props, exists := voxel.GetVoxelProperties(d.Type); exists {
+			color = props.Color
+		}
+
+		m.Positions = append(m.Positions, [3]float32{origin[0] + d.Position[0], origin[1] + d.Position[1], origin[2] + d.Position[2]})
+		m.Normals = append(m.Normals, [3]float32{normal.X, normal.Y, normal.Z})
+		m.UVs = append(m.UVs, [2]float32{float32(d.U), float32(d.V)})
+		m.Colors = append(m.Colors, color)
+	}
+}
+
+// FromRegion meshes every loaded chunk between min and max (inclusive chunk
+// positions). With greedy set, faces are merged into larger quads first.
+// Dirty flags are left untouched so renderers still pick up pending changes.
+func FromRegion(cm *chunk.ChunkManager, min, max chunk.ChunkPosition, greedy bool) *Mesh {
+	chunks := make([]*chunk.Chunk, 0)
+	for _, c := range cm.GetLoadedChunks() {
+		p := c.Position
+		if p.X < min.X || p.Y < min.Y || p.Z < min.Z || p.X > max.X || p.Y > max.Y || p.Z > max.Z {
+			continue
+		}
+		chunks = append(chunks, c)
+	}
+
+	// Sort for deterministic output regardless of map iteration order
+	sort.Slice(chunks, func(i, j int) bool {
+		a, b := chunks[i].Position, chunks[j].Position
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	})
+
+	meshes := make([]*chunk.ChunkMesh, 0, len(chunks))
+	for _, c := range chunks {
+		meshes = append(meshes, c.BuildMesh(greedy))
+	}
+
+	return FromChunkMeshes(meshes)
+}
+
+// TriangleCount returns the number of triangles in the mesh
+func (m *Mesh) TriangleCount() int {
+	return len(m.Indices) / 3
+}
+
+// VertexCount returns the number of vertices in the mesh
+func (m *Mesh) VertexCount() int {
+	return len(m.Positions)
+}
+
+type weldKey struct {
+	position [3]float32
+	normal   [3]float32
+	uv       [2]float32
+	color    [3]float32
+}
+
+// Weld returns a copy of the mesh in which vertices with identical attributes
+// are shared between triangles
+func (m *Mesh) Weld() *Mesh {
+	welded := &Mesh{Indices: make([]uint32, len(m.Indices))}
+	remap := make(map[weldKey]uint32, len(m.Positions))
+	indexMap := make([]uint32, len(m.Positions))
+
+	for i := range m.Positions {
+		key := weldKey{m.Positions[i], m.Normals[i], m.UVs[i], m.Colors[i]}
+		index, exists := remap[key]
+		if !exists {
+			index = uint32(len(welded.Positions))
+			remap[key] = index
+			welded.Positions = append(welded.Positions, key.position)
+			welded.Normals = append(welded.Normals, key.normal)
+			welded.UVs = append(welded.UVs, key.uv)
+			welded.Colors = append(welded.Colors, key.color)
+		}
+		indexMap[i] = index
+	}
+
+	for i, index := range m.Indices {
+		welded.Indices[i] = indexMap[index]
+	}
+
+	return welded
+}
+
+// material groups the triangles that share a vertex colour
+type material struct {
+	Name      string
+	Color     [3]float32
+	Triangles []int
+}
+
+// materials groups triangles by the colour of their first vertex and names
+// each group after the registered voxel type with that colour
+func (m *Mesh) materials() []material {
+	byColor := make(map[[3]float32]int)
+	groups := make([]material, 0)
+
+	for tri := 0; tri < m.TriangleCount(); tri++ {
+		color := m.Colors[m.Indices[tri*3]]
+		index, exists := byColor[color]
+		if !exists {
+			index = len(groups)
+			byColor[color] = index
+			groups = append(groups, material{Name: materialName(color, index), Color: color})
+		}
+		groups[index].Triangles = append(groups[index].Triangles, tri)
+	}
+
+	return groups
+}
+
+// materialName names a material after the voxel type with its colour. Spaces
+// become underscores since OBJ and MTL names end at the first whitespace.
+func materialName(color [3]float32, index int) string {
+	for _, voxelType := range voxel.RegisteredVoxelTypes() {
+		if props, _ := voxel.GetVoxelProperties(voxelType); props.Color == color {
+			return strings.Join(strings.Fields(props.Name), "_")
+		}
+	}
+	return fmt.Sprintf("material_%d", index)
+}
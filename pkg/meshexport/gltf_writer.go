@@ -0,0 +1,203 @@
+package meshexport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbVersion   = 2
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\0"
+
+	gltfFloat        = 5126
+	gltfUnsignedInt  = 5125
+	gltfArrayBuffer  = 34962
+	gltfElementArray = 34963
+	gltfTriangles    = 4
+)
+
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Mesh int `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Mode       int            `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+// WriteGLB writes the mesh as a binary glTF 2.0 file with positions,
+// normals, texture coordinates and vertex colours
+func WriteGLB(w io.Writer, m *Mesh) error {
+	if m.VertexCount() == 0 || m.TriangleCount() == 0 {
+		return fmt.Errorf("cannot export an empty mesh to glTF")
+	}
+
+	doc := gltfDocument{
+		Asset:  gltfAsset{Version: "2.0", Generator: "Ceres"},
+		Scenes: []gltfScene{{Nodes: []int{0}}},
+		Nodes:  []gltfNode{{Mesh: 0}},
+	}
+
+	var bin bytes.Buffer
+	addView := func(data []float32, target int) int {
+		offset := bin.Len()
+		for _, f := range data {
+			binary.Write(&bin, binary.LittleEndian, math.Float32bits(f))
+		}
+		doc.BufferViews = append(doc.BufferViews, gltfBufferView{
+			ByteOffset: offset,
+			ByteLength: bin.Len() - offset,
+			Target:     target,
+		})
+		return len(doc.BufferViews) - 1
+	}
+
+	positions := make([]float32, 0, len(m.Positions)*3)
+	min := m.Positions[0]
+	max := m.Positions[0]
+	for _, p := range m.Positions {
+		positions = append(positions, p[0], p[1], p[2])
+		for axis := 0; axis < 3; axis++ {
+			min[axis] = float32(math.Min(float64(min[axis]), float64(p[axis])))
+			max[axis] = float32(math.Max(float64(max[axis]), float64(p[axis])))
+		}
+	}
+
+	normals := make([]float32, 0, len(m.Normals)*3)
+	for _, n := range m.Normals {
+		normals = append(normals, n[0], n[1], n[2])
+	}
+
+	// glTF puts the texture origin at the top-left corner
+	uvs := make([]float32, 0, len(m.UVs)*2)
+	for _, uv := range m.UVs {
+		uvs = append(uvs, uv[0], 1-uv[1])
+	}
+
+	colors := make([]float32, 0, len(m.Colors)*3)
+	for _, c := range m.Colors {
+		colors = append(colors, c[0], c[1], c[2])
+	}
+
+	count := m.VertexCount()
+	doc.Accessors = append(doc.Accessors,
+		gltfAccessor{BufferView: addView(positions, gltfArrayBuffer), ComponentType: gltfFloat, Count: count,
+			Type: "VEC3", Min: min[:], Max: max[:]},
+		gltfAccessor{BufferView: addView(normals, gltfArrayBuffer), ComponentType: gltfFloat, Count: count, Type: "VEC3"},
+		gltfAccessor{BufferView: addView(uvs, gltfArrayBuffer), ComponentType: gltfFloat, Count: count, Type: "VEC2"},
+		gltfAccessor{BufferView: addView(colors, gltfArrayBuffer), ComponentType: gltfFloat, Count: count, Type: "VEC3"},
+	)
+
+	indexOffset := bin.Len()
+	for _, index := range m.Indices {
+		binary.Write(&bin, binary.LittleEndian, index)
+	}
+	doc.BufferViews = append(doc.BufferViews, gltfBufferView{
+		ByteOffset: indexOffset,
+		ByteLength: bin.Len() - indexOffset,
+		Target:     gltfElementArray,
+	})
+	doc.Accessors = append(doc.Accessors, gltfAccessor{
+		BufferView:    len(doc.BufferViews) - 1,
+		ComponentType: gltfUnsignedInt,
+		Count:         len(m.Indices),
+		Type:          "SCALAR",
+	})
+
+	doc.Meshes = []gltfMesh{{Primitives: []gltfPrimitive{{
+		Attributes: map[string]int{"POSITION": 0, "NORMAL": 1, "TEXCOORD_0": 2, "COLOR_0": 3},
+		Indices:    4,
+		Mode:       gltfTriangles,
+	}}}}
+	doc.Buffers = []gltfBuffer{{ByteLength: bin.Len()}}
+
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode glTF JSON: %w", err)
+	}
+	for len(jsonData)%4 != 0 {
+		jsonData = append(jsonData, ' ')
+	}
+	for bin.Len()%4 != 0 {
+		bin.WriteByte(0)
+	}
+
+	var out bytes.Buffer
+	totalLength := 12 + 8 + len(jsonData) + 8 + bin.Len()
+	binary.Write(&out, binary.LittleEndian, []uint32{glbMagic, glbVersion, uint32(totalLength)})
+	binary.Write(&out, binary.LittleEndian, []uint32{uint32(len(jsonData)), glbChunkJSON})
+	out.Write(jsonData)
+	binary.Write(&out, binary.LittleEndian, []uint32{uint32(bin.Len()), glbChunkBIN})
+	out.Write(bin.Bytes())
+
+	if _, err := w.Write(out.Bytes()); err != nil {
+		return fmt.Errorf("failed to write GLB: %w", err)
+	}
+	return nil
+}
+
+// SaveGLB writes the mesh to a binary glTF file
+func SaveGLB(path string, m *Mesh) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create GLB file: %w", err)
+	}
+
+	if err := WriteGLB(f, m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,305 @@
+package meshexport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+func buildTestWorld() *chunk.ChunkManager {
+	cm := chunk.NewChunkManager()
+	for x := int32(-8); x < 40; x++ {
+		for z := int32(-4); z < 20; z++ {
+			height := 3 + (x+z)&3
+			for y := int32(0); y < height; y++ {
+				voxelType := voxel.VoxelTypeStone
+				if y == height-1 {
+					voxelType = voxel.VoxelTypeGrass
+				}
+				cm.SetVoxel(voxel.NewVoxelPosition(x, y, z), voxel.NewVoxel(voxelType))
+			}
+		}
+	}
+	cm.SetVoxel(voxel.NewVoxelPosition(4, 10, 4), voxel.NewVoxel(voxel.VoxelTypeBrick))
+	return cm
+}
+
+func regionMesh(greedy bool) *Mesh {
+	return FromRegion(buildTestWorld(), chunk.NewChunkPosition(-1, 0, -1), chunk.NewChunkPosition(1, 0, 0), greedy)
+}
+
+func countOBJTriangles(data []byte) int {
+	triangles := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && fields[0] == "f" {
+			triangles += len(fields) - 3
+		}
+	}
+	return triangles
+}
+
+func countPLYTriangles(t *testing.T, data []byte) int {
+	headerEnd := bytes.Index(data, []byte("end_header\n"))
+	if headerEnd < 0 {
+		t.Fatal("PLY header not terminated")
+	}
+
+	var vertices, faces int
+	for _, line := range strings.Split(string(data[:headerEnd]), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "element" {
+			n, _ := strconv.Atoi(fields[2])
+			if fields[1] == "vertex" {
+				vertices = n
+			} else if fields[1] == "face" {
+				faces = n
+			}
+		}
+	}
+
+	body := data[headerEnd+len("end_header\n"):]
+	const vertexSize = 8*4 + 3
+	faceData := body[vertices*vertexSize:]
+	if len(faceData) != faces*13 {
+		t.Fatalf("Expected %d bytes of face data, got %d", faces*13, len(faceData))
+	}
+	for i := 0; i < faces; i++ {
+		face := faceData[i*13:]
+		if face[0] != 3 {
+			t.Fatalf("Face %d: expected 3 indices, got %d", i, face[0])
+		}
+		for k := 0; k < 3; k++ {
+			if index := binary.LittleEndian.Uint32(face[1+k*4:]); int(index) >= vertices {
+				t.Fatalf("Face %d: index %d out of range", i, index)
+			}
+		}
+	}
+	return faces
+}
+
+func countGLBTriangles(t *testing.T, data []byte) int {
+	if binary.LittleEndian.Uint32(data[0:]) != glbMagic {
+		t.Fatal("Invalid GLB magic")
+	}
+	if int(binary.LittleEndian.Uint32(data[8:])) != len(data) {
+		t.Fatal("GLB length does not match data size")
+	}
+
+	jsonLength := binary.LittleEndian.Uint32(data[12:])
+	var doc gltfDocument
+	if err := json.Unmarshal(data[20:20+jsonLength], &doc); err != nil {
+		t.Fatalf("Invalid glTF JSON: %v", err)
+	}
+
+	binStart := 20 + int(jsonLength)
+	binLength := int(binary.LittleEndian.Uint32(data[binStart:]))
+	if binLength < doc.Buffers[0].ByteLength {
+		t.Fatal("BIN chunk shorter than declared buffer")
+	}
+
+	primitive := doc.Meshes[0].Primitives[0]
+	for _, name := range []string{"POSITION", "NORMAL", "TEXCOORD_0", "COLOR_0"} {
+		if _, ok := primitive.Attributes[name]; !ok {
+			t.Errorf("Missing %s attribute", name)
+		}
+	}
+	return doc.Accessors[primitive.Indices].Count / 3
+}
+
+func TestRoundTripTriangleCounts(t *testing.T) {
+	for _, greedy := range []bool{false, true} {
+		for _, weld := range []bool{false, true} {
+			m := regionMesh(greedy)
+			if weld {
+				m = m.Weld()
+			}
+			expected := m.TriangleCount()
+			if expected == 0 {
+				t.Fatal("Expected a non-empty test mesh")
+			}
+
+			var obj, mtl, ply, glb bytes.Buffer
+			if err := WriteOBJ(&obj, &mtl, "test.mtl", m); err != nil {
+				t.Fatalf("WriteOBJ failed: %v", err)
+			}
+			if err := WritePLY(&ply, m); err != nil {
+				t.Fatalf("WritePLY failed: %v", err)
+			}
+			if err := WriteGLB(&glb, m); err != nil {
+				t.Fatalf("WriteGLB failed: %v", err)
+			}
+
+			if got := countOBJTriangles(obj.Bytes()); got != expected {
+				t.Errorf("greedy=%v weld=%v: OBJ expected %d triangles, got %d", greedy, weld, expected, got)
+			}
+			if got := countPLYTriangles(t, ply.Bytes()); got != expected {
+				t.Errorf("greedy=%v weld=%v: PLY expected %d triangles, got %d", greedy, weld, expected, got)
+			}
+			if got := countGLBTriangles(t, glb.Bytes()); got != expected {
+				t.Errorf("greedy=%v weld=%v: GLB expected %d triangles, got %d", greedy, weld, expected, got)
+			}
+		}
+	}
+}
+
+func TestGreedyMeshCoversSameArea(t *testing.T) {
+	area := func(m *Mesh) float32 {
+		total := float32(0)
+		for tri := 0; tri < m.TriangleCount(); tri++ {
+			a := m.Positions[m.Indices[tri*3]]
+			b := m.Positions[m.Indices[tri*3+1]]
+			c := m.Positions[m.Indices[tri*3+2]]
+			ab := [3]float32{b[0] - a[0], b[1] - a[1], b[2] - a[2]}
+			ac := [3]float32{c[0] - a[0], c[1] - a[1], c[2] - a[2]}
+			cross := [3]float32{
+				ab[1]*ac[2] - ab[2]*ac[1],
+				ab[2]*ac[0] - ab[0]*ac[2],
+				ab[0]*ac[1] - ab[1]*ac[0],
+			}
+			// Faces are axis aligned so only one component is non-zero
+			total += (abs(cross[0]) + abs(cross[1]) + abs(cross[2])) / 2
+		}
+		return total
+	}
+
+	naive := regionMesh(false)
+	greedy := regionMesh(true)
+
+	if greedy.TriangleCount() >= naive.TriangleCount() {
+		t.Errorf("Expected greedy mesh to have fewer triangles: naive %d, greedy %d",
+			naive.TriangleCount(), greedy.TriangleCount())
+	}
+	if area(naive) != area(greedy) {
+		t.Errorf("Expected equal surface area: naive %f, greedy %f", area(naive), area(greedy))
+	}
+}
+
+func TestWeldSharesVertices(t *testing.T) {
+	m := FromChunkMeshes([]*chunk.ChunkMesh{twoVoxelMesh(), twoVoxelMesh()})
+	welded := m.Weld()
+
+	if welded.VertexCount()*2 != m.VertexCount() {
+		t.Errorf("Expected duplicated meshes to weld to half the vertices: %d -> %d",
+			m.VertexCount(), welded.VertexCount())
+	}
+	if welded.TriangleCount() != m.TriangleCount() {
+		t.Errorf("Expected welding to keep %d triangles, got %d", m.TriangleCount(), welded.TriangleCount())
+	}
+}
+
+func TestOBJMaterialsNamedAfterVoxelTypes(t *testing.T) {
+	var obj, mtl bytes.Buffer
+	if err := WriteOBJ(&obj, &mtl, "world.mtl", regionMesh(true)); err != nil {
+		t.Fatalf("WriteOBJ failed: %v", err)
+	}
+
+	for _, name := range []string{"Stone", "Grass", "Brick"} {
+		if !strings.Contains(mtl.String(), "newmtl "+name+"\n") {
+			t.Errorf("Expected material %s in MTL output", name)
+		}
+		if !strings.Contains(obj.String(), "usemtl "+name+"\n") {
+			t.Errorf("Expected usemtl %s in OBJ output", name)
+		}
+	}
+}
+
+func TestOBJMaterialNamesMatchMTL(t *testing.T) {
+	world := buildTestWorld()
+	world.SetVoxel(voxel.NewVoxelPosition(6, 10, 4), voxel.NewVoxel(voxel.VoxelTypeCoalOre))
+	world.SetVoxel(voxel.NewVoxelPosition(8, 10, 4), voxel.NewVoxel(voxel.VoxelTypeIronOre))
+	m := FromRegion(world, chunk.NewChunkPosition(-1, 0, -1), chunk.NewChunkPosition(1, 0, 0), true)
+
+	var obj, mtl bytes.Buffer
+	if err := WriteOBJ(&obj, &mtl, "world.mtl", m); err != nil {
+		t.Fatalf("WriteOBJ failed: %v", err)
+	}
+
+	names := func(data []byte, keyword string) map[string]bool {
+		found := make(map[string]bool)
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || fields[0] != keyword {
+				continue
+			}
+			if len(fields) != 2 {
+				t.Errorf("Expected a single-word material name, got %q", line)
+			}
+			found[strings.TrimPrefix(line, keyword+" ")] = true
+		}
+		return found
+	}
+	used := names(obj.Bytes(), "usemtl")
+	defined := names(mtl.Bytes(), "newmtl")
+
+	if len(used) != len(defined) {
+		t.Errorf("Expected %d used materials to match %d defined", len(used), len(defined))
+	}
+	for name := range used {
+		if !defined[name] {
+			t.Errorf("Expected material %q used in OBJ to be defined in MTL", name)
+		}
+	}
+	for _, name := range []string{"Coal_Ore", "Iron_Ore"} {
+		if !used[name] {
+			t.Errorf("Expected material %s, got %v", name, used)
+		}
+	}
+}
+
+func TestPackedMeshesExport(t *testing.T) {
+	world := buildTestWorld()
+	c := world.GetChunkIfExists(chunk.NewChunkPosition(0, 0, 0))
+	floats := FromChunkMeshes([]*chunk.ChunkMesh{c.BuildMesh(false)})
+	packed := FromChunkMeshes([]*chunk.ChunkMesh{c.BuildPackedMesh(false)})
+
+	if packed.TriangleCount() == 0 || packed.TriangleCount() != floats.TriangleCount() {
+		t.Fatalf("Expected %d packed triangles, got %d", floats.TriangleCount(), packed.TriangleCount())
+	}
+	if packed.VertexCount() != floats.VertexCount() {
+		t.Fatalf("Expected %d packed vertices, got %d", floats.VertexCount(), packed.VertexCount())
+	}
+	for i := range packed.Positions {
+		if packed.Positions[i] != floats.Positions[i] {
+			t.Fatalf("Vertex %d: expected position %v, got %v", i, floats.Positions[i], packed.Positions[i])
+		}
+		if packed.Normals[i] != floats.Normals[i] {
+			t.Fatalf("Vertex %d: expected normal %v, got %v", i, floats.Normals[i], packed.Normals[i])
+		}
+	}
+
+	var obj, mtl bytes.Buffer
+	if err := WriteOBJ(&obj, &mtl, "packed.mtl", packed); err != nil {
+		t.Fatalf("WriteOBJ failed: %v", err)
+	}
+	if got := countOBJTriangles(obj.Bytes()); got != packed.TriangleCount() {
+		t.Errorf("Expected %d OBJ triangles, got %d", packed.TriangleCount(), got)
+	}
+	for _, name := range []string{"Stone", "Grass", "Brick"} {
+		if !strings.Contains(mtl.String(), "newmtl "+name+"\n") {
+			t.Errorf("Expected palette material %s for the packed mesh", name)
+		}
+	}
+}
+
+func twoVoxelMesh() *chunk.ChunkMesh {
+	c := chunk.NewChunk(chunk.NewChunkPosition(0, 0, 0))
+	c.SetVoxel(1, 1, 1, voxel.NewVoxel(voxel.VoxelTypeDirt))
+	c.SetVoxel(2, 1, 1, voxel.NewVoxel(voxel.VoxelTypeDirt))
+	return c.BuildMesh(false)
+}
+
+func abs(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
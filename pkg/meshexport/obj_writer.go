@@ -0,0 +1,118 @@
+package meshexport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteOBJ writes the mesh as Wavefront OBJ, with one material per voxel
+// colour written to mtl. mtlName is the file name referenced by mtllib.
+// Positions, normals and texture coordinates are each de-duplicated.
+func WriteOBJ(obj, mtl io.Writer, mtlName string, m *Mesh) error {
+	materials := m.materials()
+
+	mw := bufio.NewWriter(mtl)
+	for _, mat := range materials {
+		fmt.Fprintf(mw, "newmtl %s\n", mat.Name)
+		fmt.Fprintf(mw, "Ka %g %g %g\n", mat.Color[0], mat.Color[1], mat.Color[2])
+		fmt.Fprintf(mw, "Kd %g %g %g\n", mat.Color[0], mat.Color[1], mat.Color[2])
+		fmt.Fprintf(mw, "Ks 0 0 0\n")
+		fmt.Fprintf(mw, "d 1\n")
+		fmt.Fprintf(mw, "illum 1\n\n")
+	}
+	if err := mw.Flush(); err != nil {
+		return fmt.Errorf("failed to write MTL: %w", err)
+	}
+
+	w := bufio.NewWriter(obj)
+	fmt.Fprintf(w, "# Ceres voxel mesh export\n")
+	fmt.Fprintf(w, "mtllib %s\n", mtlName)
+	fmt.Fprintf(w, "o ceres_mesh\n")
+
+	positions := dedupe3(w, "v", m.Positions)
+	uvs := dedupe2(w, "vt", m.UVs)
+	normals := dedupe3(w, "vn", m.Normals)
+
+	for _, mat := range materials {
+		fmt.Fprintf(w, "usemtl %s\n", mat.Name)
+		for _, tri := range mat.Triangles {
+			w.WriteString("f")
+			for k := 0; k < 3; k++ {
+				index := m.Indices[tri*3+k]
+				fmt.Fprintf(w, " %d/%d/%d", positions[index]+1, uvs[index]+1, normals[index]+1)
+			}
+			w.WriteString("\n")
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write OBJ: %w", err)
+	}
+	return nil
+}
+
+// SaveOBJ writes path and a sibling .mtl file with the same base name
+func SaveOBJ(path string, m *Mesh) error {
+	mtlPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mtl"
+
+	objFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create OBJ file: %w", err)
+	}
+	defer objFile.Close()
+
+	mtlFile, err := os.Create(mtlPath)
+	if err != nil {
+		return fmt.Errorf("failed to create MTL file: %w", err)
+	}
+	defer mtlFile.Close()
+
+	if err := WriteOBJ(objFile, mtlFile, filepath.Base(mtlPath), m); err != nil {
+		return err
+	}
+
+	if err := mtlFile.Close(); err != nil {
+		return err
+	}
+	return objFile.Close()
+}
+
+// dedupe3 writes each distinct value once and returns, for every input
+// element, the zero-based index of its written value
+func dedupe3(w *bufio.Writer, prefix string, values [][3]float32) []int {
+	seen := make(map[[3]float32]int)
+	indices := make([]int, len(values))
+
+	for i, value := range values {
+		index, exists := seen[value]
+		if !exists {
+			index = len(seen)
+			seen[value] = index
+			fmt.Fprintf(w, "%s %g %g %g\n", prefix, value[0], value[1], value[2])
+		}
+		indices[i] = index
+	}
+
+	return indices
+}
+
+func dedupe2(w *bufio.Writer, prefix string, values [][2]float32) []int {
+	seen := make(map[[2]float32]int)
+	indices := make([]int, len(values))
+
+	for i, value := range values {
+		index, exists := seen[value]
+		if !exists {
+			index = len(seen)
+			seen[value] = index
+			fmt.Fprintf(w, "%s %g %g\n", prefix, value[0], value[1])
+		}
+		indices[i] = index
+	}
+
+	return indices
+}
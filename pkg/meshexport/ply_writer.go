@@ -0,0 +1,85 @@
+package meshexport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// WritePLY writes the mesh as binary little-endian PLY with per-vertex
+// normals, texture coordinates and 8-bit colours
+func WritePLY(w io.Writer, m *Mesh) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "ply\n")
+	fmt.Fprintf(bw, "format binary_little_endian 1.0\n")
+	fmt.Fprintf(bw, "comment Ceres voxel mesh export\n")
+	fmt.Fprintf(bw, "element vertex %d\n", m.VertexCount())
+	fmt.Fprintf(bw, "property float x\nproperty float y\nproperty float z\n")
+	fmt.Fprintf(bw, "property float nx\nproperty float ny\nproperty float nz\n")
+	fmt.Fprintf(bw, "property float s\nproperty float t\n")
+	fmt.Fprintf(bw, "property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	fmt.Fprintf(bw, "element face %d\n", m.TriangleCount())
+	fmt.Fprintf(bw, "property list uchar uint vertex_indices\n")
+	fmt.Fprintf(bw, "end_header\n")
+
+	var buf [4]byte
+	writeFloat := func(f float32) {
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(f))
+		bw.Write(buf[:])
+	}
+
+	for i := range m.Positions {
+		for _, f := range m.Positions[i] {
+			writeFloat(f)
+		}
+		for _, f := range m.Normals[i] {
+			writeFloat(f)
+		}
+		writeFloat(m.UVs[i][0])
+		writeFloat(m.UVs[i][1])
+		for _, c := range m.Colors[i] {
+			bw.WriteByte(colorToByte(c))
+		}
+	}
+
+	for tri := 0; tri < m.TriangleCount(); tri++ {
+		bw.WriteByte(3)
+		for k := 0; k < 3; k++ {
+			binary.LittleEndian.PutUint32(buf[:], m.Indices[tri*3+k])
+			bw.Write(buf[:])
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write PLY: %w", err)
+	}
+	return nil
+}
+
+// SavePLY writes the mesh to a binary PLY file
+func SavePLY(path string, m *Mesh) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create PLY file: %w", err)
+	}
+
+	if err := WritePLY(f, m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func colorToByte(c float32) uint8 {
+	if c <= 0 {
+		return 0
+	}
+	if c >= 1 {
+		return 255
+	}
+	return uint8(c*255 + 0.5)
+}
@@ -0,0 +1,391 @@
+package pathfind
+
+import (
+	"container/heap"
+	"container/list"
+	"errors"
+	"math"
+	"sync"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+var (
+	// ErrInvalidStart is returned when the start is not a cell a mob can stand in
+	ErrInvalidStart = errors.New("start is not walkable")
+	// ErrInvalidGoal is returned when the goal is not a cell a mob can stand in
+	ErrInvalidGoal = errors.New("goal is not walkable")
+	// ErrNoPath is returned when the goal cannot be reached from the start
+	ErrNoPath = errors.New("no path to goal")
+	// ErrNodeBudgetExceeded is returned when the search expanded MaxNodes
+	// cells without reaching the goal
+	ErrNodeBudgetExceeded = errors.New("node budget exceeded")
+)
+
+// Config describes how a mob moves. Positions in paths are the cell the
+// mob's feet occupy; a mob is two cells tall.
+type Config struct {
+	// MaxStepUp is how many blocks a mob can climb in a single step
+	MaxStepUp int32
+	// MaxDrop is how many blocks a mob will step down in a single step
+	MaxDrop int32
+
+	// AllowJump lets mobs jump across one-wide gaps at the same height
+	AllowJump bool
+	// AllowSwim lets mobs move through fluids, including straight up and down
+	AllowSwim bool
+
+	// MaxNodes limits the cells expanded per search
+	MaxNodes int
+
+	// Costs multiplies the cost of moves onto a voxel type: the ground below
+	// when walking, or the fluid when swimming. Types without an entry cost 1;
+	// an infinite cost makes the type unwalkable.
+	Costs map[voxel.VoxelType]float32
+
+	// StepUpCost and JumpCost are added to a move's cost
+	StepUpCost float32
+	JumpCost   float32
+}
+
+// DefaultConfig returns a config for a typical land mob
+func DefaultConfig() Config {
+	return Config{
+		MaxStepUp:  1,
+		MaxDrop:    3,
+		AllowJump:  true,
+		AllowSwim:  true,
+		MaxNodes:   4096,
+		Costs:      map[voxel.VoxelType]float32{voxel.VoxelTypeWater: 4},
+		StepUpCost: 0.5,
+		JumpCost:   1.5,
+	}
+}
+
+// maxCachedPaths caps the number of search results a pathfinder keeps. The
+// least recently used result is dropped first.
+const maxCachedPaths = 256
+
+type cacheKey struct {
+	start, goal voxel.VoxelPosition
+}
+
+// cachedPath remembers a search result along with the bounds of every cell
+// the search looked at, so an edit outside those bounds cannot change it
+type cachedPath struct {
+	key      cacheKey
+	path     []voxel.VoxelPosition
+	err      error
+	min, max voxel.VoxelPosition
+	element  *list.Element
+}
+
+// Pathfinder finds paths over a chunk manager. Voxels in unloaded chunks are
+// treated as blocked. Results are cached until a voxel inside the area the
+// search examined changes, or a chunk overlapping it is inserted or generated.
+type Pathfinder struct {
+	chunks *chunk.ChunkManager
+	config Config
+
+	cache map[cacheKey]*cachedPath
+	// recent orders cached results from most to least recently used
+	recent *list.List
+	// byChunk indexes cached results by every chunk their bounds overlap, so a
+	// voxel change only checks the results near it
+	byChunk map[chunk.ChunkPosition]map[*cachedPath]struct{}
+	// generation counts invalidations. A search that sees it move while running
+	// may have read a voxel before the change, so its result is not cached.
+	generation uint64
+	mutex      sync.Mutex
+}
+
+// NewPathfinder creates a pathfinder and subscribes it to voxel changes and
+// chunk loads
+func NewPathfinder(cm *chunk.ChunkManager, config Config) *Pathfinder {
+	pf := &Pathfinder{
+		chunks:  cm,
+		config:  config,
+		cache:   make(map[cacheKey]*cachedPath),
+		recent:  list.New(),
+		byChunk: make(map[chunk.ChunkPosition]map[*cachedPath]struct{}),
+	}
+	cm.AddVoxelChangeListener(pf.invalidate)
+	cm.AddChunkLoadListener(pf.invalidateChunk)
+	return pf
+}
+
+// FindPath returns the cells from start to goal, both included. When the
+// node budget runs out it returns the path to the explored cell closest to
+// the goal together with ErrNodeBudgetExceeded, so a mob can make progress
+// and search again later.
+func (pf *Pathfinder) FindPath(start, goal voxel.VoxelPosition) ([]voxel.VoxelPosition, error) {
+	key := cacheKey{start, goal}
+
+	pf.mutex.Lock()
+	if cached, ok := pf.cache[key]; ok {
+		pf.recent.MoveToFront(cached.element)
+		path, err := append([]voxel.VoxelPosition(nil), cached.path...), cached.err
+		pf.mutex.Unlock()
+		return path, err
+	}
+	generation := pf.generation
+	pf.mutex.Unlock()
+
+	s := newSearch(pf.chunks, &pf.config)
+	path, err := s.run(start, goal)
+
+	if !errors.Is(err, ErrNodeBudgetExceeded) {
+		pf.store(&cachedPath{key: key, path: path, err: err, min: s.min, max: s.max}, generation)
+	}
+
+	return append([]voxel.VoxelPosition(nil), path...), err
+}
+
+// store caches a result found by a search that started at generation. The
+// result is dropped if anything was invalidated since then.
+func (pf *Pathfinder) store(cached *cachedPath, generation uint64) {
+	pf.mutex.Lock()
+	defer pf.mutex.Unlock()
+
+	if pf.generation != generation {
+		return
+	}
+	if old, ok := pf.cache[cached.key]; ok {
+		pf.remove(old)
+	}
+
+	cached.element = pf.recent.PushFront(cached)
+	pf.cache[cached.key] = cached
+	pf.forEachChunk(cached, func(pos chunk.ChunkPosition) {
+		entries, ok := pf.byChunk[pos]
+		if !ok {
+			entries = make(map[*cachedPath]struct{})
+			pf.byChunk[pos] = entries
+		}
+		entries[cached] = struct{}{}
+	})
+
+	for pf.recent.Len() > maxCachedPaths {
+		pf.remove(pf.recent.Back().Value.(*cachedPath))
+	}
+}
+
+// remove drops a cached result from the cache, the recency list and the chunk
+// index. The caller must hold the mutex.
+func (pf *Pathfinder) remove(cached *cachedPath) {
+	delete(pf.cache, cached.key)
+	pf.recent.Remove(cached.element)
+	pf.forEachChunk(cached, func(pos chunk.ChunkPosition) {
+		entries := pf.byChunk[pos]
+		delete(entries, cached)
+		if len(entries) == 0 {
+			delete(pf.byChunk, pos)
+		}
+	})
+}
+
+// forEachChunk calls fn for every chunk the searched bounds of cached overlap
+func (pf *Pathfinder) forEachChunk(cached *cachedPath, fn func(pos chunk.ChunkPosition)) {
+	first := chunk.VoxelToChunkPosition(cached.min)
+	last := chunk.VoxelToChunkPosition(cached.max)
+	for x := first.X; x <= last.X; x++ {
+		for y := first.Y; y <= last.Y; y++ {
+			for z := first.Z; z <= last.Z; z++ {
+				fn(chunk.NewChunkPosition(x, y, z))
+			}
+		}
+	}
+}
+
+// CachedPaths returns the number of cached search results
+func (pf *Pathfinder) CachedPaths() int {
+	pf.mutex.Lock()
+	defer pf.mutex.Unlock()
+
+	return len(pf.cache)
+}
+
+// ClearCache drops every cached result, for example after unloading chunks
+func (pf *Pathfinder) ClearCache() {
+	pf.mutex.Lock()
+	defer pf.mutex.Unlock()
+
+	clear(pf.cache)
+	clear(pf.byChunk)
+	pf.recent.Init()
+	pf.generation++
+}
+
+func (pf *Pathfinder) invalidate(change chunk.VoxelChange) {
+	pf.invalidateArea(chunk.VoxelToChunkPosition(change.Position), change.Position, change.Position)
+}
+
+// invalidateChunk drops results that read the chunk at pos, which were found
+// while it was unloaded or before it generated
+func (pf *Pathfinder) invalidateChunk(pos chunk.ChunkPosition) {
+	origin := pos.GetWorldPosition()
+	last := voxel.NewVoxelPosition(origin.X+chunk.ChunkSize-1, origin.Y+chunk.ChunkSize-1, origin.Z+chunk.ChunkSize-1)
+	pf.invalidateArea(pos, origin, last)
+}
+
+// invalidateArea drops results whose searched bounds overlap min to max,
+// inclusive, which must lie inside the chunk at pos. Only results indexed
+// under that chunk are checked.
+func (pf *Pathfinder) invalidateArea(pos chunk.ChunkPosition, min, max voxel.VoxelPosition) {
+	pf.mutex.Lock()
+	defer pf.mutex.Unlock()
+
+	pf.generation++
+	for cached := range pf.byChunk[pos] {
+		if max.X >= cached.min.X && min.X <= cached.max.X &&
+			max.Y >= cached.min.Y && min.Y <= cached.max.Y &&
+			max.Z >= cached.min.Z && min.Z <= cached.max.Z {
+			pf.remove(cached)
+		}
+	}
+}
+
+// node is an entry in the open set
+type node struct {
+	pos   voxel.VoxelPosition
+	g, f  float32
+	index int
+}
+
+type openSet []*node
+
+func (o openSet) Len() int { return len(o) }
+
+func (o openSet) Less(i, j int) bool {
+	if o[i].f != o[j].f {
+		return o[i].f < o[j].f
+	}
+	return o[i].g > o[j].g
+}
+
+func (o openSet) Swap(i, j int) {
+	o[i], o[j] = o[j], o[i]
+	o[i].index = i
+	o[j].index = j
+}
+
+func (o *openSet) Push(x any) {
+	n := x.(*node)
+	n.index = len(*o)
+	*o = append(*o, n)
+}
+
+func (o *openSet) Pop() any {
+	old := *o
+	n := old[len(old)-1]
+	*o = old[:len(old)-1]
+	n.index = -1
+	return n
+}
+
+// search holds the state of a single A* run
+type search struct {
+	chunks *chunk.ChunkManager
+	config *Config
+	props  *voxel.PropertyTable
+
+	// minCost is the cheapest cost per block of horizontal progress, which
+	// keeps the heuristic admissible
+	minCost float32
+
+	// min and max bound every cell read during the search
+	min, max voxel.VoxelPosition
+	touched  bool
+	last     *chunk.Chunk
+}
+
+func newSearch(cm *chunk.ChunkManager, config *Config) *search {
+	minCost := float32(1)
+	for _, cost := range config.Costs {
+		minCost = min(minCost, cost)
+	}
+
+	return &search{chunks: cm, config: config, props: voxel.CurrentPropertyTable(), minCost: max(minCost, 0)}
+}
+
+func (s *search) run(start, goal voxel.VoxelPosition) ([]voxel.VoxelPosition, error) {
+	if !s.standable(start) {
+		return nil, ErrInvalidStart
+	}
+	if !s.standable(goal) {
+		return nil, ErrInvalidGoal
+	}
+
+	nodes := map[voxel.VoxelPosition]*node{}
+	cameFrom := map[voxel.VoxelPosition]voxel.VoxelPosition{}
+	closed := map[voxel.VoxelPosition]bool{}
+
+	open := &openSet{}
+	startNode := &node{pos: start, f: s.heuristic(start, goal)}
+	nodes[start] = startNode
+	heap.Push(open, startNode)
+
+	best, bestH := start, startNode.f
+	expanded := 0
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(*node)
+		if current.pos == goal {
+			return reconstruct(cameFrom, start, goal), nil
+		}
+		if expanded >= s.config.MaxNodes {
+			return reconstruct(cameFrom, start, best), ErrNodeBudgetExceeded
+		}
+		closed[current.pos] = true
+		expanded++
+
+		s.neighbors(current.pos, func(next voxel.VoxelPosition, cost float32) {
+			if closed[next] {
+				return
+			}
+
+			g := current.g + cost
+			n, seen := nodes[next]
+			if seen && g >= n.g {
+				return
+			}
+
+			h := s.heuristic(next, goal)
+			cameFrom[next] = current.pos
+			if h < bestH {
+				best, bestH = next, h
+			}
+
+			if !seen {
+				n = &node{pos: next, g: g, f: g + h}
+				nodes[next] = n
+				heap.Push(open, n)
+				return
+			}
+			n.g, n.f = g, g+h
+			heap.Fix(open, n.index)
+		})
+	}
+
+	return nil, ErrNoPath
+}
+
+func (s *search) heuristic(a, b voxel.VoxelPosition) float32 {
+	dx := math.Abs(float64(a.X - b.X))
+	dz := math.Abs(float64(a.Z - b.Z))
+	return float32(dx+dz) * s.minCost
+}
+
+func reconstruct(cameFrom map[voxel.VoxelPosition]voxel.VoxelPosition, start, end voxel.VoxelPosition) []voxel.VoxelPosition {
+	path := []voxel.VoxelPosition{end}
+	for current := end; current != start; {
+		current = cameFrom[current]
+		path = append(path, current)
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
@@ -0,0 +1,182 @@
+package pathfind
+
+import (
+	"math"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// horizontalDirections are the four walking directions, in a fixed order so
+// searches are deterministic
+var horizontalDirections = [4]voxel.VoxelPosition{
+	{X: 1}, {X: -1}, {Z: 1}, {Z: -1},
+}
+
+var up = voxel.VoxelPosition{Y: 1}
+
+// neighbors calls fn with every cell reachable from pos in one move and the
+// cost of that move
+func (s *search) neighbors(pos voxel.VoxelPosition, fn func(next voxel.VoxelPosition, cost float32)) {
+	for _, dir := range horizontalDirections {
+		side := pos.Add(dir)
+
+		if s.standable(side) {
+			fn(side, s.moveCost(side))
+			continue
+		}
+
+		if s.stepUp(pos, side, fn) {
+			continue
+		}
+		if s.stepDown(pos, side, fn) {
+			continue
+		}
+		if s.config.AllowJump {
+			s.jump(pos, dir, fn)
+		}
+	}
+
+	if s.config.AllowSwim && s.isFluid(pos) {
+		for _, next := range [2]voxel.VoxelPosition{pos.Add(up), pos.Sub(up)} {
+			if s.standable(next) {
+				fn(next, s.moveCost(next))
+			}
+		}
+	}
+}
+
+// stepUp tries to climb onto side raised by up to MaxStepUp blocks and
+// reports whether it found a landing
+func (s *search) stepUp(pos, side voxel.VoxelPosition, fn func(voxel.VoxelPosition, float32)) bool {
+	for h := int32(1); h <= s.config.MaxStepUp; h++ {
+		// The mob's head rises into the cell above its current head
+		if !s.passable(pos.Add(voxel.VoxelPosition{Y: h + 1})) {
+			return false
+		}
+
+		next := side.Add(voxel.VoxelPosition{Y: h})
+		if s.standable(next) {
+			fn(next, s.moveCost(next)+s.config.StepUpCost*float32(h))
+			return true
+		}
+	}
+	return false
+}
+
+// stepDown tries to drop from pos into the column at side by up to MaxDrop
+// blocks and reports whether it found a landing
+func (s *search) stepDown(pos, side voxel.VoxelPosition, fn func(voxel.VoxelPosition, float32)) bool {
+	if !s.passable(side) || !s.passable(side.Add(up)) {
+		return false
+	}
+
+	for h := int32(1); h <= s.config.MaxDrop; h++ {
+		next := side.Sub(voxel.VoxelPosition{Y: h})
+		if !s.passable(next) {
+			return false
+		}
+		if s.standable(next) {
+			fn(next, s.moveCost(next))
+			return true
+		}
+	}
+	return false
+}
+
+// jump tries to leap over a one-wide gap to a landing at the same height
+func (s *search) jump(pos, dir voxel.VoxelPosition, fn func(voxel.VoxelPosition, float32)) {
+	gap := pos.Add(dir)
+	for y := int32(0); y <= 2; y++ {
+		if !s.passable(gap.Add(voxel.VoxelPosition{Y: y})) {
+			return
+		}
+	}
+	if !s.passable(pos.Add(voxel.VoxelPosition{Y: 2})) {
+		return
+	}
+
+	landing := gap.Add(dir)
+	if s.standable(landing) && s.passable(landing.Add(voxel.VoxelPosition{Y: 2})) {
+		fn(landing, s.moveCost(landing)*2+s.config.JumpCost)
+	}
+}
+
+// standable reports whether a mob can occupy pos: two passable cells with
+// solid ground below, or a fluid cell when swimming is allowed
+func (s *search) standable(pos voxel.VoxelPosition) bool {
+	if !s.passable(pos) || !s.passable(pos.Add(up)) {
+		return false
+	}
+	if s.config.AllowSwim && s.isFluid(pos) {
+		return !math.IsInf(float64(s.cost(s.voxelAt(pos).Type)), 1)
+	}
+
+	ground, loaded := s.lookup(pos.Sub(up))
+	if !loaded || !s.solid(ground) {
+		return false
+	}
+	return !math.IsInf(float64(s.cost(ground.Type)), 1)
+}
+
+// moveCost returns the cost of moving into a standable cell
+func (s *search) moveCost(pos voxel.VoxelPosition) float32 {
+	if s.config.AllowSwim && s.isFluid(pos) {
+		return s.cost(s.voxelAt(pos).Type)
+	}
+	return s.cost(s.voxelAt(pos.Sub(up)).Type)
+}
+
+func (s *search) cost(voxelType voxel.VoxelType) float32 {
+	if cost, ok := s.config.Costs[voxelType]; ok {
+		return cost
+	}
+	return 1
+}
+
+// passable reports whether a mob's body can be inside pos: the voxel has no
+// collision boxes, like air and plants, and is not a fluid unless swimming
+func (s *search) passable(pos voxel.VoxelPosition) bool {
+	v, loaded := s.lookup(pos)
+	if !loaded || s.solid(v) {
+		return false
+	}
+	return !s.props.IsFluid(v) || s.config.AllowSwim
+}
+
+// solid reports whether a voxel has collision boxes, so a mob can stand on it
+// and cannot walk through it
+func (s *search) solid(v voxel.Voxel) bool {
+	return len(s.props.Boxes(v, nil)) > 0
+}
+
+func (s *search) isFluid(pos voxel.VoxelPosition) bool {
+	return s.props.IsFluid(s.voxelAt(pos))
+}
+
+func (s *search) voxelAt(pos voxel.VoxelPosition) voxel.Voxel {
+	v, _ := s.lookup(pos)
+	return v
+}
+
+// lookup reads a voxel without creating chunks and grows the bounds of the
+// area this search depends on
+func (s *search) lookup(pos voxel.VoxelPosition) (voxel.Voxel, bool) {
+	if !s.touched {
+		s.min, s.max, s.touched = pos, pos, true
+	} else {
+		s.min = voxel.NewVoxelPosition(min(s.min.X, pos.X), min(s.min.Y, pos.Y), min(s.min.Z, pos.Z))
+		s.max = voxel.NewVoxelPosition(max(s.max.X, pos.X), max(s.max.Y, pos.Y), max(s.max.Z, pos.Z))
+	}
+
+	chunkPos := chunk.VoxelToChunkPosition(pos)
+	if s.last == nil || s.last.Position != chunkPos {
+		s.last = s.chunks.GetChunkIfExists(chunkPos)
+		if s.last == nil {
+			return voxel.NewVoxel(voxel.VoxelTypeAir), false
+		}
+	}
+
+	x, y, z := chunk.VoxelToLocalPosition(pos)
+	return s.last.GetVoxel(x, y, z), true
+}
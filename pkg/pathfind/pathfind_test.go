@@ -0,0 +1,344 @@
+package pathfind
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"Ceres/internal/testworld"
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// newFlatWorld creates a 16x16 stone floor whose top is at floorY
+func newFlatWorld(floorY int32) *chunk.ChunkManager {
+	cm := chunk.NewChunkManager()
+	testworld.FillBox(cm, voxel.NewVoxelPosition(0, 0, 0), voxel.NewVoxelPosition(15, floorY, 15), voxel.NewVoxel(voxel.VoxelTypeStone))
+	return cm
+}
+
+func checkPath(t *testing.T, path []voxel.VoxelPosition, start, goal voxel.VoxelPosition) {
+	t.Helper()
+	if len(path) == 0 || path[0] != start || path[len(path)-1] != goal {
+		t.Fatalf("Expected path from %v to %v, got %v", start, goal, path)
+	}
+}
+
+func TestFindPathOnFlatGround(t *testing.T) {
+	pf := NewPathfinder(newFlatWorld(0), DefaultConfig())
+	start, goal := voxel.NewVoxelPosition(1, 1, 1), voxel.NewVoxelPosition(10, 1, 6)
+
+	path, err := pf.FindPath(start, goal)
+	if err != nil {
+		t.Fatalf("Expected a path, got %v", err)
+	}
+	checkPath(t, path, start, goal)
+	if len(path) != 9+5+1 {
+		t.Errorf("Expected a shortest path of 15 cells, got %d", len(path))
+	}
+
+	if _, err := pf.FindPath(voxel.NewVoxelPosition(1, 3, 1), goal); !errors.Is(err, ErrInvalidStart) {
+		t.Errorf("Expected ErrInvalidStart for a start in mid-air, got %v", err)
+	}
+}
+
+func TestFindPathStepsAndWalls(t *testing.T) {
+	cm := newFlatWorld(0)
+	stone := voxel.NewVoxel(voxel.VoxelTypeStone)
+	// A one-high ledge can be stepped over, a two-high wall with a gap at z=15 cannot
+	testworld.FillBox(cm, voxel.NewVoxelPosition(4, 1, 0), voxel.NewVoxelPosition(4, 1, 15), stone)
+	testworld.FillBox(cm, voxel.NewVoxelPosition(8, 1, 0), voxel.NewVoxelPosition(8, 2, 14), stone)
+
+	pf := NewPathfinder(cm, DefaultConfig())
+	start, goal := voxel.NewVoxelPosition(1, 1, 2), voxel.NewVoxelPosition(12, 1, 2)
+
+	path, err := pf.FindPath(start, goal)
+	if err != nil {
+		t.Fatalf("Expected a path, got %v", err)
+	}
+	checkPath(t, path, start, goal)
+
+	steppedOnLedge, detoured := false, false
+	for _, p := range path {
+		if p.X == 4 && p.Y == 2 {
+			steppedOnLedge = true
+		}
+		if p.X == 8 && p.Z == 15 {
+			detoured = true
+		}
+	}
+	if !steppedOnLedge || !detoured {
+		t.Errorf("Expected the path to climb the ledge and go around the wall, got %v", path)
+	}
+}
+
+func TestFindPathDropLimit(t *testing.T) {
+	cm := newFlatWorld(4)
+	start := voxel.NewVoxelPosition(1, 5, 1)
+
+	// Lower the far half of the floor by three blocks
+	testworld.FillBox(cm, voxel.NewVoxelPosition(8, 2, 0), voxel.NewVoxelPosition(15, 4, 15), voxel.NewVoxel(voxel.VoxelTypeAir))
+	pf := NewPathfinder(cm, DefaultConfig())
+	if _, err := pf.FindPath(start, voxel.NewVoxelPosition(12, 2, 1)); err != nil {
+		t.Errorf("Expected a three block drop to be walkable, got %v", err)
+	}
+
+	// A four block drop is too far, and climbing back up is impossible
+	testworld.FillBox(cm, voxel.NewVoxelPosition(8, 1, 0), voxel.NewVoxelPosition(15, 1, 15), voxel.NewVoxel(voxel.VoxelTypeAir))
+	if _, err := pf.FindPath(start, voxel.NewVoxelPosition(12, 1, 1)); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Expected ErrNoPath for a four block drop, got %v", err)
+	}
+}
+
+func TestFindPathJumpsGaps(t *testing.T) {
+	cm := newFlatWorld(0)
+	// A trench through the whole floor; below it is unloaded and blocked
+	testworld.FillBox(cm, voxel.NewVoxelPosition(6, 0, 0), voxel.NewVoxelPosition(6, 0, 15), voxel.NewVoxel(voxel.VoxelTypeAir))
+	start, goal := voxel.NewVoxelPosition(2, 1, 3), voxel.NewVoxelPosition(10, 1, 3)
+
+	path, err := NewPathfinder(cm, DefaultConfig()).FindPath(start, goal)
+	if err != nil {
+		t.Fatalf("Expected to jump the gap, got %v", err)
+	}
+	checkPath(t, path, start, goal)
+
+	config := DefaultConfig()
+	config.AllowJump = false
+	if _, err := NewPathfinder(cm, config).FindPath(start, goal); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Expected ErrNoPath without jumping, got %v", err)
+	}
+}
+
+func TestFindPathSwimmingAndCosts(t *testing.T) {
+	cm := newFlatWorld(4)
+	testworld.FillBox(cm, voxel.NewVoxelPosition(6, 2, 0), voxel.NewVoxelPosition(8, 4, 15), voxel.NewFluidVoxel(voxel.VoxelTypeWater, 0, false))
+	start, goal := voxel.NewVoxelPosition(2, 5, 3), voxel.NewVoxelPosition(12, 5, 3)
+
+	path, err := NewPathfinder(cm, DefaultConfig()).FindPath(start, goal)
+	if err != nil {
+		t.Fatalf("Expected to swim across the pool, got %v", err)
+	}
+	checkPath(t, path, start, goal)
+
+	config := DefaultConfig()
+	config.AllowSwim = false
+	if _, err := NewPathfinder(cm, config).FindPath(start, goal); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Expected ErrNoPath without swimming, got %v", err)
+	}
+
+	// Unwalkable sand forces a detour through the one stone gap
+	sandWorld := newFlatWorld(0)
+	testworld.FillBox(sandWorld, voxel.NewVoxelPosition(6, 0, 0), voxel.NewVoxelPosition(6, 0, 14), voxel.NewVoxel(voxel.VoxelTypeSand))
+	config = DefaultConfig()
+	config.Costs = map[voxel.VoxelType]float32{voxel.VoxelTypeSand: float32(math.Inf(1))}
+
+	path, err = NewPathfinder(sandWorld, config).FindPath(voxel.NewVoxelPosition(2, 1, 3), voxel.NewVoxelPosition(10, 1, 3))
+	if err != nil {
+		t.Fatalf("Expected a path around the sand, got %v", err)
+	}
+	for _, p := range path {
+		if p.X == 6 && p.Z != 15 {
+			t.Fatalf("Expected the path to avoid sand, got %v", path)
+		}
+	}
+}
+
+func TestFindPathNodeBudget(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxNodes = 5
+	pf := NewPathfinder(newFlatWorld(0), config)
+	start := voxel.NewVoxelPosition(1, 1, 1)
+
+	path, err := pf.FindPath(start, voxel.NewVoxelPosition(14, 1, 14))
+	if !errors.Is(err, ErrNodeBudgetExceeded) {
+		t.Fatalf("Expected ErrNodeBudgetExceeded, got %v", err)
+	}
+	if len(path) < 2 || path[0] != start {
+		t.Errorf("Expected a partial path from the start, got %v", path)
+	}
+	if pf.CachedPaths() != 0 {
+		t.Error("Expected partial results not to be cached")
+	}
+}
+
+func TestCachedPathInvalidatedBySetVoxel(t *testing.T) {
+	cm := newFlatWorld(0)
+	pf := NewPathfinder(cm, DefaultConfig())
+	start, goal := voxel.NewVoxelPosition(1, 1, 1), voxel.NewVoxelPosition(5, 1, 1)
+
+	if _, err := pf.FindPath(start, goal); err != nil {
+		t.Fatalf("Expected a path, got %v", err)
+	}
+	if pf.CachedPaths() != 1 {
+		t.Fatalf("Expected 1 cached path, got %d", pf.CachedPaths())
+	}
+
+	// An edit far outside the searched area keeps the cache
+	cm.SetVoxel(voxel.NewVoxelPosition(30, 30, 30), voxel.NewVoxel(voxel.VoxelTypeStone))
+	if pf.CachedPaths() != 1 {
+		t.Errorf("Expected distant edit to keep the cache, got %d paths", pf.CachedPaths())
+	}
+
+	// A wall across the straight path invalidates it
+	testworld.FillBox(cm, voxel.NewVoxelPosition(3, 1, 0), voxel.NewVoxelPosition(3, 2, 3), voxel.NewVoxel(voxel.VoxelTypeStone))
+	if pf.CachedPaths() != 0 {
+		t.Fatalf("Expected edit on the path to invalidate the cache, got %d paths", pf.CachedPaths())
+	}
+
+	path, err := pf.FindPath(start, goal)
+	if err != nil {
+		t.Fatalf("Expected a path around the wall, got %v", err)
+	}
+	for _, p := range path {
+		if p.X == 3 && p.Z <= 3 {
+			t.Fatalf("Expected the new path to avoid the wall, got %v", path)
+		}
+	}
+}
+
+func TestCachedPathInvalidatedByChunkLoad(t *testing.T) {
+	cm := chunk.NewChunkManager()
+	testworld.FillBox(cm, voxel.NewVoxelPosition(0, 0, 0), voxel.NewVoxelPosition(chunk.ChunkSize-1, 0, 3), voxel.NewVoxel(voxel.VoxelTypeStone))
+	pf := NewPathfinder(cm, DefaultConfig())
+	start, goal := voxel.NewVoxelPosition(1, 1, 1), voxel.NewVoxelPosition(chunk.ChunkSize+4, 1, 1)
+
+	// The goal's chunk is not loaded yet
+	if _, err := pf.FindPath(start, goal); !errors.Is(err, ErrInvalidGoal) {
+		t.Fatalf("Expected the unloaded goal to be invalid, got %v", err)
+	}
+	if pf.CachedPaths() != 1 {
+		t.Fatalf("Expected 1 cached result, got %d", pf.CachedPaths())
+	}
+
+	// A chunk far from the search keeps the cache
+	cm.InsertChunk(chunk.NewChunk(chunk.NewChunkPosition(5, 0, 5)))
+	if pf.CachedPaths() != 1 {
+		t.Errorf("Expected a distant chunk to keep the cache, got %d paths", pf.CachedPaths())
+	}
+
+	loaded := chunk.NewChunk(chunk.NewChunkPosition(1, 0, 0))
+	for x := int32(0); x < chunk.ChunkSize; x++ {
+		for z := int32(0); z <= 3; z++ {
+			loaded.SetVoxel(x, 0, z, voxel.NewVoxel(voxel.VoxelTypeStone))
+		}
+	}
+	cm.InsertChunk(loaded)
+	if pf.CachedPaths() != 0 {
+		t.Fatalf("Expected the loaded chunk to invalidate the cache, got %d paths", pf.CachedPaths())
+	}
+	path, err := pf.FindPath(start, goal)
+	if err != nil {
+		t.Fatalf("Expected a path into the loaded chunk, got %v", err)
+	}
+	checkPath(t, path, start, goal)
+
+	// Generation fills chunks without SetVoxel too
+	pf.ClearCache()
+	if _, err := pf.FindPath(start, voxel.NewVoxelPosition(1, 1, -5)); !errors.Is(err, ErrInvalidGoal) {
+		t.Fatalf("Expected the ungenerated goal to be invalid, got %v", err)
+	}
+	cm.FinishGeneration(chunk.NewChunkPosition(0, 0, -1))
+	if pf.CachedPaths() != 0 {
+		t.Errorf("Expected the generated chunk to invalidate the cache, got %d paths", pf.CachedPaths())
+	}
+}
+
+func TestEditDuringSearchIsNotCached(t *testing.T) {
+	cm := newFlatWorld(0)
+	pf := NewPathfinder(cm, DefaultConfig())
+	start, goal := voxel.NewVoxelPosition(1, 1, 1), voxel.NewVoxelPosition(5, 1, 1)
+
+	// Replay FindPath with a wall going up while the search runs, after it
+	// read the cells but before its result reaches the cache
+	pf.mutex.Lock()
+	generation := pf.generation
+	pf.mutex.Unlock()
+	s := newSearch(cm, &pf.config)
+	path, err := s.run(start, goal)
+	if err != nil {
+		t.Fatalf("Expected a path, got %v", err)
+	}
+	testworld.FillBox(cm, voxel.NewVoxelPosition(3, 1, 0), voxel.NewVoxelPosition(3, 2, 3), voxel.NewVoxel(voxel.VoxelTypeStone))
+	pf.store(&cachedPath{key: cacheKey{start, goal}, path: path, min: s.min, max: s.max}, generation)
+
+	if pf.CachedPaths() != 0 {
+		t.Fatalf("Expected the stale result to be dropped, got %d paths", pf.CachedPaths())
+	}
+	path, err = pf.FindPath(start, goal)
+	if err != nil {
+		t.Fatalf("Expected a path around the wall, got %v", err)
+	}
+	for _, p := range path {
+		if p.X == 3 && p.Z <= 3 {
+			t.Fatalf("Expected the path to avoid the wall, got %v", path)
+		}
+	}
+}
+
+func TestCacheIsBounded(t *testing.T) {
+	cm := newFlatWorld(0)
+	pf := NewPathfinder(cm, DefaultConfig())
+	first := cacheKey{voxel.NewVoxelPosition(0, 1, 0), voxel.NewVoxelPosition(0, 1, 0)}
+
+	for _, start := range []voxel.VoxelPosition{first.start, voxel.NewVoxelPosition(15, 1, 15)} {
+		for x := int32(0); x < 16; x++ {
+			for z := int32(0); z < 16; z++ {
+				if _, err := pf.FindPath(start, voxel.NewVoxelPosition(x, 1, z)); err != nil {
+					t.Fatalf("Expected a path to (%d, 1, %d), got %v", x, z, err)
+				}
+			}
+		}
+	}
+
+	if pf.CachedPaths() != maxCachedPaths {
+		t.Errorf("Expected %d cached paths, got %d", maxCachedPaths, pf.CachedPaths())
+	}
+	pf.mutex.Lock()
+	_, ok := pf.cache[first]
+	indexed := make(map[*cachedPath]struct{})
+	for _, entries := range pf.byChunk {
+		for cached := range entries {
+			indexed[cached] = struct{}{}
+		}
+	}
+	pf.mutex.Unlock()
+	if ok {
+		t.Errorf("Expected the least recently used path to be evicted")
+	}
+	if len(indexed) != maxCachedPaths {
+		t.Errorf("Expected %d indexed paths, got %d", maxCachedPaths, len(indexed))
+	}
+}
+
+func TestFindPathWalksThroughPlants(t *testing.T) {
+	cm := newFlatWorld(0)
+	// A row of plants across the whole floor, which have no collision boxes
+	for z := int32(0); z < 16; z++ {
+		plant := voxel.VoxelTypeFlower
+		if z%2 == 1 {
+			plant = voxel.VoxelTypeTallGrass
+		}
+		cm.SetVoxel(voxel.NewVoxelPosition(3, 1, z), voxel.NewVoxel(plant))
+	}
+	pf := NewPathfinder(cm, DefaultConfig())
+	start, goal := voxel.NewVoxelPosition(1, 1, 1), voxel.NewVoxelPosition(5, 1, 1)
+
+	path, err := pf.FindPath(start, goal)
+	if err != nil {
+		t.Fatalf("Expected a path through the plants, got %v", err)
+	}
+	checkPath(t, path, start, goal)
+	if len(path) != 5 {
+		t.Errorf("Expected a straight 5-cell path, got %v", path)
+	}
+	for _, p := range path {
+		if p.Y != 1 {
+			t.Fatalf("Expected the path to stay on the floor instead of climbing the plants, got %v", path)
+		}
+	}
+
+	// A plant is not ground to stand on
+	if _, err := pf.FindPath(start, voxel.NewVoxelPosition(3, 2, 4)); !errors.Is(err, ErrInvalidGoal) {
+		t.Errorf("Expected a cell above a flower to be invalid, got %v", err)
+	}
+}
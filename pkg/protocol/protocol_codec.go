@@ -0,0 +1,211 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"Ceres/pkg/chunk"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+var errShortPacket = errors.New("packet payload too short")
+
+// encoder appends little-endian values to a packet payload
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) u8(v uint8) {
+	e.buf = append(e.buf, v)
+}
+
+func (e *encoder) u16(v uint16) {
+	e.buf = binary.LittleEndian.AppendUint16(e.buf, v)
+}
+
+func (e *encoder) u32(v uint32) {
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) u64(v uint64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, v)
+}
+
+func (e *encoder) i32(v int32) {
+	e.u32(uint32(v))
+}
+
+func (e *encoder) i64(v int64) {
+	e.u64(uint64(v))
+}
+
+func (e *encoder) f32(v float32) {
+	e.u32(math.Float32bits(v))
+}
+
+func (e *encoder) f64(v float64) {
+	e.u64(math.Float64bits(v))
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.u8(1)
+	} else {
+		e.u8(0)
+	}
+}
+
+func (e *encoder) string(s string) {
+	e.u16(uint16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.u32(uint32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) vector3(v ceresmath.Vector3) {
+	e.f32(v.X)
+	e.f32(v.Y)
+	e.f32(v.Z)
+}
+
+// vector3d writes a world position in full precision
+func (e *encoder) vector3d(v ceresmath.Vector3d) {
+	e.f64(v.X)
+	e.f64(v.Y)
+	e.f64(v.Z)
+}
+
+func (e *encoder) chunkPosition(p chunk.ChunkPosition) {
+	e.i32(p.X)
+	e.i32(p.Y)
+	e.i32(p.Z)
+}
+
+func (e *encoder) voxelPosition(p voxel.VoxelPosition) {
+	e.i32(p.X)
+	e.i32(p.Y)
+	e.i32(p.Z)
+}
+
+func (e *encoder) voxel(v voxel.Voxel) {
+	e.u8(uint8(v.Type))
+	e.u8(v.State)
+}
+
+// decoder reads little-endian values from a packet payload. After the first
+// short read every further read returns zero and err is set.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = errShortPacket
+		d.data = nil
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) u8() uint8 {
+	b := d.take(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (d *decoder) u16() uint16 {
+	b := d.take(2)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func (d *decoder) u32() uint32 {
+	b := d.take(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (d *decoder) u64() uint64 {
+	b := d.take(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (d *decoder) i32() int32 {
+	return int32(d.u32())
+}
+
+func (d *decoder) i64() int64 {
+	return int64(d.u64())
+}
+
+func (d *decoder) f32() float32 {
+	return math.Float32frombits(d.u32())
+}
+
+func (d *decoder) f64() float64 {
+	return math.Float64frombits(d.u64())
+}
+
+func (d *decoder) bool() bool {
+	return d.u8() != 0
+}
+
+func (d *decoder) string() string {
+	return string(d.take(int(d.u16())))
+}
+
+func (d *decoder) bytes() []byte {
+	b := d.take(int(d.u32()))
+	return append([]byte(nil), b...)
+}
+
+func (d *decoder) vector3() ceresmath.Vector3 {
+	return ceresmath.Vector3{X: d.f32(), Y: d.f32(), Z: d.f32()}
+}
+
+func (d *decoder) vector3d() ceresmath.Vector3d {
+	return ceresmath.Vector3d{X: d.f64(), Y: d.f64(), Z: d.f64()}
+}
+
+func (d *decoder) chunkPosition() chunk.ChunkPosition {
+	return chunk.ChunkPosition{X: d.i32(), Y: d.i32(), Z: d.i32()}
+}
+
+func (d *decoder) voxelPosition() voxel.VoxelPosition {
+	return voxel.VoxelPosition{X: d.i32(), Y: d.i32(), Z: d.i32()}
+}
+
+func (d *decoder) voxel() voxel.Voxel {
+	return voxel.Voxel{Type: voxel.VoxelType(d.u8()), State: d.u8()}
+}
+
+// count reads a collection length and rejects lengths that cannot fit in the
+// remaining payload, given the minimum encoded size of one element
+func (d *decoder) count(elementSize int) int {
+	n := int(d.u32())
+	if d.err == nil && n*elementSize > len(d.data) {
+		d.err = errShortPacket
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,155 @@
+package protocol
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// MaxPacketSize limits the size of a single packet, including its type byte
+const MaxPacketSize = 4 << 20
+
+var (
+	// ErrPacketTooLarge is returned for frames larger than MaxPacketSize
+	ErrPacketTooLarge = errors.New("packet too large")
+	// ErrUnknownPacket is returned for frames with an unknown packet type
+	ErrUnknownPacket = errors.New("unknown packet type")
+	// ErrVersionMismatch is returned when the peer speaks another protocol version
+	ErrVersionMismatch = errors.New("protocol version mismatch")
+)
+
+// WritePacket writes a length-prefixed frame holding the packet type and payload
+func WritePacket(w io.Writer, p Packet) error {
+	e := &encoder{buf: make([]byte, 5, 64)}
+	e.buf[4] = uint8(p.Type())
+	p.encode(e)
+
+	size := len(e.buf) - 4
+	if size > MaxPacketSize {
+		return fmt.Errorf("%w: %d bytes", ErrPacketTooLarge, size)
+	}
+	binary.LittleEndian.PutUint32(e.buf, uint32(size))
+
+	_, err := w.Write(e.buf)
+	return err
+}
+
+// ReadPacket reads one frame written by WritePacket
+func ReadPacket(r io.Reader) (Packet, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[:])
+	if size == 0 || size > MaxPacketSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrPacketTooLarge, size)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, fmt.Errorf("failed to read packet: %w", err)
+	}
+
+	p := newPacket(PacketType(frame[0]))
+	if p == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownPacket, frame[0])
+	}
+
+	d := &decoder{data: frame[1:]}
+	p.decode(d)
+	if d.err != nil {
+		return nil, fmt.Errorf("failed to decode packet %d: %w", frame[0], d.err)
+	}
+	return p, nil
+}
+
+// Transport sends and receives whole packets. Conn implements it over a
+// stream connection and SimulatedLink provides in-memory pairs for tests.
+type Transport interface {
+	Send(p Packet) error
+	Receive() (Packet, error)
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+// Conn sends and receives packets over a stream connection. Send may be
+// called from several goroutines; Receive must only be called from one.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMutex sync.Mutex
+}
+
+// NewConn wraps a network connection
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+}
+
+// Send writes a packet
+func (c *Conn) Send(p Packet) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	return WritePacket(c.conn, p)
+}
+
+// Receive reads the next packet
+func (c *Conn) Receive() (Packet, error) {
+	return ReadPacket(c.reader)
+}
+
+// SetReadDeadline sets the deadline for the next Receive
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future Sends
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// RemoteAddr returns the address of the peer
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close closes the underlying connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// ClientHandshake sends Hello and waits for the server's Welcome
+func ClientHandshake(c Transport, name string) (*Welcome, error) {
+	if err := c.Send(&Hello{Version: Version, Name: name}); err != nil {
+		return nil, fmt.Errorf("failed to send hello: %w", err)
+	}
+
+	p, err := c.Receive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read welcome: %w", err)
+	}
+
+	switch p := p.(type) {
+	case *Welcome:
+		if p.Version != Version {
+			return nil, fmt.Errorf("%w: server %d, client %d", ErrVersionMismatch, p.Version, Version)
+		}
+		return p, nil
+	case *Disconnect:
+		return nil, fmt.Errorf("server refused connection: %s", p.Reason)
+	default:
+		return nil, fmt.Errorf("expected welcome, got packet %d", p.Type())
+	}
+}
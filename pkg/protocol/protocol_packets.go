@@ -0,0 +1,498 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+// Version is the protocol version. Peers with different versions refuse the
+// handshake.
+const Version uint16 = 7
+
+// PacketType identifies a packet on the wire
+type PacketType uint8
+
+const (
+	PacketHello PacketType = iota + 1
+	PacketWelcome
+	PacketDisconnect
+	PacketKeepAlive
+	PacketPlayerPosition
+	PacketChunkData
+	PacketUnloadChunk
+	PacketVoxelDelta
+	PacketEntitySpawn
+	PacketEntityMove
+	PacketEntityDespawn
+	PacketPlayerInput
+	PacketPlayerSnapshot
+	PacketEditRequest
+	PacketEditResult
+	PacketTimeUpdate
+)
+
+// Packet is a message exchanged between client and server
+type Packet interface {
+	Type() PacketType
+	encode(e *encoder)
+	decode(d *decoder)
+}
+
+// newPacket returns an empty packet of the given type, or nil if the type is unknown
+func newPacket(t PacketType) Packet {
+	switch t {
+	case PacketHello:
+		return &Hello{}
+	case PacketWelcome:
+		return &Welcome{}
+	case PacketDisconnect:
+		return &Disconnect{}
+	case PacketKeepAlive:
+		return &KeepAlive{}
+	case PacketPlayerPosition:
+		return &PlayerPosition{}
+	case PacketChunkData:
+		return &ChunkData{}
+	case PacketUnloadChunk:
+		return &UnloadChunk{}
+	case PacketVoxelDelta:
+		return &VoxelDelta{}
+	case PacketEntitySpawn:
+		return &EntitySpawn{}
+	case PacketEntityMove:
+		return &EntityMove{}
+	case PacketEntityDespawn:
+		return &EntityDespawn{}
+	case PacketPlayerInput:
+		return &PlayerInput{}
+	case PacketPlayerSnapshot:
+		return &PlayerSnapshot{}
+	case PacketEditRequest:
+		return &EditRequest{}
+	case PacketEditResult:
+		return &EditResult{}
+	case PacketTimeUpdate:
+		return &TimeUpdate{}
+	default:
+		return nil
+	}
+}
+
+// Hello is the first packet a client sends
+type Hello struct {
+	Version uint16
+	Name    string
+}
+
+func (p *Hello) Type() PacketType { return PacketHello }
+
+func (p *Hello) encode(e *encoder) {
+	e.u16(p.Version)
+	e.string(p.Name)
+}
+
+func (p *Hello) decode(d *decoder) {
+	p.Version = d.u16()
+	p.Name = d.string()
+}
+
+// Welcome accepts a client. PlayerID is the entity that represents the
+// client in entity packets, and Timestep is the server's tick length, which
+// the client needs to predict movement the same way the server simulates it.
+// MeshMode is how the world's chunks are meshed, and Time is the world clock
+// at Tick, see TimeUpdate.
+type Welcome struct {
+	Version  uint16
+	PlayerID uint64
+	Seed     int64
+	Tick     uint64
+	Timestep time.Duration
+	Spawn    ceresmath.Vector3d
+	MeshMode chunk.MeshMode
+	Time     uint64
+}
+
+func (p *Welcome) Type() PacketType { return PacketWelcome }
+
+func (p *Welcome) encode(e *encoder) {
+	e.u16(p.Version)
+	e.u64(p.PlayerID)
+	e.i64(p.Seed)
+	e.u64(p.Tick)
+	e.i64(int64(p.Timestep))
+	e.vector3d(p.Spawn)
+	e.u8(uint8(p.MeshMode))
+	e.u64(p.Time)
+}
+
+func (p *Welcome) decode(d *decoder) {
+	p.Version = d.u16()
+	p.PlayerID = d.u64()
+	p.Seed = d.i64()
+	p.Tick = d.u64()
+	p.Timestep = time.Duration(d.i64())
+	p.Spawn = d.vector3d()
+	p.MeshMode = chunk.MeshMode(d.u8())
+	p.Time = d.u64()
+}
+
+// Disconnect is sent before closing a connection
+type Disconnect struct {
+	Reason string
+}
+
+func (p *Disconnect) Type() PacketType { return PacketDisconnect }
+
+func (p *Disconnect) encode(e *encoder) {
+	e.string(p.Reason)
+}
+
+func (p *Disconnect) decode(d *decoder) {
+	p.Reason = d.string()
+}
+
+// KeepAlive is sent periodically by the server; the client echoes the nonce
+type KeepAlive struct {
+	Nonce uint64
+}
+
+func (p *KeepAlive) Type() PacketType { return PacketKeepAlive }
+
+func (p *KeepAlive) encode(e *encoder) {
+	e.u64(p.Nonce)
+}
+
+func (p *KeepAlive) decode(d *decoder) {
+	p.Nonce = d.u64()
+}
+
+// PlayerPosition asks to move the player directly, bypassing movement
+// physics. The server only allows it for spectators, and answers with a
+// PlayerSnapshot of where the player ends up.
+type PlayerPosition struct {
+	Position ceresmath.Vector3d
+}
+
+func (p *PlayerPosition) Type() PacketType { return PacketPlayerPosition }
+
+func (p *PlayerPosition) encode(e *encoder) {
+	e.vector3d(p.Position)
+}
+
+func (p *PlayerPosition) decode(d *decoder) {
+	p.Position = d.vector3d()
+}
+
+// ChunkData carries a whole chunk in the compressed palette format written
+// by chunk.Encode
+type ChunkData struct {
+	Position chunk.ChunkPosition
+	Data     []byte
+}
+
+// NewChunkData encodes a chunk into a packet
+func NewChunkData(c *chunk.Chunk) (*ChunkData, error) {
+	var buf bytes.Buffer
+	if err := c.Encode(&buf); err != nil {
+		return nil, fmt.Errorf("failed to encode chunk %v: %w", c.Position, err)
+	}
+	return &ChunkData{Position: c.Position, Data: buf.Bytes()}, nil
+}
+
+// Chunk decodes the chunk carried by the packet
+func (p *ChunkData) Chunk() (*chunk.Chunk, error) {
+	c, err := chunk.DecodeChunk(bytes.NewReader(p.Data))
+	if err != nil {
+		return nil, err
+	}
+	if c.Position != p.Position {
+		return nil, fmt.Errorf("chunk packet for %v contains chunk %v", p.Position, c.Position)
+	}
+	return c, nil
+}
+
+func (p *ChunkData) Type() PacketType { return PacketChunkData }
+
+func (p *ChunkData) encode(e *encoder) {
+	e.chunkPosition(p.Position)
+	e.bytes(p.Data)
+}
+
+func (p *ChunkData) decode(d *decoder) {
+	p.Position = d.chunkPosition()
+	p.Data = d.bytes()
+}
+
+// UnloadChunk tells the client it no longer receives updates for a chunk
+type UnloadChunk struct {
+	Position chunk.ChunkPosition
+}
+
+func (p *UnloadChunk) Type() PacketType { return PacketUnloadChunk }
+
+func (p *UnloadChunk) encode(e *encoder) {
+	e.chunkPosition(p.Position)
+}
+
+func (p *UnloadChunk) decode(d *decoder) {
+	p.Position = d.chunkPosition()
+}
+
+// VoxelDelta carries voxel changes within chunks the client has loaded
+type VoxelDelta struct {
+	Changes []chunk.VoxelWrite
+}
+
+func (p *VoxelDelta) Type() PacketType { return PacketVoxelDelta }
+
+func (p *VoxelDelta) encode(e *encoder) {
+	e.u32(uint32(len(p.Changes)))
+	for _, change := range p.Changes {
+		e.voxelPosition(change.Position)
+		e.voxel(change.Voxel)
+	}
+}
+
+func (p *VoxelDelta) decode(d *decoder) {
+	n := d.count(14)
+	p.Changes = make([]chunk.VoxelWrite, n)
+	for i := range p.Changes {
+		p.Changes[i].Position = d.voxelPosition()
+		p.Changes[i].Voxel = d.voxel()
+	}
+}
+
+// EntitySpawn introduces an entity to the client. Tick is the server tick
+// the position belongs to.
+type EntitySpawn struct {
+	ID       uint64
+	Kind     string
+	Tick     uint64
+	Position ceresmath.Vector3d
+}
+
+func (p *EntitySpawn) Type() PacketType { return PacketEntitySpawn }
+
+func (p *EntitySpawn) encode(e *encoder) {
+	e.u64(p.ID)
+	e.string(p.Kind)
+	e.u64(p.Tick)
+	e.vector3d(p.Position)
+}
+
+func (p *EntitySpawn) decode(d *decoder) {
+	p.ID = d.u64()
+	p.Kind = d.string()
+	p.Tick = d.u64()
+	p.Position = d.vector3d()
+}
+
+// EntityMove updates the position and velocity of a known entity at a server tick
+type EntityMove struct {
+	ID       uint64
+	Tick     uint64
+	Position ceresmath.Vector3d
+	Velocity ceresmath.Vector3
+}
+
+func (p *EntityMove) Type() PacketType { return PacketEntityMove }
+
+func (p *EntityMove) encode(e *encoder) {
+	e.u64(p.ID)
+	e.u64(p.Tick)
+	e.vector3d(p.Position)
+	e.vector3(p.Velocity)
+}
+
+func (p *EntityMove) decode(d *decoder) {
+	p.ID = d.u64()
+	p.Tick = d.u64()
+	p.Position = d.vector3d()
+	p.Velocity = d.vector3()
+}
+
+// EntityDespawn removes an entity from the client
+type EntityDespawn struct {
+	ID uint64
+}
+
+func (p *EntityDespawn) Type() PacketType { return PacketEntityDespawn }
+
+func (p *EntityDespawn) encode(e *encoder) {
+	e.u64(p.ID)
+}
+
+func (p *EntityDespawn) decode(d *decoder) {
+	p.ID = d.u64()
+}
+
+// PlayerInput carries the client's most recent unacknowledged inputs. Sending
+// a few inputs per packet means a lost packet rarely loses an input; the
+// server ignores inputs it has already applied.
+type PlayerInput struct {
+	Inputs []ecs.PlayerInput
+}
+
+func (p *PlayerInput) Type() PacketType { return PacketPlayerInput }
+
+func (p *PlayerInput) encode(e *encoder) {
+	e.u32(uint32(len(p.Inputs)))
+	for _, input := range p.Inputs {
+		e.u32(input.Sequence)
+		e.f32(input.Forward)
+		e.f32(input.Strafe)
+		e.f32(input.Yaw)
+		e.bool(input.Jump)
+	}
+}
+
+func (p *PlayerInput) decode(d *decoder) {
+	n := d.count(17)
+	p.Inputs = make([]ecs.PlayerInput, n)
+	for i := range p.Inputs {
+		p.Inputs[i] = ecs.PlayerInput{
+			Sequence: d.u32(),
+			Forward:  d.f32(),
+			Strafe:   d.f32(),
+			Yaw:      d.f32(),
+			Jump:     d.bool(),
+		}
+	}
+}
+
+// PlayerSnapshot is the server's authoritative state of the client's player
+// after applying every input up to LastInput
+type PlayerSnapshot struct {
+	Tick      uint64
+	LastInput uint32
+	State     ecs.PlayerState
+}
+
+func (p *PlayerSnapshot) Type() PacketType { return PacketPlayerSnapshot }
+
+func (p *PlayerSnapshot) encode(e *encoder) {
+	e.u64(p.Tick)
+	e.u32(p.LastInput)
+	e.vector3d(p.State.Position)
+	e.vector3(p.State.Velocity)
+	e.bool(p.State.OnGround)
+}
+
+func (p *PlayerSnapshot) decode(d *decoder) {
+	p.Tick = d.u64()
+	p.LastInput = d.u32()
+	p.State.Position = d.vector3d()
+	p.State.Velocity = d.vector3()
+	p.State.OnGround = d.bool()
+}
+
+// EditRequest asks the server to change a voxel the client has already
+// changed locally. Expected is the voxel the client replaced; the server
+// rejects the edit as a conflict if its voxel differs.
+type EditRequest struct {
+	ID       uint32
+	Position voxel.VoxelPosition
+	Expected voxel.Voxel
+	Voxel    voxel.Voxel
+}
+
+func (p *EditRequest) Type() PacketType { return PacketEditRequest }
+
+func (p *EditRequest) encode(e *encoder) {
+	e.u32(p.ID)
+	e.voxelPosition(p.Position)
+	e.voxel(p.Expected)
+	e.voxel(p.Voxel)
+}
+
+func (p *EditRequest) decode(d *decoder) {
+	p.ID = d.u32()
+	p.Position = d.voxelPosition()
+	p.Expected = d.voxel()
+	p.Voxel = d.voxel()
+}
+
+// EditStatus is the server's verdict on an edit request
+type EditStatus uint8
+
+const (
+	EditAccepted EditStatus = iota
+	// EditOutOfReach is returned for voxels too far from the player
+	EditOutOfReach
+	// EditProtected is returned for voxels inside a protected region
+	EditProtected
+	// EditConflict is returned when the voxel changed since the client saw it
+	EditConflict
+	// EditUnloaded is returned for voxels in chunks the server has not loaded
+	EditUnloaded
+	// EditForbidden is returned for players who may not edit, such as
+	// spectators
+	EditForbidden
+)
+
+func (s EditStatus) String() string {
+	switch s {
+	case EditAccepted:
+		return "accepted"
+	case EditOutOfReach:
+		return "out of reach"
+	case EditProtected:
+		return "protected"
+	case EditConflict:
+		return "conflict"
+	case EditUnloaded:
+		return "unloaded"
+	case EditForbidden:
+		return "forbidden"
+	default:
+		return fmt.Sprintf("EditStatus(%d)", uint8(s))
+	}
+}
+
+// EditResult answers an edit request. Voxel is the server's voxel at the
+// position after the edit was accepted or rejected.
+type EditResult struct {
+	ID     uint32
+	Status EditStatus
+	Voxel  voxel.Voxel
+}
+
+func (p *EditResult) Type() PacketType { return PacketEditResult }
+
+func (p *EditResult) encode(e *encoder) {
+	e.u32(p.ID)
+	e.u8(uint8(p.Status))
+	e.voxel(p.Voxel)
+}
+
+func (p *EditResult) decode(d *decoder) {
+	p.ID = d.u32()
+	p.Status = EditStatus(d.u8())
+	p.Voxel = d.voxel()
+}
+
+// TimeUpdate gives the world clock, in ticks as sky.TimeOfDay reads them, at
+// a server tick. The clock advances once per tick in between, so it is sent
+// when the time is set and with keep-alives to correct drift.
+type TimeUpdate struct {
+	Tick uint64
+	Time uint64
+}
+
+func (p *TimeUpdate) Type() PacketType { return PacketTimeUpdate }
+
+func (p *TimeUpdate) encode(e *encoder) {
+	e.u64(p.Tick)
+	e.u64(p.Time)
+}
+
+func (p *TimeUpdate) decode(d *decoder) {
+	p.Tick = d.u64()
+	p.Time = d.u64()
+}
@@ -0,0 +1,254 @@
+package protocol
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// LinkConfig describes the network conditions of a SimulatedLink
+type LinkConfig struct {
+	// Latency is the one-way delay of every packet
+	Latency time.Duration
+	// Jitter is the largest random delay added on top of Latency
+	Jitter time.Duration
+	// Loss is the probability that an unreliable packet is dropped
+	Loss float64
+	// Seed makes jitter and loss repeatable
+	Seed int64
+}
+
+// Unreliable reports whether a packet may be dropped by a lossy link. Inputs
+// are sent redundantly and snapshots are superseded by the next one, so a
+// real transport could send both without retransmission.
+func Unreliable(p Packet) bool {
+	switch p.(type) {
+	case *PlayerInput, *PlayerSnapshot:
+		return true
+	default:
+		return false
+	}
+}
+
+// SimulatedLink connects two in-memory transports with configurable latency,
+// jitter and loss. Time on the link only passes in Advance, so tests decide
+// exactly when packets arrive. Packets are encoded on Send and decoded on
+// Receive as on a real connection, and arrive in the order they were sent.
+type SimulatedLink struct {
+	config LinkConfig
+	now    time.Duration
+	ends   [2]*SimulatedTransport
+
+	mutex sync.Mutex
+	cond  *sync.Cond
+}
+
+// SimulatedTransport is one end of a SimulatedLink. Read and write deadlines
+// are ignored because the link has no real time.
+type SimulatedTransport struct {
+	link   *SimulatedLink
+	name   string
+	random *rand.Rand
+
+	// incoming holds packets on their way to this end, in arrival order
+	incoming []simulatedPacket
+	// busy is set while the receiver handles a packet, see WaitIdle
+	busy    bool
+	closed  bool
+	dropped int
+}
+
+type simulatedPacket struct {
+	due   time.Duration
+	frame []byte
+}
+
+// simulatedAddr names an end of a simulated link
+type simulatedAddr string
+
+func (a simulatedAddr) Network() string { return "simulated" }
+func (a simulatedAddr) String() string  { return string(a) }
+
+// NewSimulatedLink creates a link with the given conditions
+func NewSimulatedLink(config LinkConfig) *SimulatedLink {
+	l := &SimulatedLink{config: config}
+	l.cond = sync.NewCond(&l.mutex)
+	for i, name := range []string{"client", "server"} {
+		l.ends[i] = &SimulatedTransport{
+			link:   l,
+			name:   name,
+			random: rand.New(rand.NewSource(config.Seed + int64(i))),
+		}
+	}
+	return l
+}
+
+// Client returns the client end of the link
+func (l *SimulatedLink) Client() *SimulatedTransport {
+	return l.ends[0]
+}
+
+// Server returns the server end of the link
+func (l *SimulatedLink) Server() *SimulatedTransport {
+	return l.ends[1]
+}
+
+// SetConfig changes the conditions for packets sent from now on. The seed
+// only applies when the link is created.
+func (l *SimulatedLink) SetConfig(config LinkConfig) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.config = config
+}
+
+// Now returns the time that has passed on the link
+func (l *SimulatedLink) Now() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.now
+}
+
+// Advance moves the link's time forward, releasing packets that are due
+func (l *SimulatedLink) Advance(d time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.now += d
+	l.cond.Broadcast()
+}
+
+// WaitIdle blocks until each end has received every packet that is due and
+// its receiver is waiting for the next one, so everything delivered so far
+// has been handled
+func (l *SimulatedLink) WaitIdle() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for !l.idle() {
+		l.cond.Wait()
+	}
+}
+
+func (l *SimulatedLink) idle() bool {
+	for _, end := range l.ends {
+		if !end.closed && (end.busy || end.due()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *SimulatedTransport) peer() *SimulatedTransport {
+	if t == t.link.ends[0] {
+		return t.link.ends[1]
+	}
+	return t.link.ends[0]
+}
+
+// due reports whether the next incoming packet has arrived. The caller
+// holds the link's lock.
+func (t *SimulatedTransport) due() bool {
+	return len(t.incoming) > 0 && t.incoming[0].due <= t.link.now
+}
+
+// Send queues a packet for the other end, unless the link drops it
+func (t *SimulatedTransport) Send(p Packet) error {
+	var buf bytes.Buffer
+	if err := WritePacket(&buf, p); err != nil {
+		return err
+	}
+
+	l := t.link
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	peer := t.peer()
+	if t.closed || peer.closed {
+		return net.ErrClosed
+	}
+
+	if Unreliable(p) && l.config.Loss > 0 && t.random.Float64() < l.config.Loss {
+		t.dropped++
+		return nil
+	}
+
+	due := l.now + l.config.Latency
+	if l.config.Jitter > 0 {
+		due += time.Duration(t.random.Int63n(int64(l.config.Jitter) + 1))
+	}
+	// Packets never overtake each other, like on a stream connection
+	if n := len(peer.incoming); n > 0 && peer.incoming[n-1].due > due {
+		due = peer.incoming[n-1].due
+	}
+
+	peer.incoming = append(peer.incoming, simulatedPacket{due: due, frame: buf.Bytes()})
+	l.cond.Broadcast()
+	return nil
+}
+
+// Receive waits for the next packet to arrive. It returns io.EOF once the
+// other end has closed and every packet it sent has been received.
+func (t *SimulatedTransport) Receive() (Packet, error) {
+	l := t.link
+	l.mutex.Lock()
+	t.busy = false
+	l.cond.Broadcast()
+
+	for {
+		if t.closed {
+			l.mutex.Unlock()
+			return nil, net.ErrClosed
+		}
+		if t.due() {
+			break
+		}
+		if t.peer().closed && len(t.incoming) == 0 {
+			l.mutex.Unlock()
+			return nil, io.EOF
+		}
+		l.cond.Wait()
+	}
+
+	packet := t.incoming[0]
+	t.incoming = t.incoming[1:]
+	t.busy = true
+	l.mutex.Unlock()
+
+	return ReadPacket(bytes.NewReader(packet.frame))
+}
+
+// Dropped returns the number of packets sent from this end that the link dropped
+func (t *SimulatedTransport) Dropped() int {
+	t.link.mutex.Lock()
+	defer t.link.mutex.Unlock()
+
+	return t.dropped
+}
+
+func (t *SimulatedTransport) SetReadDeadline(deadline time.Time) error {
+	return nil
+}
+
+func (t *SimulatedTransport) SetWriteDeadline(deadline time.Time) error {
+	return nil
+}
+
+// RemoteAddr returns the name of the other end
+func (t *SimulatedTransport) RemoteAddr() net.Addr {
+	return simulatedAddr("simulated-" + t.peer().name)
+}
+
+// Close closes this end. The other end still receives packets already sent.
+func (t *SimulatedTransport) Close() error {
+	t.link.mutex.Lock()
+	defer t.link.mutex.Unlock()
+
+	t.closed = true
+	t.link.cond.Broadcast()
+	return nil
+}
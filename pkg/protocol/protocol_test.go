@@ -0,0 +1,202 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/voxel"
+)
+
+func TestPacketRoundTrip(t *testing.T) {
+	packets := []Packet{
+		&Hello{Version: Version, Name: "steve"},
+		&Welcome{Version: Version, PlayerID: 1<<32 | 7, Seed: -42, Tick: 99, Timestep: 50 * time.Millisecond, Spawn: ceresmath.Vector3d{X: 1, Y: 64, Z: -3}, MeshMode: chunk.MeshModeSmooth, Time: 30000},
+		&Disconnect{Reason: "bye"},
+		&KeepAlive{Nonce: 12345},
+		&PlayerPosition{Position: ceresmath.Vector3d{X: 10_000_000.5, Y: 70, Z: -10_000_000.25}},
+		&UnloadChunk{Position: chunk.NewChunkPosition(-1, 2, 3)},
+		&VoxelDelta{Changes: []chunk.VoxelWrite{
+			{Position: voxel.NewVoxelPosition(-5, 6, 7), Voxel: voxel.NewVoxel(voxel.VoxelTypeStone)},
+			{Position: voxel.NewVoxelPosition(8, 9, 10), Voxel: voxel.NewFluidVoxel(voxel.VoxelTypeWater, 3, true)},
+		}},
+		&EntitySpawn{ID: 3, Kind: "player", Tick: 4, Position: ceresmath.Vector3d{Y: 1}},
+		&EntityMove{ID: 3, Tick: 5, Position: ceresmath.Vector3d{X: 2}, Velocity: ceresmath.Vector3{Z: -1}},
+		&EntityDespawn{ID: 3},
+		&PlayerInput{Inputs: []ecs.PlayerInput{
+			{Sequence: 1, Forward: 1, Yaw: -90},
+			{Sequence: 2, Forward: 0.5, Strafe: -1, Yaw: 45, Jump: true},
+		}},
+		&PlayerSnapshot{Tick: 6, LastInput: 2, State: ecs.PlayerState{
+			Position: ceresmath.Vector3d{X: 1, Y: 2, Z: 3},
+			Velocity: ceresmath.Vector3{Y: -4},
+			OnGround: true,
+		}},
+		&EditRequest{ID: 9, Position: voxel.NewVoxelPosition(1, -2, 3), Expected: voxel.NewVoxel(voxel.VoxelTypeAir), Voxel: voxel.NewVoxel(voxel.VoxelTypeBrick)},
+		&EditResult{ID: 9, Status: EditConflict, Voxel: voxel.NewVoxel(voxel.VoxelTypeStone)},
+		&TimeUpdate{Tick: 100, Time: 48000 + 6000},
+	}
+
+	var buf bytes.Buffer
+	for _, p := range packets {
+		if err := WritePacket(&buf, p); err != nil {
+			t.Fatalf("Expected %T to encode, got %v", p, err)
+		}
+	}
+
+	for _, want := range packets {
+		got, err := ReadPacket(&buf)
+		if err != nil {
+			t.Fatalf("Expected %T to decode, got %v", want, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+}
+
+func TestChunkDataRoundTrip(t *testing.T) {
+	c := chunk.NewChunk(chunk.NewChunkPosition(2, -1, 5))
+	c.SetVoxel(1, 2, 3, voxel.NewVoxel(voxel.VoxelTypeBrick))
+	c.SetVoxel(31, 31, 31, voxel.NewVoxel(voxel.VoxelTypeGlass))
+
+	packet, err := NewChunkData(c)
+	if err != nil {
+		t.Fatalf("Expected chunk to encode, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePacket(&buf, packet); err != nil {
+		t.Fatalf("Expected packet to encode, got %v", err)
+	}
+	read, err := ReadPacket(&buf)
+	if err != nil {
+		t.Fatalf("Expected packet to decode, got %v", err)
+	}
+
+	decoded, err := read.(*ChunkData).Chunk()
+	if err != nil {
+		t.Fatalf("Expected chunk to decode, got %v", err)
+	}
+	if decoded.Position != c.Position ||
+		decoded.GetVoxel(1, 2, 3).Type != voxel.VoxelTypeBrick ||
+		decoded.GetVoxel(31, 31, 31).Type != voxel.VoxelTypeGlass {
+		t.Error("Expected decoded chunk to match the original")
+	}
+}
+
+func TestReadPacketRejectsBadFrames(t *testing.T) {
+	unknown := []byte{1, 0, 0, 0, 0xff}
+	if _, err := ReadPacket(bytes.NewReader(unknown)); !errors.Is(err, ErrUnknownPacket) {
+		t.Errorf("Expected ErrUnknownPacket, got %v", err)
+	}
+
+	huge := []byte{0xff, 0xff, 0xff, 0x7f}
+	if _, err := ReadPacket(bytes.NewReader(huge)); !errors.Is(err, ErrPacketTooLarge) {
+		t.Errorf("Expected ErrPacketTooLarge, got %v", err)
+	}
+
+	// A voxel delta claiming a million changes with no payload
+	truncated := []byte{5, 0, 0, 0, uint8(PacketVoxelDelta), 0x40, 0x42, 0x0f, 0x00}
+	if _, err := ReadPacket(bytes.NewReader(truncated)); err == nil {
+		t.Error("Expected truncated packet to fail")
+	}
+}
+
+// deliveries sends count inputs and keep-alives over a fresh link, advancing
+// it one millisecond per packet, and returns the sequences and nonces received
+func deliveries(t *testing.T, config LinkConfig, count int) []uint64 {
+	t.Helper()
+
+	link := NewSimulatedLink(config)
+	received := make(chan uint64, 2*count)
+	go func() {
+		for {
+			p, err := link.Server().Receive()
+			if err != nil {
+				close(received)
+				return
+			}
+			switch p := p.(type) {
+			case *PlayerInput:
+				received <- uint64(p.Inputs[0].Sequence)
+			case *KeepAlive:
+				received <- p.Nonce
+			}
+		}
+	}()
+
+	for i := 1; i <= count; i++ {
+		link.Client().Send(&PlayerInput{Inputs: []ecs.PlayerInput{{Sequence: uint32(i)}}})
+		link.Client().Send(&KeepAlive{Nonce: uint64(1000 + i)})
+		link.Advance(time.Millisecond)
+	}
+	link.Advance(time.Second)
+	link.WaitIdle()
+	link.Client().Close()
+
+	var got []uint64
+	for v := range received {
+		got = append(got, v)
+	}
+	return got
+}
+
+func TestSimulatedLinkLatency(t *testing.T) {
+	link := NewSimulatedLink(LinkConfig{Latency: 100 * time.Millisecond})
+	link.Client().Send(&KeepAlive{Nonce: 1})
+
+	received := make(chan Packet, 1)
+	go func() {
+		p, _ := link.Server().Receive()
+		received <- p
+	}()
+
+	link.Advance(99 * time.Millisecond)
+	link.WaitIdle()
+	select {
+	case <-received:
+		t.Fatal("Expected packet to be held until the latency passed")
+	default:
+	}
+
+	link.Advance(time.Millisecond)
+	if p := <-received; !reflect.DeepEqual(p, &KeepAlive{Nonce: 1}) {
+		t.Errorf("Expected keep-alive, got %+v", p)
+	}
+}
+
+func TestSimulatedLinkLossIsDeterministic(t *testing.T) {
+	config := LinkConfig{Latency: 20 * time.Millisecond, Jitter: 30 * time.Millisecond, Loss: 0.3, Seed: 7}
+	first := deliveries(t, config, 200)
+	second := deliveries(t, config, 200)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Error("Expected the same seed to deliver the same packets")
+	}
+
+	inputs, keepAlives := 0, 0
+	var last uint64
+	for _, v := range first {
+		if v >= 1000 {
+			keepAlives++
+			continue
+		}
+		inputs++
+		if v <= last {
+			t.Errorf("Expected inputs in order, got %d after %d", v, last)
+		}
+		last = v
+	}
+	if keepAlives != 200 {
+		t.Errorf("Expected every reliable packet to arrive, got %d", keepAlives)
+	}
+	if inputs < 100 || inputs > 180 {
+		t.Errorf("Expected about 30%% of inputs to be dropped, got %d of 200", inputs)
+	}
+}
@@ -0,0 +1,396 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+	"Ceres/pkg/engine"
+	"Ceres/pkg/fluid"
+	"Ceres/pkg/gravity"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/sky"
+	"Ceres/pkg/tick"
+	"Ceres/pkg/voxel"
+	"Ceres/pkg/worldgen"
+)
+
+// Config describes a dedicated server
+type Config struct {
+	// Dir holds the world's chunk files and metadata
+	Dir string
+	// Seed is used when the world is created; existing worlds keep their seed
+	Seed int64
+	// MeshMode is how clients mesh a new world's chunks; existing worlds keep
+	// their mode
+	MeshMode chunk.MeshMode
+
+	// Address is the TCP address to listen on; empty disables networking
+	Address string
+
+	// TickRate is the number of simulation ticks per second
+	TickRate int
+
+	// SpawnRadius is the horizontal radius in chunks loaded around the origin,
+	// between MinChunkY and MaxChunkY inclusive
+	SpawnRadius int32
+	MinChunkY   int32
+	MaxChunkY   int32
+
+	// AutosaveInterval is the simulated time between saves; zero disables autosave
+	AutosaveInterval time.Duration
+
+	// ViewDistance is the radius in chunks streamed around each client
+	ViewDistance int32
+	// ChunksPerTick limits the chunks sent to each client per tick
+	ChunksPerTick int
+
+	// KeepAliveInterval is the time between keep-alive packets, and clients
+	// that send nothing for Timeout are disconnected
+	KeepAliveInterval time.Duration
+	Timeout           time.Duration
+
+	// Reach is how far from a player's eyes, in blocks, it may edit voxels
+	Reach float32
+	// ProtectedRegions are areas no player may edit
+	ProtectedRegions []Region
+}
+
+// DefaultConfig returns a config for a world in dir
+func DefaultConfig(dir string) Config {
+	return Config{
+		Dir:              dir,
+		Address:          ":25575",
+		TickRate:         20,
+		SpawnRadius:      4,
+		MinChunkY:        0,
+		MaxChunkY:        3,
+		AutosaveInterval: time.Minute,
+
+		ViewDistance:  4,
+		ChunksPerTick: 8,
+
+		KeepAliveInterval: 5 * time.Second,
+		Timeout:           15 * time.Second,
+
+		Reach: 6,
+	}
+}
+
+// Server simulates a world without any graphics. It implements engine.App,
+// so the engine's fixed timestep drives the simulation.
+type Server struct {
+	config Config
+
+	info      worldInfo
+	storage   *chunk.Storage
+	chunks    *chunk.ChunkManager
+	generator *worldgen.Generator
+
+	ticks    *tick.Scheduler
+	fluids   *fluid.Simulator
+	gravity  *gravity.System
+	entities *ecs.World
+
+	autosaveTicks  uint64
+	sinceSave      uint64
+	keepAliveTicks uint64
+	spawn          ceresmath.Vector3d
+	timeChanged    bool
+
+	network  *network
+	sessions []*session
+
+	// changes collects voxel changes until they are sent at the end of a tick
+	changes      []chunk.VoxelChange
+	changesMutex sync.Mutex
+
+	// mutex serialises the simulation tick with connection goroutines that
+	// touch the world, such as a player joining
+	mutex sync.Mutex
+}
+
+// NewServer creates a server for the config. The world is loaded in Init.
+func NewServer(config Config) *Server {
+	return &Server{config: config}
+}
+
+// Chunks returns the server's world
+func (s *Server) Chunks() *chunk.ChunkManager {
+	return s.chunks
+}
+
+// Entities returns the server's entity world
+func (s *Server) Entities() *ecs.World {
+	return s.entities
+}
+
+// Addr returns the address the server listens on, or nil without networking
+func (s *Server) Addr() net.Addr {
+	if s.network == nil {
+		return nil
+	}
+	return s.network.Addr()
+}
+
+// ClientCount returns the number of connected clients
+func (s *Server) ClientCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	for _, sess := range s.sessions {
+		if !sess.isClosed() {
+			count++
+		}
+	}
+	return count
+}
+
+// Seed returns the world seed
+func (s *Server) Seed() int64 {
+	return s.info.Seed
+}
+
+// Time returns the world clock, see sky.TimeOfDay
+func (s *Server) Time() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.info.Time
+}
+
+// SetTime sets the world clock. Clients are told at the end of the tick.
+func (s *Server) SetTime(worldTime uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.info.Time = worldTime
+	s.timeChanged = true
+}
+
+// Teleport moves a connected player's collider centre directly, bypassing
+// movement physics, as a command would. It reports whether the player is
+// connected.
+func (s *Server) Teleport(player ecs.Entity, position ceresmath.Vector3d) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sess := s.session(player)
+	if sess == nil {
+		return false
+	}
+	sess.state = ecs.PlayerState{Position: position}
+	sess.changed = true
+	if transform, ok := ecs.Get[ecs.Transform](s.entities, player); ok {
+		transform.Position = position
+	}
+	return true
+}
+
+// SetSpectator sets whether a connected player is a spectator. Spectators
+// may move themselves directly with PlayerPosition packets, to any loaded
+// position clear of solid voxels; other players' positions come only from
+// their movement inputs. It reports whether the player is connected.
+func (s *Server) SetSpectator(player ecs.Entity, spectator bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sess := s.session(player)
+	if sess == nil {
+		return false
+	}
+	sess.spectator = spectator
+	return true
+}
+
+// session returns the open session playing as player, if any
+func (s *Server) session(player ecs.Entity) *session {
+	for _, sess := range s.sessions {
+		if sess.player == player && !sess.isClosed() {
+			return sess
+		}
+	}
+	return nil
+}
+
+// MeshMode returns how clients mesh the world's chunks
+func (s *Server) MeshMode() chunk.MeshMode {
+	return s.info.MeshMode
+}
+
+// CurrentTick returns the number of simulation ticks the world has run
+func (s *Server) CurrentTick() uint64 {
+	return s.ticks.CurrentTick()
+}
+
+// FixedTimestep returns the simulation timestep for the configured tick rate
+func (s *Server) FixedTimestep() time.Duration {
+	if s.config.TickRate <= 0 {
+		return engine.DefaultFixedTimestep
+	}
+	return time.Second / time.Duration(s.config.TickRate)
+}
+
+// SetVoxel changes a voxel on behalf of a player or command, waking nearby
+// fluids so they flow into or around the change
+func (s *Server) SetVoxel(pos voxel.VoxelPosition, v voxel.Voxel) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.setVoxel(pos, v)
+}
+
+// setVoxel is SetVoxel for callers that hold the mutex
+func (s *Server) setVoxel(pos voxel.VoxelPosition, v voxel.Voxel) {
+	s.chunks.SetVoxel(pos, v)
+	s.fluids.NotifyChanged(pos)
+}
+
+func (s *Server) Init(e *engine.Engine) error {
+	storage, err := chunk.NewStorage(s.config.Dir)
+	if err != nil {
+		return err
+	}
+	s.storage = storage
+
+	info, err := loadWorldInfo(s.config.Dir, worldInfo{Seed: s.config.Seed, MeshMode: s.config.MeshMode, Time: sky.Noon})
+	if err != nil {
+		return err
+	}
+	s.info = info
+
+	s.chunks = chunk.NewChunkManager()
+	s.chunks.AddVoxelChangeListener(s.onVoxelChanged)
+	s.generator = worldgen.NewDefaultGenerator(info.Seed)
+
+	pending, err := storage.LoadPending()
+	if err != nil {
+		return err
+	}
+	// Nothing is generated yet, so every write is queued again
+	s.chunks.RestorePendingWrites(pending)
+
+	s.ticks = tick.NewScheduler(s.chunks, info.Seed)
+	tick.RegisterDefaultHandlers(s.ticks)
+	s.ticks.SetTick(info.Tick)
+	s.fluids = fluid.NewSimulator(s.chunks)
+	s.fluids.SetTick(info.Tick)
+	s.gravity = gravity.NewSystem(s.chunks)
+
+	s.entities = ecs.NewWorld()
+	s.entities.AddSystem(ecs.NewPhysicsSystem(s.chunks))
+
+	if s.config.AutosaveInterval > 0 {
+		s.autosaveTicks = uint64(s.config.AutosaveInterval / s.FixedTimestep())
+	}
+	s.keepAliveTicks = max(uint64(s.config.KeepAliveInterval/s.FixedTimestep()), 1)
+
+	if err := s.loadSpawnArea(); err != nil {
+		return err
+	}
+	s.spawn = s.findSpawn()
+
+	if s.config.Address != "" {
+		network, err := listen(s.config.Address, s.Connect)
+		if err != nil {
+			return err
+		}
+		s.network = network
+		log.Printf("Listening on %s", network.Addr())
+	}
+
+	return nil
+}
+
+func (s *Server) Update(dt float32) {}
+
+func (s *Server) FixedUpdate(dt float32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.syncPlayers()
+	s.applyEdits()
+	s.ticks.Tick()
+	s.info.Time++
+	s.fluids.Tick()
+	s.gravity.Update(dt)
+	s.entities.Update(dt)
+	s.updateSessions()
+
+	s.sinceSave++
+	if s.autosaveTicks > 0 && s.sinceSave >= s.autosaveTicks {
+		if err := s.save(); err != nil {
+			log.Printf("Autosave failed: %v", err)
+		}
+	}
+}
+
+// Flush blocks until every packet queued for a client has been written to
+// its connection, so tests know everything a tick sent is on its way
+func (s *Server) Flush() {
+	s.mutex.Lock()
+	sessions := append([]*session(nil), s.sessions...)
+	s.mutex.Unlock()
+
+	for _, sess := range sessions {
+		sess.flush()
+	}
+}
+
+func (s *Server) Render(alpha float32) {}
+
+func (s *Server) Shutdown() {
+	if s.network != nil {
+		s.network.Close()
+	}
+
+	s.mutex.Lock()
+	sessions := s.sessions
+	s.mutex.Unlock()
+	for _, sess := range sessions {
+		sess.disconnect("server shutting down")
+		sess.wait()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.save(); err != nil {
+		log.Printf("Final save failed: %v", err)
+	}
+}
+
+// Save writes every modified chunk and the world metadata
+func (s *Server) Save() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.save()
+}
+
+func (s *Server) save() error {
+	s.sinceSave = 0
+
+	saved, err := s.chunks.SaveModified(s.storage)
+	if err != nil {
+		return fmt.Errorf("failed to save chunks: %w", err)
+	}
+	if err := s.storage.SavePending(s.chunks.PendingWrites()); err != nil {
+		return err
+	}
+
+	s.info.Tick = s.ticks.CurrentTick()
+	if err := saveWorldInfo(s.config.Dir, s.info); err != nil {
+		return err
+	}
+
+	if saved > 0 {
+		log.Printf("Saved %d chunks", saved)
+	}
+	return nil
+}
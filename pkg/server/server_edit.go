@@ -0,0 +1,75 @@
+package server
+
+import (
+	"Ceres/pkg/chunk"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/protocol"
+	"Ceres/pkg/voxel"
+)
+
+// Region is a box of voxels, inclusive at both corners
+type Region struct {
+	Min, Max voxel.VoxelPosition
+}
+
+// Contains reports whether the voxel lies inside the region
+func (r Region) Contains(pos voxel.VoxelPosition) bool {
+	return pos.X >= r.Min.X && pos.X <= r.Max.X &&
+		pos.Y >= r.Min.Y && pos.Y <= r.Max.Y &&
+		pos.Z >= r.Min.Z && pos.Z <= r.Max.Z
+}
+
+// applyEdits validates and applies the edit requests clients sent since the
+// last tick. Sessions are handled in join order and each session's edits in
+// the order they arrived, so when two clients edit the same voxel in one tick
+// the first wins and the second is rejected as a conflict. Accepted edits
+// reach every client with the chunk loaded through the usual voxel deltas.
+func (s *Server) applyEdits() {
+	for _, sess := range s.sessions {
+		for _, edit := range sess.takeEdits() {
+			status := s.checkEdit(sess, edit)
+			if status == protocol.EditAccepted {
+				s.setVoxel(edit.Position, edit.Voxel)
+			}
+
+			result := &protocol.EditResult{ID: edit.ID, Status: status}
+			if status != protocol.EditUnloaded {
+				result.Voxel = s.chunks.GetVoxel(edit.Position)
+			}
+			sess.send(result)
+		}
+	}
+}
+
+// checkEdit decides whether a session may make an edit. Reach is measured
+// from the player's state, which only movement inputs and the server move;
+// spectators move themselves freely, so they may not edit at all.
+func (s *Server) checkEdit(sess *session, edit protocol.EditRequest) protocol.EditStatus {
+	if sess.spectator {
+		return protocol.EditForbidden
+	}
+
+	center := ceresmath.Vector3d{
+		X: float64(edit.Position.X) + 0.5,
+		Y: float64(edit.Position.Y) + 0.5,
+		Z: float64(edit.Position.Z) + 0.5,
+	}
+	if center.Distance(sess.state.Eye()) > float64(s.config.Reach) {
+		return protocol.EditOutOfReach
+	}
+
+	for _, region := range s.config.ProtectedRegions {
+		if region.Contains(edit.Position) {
+			return protocol.EditProtected
+		}
+	}
+
+	if s.chunks.GetChunkIfExists(chunk.VoxelToChunkPosition(edit.Position)) == nil {
+		return protocol.EditUnloaded
+	}
+	if s.chunks.GetVoxel(edit.Position) != edit.Expected {
+		return protocol.EditConflict
+	}
+
+	return protocol.EditAccepted
+}
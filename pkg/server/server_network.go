@@ -0,0 +1,51 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+)
+
+// network accepts TCP connections and hands them to the server
+type network struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+func listen(address string, accept func(conn net.Conn)) (*network, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	n := &network{listener: listener}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					log.Printf("Accept failed: %v", err)
+				}
+				return
+			}
+			accept(conn)
+		}
+	}()
+
+	return n, nil
+}
+
+// Addr returns the address the server listens on
+func (n *network) Addr() net.Addr {
+	return n.listener.Addr()
+}
+
+// Close stops accepting connections
+func (n *network) Close() {
+	n.listener.Close()
+	n.wg.Wait()
+}
@@ -0,0 +1,326 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/protocol"
+)
+
+// outgoingQueueSize is how many packets may wait for a slow client before it
+// is disconnected
+const outgoingQueueSize = 1024
+
+const (
+	// maxQueuedInputs limits the inputs waiting for the simulation per client
+	maxQueuedInputs = 64
+	// maxInputBurst limits the inputs a client may save up. Each tick earns a
+	// client one input, so sending inputs faster than the tick rate never
+	// moves a player faster; the saved inputs absorb network jitter, when
+	// one tick's input arrives late and the next on time.
+	maxInputBurst = 3
+
+	// maxQueuedEdits is how many edit requests may wait before the client is
+	// disconnected for flooding, and maxEditsPerTick how many one tick applies
+	maxQueuedEdits  = 256
+	maxEditsPerTick = 16
+)
+
+// PlayerKind is the entity kind clients use for other players
+const PlayerKind = "player"
+
+// session is one connected client. The connection goroutines only touch the
+// fields guarded by mutex; everything else belongs to the simulation tick.
+type session struct {
+	conn   protocol.Transport
+	name   string
+	player ecs.Entity
+
+	outgoing chan protocol.Packet
+	done     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+
+	mutex    sync.Mutex
+	flushed  *sync.Cond
+	pending  int
+	inputs   []ecs.PlayerInput
+	edits    []protocol.EditRequest
+	teleport *ceresmath.Vector3d
+
+	// state is the authoritative player state after applying lastInput
+	state     ecs.PlayerState
+	lastInput uint32
+	changed   bool
+	// inputBudget is how many queued inputs the next tick may apply
+	inputBudget int
+	// spectator lets the client move its player with PlayerPosition, see
+	// Server.SetSpectator
+	spectator bool
+
+	loaded map[chunk.ChunkPosition]bool
+	known  map[ecs.Entity]ceresmath.Vector3d
+}
+
+// Connect serves a client connection, performing the handshake in the
+// background. Any net.Conn works, which lets tests use net.Pipe.
+func (s *Server) Connect(conn net.Conn) {
+	s.ConnectTransport(protocol.NewConn(conn))
+}
+
+// ConnectTransport serves a client over any packet transport, such as one
+// end of a protocol.SimulatedLink
+func (s *Server) ConnectTransport(conn protocol.Transport) {
+	go func() {
+		if err := s.join(conn); err != nil {
+			log.Printf("Client %s failed to join: %v", conn.RemoteAddr(), err)
+			conn.Close()
+		}
+	}()
+}
+
+func (s *Server) join(conn protocol.Transport) error {
+	conn.SetReadDeadline(time.Now().Add(s.config.Timeout))
+	p, err := conn.Receive()
+	if err != nil {
+		return fmt.Errorf("failed to read hello: %w", err)
+	}
+	hello, ok := p.(*protocol.Hello)
+	if !ok {
+		return fmt.Errorf("expected hello, got packet %d", p.Type())
+	}
+	if hello.Version != protocol.Version {
+		conn.Send(&protocol.Disconnect{Reason: fmt.Sprintf("server speaks protocol %d", protocol.Version)})
+		return fmt.Errorf("%w: client %d", protocol.ErrVersionMismatch, hello.Version)
+	}
+
+	sess := &session{
+		conn:     conn,
+		name:     hello.Name,
+		outgoing: make(chan protocol.Packet, outgoingQueueSize),
+		done:     make(chan struct{}),
+		state:    ecs.PlayerAt(s.spawn),
+		loaded:   make(map[chunk.ChunkPosition]bool),
+		known:    make(map[ecs.Entity]ceresmath.Vector3d),
+	}
+	sess.flushed = sync.NewCond(&sess.mutex)
+
+	s.mutex.Lock()
+	sess.player = s.entities.CreateEntity()
+	ecs.Add(s.entities, sess.player, ecs.Transform{Position: sess.state.Position})
+	ecs.Add(s.entities, sess.player, ecs.Renderable{Mesh: PlayerKind, Visible: true})
+	welcome := &protocol.Welcome{
+		Version:  protocol.Version,
+		PlayerID: uint64(sess.player),
+		Seed:     s.info.Seed,
+		MeshMode: s.info.MeshMode,
+		Time:     s.info.Time,
+		Tick:     s.ticks.CurrentTick(),
+		Timestep: s.FixedTimestep(),
+		Spawn:    s.spawn,
+	}
+	s.sessions = append(s.sessions, sess)
+	s.mutex.Unlock()
+
+	// The welcome goes out before the writer starts, so it is always first
+	if err := conn.Send(welcome); err != nil {
+		sess.close()
+		return nil
+	}
+
+	log.Printf("%s joined from %s", sess.name, conn.RemoteAddr())
+	sess.wg.Add(2)
+	go sess.writeLoop(s.config.Timeout)
+	go sess.readLoop(s.config.Timeout)
+	return nil
+}
+
+// send queues a packet, disconnecting clients that fall too far behind
+func (sess *session) send(p protocol.Packet) {
+	sess.mutex.Lock()
+	sess.pending++
+	sess.mutex.Unlock()
+
+	select {
+	case sess.outgoing <- p:
+	default:
+		sess.sent()
+		log.Printf("Disconnecting %s: too far behind", sess.name)
+		sess.close()
+	}
+}
+
+// sent marks a queued packet as written or discarded
+func (sess *session) sent() {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	sess.pending--
+	if sess.pending == 0 {
+		sess.flushed.Broadcast()
+	}
+}
+
+// flush blocks until every queued packet is written or the session closes
+func (sess *session) flush() {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	for sess.pending > 0 && !sess.isClosed() {
+		sess.flushed.Wait()
+	}
+}
+
+func (sess *session) writeLoop(timeout time.Duration) {
+	defer sess.wg.Done()
+
+	for {
+		select {
+		case p := <-sess.outgoing:
+			sess.conn.SetWriteDeadline(time.Now().Add(timeout))
+			err := sess.conn.Send(p)
+			sess.sent()
+			if err != nil {
+				sess.close()
+				return
+			}
+		case <-sess.done:
+			return
+		}
+	}
+}
+
+func (sess *session) readLoop(timeout time.Duration) {
+	defer sess.wg.Done()
+	defer sess.close()
+
+	for {
+		sess.conn.SetReadDeadline(time.Now().Add(timeout))
+		p, err := sess.conn.Receive()
+		if err != nil {
+			return
+		}
+
+		switch p := p.(type) {
+		case *protocol.PlayerPosition:
+			sess.mutex.Lock()
+			sess.teleport = &p.Position
+			sess.mutex.Unlock()
+		case *protocol.PlayerInput:
+			sess.mutex.Lock()
+			sess.inputs = append(sess.inputs, p.Inputs...)
+			if excess := len(sess.inputs) - maxQueuedInputs; excess > 0 {
+				sess.inputs = sess.inputs[excess:]
+			}
+			sess.mutex.Unlock()
+		case *protocol.EditRequest:
+			sess.mutex.Lock()
+			sess.edits = append(sess.edits, *p)
+			flooding := len(sess.edits) > maxQueuedEdits
+			sess.mutex.Unlock()
+			if flooding {
+				sess.disconnect("too many edits")
+				return
+			}
+		case *protocol.Disconnect:
+			return
+		}
+	}
+}
+
+// disconnect sends a reason to the client, then closes the connection
+func (sess *session) disconnect(reason string) {
+	sess.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	sess.conn.Send(&protocol.Disconnect{Reason: reason})
+	sess.close()
+}
+
+func (sess *session) close() {
+	sess.once.Do(func() {
+		sess.mutex.Lock()
+		close(sess.done)
+		sess.flushed.Broadcast()
+		sess.mutex.Unlock()
+		sess.conn.Close()
+	})
+}
+
+func (sess *session) isClosed() bool {
+	select {
+	case <-sess.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait blocks until the connection goroutines have exited
+func (sess *session) wait() {
+	sess.wg.Wait()
+}
+
+// takeTeleport returns and clears the position the client last asked to move
+// to directly, which only spectators may do
+func (sess *session) takeTeleport() (ceresmath.Vector3d, bool) {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	if sess.teleport == nil {
+		return ceresmath.Vector3d{}, false
+	}
+	position := *sess.teleport
+	sess.teleport = nil
+	return position, true
+}
+
+// takeEdits removes and returns up to maxEditsPerTick queued edit requests
+// in the order they arrived
+func (sess *session) takeEdits() []protocol.EditRequest {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	n := min(len(sess.edits), maxEditsPerTick)
+	edits := append([]protocol.EditRequest(nil), sess.edits[:n]...)
+	sess.edits = append(sess.edits[:0], sess.edits[n:]...)
+	return edits
+}
+
+// takeInputs removes and returns the queued inputs newer than the last one
+// applied, in sequence order and at most limit of them. Duplicates from
+// redundant sends are discarded.
+func (sess *session) takeInputs(limit int) []ecs.PlayerInput {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	fresh := sess.inputs[:0]
+	for _, input := range sess.inputs {
+		if input.Sequence > sess.lastInput {
+			fresh = append(fresh, input)
+		}
+	}
+	sort.SliceStable(fresh, func(i, j int) bool { return fresh[i].Sequence < fresh[j].Sequence })
+
+	var taken []ecs.PlayerInput
+	rest := fresh[:0]
+	last := sess.lastInput
+	for _, input := range fresh {
+		switch {
+		case input.Sequence == last:
+			continue
+		case len(taken) < limit:
+			taken = append(taken, input)
+		default:
+			rest = append(rest, input)
+		}
+		last = input.Sequence
+	}
+	sess.inputs = rest
+	return taken
+}
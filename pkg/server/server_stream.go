@@ -0,0 +1,310 @@
+package server
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/ecs"
+	ceresmath "Ceres/pkg/math"
+	"Ceres/pkg/protocol"
+	"Ceres/pkg/voxel"
+)
+
+func (s *Server) onVoxelChanged(change chunk.VoxelChange) {
+	s.changesMutex.Lock()
+	defer s.changesMutex.Unlock()
+
+	s.changes = append(s.changes, change)
+}
+
+// findSpawn returns the position above the highest solid voxel at the origin
+func (s *Server) findSpawn() ceresmath.Vector3d {
+	top := (s.config.MaxChunkY+1)*chunk.ChunkSize - 1
+	bottom := s.config.MinChunkY * chunk.ChunkSize
+	for y := top; y >= bottom; y-- {
+		if ecs.IsSolidVoxel(s.chunks.GetVoxel(voxel.NewVoxelPosition(0, y, 0))) {
+			return ceresmath.Vector3d{X: 0.5, Y: float64(y + 1), Z: 0.5}
+		}
+	}
+	return ceresmath.Vector3d{X: 0.5, Y: float64(top + 1), Z: 0.5}
+}
+
+// syncPlayers drops closed sessions and moves each player by the inputs its
+// client sent since the last tick, using the same movement code the client
+// predicts with
+func (s *Server) syncPlayers() {
+	dt := float32(s.FixedTimestep().Seconds())
+
+	open := s.sessions[:0]
+	for _, sess := range s.sessions {
+		if sess.isClosed() {
+			s.entities.Destroy(sess.player)
+			log.Printf("%s left", sess.name)
+			continue
+		}
+		open = append(open, sess)
+
+		if position, ok := sess.takeTeleport(); ok {
+			if sess.spectator && s.canTeleport(position) {
+				sess.state = ecs.PlayerState{Position: position}
+			}
+			// A rejected client is sent the state it must return to
+			sess.changed = true
+		}
+
+		sess.inputBudget = min(sess.inputBudget+1, maxInputBurst)
+		inputs := sess.takeInputs(sess.inputBudget)
+		sess.inputBudget -= len(inputs)
+		for _, input := range inputs {
+			sess.state = ecs.StepPlayer(s.chunks, sess.state, input, dt)
+			sess.lastInput = input.Sequence
+			sess.changed = true
+		}
+
+		if transform, ok := ecs.Get[ecs.Transform](s.entities, sess.player); ok {
+			transform.Position = sess.state.Position
+		}
+	}
+	clear(s.sessions[len(open):])
+	s.sessions = open
+}
+
+// maxTeleportCoordinate bounds teleports well inside the int32 voxel grid
+const maxTeleportCoordinate = 1 << 30
+
+// canTeleport reports whether a player may be moved directly to position:
+// into a loaded chunk within the world's height, clear of solid voxels
+func (s *Server) canTeleport(position ceresmath.Vector3d) bool {
+	for _, v := range [3]float64{position.X, position.Y, position.Z} {
+		if math.IsNaN(v) || math.Abs(v) > maxTeleportCoordinate {
+			return false
+		}
+	}
+
+	chunkPos := chunkOf(position)
+	if chunkPos.Y < s.config.MinChunkY || chunkPos.Y > s.config.MaxChunkY || s.chunks.GetChunkIfExists(chunkPos) == nil {
+		return false
+	}
+	return !ecs.Overlaps(s.chunks, ecs.PlayerCollider.Bounds(position))
+}
+
+// updateSessions sends every client the changes of this tick: voxel deltas
+// for chunks it has, entity updates in range, new chunks around it and
+// keep-alives
+func (s *Server) updateSessions() {
+	s.changesMutex.Lock()
+	changes := s.changes
+	s.changes = nil
+	s.changesMutex.Unlock()
+
+	deltas := groupChanges(changes)
+	entities := s.replicatedEntities()
+	keepAlive := s.ticks.CurrentTick()%s.keepAliveTicks == 0
+	sendTime := keepAlive || s.timeChanged
+	s.timeChanged = false
+
+	for _, sess := range s.sessions {
+		if sess.isClosed() {
+			continue
+		}
+
+		if sess.changed {
+			sess.send(&protocol.PlayerSnapshot{Tick: s.ticks.CurrentTick(), LastInput: sess.lastInput, State: sess.state})
+			sess.changed = false
+		}
+		s.sendDeltas(sess, deltas)
+		s.streamEntities(sess, entities)
+		s.streamChunks(sess)
+
+		if sendTime {
+			sess.send(&protocol.TimeUpdate{Tick: s.ticks.CurrentTick(), Time: s.info.Time})
+		}
+		if keepAlive {
+			sess.send(&protocol.KeepAlive{Nonce: s.ticks.CurrentTick()})
+		}
+	}
+}
+
+// groupChanges collects the final state of each changed voxel by chunk
+func groupChanges(changes []chunk.VoxelChange) map[chunk.ChunkPosition][]chunk.VoxelWrite {
+	latest := make(map[voxel.VoxelPosition]int)
+	deltas := make(map[chunk.ChunkPosition][]chunk.VoxelWrite)
+
+	for _, change := range changes {
+		chunkPos := chunk.VoxelToChunkPosition(change.Position)
+		write := chunk.VoxelWrite{Position: change.Position, Voxel: change.New}
+
+		if i, ok := latest[change.Position]; ok {
+			deltas[chunkPos][i] = write
+			continue
+		}
+		latest[change.Position] = len(deltas[chunkPos])
+		deltas[chunkPos] = append(deltas[chunkPos], write)
+	}
+	return deltas
+}
+
+func (s *Server) sendDeltas(sess *session, deltas map[chunk.ChunkPosition][]chunk.VoxelWrite) {
+	var writes []chunk.VoxelWrite
+	for chunkPos, chunkWrites := range deltas {
+		if sess.loaded[chunkPos] {
+			writes = append(writes, chunkWrites...)
+		}
+	}
+	if len(writes) > 0 {
+		sess.send(&protocol.VoxelDelta{Changes: writes})
+	}
+}
+
+// replicatedEntity is the networked state of an entity
+type replicatedEntity struct {
+	id       ecs.Entity
+	kind     string
+	position ceresmath.Vector3d
+	velocity ceresmath.Vector3
+}
+
+func (s *Server) replicatedEntities() []replicatedEntity {
+	var entities []replicatedEntity
+	ecs.Each(s.entities, func(e ecs.Entity, transform *ecs.Transform) {
+		entity := replicatedEntity{id: e, position: transform.Position}
+		if renderable, ok := ecs.Get[ecs.Renderable](s.entities, e); ok {
+			entity.kind = renderable.Mesh
+		}
+		if velocity, ok := ecs.Get[ecs.Velocity](s.entities, e); ok {
+			entity.velocity = velocity.Linear
+		}
+		entities = append(entities, entity)
+	})
+
+	sort.Slice(entities, func(i, j int) bool { return entities[i].id < entities[j].id })
+	return entities
+}
+
+func (s *Server) streamEntities(sess *session, entities []replicatedEntity) {
+	center := chunkOf(sess.state.Position)
+	tick := s.ticks.CurrentTick()
+	visible := make(map[ecs.Entity]bool)
+
+	for _, entity := range entities {
+		if entity.id == sess.player || !s.inView(center, chunkOf(entity.position)) {
+			continue
+		}
+		visible[entity.id] = true
+
+		last, known := sess.known[entity.id]
+		switch {
+		case !known:
+			sess.send(&protocol.EntitySpawn{ID: uint64(entity.id), Kind: entity.kind, Tick: tick, Position: entity.position})
+		case last != entity.position:
+			sess.send(&protocol.EntityMove{ID: uint64(entity.id), Tick: tick, Position: entity.position, Velocity: entity.velocity})
+		default:
+			continue
+		}
+		sess.known[entity.id] = entity.position
+	}
+
+	for id := range sess.known {
+		if !visible[id] {
+			sess.send(&protocol.EntityDespawn{ID: uint64(id)})
+			delete(sess.known, id)
+		}
+	}
+}
+
+// streamChunks unloads chunks the client moved away from and sends the
+// nearest missing chunks, up to ChunksPerTick
+func (s *Server) streamChunks(sess *session) {
+	center := chunkOf(sess.state.Position)
+
+	for chunkPos := range sess.loaded {
+		// One chunk of slack keeps clients on a border from thrashing
+		if chebyshev(center, chunkPos) > s.config.ViewDistance+1 {
+			sess.send(&protocol.UnloadChunk{Position: chunkPos})
+			delete(sess.loaded, chunkPos)
+		}
+	}
+
+	var missing []chunk.ChunkPosition
+	r := s.config.ViewDistance
+	for x := center.X - r; x <= center.X+r; x++ {
+		for y := max(center.Y-r, s.config.MinChunkY); y <= min(center.Y+r, s.config.MaxChunkY); y++ {
+			for z := center.Z - r; z <= center.Z+r; z++ {
+				chunkPos := chunk.NewChunkPosition(x, y, z)
+				if !sess.loaded[chunkPos] {
+					missing = append(missing, chunkPos)
+				}
+			}
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		di, dj := distanceSquared(center, missing[i]), distanceSquared(center, missing[j])
+		if di != dj {
+			return di < dj
+		}
+		return lessChunkPosition(missing[i], missing[j])
+	})
+
+	for i := 0; i < len(missing) && i < s.config.ChunksPerTick; i++ {
+		chunkPos := missing[i]
+		if err := s.loadChunk(chunkPos); err != nil {
+			log.Printf("Failed to load chunk %v: %v", chunkPos, err)
+			continue
+		}
+
+		packet, err := protocol.NewChunkData(s.chunks.GetChunkIfExists(chunkPos))
+		if err != nil {
+			log.Printf("Failed to send chunk %v: %v", chunkPos, err)
+			continue
+		}
+		sess.send(packet)
+		sess.loaded[chunkPos] = true
+	}
+}
+
+// inView reports whether an entity's chunk is within view distance of a
+// client's chunk
+func (s *Server) inView(center, chunkPos chunk.ChunkPosition) bool {
+	return chebyshev(center, chunkPos) <= s.config.ViewDistance
+}
+
+func chunkOf(position ceresmath.Vector3d) chunk.ChunkPosition {
+	return chunk.VoxelToChunkPosition(voxel.NewVoxelPosition(
+		floorInt32(position.X), floorInt32(position.Y), floorInt32(position.Z),
+	))
+}
+
+func floorInt32(v float64) int32 {
+	return int32(math.Floor(v))
+}
+
+// chebyshev returns the distance between two chunks along the axis where
+// they are furthest apart
+func chebyshev(a, b chunk.ChunkPosition) int32 {
+	return max(abs(a.X-b.X), abs(a.Y-b.Y), abs(a.Z-b.Z))
+}
+
+func distanceSquared(a, b chunk.ChunkPosition) int32 {
+	dx, dy, dz := a.X-b.X, a.Y-b.Y, a.Z-b.Z
+	return dx*dx + dy*dy + dz*dz
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func lessChunkPosition(a, b chunk.ChunkPosition) bool {
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.Z < b.Z
+}
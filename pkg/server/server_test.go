@@ -0,0 +1,189 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/engine"
+	"Ceres/pkg/protocol"
+	"Ceres/pkg/sky"
+	"Ceres/pkg/voxel"
+)
+
+func testConfig(dir string) Config {
+	config := DefaultConfig(dir)
+	config.Seed = 42
+	config.Address = ""
+	config.SpawnRadius = 0
+	config.MaxChunkY = 1
+	return config
+}
+
+func startServer(t *testing.T, config Config) (*Server, *engine.Engine, *engine.FakeClock) {
+	t.Helper()
+
+	srv := NewServer(config)
+	clock := engine.NewFakeClock()
+	e := engine.NewEngine(srv, nil)
+	e.SetClock(clock)
+	e.FixedTimestep = srv.FixedTimestep()
+	if err := e.Start(); err != nil {
+		t.Fatalf("Expected server to start, got %v", err)
+	}
+	return srv, e, clock
+}
+
+func TestServerPersistsWorld(t *testing.T) {
+	dir := t.TempDir()
+	srv, e, clock := startServer(t, testConfig(dir))
+
+	if srv.Chunks().GetStats().LoadedChunks != 2 {
+		t.Fatalf("Expected 2 spawn chunks, got %d", srv.Chunks().GetStats().LoadedChunks)
+	}
+
+	pos := voxel.NewVoxelPosition(3, 60, 3)
+	srv.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelTypeBrick))
+
+	for i := 0; i < 10; i++ {
+		clock.Advance(srv.FixedTimestep())
+		e.Frame()
+	}
+	if srv.CurrentTick() != 10 {
+		t.Errorf("Expected 10 ticks, got %d", srv.CurrentTick())
+	}
+	e.Shutdown()
+
+	config := testConfig(dir)
+	config.Seed = 7
+	config.MeshMode = chunk.MeshModeSmooth
+	reloaded, e, _ := startServer(t, config)
+	defer e.Shutdown()
+
+	if reloaded.Seed() != 42 {
+		t.Errorf("Expected the saved seed 42, got %d", reloaded.Seed())
+	}
+	if reloaded.Time() != sky.Noon+10 {
+		t.Errorf("Expected the world clock to persist, got %d", reloaded.Time())
+	}
+	if reloaded.MeshMode() != chunk.MeshModeBlocky {
+		t.Errorf("Expected the saved mesh mode, got %v", reloaded.MeshMode())
+	}
+	if reloaded.CurrentTick() != 10 {
+		t.Errorf("Expected the tick count to persist, got %d", reloaded.CurrentTick())
+	}
+	if reloaded.Chunks().GetVoxel(pos).Type != voxel.VoxelTypeBrick {
+		t.Error("Expected the placed brick to be loaded from disk")
+	}
+}
+
+func TestServerPersistsDecorationsAcrossBorders(t *testing.T) {
+	dir := t.TempDir()
+	srv, e, _ := startServer(t, testConfig(dir))
+
+	// Trees near the spawn column's edges reach into chunks not generated yet
+	pending := srv.Chunks().PendingWrites()
+	if len(pending) == 0 {
+		t.Fatal("Expected decorations to queue writes for neighbouring chunks")
+	}
+	e.Shutdown()
+
+	reloaded, e, _ := startServer(t, testConfig(dir))
+	defer e.Shutdown()
+	if got := reloaded.Chunks().PendingWrites(); len(got) != len(pending) {
+		t.Fatalf("Expected %d pending writes after a restart, got %d", len(pending), len(got))
+	}
+
+	// The neighbour generated after the restart matches one generated by a
+	// server that never restarted
+	neighbor := chunk.VoxelToChunkPosition(pending[0].Position)
+	reference, referenceEngine, _ := startServer(t, testConfig(t.TempDir()))
+	defer referenceEngine.Shutdown()
+	for _, s := range []*Server{reloaded, reference} {
+		if err := s.loadChunk(neighbor); err != nil {
+			t.Fatalf("Expected chunk %v to generate, got %v", neighbor, err)
+		}
+	}
+
+	got, want := reloaded.Chunks().GetChunkIfExists(neighbor), reference.Chunks().GetChunkIfExists(neighbor)
+	for x := int32(0); x < chunk.ChunkSize; x++ {
+		for y := int32(0); y < chunk.ChunkSize; y++ {
+			for z := int32(0); z < chunk.ChunkSize; z++ {
+				if got.GetVoxel(x, y, z) != want.GetVoxel(x, y, z) {
+					t.Fatalf("Expected chunk %v to match at (%d, %d, %d), got %v instead of %v",
+						neighbor, x, y, z, got.GetVoxel(x, y, z).Type, want.GetVoxel(x, y, z).Type)
+				}
+			}
+		}
+	}
+
+	// Writes only replace voxels they outrank, but some must have landed
+	applied := 0
+	for _, write := range pending {
+		if chunk.VoxelToChunkPosition(write.Position) == neighbor && got.GetVoxel(chunk.VoxelToLocalPosition(write.Position)) == write.Voxel {
+			applied++
+		}
+	}
+	if applied == 0 {
+		t.Error("Expected the saved writes to be applied to the neighbour")
+	}
+}
+
+func TestServerAutosave(t *testing.T) {
+	config := testConfig(t.TempDir())
+	config.AutosaveInterval = time.Second
+	srv, e, clock := startServer(t, config)
+	defer e.Shutdown()
+
+	srv.Chunks().SaveModified(srv.storage)
+	pos := voxel.NewVoxelPosition(1, 60, 1)
+	srv.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelTypeGlass))
+
+	for i := 0; i < config.TickRate; i++ {
+		clock.Advance(srv.FixedTimestep())
+		e.Frame()
+	}
+
+	saved, err := srv.storage.Load(chunk.VoxelToChunkPosition(pos))
+	if err != nil {
+		t.Fatalf("Expected the chunk to be autosaved, got %v", err)
+	}
+	x, y, z := chunk.VoxelToLocalPosition(pos)
+	if saved.GetVoxel(x, y, z).Type != voxel.VoxelTypeGlass {
+		t.Error("Expected the autosaved chunk to contain the glass")
+	}
+}
+
+func TestServerAcceptsConnections(t *testing.T) {
+	config := testConfig(t.TempDir())
+	config.Address = "127.0.0.1:0"
+	srv, e, _ := startServer(t, config)
+
+	conn, err := net.Dial("tcp", srv.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected to connect, got %v", err)
+	}
+	defer conn.Close()
+
+	welcome, err := protocol.ClientHandshake(protocol.NewConn(conn), "tester")
+	if err != nil {
+		t.Fatalf("Expected handshake to succeed, got %v", err)
+	}
+	if welcome.Seed != 42 || welcome.Spawn.Y <= 0 {
+		t.Errorf("Expected welcome with the world seed and a spawn point, got %+v", welcome)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.ClientCount() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if srv.ClientCount() != 1 {
+		t.Fatalf("Expected 1 client, got %d", srv.ClientCount())
+	}
+
+	e.Shutdown()
+	if srv.ClientCount() != 0 {
+		t.Errorf("Expected clients to be disconnected on shutdown, got %d", srv.ClientCount())
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"Ceres/pkg/chunk"
+)
+
+const worldInfoFile = "world.json"
+
+// worldInfo is the world metadata stored next to the chunk files
+type worldInfo struct {
+	Seed     int64          `json:"seed"`
+	Tick     uint64         `json:"tick"`
+	MeshMode chunk.MeshMode `json:"meshMode"`
+	// Time is the world clock, see sky.TimeOfDay
+	Time uint64 `json:"time"`
+}
+
+// loadWorldInfo reads the world metadata in dir, or returns the fresh
+// metadata for a new world
+func loadWorldInfo(dir string, fresh worldInfo) (worldInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, worldInfoFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return fresh, nil
+	}
+	if err != nil {
+		return worldInfo{}, fmt.Errorf("failed to read world info: %w", err)
+	}
+
+	// Worlds saved before the clock existed start at a new world's time
+	info := worldInfo{Time: fresh.Time}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return worldInfo{}, fmt.Errorf("failed to parse world info: %w", err)
+	}
+	return info, nil
+}
+
+func saveWorldInfo(dir string, info worldInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode world info: %w", err)
+	}
+
+	path := filepath.Join(dir, worldInfoFile)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("failed to write world info: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write world info: %w", err)
+	}
+	return nil
+}
+
+// loadSpawnArea loads the chunks around the origin, generating those that
+// were never saved
+func (s *Server) loadSpawnArea() error {
+	r := s.config.SpawnRadius
+	for x := -r; x <= r; x++ {
+		for z := -r; z <= r; z++ {
+			for y := s.config.MinChunkY; y <= s.config.MaxChunkY; y++ {
+				if err := s.loadChunk(chunk.NewChunkPosition(x, y, z)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// loadChunk loads the chunk at pos from disk or generates it
+func (s *Server) loadChunk(pos chunk.ChunkPosition) error {
+	if s.chunks.GetChunkIfExists(pos) != nil {
+		return nil
+	}
+
+	c, err := s.storage.Load(pos)
+	if errors.Is(err, os.ErrNotExist) {
+		s.generator.GenerateChunk(s.chunks, pos)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.generator.InsertLoadedChunk(s.chunks, c)
+	return nil
+}
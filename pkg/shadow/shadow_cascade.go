@@ -0,0 +1,147 @@
+package shadow
+
+import (
+	"math"
+
+	"Ceres/pkg/camera"
+	ceresmath "Ceres/pkg/math"
+)
+
+// MaxCascades is the most cascades the chunk shaders can sample
+const MaxCascades = 4
+
+// minSplitNear keeps logarithmic splits finite when the near plane is at 0
+const minSplitNear = 1e-3
+
+// Config describes how shadows are split into cascades
+type Config struct {
+	// Cascades is the number of shadow maps, from 1 to MaxCascades
+	Cascades int
+	// Resolution is the width and height of each cascade's map in texels
+	Resolution int
+	// Distance is how far from the camera, along its view, shadows reach
+	Distance float32
+	// SplitLambda blends the cascade splits from evenly spaced at 0 to
+	// logarithmic at 1, which gives nearby shadows more texels
+	SplitLambda float32
+	// CasterDistance is how far beyond a cascade towards the light blocks
+	// still cast shadows into it
+	CasterDistance float32
+}
+
+// DefaultConfig returns four 2048 texel cascades reaching 160 blocks
+func DefaultConfig() Config {
+	return Config{
+		Cascades:       4,
+		Resolution:     2048,
+		Distance:       160,
+		SplitLambda:    0.75,
+		CasterDistance: 256,
+	}
+}
+
+// Cascade is the shadow map covering one slice of the camera frustum, from
+// Near to Far along the camera's view
+type Cascade struct {
+	Near, Far float32
+	// ViewProjection takes camera-relative positions, as chunks are drawn
+	// with, to the cascade's clip space
+	ViewProjection ceresmath.Matrix4
+	// TexelSize is the width of a shadow map texel in blocks
+	TexelSize float32
+}
+
+// SplitDistances returns the count+1 distances along the view that bound
+// count cascades from near to far, see Config.SplitLambda. The logarithmic
+// splits treat near as at least minSplitNear.
+func SplitDistances(near, far float32, count int, lambda float32) []float32 {
+	logNear := math.Max(float64(near), minSplitNear)
+
+	splits := make([]float32, count+1)
+	for i := range splits {
+		t := float64(i) / float64(count)
+		split := float64(near) + float64(far-near)*t
+		if lambda != 0 {
+			logarithmic := logNear * math.Pow(float64(far)/logNear, t)
+			split += (logarithmic - split) * float64(lambda)
+		}
+		splits[i] = float32(split)
+	}
+	splits[0], splits[count] = near, far
+	return splits
+}
+
+// Fit fits cascades around the camera's frustum from near to
+// config.Distance, for a directional light shining from lightDirection, the
+// unit direction towards the light.
+//
+// Each cascade bounds its frustum slice with a sphere, whose size does not
+// change as the camera turns, so neither does the texel size. The cascade
+// is then moved in whole texels of a grid fixed in the world, so shadow
+// edges stay still as the camera moves rather than shimmering. The grid is
+// placed in double precision from the camera's world position, keeping it
+// stable far from the origin.
+func Fit(cam *camera.Camera, aspectRatio, near float32, lightDirection ceresmath.Vector3, config Config) []Cascade {
+	count := min(max(config.Cascades, 1), MaxCascades)
+	splits := SplitDistances(near, config.Distance, count, config.SplitLambda)
+	lightView := lightRotation(lightDirection)
+
+	// How far the frustum's corners spread per unit of view distance
+	tanHalfFov := math.Tan(float64(ceresmath.Deg2Rad(cam.Zoom)) / 2)
+	spread := tanHalfFov * tanHalfFov * (1 + float64(aspectRatio)*float64(aspectRatio))
+
+	// The camera in light view space, for the world-fixed texel grid
+	cameraInLight := rotateDouble(lightView, cam.Position)
+
+	cascades := make([]Cascade, count)
+	for i := range cascades {
+		n, f := float64(splits[i]), float64(splits[i+1])
+
+		// The smallest sphere around the slice's near and far rectangles
+		centerDistance := math.Min((n+f)*(1+spread)/2, f)
+		radius := math.Sqrt((f-centerDistance)*(f-centerDistance) + f*f*spread)
+		// Rounding up keeps the radius from flickering with rounding errors
+		radius = math.Ceil(radius*16) / 16
+
+		texelSize := 2 * radius / float64(config.Resolution)
+		center := lightView.MulVec(cam.Front.Mul(float32(centerDistance)))
+
+		var snapped [2]float32
+		for axis, value := range [2]float64{float64(center.X), float64(center.Y)} {
+			offset := math.Mod(cameraInLight[axis], texelSize)
+			snapped[axis] = float32(math.Round((value+offset)/texelSize)*texelSize - offset)
+		}
+
+		r := float32(radius)
+		view := ceresmath.Translate(-snapped[0], -snapped[1], -center.Z).Mul(lightView)
+		projection := ceresmath.Ortho(-r, r, -r, r, -r-config.CasterDistance, r)
+
+		cascades[i] = Cascade{
+			Near:           splits[i],
+			Far:            splits[i+1],
+			ViewProjection: projection.Mul(view),
+			TexelSize:      float32(texelSize),
+		}
+	}
+	return cascades
+}
+
+// lightRotation returns the view rotation of a light shining from
+// direction, looking down -Z like a camera
+func lightRotation(direction ceresmath.Vector3) ceresmath.Matrix4 {
+	up := ceresmath.Up()
+	if math.Abs(float64(direction.Y)) > 0.99 {
+		up = ceresmath.Forward()
+	}
+	return ceresmath.LookAt(ceresmath.Zero(), direction.Mul(-1), up)
+}
+
+// rotateDouble applies the rotation part of m to a world position in double
+// precision
+func rotateDouble(m ceresmath.Matrix4, v ceresmath.Vector3d) [3]float64 {
+	var out [3]float64
+	for row := range out {
+		out[row] = float64(m.At(row, 0))*v.X + float64(m.At(row, 1))*v.Y + float64(m.At(row, 2))*v.Z
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package shadow
+
+import (
+	"math"
+	"testing"
+
+	"Ceres/pkg/camera"
+	ceresmath "Ceres/pkg/math"
+)
+
+const aspectRatio = 16.0 / 9.0
+
+var sunDirection = ceresmath.NewVector3(0.3, 0.9, 0.2).Normalize()
+
+func newCamera(position ceresmath.Vector3d, yaw, pitch float32) *camera.Camera {
+	cam := camera.NewCamera(position)
+	cam.SetYaw(yaw)
+	cam.SetPitch(pitch)
+	return cam
+}
+
+// clipSpace returns the cascade's clip space position of a world position
+func clipSpace(cam *camera.Camera, cascade Cascade, world ceresmath.Vector3d) ceresmath.Vector3 {
+	return cascade.ViewProjection.MulVec(world.RelativeTo(cam.Position))
+}
+
+func TestSplitDistances(t *testing.T) {
+	splits := SplitDistances(0.1, 160, 4, 0.75)
+	if len(splits) != 5 || splits[0] != 0.1 || splits[4] != 160 {
+		t.Fatalf("Expected 5 splits from near to far, got %v", splits)
+	}
+	for i := 1; i < len(splits); i++ {
+		if splits[i] <= splits[i-1] {
+			t.Errorf("Expected increasing splits, got %v", splits)
+		}
+	}
+
+	uniform := SplitDistances(0, 100, 4, 0)
+	for i, want := range []float32{0, 25, 50, 75, 100} {
+		if !(math.Abs(float64(uniform[i]-want)) <= 1e-4) {
+			t.Errorf("Expected uniform split %d at %f, got %f", i, want, uniform[i])
+		}
+	}
+
+	logarithmic := SplitDistances(1, 1000, 3, 1)
+	for i, want := range []float32{1, 10, 100, 1000} {
+		if !(math.Abs(float64(logarithmic[i]-want))/float64(want) <= 1e-4) {
+			t.Errorf("Expected logarithmic split %d at %f, got %f", i, want, logarithmic[i])
+		}
+	}
+
+	// A near plane at 0 still gives finite, increasing logarithmic splits
+	fromZero := SplitDistances(0, 100, 4, 0.75)
+	for i := 1; i < len(fromZero); i++ {
+		if !(fromZero[i] > fromZero[i-1]) {
+			t.Errorf("Expected finite increasing splits from a near plane at 0, got %v", fromZero)
+		}
+	}
+}
+
+func TestCascadesCoverFrustum(t *testing.T) {
+	config := DefaultConfig()
+	for _, light := range []ceresmath.Vector3{sunDirection, ceresmath.Up(), ceresmath.NewVector3(1, 0.05, 0).Normalize()} {
+		cam := newCamera(ceresmath.NewVector3d(1e6+0.3, 70, -2e6), 37, -20)
+		cascades := Fit(cam, aspectRatio, 0.1, light, config)
+		if len(cascades) != config.Cascades {
+			t.Fatalf("Expected %d cascades, got %d", config.Cascades, len(cascades))
+		}
+
+		tanY := math.Tan(float64(ceresmath.Deg2Rad(cam.Zoom)) / 2)
+		tanX := tanY * aspectRatio
+		for i, cascade := range cascades {
+			for _, distance := range []float32{cascade.Near, cascade.Far} {
+				for _, corner := range [4][2]float64{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}} {
+					point := cam.Front.Mul(distance).
+						Add(cam.Right.Mul(distance * float32(corner[0]*tanX))).
+						Add(cam.Up.Mul(distance * float32(corner[1]*tanY)))
+					clip := cascade.ViewProjection.MulVec(point)
+					if math.Abs(float64(clip.X)) > 1 || math.Abs(float64(clip.Y)) > 1 || math.Abs(float64(clip.Z)) > 1 {
+						t.Errorf("Expected cascade %d lit from %v to cover its frustum corner %v, got %v", i, light, point, clip)
+					}
+				}
+			}
+
+			// Blocks between the cascade and the light still cast shadows
+			// into it
+			caster := cam.Front.Mul((cascade.Near + cascade.Far) / 2).Add(light.Mul(config.CasterDistance))
+			if clip := cascade.ViewProjection.MulVec(caster); clip.Z < -1 {
+				t.Errorf("Expected cascade %d to include casters towards the light, got depth %f", i, clip.Z)
+			}
+		}
+	}
+}
+
+func TestCascadeTexelSizeIgnoresRotation(t *testing.T) {
+	config := DefaultConfig()
+	first := Fit(newCamera(ceresmath.Vector3d{}, 0, 0), aspectRatio, 0.1, sunDirection, config)
+	for _, view := range [][2]float32{{90, 10}, {-135, 60}, {12.5, -89}} {
+		cascades := Fit(newCamera(ceresmath.Vector3d{}, view[0], view[1]), aspectRatio, 0.1, sunDirection, config)
+		for i := range cascades {
+			if cascades[i].TexelSize != first[i].TexelSize {
+				t.Errorf("Expected cascade %d's texel size not to change as the camera turns, got %f and %f", i, first[i].TexelSize, cascades[i].TexelSize)
+			}
+		}
+	}
+}
+
+func TestCascadesSnapToWorldTexels(t *testing.T) {
+	config := DefaultConfig()
+	for _, start := range []ceresmath.Vector3d{{}, {X: 3e6, Y: 64, Z: -5e6}} {
+		cam := newCamera(start, 30, -15)
+		// Fixed points in the world near the camera
+		points := []ceresmath.Vector3d{
+			start.Offset(ceresmath.NewVector3(5, -2, 8)),
+			start.Offset(ceresmath.NewVector3(-12.5, 3, 20)),
+		}
+
+		// texels returns where the points land on each cascade's map, in
+		// texels
+		texels := func() [][][2]float64 {
+			cascades := Fit(cam, aspectRatio, 0.1, sunDirection, config)
+			out := make([][][2]float64, len(cascades))
+			for i, cascade := range cascades {
+				for _, point := range points {
+					clip := clipSpace(cam, cascade, point)
+					out[i] = append(out[i], [2]float64{
+						(float64(clip.X) + 1) / 2 * float64(config.Resolution),
+						(float64(clip.Y) + 1) / 2 * float64(config.Resolution),
+					})
+				}
+			}
+			return out
+		}
+
+		before := texels()
+		for step := 0; step < 10; step++ {
+			cam.Position = cam.Position.Add(ceresmath.NewVector3d(0.37, 0.05, -0.21))
+			after := texels()
+			for i := range after {
+				for p := range after[i] {
+					for axis := 0; axis < 2; axis++ {
+						// The map may move under the points, but only by
+						// whole texels
+						moved := after[i][p][axis] - before[i][p][axis]
+						if math.Abs(moved-math.Round(moved)) > 0.02 {
+							t.Fatalf("Expected cascade %d to move by whole texels from %v, moved %f", i, start, moved)
+						}
+					}
+				}
+			}
+			before = after
+		}
+	}
+}
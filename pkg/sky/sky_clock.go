@@ -0,0 +1,36 @@
+package sky
+
+// TicksPerDay is the length of a day in world time. At the server's default
+// 20 ticks per second a day lasts 20 minutes.
+const TicksPerDay = 24000
+
+// Times of day, in ticks after midnight
+const (
+	Midnight uint64 = 0
+	Sunrise  uint64 = TicksPerDay / 4
+	Noon     uint64 = TicksPerDay / 2
+	Sunset   uint64 = TicksPerDay * 3 / 4
+)
+
+// TimeOfDay returns how far through its day a world time is, from 0 at
+// midnight through 0.25 at sunrise, 0.5 at noon and 0.75 at sunset
+func TimeOfDay(time uint64) float64 {
+	return float64(time%TicksPerDay) / TicksPerDay
+}
+
+// Day returns the number of whole days before a world time
+func Day(time uint64) uint64 {
+	return time / TicksPerDay
+}
+
+// SetTimeOfDay returns the first world time at or after time whose time of
+// day is timeOfDay ticks after midnight, so setting the time never runs the
+// clock backwards
+func SetTimeOfDay(time, timeOfDay uint64) uint64 {
+	timeOfDay %= TicksPerDay
+	next := Day(time)*TicksPerDay + timeOfDay
+	if next < time {
+		next += TicksPerDay
+	}
+	return next
+}
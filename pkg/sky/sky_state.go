@@ -0,0 +1,137 @@
+package sky
+
+import (
+	"math"
+
+	ceresmath "Ceres/pkg/math"
+)
+
+// axialTilt leans the sun's path towards +Z so that noon light is not
+// straight down and shadows never vanish
+const axialTilt = 20 * math.Pi / 180
+
+// Colours of the sky and its light at the extremes of the day
+var (
+	dayZenith     = [3]float32{0.32, 0.56, 0.92}
+	dayHorizon    = [3]float32{0.70, 0.84, 0.95}
+	nightZenith   = [3]float32{0.01, 0.01, 0.04}
+	nightHorizon  = [3]float32{0.04, 0.05, 0.10}
+	twilightGlow  = [3]float32{0.95, 0.50, 0.25}
+	noonSunlight  = [3]float32{1.00, 0.97, 0.90}
+	lowSunlight   = [3]float32{1.00, 0.62, 0.35}
+	moonlight     = [3]float32{0.55, 0.62, 0.85}
+	dayAmbient    = [3]float32{0.32, 0.35, 0.40}
+	nightAmbient  = [3]float32{0.05, 0.06, 0.10}
+	moonIntensity = float32(0.25)
+)
+
+// State is the sky and its lighting at one time of day. Directions point from
+// the world towards the light and are unit length; colours are linear RGB,
+// with light colours already scaled by their intensity.
+type State struct {
+	TimeOfDay float64
+
+	SunDirection  ceresmath.Vector3
+	MoonDirection ceresmath.Vector3
+
+	// LightDirection and LightColor are the direct light: the sun by day and
+	// the moon by night. The light fades out as either crosses the horizon,
+	// so shading never jumps when the direction changes.
+	LightDirection ceresmath.Vector3
+	LightColor     [3]float32
+	AmbientColor   [3]float32
+
+	// Daylight runs from 0 at night to 1 by day
+	Daylight float32
+
+	ZenithColor  [3]float32
+	HorizonColor [3]float32
+}
+
+// At returns the sky at a time of day, see TimeOfDay
+func At(timeOfDay float64) State {
+	sun := SunDirection(timeOfDay)
+	s := State{
+		TimeOfDay:     timeOfDay,
+		SunDirection:  sun,
+		MoonDirection: sun.Mul(-1),
+	}
+
+	height := sun.Y
+	s.Daylight = smoothstep(-0.1, 0.25, height)
+
+	// Twilight glows near the horizon while the sun is close to it
+	twilight := 1 - ceresmath.Clamp(ceresmath.Abs(height)/0.25, 0, 1)
+	s.ZenithColor = mix(nightZenith, dayZenith, s.Daylight)
+	s.HorizonColor = mix(mix(nightHorizon, dayHorizon, s.Daylight), twilightGlow, 0.6*twilight*twilight)
+	s.AmbientColor = mix(nightAmbient, dayAmbient, s.Daylight)
+
+	if height >= 0 {
+		s.LightDirection = sun
+		s.LightColor = scale(mix(lowSunlight, noonSunlight, smoothstep(0, 0.4, height)), smoothstep(0, 0.1, height))
+	} else {
+		s.LightDirection = s.MoonDirection
+		s.LightColor = scale(moonlight, moonIntensity*smoothstep(0, 0.1, -height))
+	}
+	return s
+}
+
+// AtTime returns the sky at a world time
+func AtTime(time uint64) State {
+	return At(TimeOfDay(time))
+}
+
+// SunDirection returns the direction towards the sun at a time of day. It
+// rises in the east (+X) at 0.25, is highest at noon and sets in the west.
+func SunDirection(timeOfDay float64) ceresmath.Vector3 {
+	angle := 2 * math.Pi * (timeOfDay - 0.25)
+	return ceresmath.NewVector3(
+		float32(math.Cos(angle)),
+		float32(math.Sin(angle)*math.Cos(axialTilt)),
+		float32(math.Sin(angle)*math.Sin(axialTilt)),
+	)
+}
+
+// Fog fades distant terrain into the horizon colour, from Start to End blocks
+// from the camera
+type Fog struct {
+	Color      [3]float32
+	Start, End float32
+}
+
+// fogStartFraction is how far into the render distance fog begins
+const fogStartFraction = 0.6
+
+// NewFog returns fog in the sky's horizon colour that hides everything at the
+// render distance, in blocks, so the edge of the loaded world is never seen
+func NewFog(s State, renderDistance float32) Fog {
+	return Fog{Color: s.HorizonColor, Start: renderDistance * fogStartFraction, End: renderDistance}
+}
+
+// Amount returns how much fog covers a point at a distance, from 0 to 1
+func (f Fog) Amount(distance float32) float32 {
+	if f.End <= f.Start {
+		return 0
+	}
+	return ceresmath.Clamp(ceresmath.InverseLerp(f.Start, f.End, distance), 0, 1)
+}
+
+// smoothstep eases from 0 at edge0 to 1 at edge1, as in GLSL
+func smoothstep(edge0, edge1, x float32) float32 {
+	t := ceresmath.Clamp((x-edge0)/(edge1-edge0), 0, 1)
+	return t * t * (3 - 2*t)
+}
+
+func mix(a, b [3]float32, t float32) [3]float32 {
+	for i := range a {
+		a[i] = ceresmath.Lerp(a[i], b[i], t)
+	}
+	return a
+}
+
+func scale(c [3]float32, s float32) [3]float32 {
+	for i := range c {
+		c[i] *= s
+	}
+	return c
+}
@@ -0,0 +1,95 @@
+package sky
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTimeOfDay(t *testing.T) {
+	if got := TimeOfDay(Noon); got != 0.5 {
+		t.Errorf("Expected noon at 0.5, got %f", got)
+	}
+	if got := TimeOfDay(3*TicksPerDay + Sunrise); got != 0.25 {
+		t.Errorf("Expected the time of day to wrap each day, got %f", got)
+	}
+	if got := Day(3*TicksPerDay + Sunset); got != 3 {
+		t.Errorf("Expected day 3, got %d", got)
+	}
+
+	// Setting the time moves forward to the next matching time
+	if got := SetTimeOfDay(TicksPerDay+Noon, Sunset); got != TicksPerDay+Sunset {
+		t.Errorf("Expected sunset the same day, got %d", got)
+	}
+	if got := SetTimeOfDay(TicksPerDay+Noon, Sunrise); got != 2*TicksPerDay+Sunrise {
+		t.Errorf("Expected sunrise the next day, got %d", got)
+	}
+}
+
+func TestSunPath(t *testing.T) {
+	for i := 0; i < 96; i++ {
+		dir := SunDirection(float64(i) / 96)
+		if length := dir.Length(); math.Abs(float64(length-1)) > 1e-5 {
+			t.Fatalf("Expected a unit sun direction, got length %f", length)
+		}
+	}
+
+	if sunrise := SunDirection(0.25); math.Abs(float64(sunrise.Y)) > 1e-6 || sunrise.X < 0.99 {
+		t.Errorf("Expected the sun to rise on the eastern horizon, got %v", sunrise)
+	}
+	if noon := SunDirection(0.5); noon.Y < 0.9 {
+		t.Errorf("Expected the sun high at noon, got %v", noon)
+	}
+	if sunset := SunDirection(0.75); math.Abs(float64(sunset.Y)) > 1e-6 || sunset.X > -0.99 {
+		t.Errorf("Expected the sun to set on the western horizon, got %v", sunset)
+	}
+
+	midnight := At(0)
+	if midnight.SunDirection.Y >= 0 || midnight.MoonDirection.Y < 0.9 || midnight.LightDirection != midnight.MoonDirection {
+		t.Errorf("Expected the moon to light the night, got %+v", midnight)
+	}
+}
+
+func TestDaylight(t *testing.T) {
+	noon, midnight := At(0.5), At(0)
+	if noon.Daylight != 1 || midnight.Daylight != 0 {
+		t.Errorf("Expected full daylight at noon and none at midnight, got %f and %f", noon.Daylight, midnight.Daylight)
+	}
+	if noon.AmbientColor[1] <= midnight.AmbientColor[1] || noon.ZenithColor[2] <= midnight.ZenithColor[2] {
+		t.Error("Expected the day to be brighter than the night")
+	}
+	if sunset := At(0.75); sunset.HorizonColor[0] <= sunset.HorizonColor[2] || noon.HorizonColor[0] >= noon.HorizonColor[2] {
+		t.Errorf("Expected a red horizon at sunset and a blue one at noon, got %v and %v", sunset.HorizonColor, noon.HorizonColor)
+	}
+
+	// Direct light changes smoothly through the day, including when it
+	// switches between the sun and the moon
+	const steps = 2400
+	previous := At(0)
+	for i := 1; i <= steps; i++ {
+		s := At(float64(i) / steps)
+		for c := range s.LightColor {
+			lit := s.LightColor[c] * s.LightDirection.Y
+			wasLit := previous.LightColor[c] * previous.LightDirection.Y
+			if math.Abs(float64(lit-wasLit)) > 0.02 {
+				t.Fatalf("Expected light on flat ground to change smoothly, jumped from %f to %f at %f", wasLit, lit, s.TimeOfDay)
+			}
+		}
+		previous = s
+	}
+}
+
+func TestFog(t *testing.T) {
+	fog := NewFog(At(0.5), 256)
+	if fog.End != 256 || fog.Start <= 0 || fog.Start >= fog.End {
+		t.Errorf("Expected fog to end at the render distance, got %+v", fog)
+	}
+	if fog.Color != At(0.5).HorizonColor {
+		t.Error("Expected fog in the horizon colour")
+	}
+	if fog.Amount(fog.Start/2) != 0 || fog.Amount(fog.End) != 1 || fog.Amount(1000) != 1 {
+		t.Error("Expected no fog up close and full fog at the render distance")
+	}
+	if amount := fog.Amount((fog.Start + fog.End) / 2); amount <= 0 || amount >= 1 {
+		t.Errorf("Expected partial fog between start and end, got %f", amount)
+	}
+}
@@ -0,0 +1,57 @@
+package tick
+
+import (
+	"Ceres/pkg/voxel"
+)
+
+// LeafSupportDistance is how far leaves may be from wood before they decay
+const LeafSupportDistance = 4
+
+// RegisterDefaultHandlers registers the built-in random updates: grass
+// spreading onto lit dirt and dying under opaque blocks, and leaf decay
+func RegisterDefaultHandlers(s *Scheduler) {
+	s.RegisterRandom(voxel.VoxelTypeGrass, GrassTick)
+	s.RegisterRandom(voxel.VoxelTypeLeaves, LeavesTick)
+}
+
+// GrassTick turns covered grass into dirt, otherwise spreads it to a random
+// nearby dirt block with a transparent block above
+func GrassTick(ctx *Context, pos voxel.VoxelPosition, v voxel.Voxel) {
+	up := voxel.NewVoxelPosition(0, 1, 0)
+	if above, ok := ctx.Voxel(pos.Add(up)); ok && above.IsOpaque() {
+		ctx.Chunks.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelTypeDirt))
+		return
+	}
+
+	target := pos.Add(voxel.NewVoxelPosition(
+		ctx.Rand.Int31n(3)-1,
+		ctx.Rand.Int31n(3)-1,
+		ctx.Rand.Int31n(3)-1,
+	))
+
+	dirt, ok := ctx.Voxel(target)
+	if !ok || dirt.Type != voxel.VoxelTypeDirt {
+		return
+	}
+	if above, ok := ctx.Voxel(target.Add(up)); ok && above.IsTransparent() {
+		ctx.Chunks.SetVoxel(target, voxel.NewVoxel(voxel.VoxelTypeGrass))
+	}
+}
+
+// LeavesTick removes leaves with no wood within LeafSupportDistance
+func LeavesTick(ctx *Context, pos voxel.VoxelPosition, v voxel.Voxel) {
+	const r = LeafSupportDistance
+	for dx := int32(-r); dx <= r; dx++ {
+		for dy := int32(-r); dy <= r; dy++ {
+			for dz := int32(-r); dz <= r; dz++ {
+				n, ok := ctx.Voxel(pos.Add(voxel.NewVoxelPosition(dx, dy, dz)))
+				// Unloaded chunks might hold the trunk, so keep the leaves
+				if !ok || n.Type == voxel.VoxelTypeWood {
+					return
+				}
+			}
+		}
+	}
+
+	ctx.Chunks.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelTypeAir))
+}
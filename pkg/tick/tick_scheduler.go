@@ -0,0 +1,172 @@
+package tick
+
+import (
+	"math/rand"
+	"sort"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// DefaultRandomTicksPerSection matches the rate at which grass spreading and
+// leaf decay look natural at 20 ticks per second
+const DefaultRandomTicksPerSection = 3
+
+// Context is passed to tick handlers
+type Context struct {
+	Chunks    *chunk.ChunkManager
+	Scheduler *Scheduler
+	Tick      uint64
+
+	// Rand is seeded from the scheduler seed and the tick, so runs are reproducible
+	Rand *rand.Rand
+}
+
+// Voxel returns the voxel at pos without creating its chunk, and false when
+// the chunk is not loaded
+func (ctx *Context) Voxel(pos voxel.VoxelPosition) (voxel.Voxel, bool) {
+	c := ctx.Chunks.GetChunkIfExists(chunk.VoxelToChunkPosition(pos))
+	if c == nil {
+		return voxel.Voxel{}, false
+	}
+
+	x, y, z := chunk.VoxelToLocalPosition(pos)
+	return c.GetVoxel(x, y, z), true
+}
+
+// Handler updates the voxel v at pos
+type Handler func(ctx *Context, pos voxel.VoxelPosition, v voxel.Voxel)
+
+// Scheduler runs block updates on the chunks of a ChunkManager. Scheduled
+// updates fire at a given tick and are stored in their chunk; random updates
+// hit RandomTicksPerSection random voxels of every chunk section each tick.
+// Handlers are chosen by the type of the voxel when the update runs.
+type Scheduler struct {
+	RandomTicksPerSection int
+
+	cm   *chunk.ChunkManager
+	seed int64
+	tick uint64
+
+	scheduledHandlers map[voxel.VoxelType]Handler
+	randomHandlers    map[voxel.VoxelType]Handler
+}
+
+// NewScheduler creates a tick scheduler for the chunk manager
+func NewScheduler(cm *chunk.ChunkManager, seed int64) *Scheduler {
+	return &Scheduler{
+		RandomTicksPerSection: DefaultRandomTicksPerSection,
+		cm:                    cm,
+		seed:                  seed,
+		scheduledHandlers:     make(map[voxel.VoxelType]Handler),
+		randomHandlers:        make(map[voxel.VoxelType]Handler),
+	}
+}
+
+// RegisterScheduled sets the handler for scheduled updates of a voxel type
+func (s *Scheduler) RegisterScheduled(voxelType voxel.VoxelType, handler Handler) {
+	s.scheduledHandlers[voxelType] = handler
+}
+
+// RegisterRandom sets the handler for random updates of a voxel type
+func (s *Scheduler) RegisterRandom(voxelType voxel.VoxelType, handler Handler) {
+	s.randomHandlers[voxelType] = handler
+}
+
+// CurrentTick returns the tick that runs next
+func (s *Scheduler) CurrentTick() uint64 {
+	return s.tick
+}
+
+// SetTick sets the current tick, e.g. when restoring a saved world whose
+// chunks hold scheduled updates at absolute ticks
+func (s *Scheduler) SetTick(tick uint64) {
+	s.tick = tick
+}
+
+// Schedule updates the voxel at pos after delay ticks. A delay of zero runs
+// on the current tick if it has not started yet, otherwise on the next one.
+// It returns false when the voxel's chunk is not loaded.
+func (s *Scheduler) Schedule(pos voxel.VoxelPosition, delay uint64) bool {
+	c := s.cm.GetChunkIfExists(chunk.VoxelToChunkPosition(pos))
+	if c == nil {
+		return false
+	}
+
+	x, y, z := chunk.VoxelToLocalPosition(pos)
+	c.ScheduleTick(x, y, z, s.tick+delay)
+	return true
+}
+
+// Tick runs the scheduled updates that are due and then the random updates
+func (s *Scheduler) Tick() {
+	chunks := s.cm.GetLoadedChunks()
+	sort.Slice(chunks, func(i, j int) bool {
+		return lessChunkPosition(chunks[i].Position, chunks[j].Position)
+	})
+
+	ctx := &Context{
+		Chunks:    s.cm,
+		Scheduler: s,
+		Tick:      s.tick,
+		Rand:      rand.New(rand.NewSource(s.seed ^ int64(s.tick)*0x5851f42d4c957f2d)),
+	}
+
+	var due []chunk.ScheduledTick
+	for _, c := range chunks {
+		due = append(due, c.TakeDueTicks(s.tick)...)
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Due < due[j].Due })
+
+	for _, scheduled := range due {
+		v, ok := ctx.Voxel(scheduled.Position)
+		if !ok {
+			continue
+		}
+		if handler, exists := s.scheduledHandlers[v.Type]; exists {
+			handler(ctx, scheduled.Position, v)
+		}
+	}
+
+	if len(s.randomHandlers) > 0 {
+		for _, c := range chunks {
+			s.randomTickChunk(ctx, c)
+		}
+	}
+
+	s.tick++
+}
+
+func (s *Scheduler) randomTickChunk(ctx *Context, c *chunk.Chunk) {
+	if c.IsEmpty() {
+		return
+	}
+
+	origin := c.GetWorldPosition()
+	for sx := int32(0); sx < chunk.SectionsPerAxis; sx++ {
+		for sy := int32(0); sy < chunk.SectionsPerAxis; sy++ {
+			for sz := int32(0); sz < chunk.SectionsPerAxis; sz++ {
+				for i := 0; i < s.RandomTicksPerSection; i++ {
+					x := sx*chunk.SectionSize + ctx.Rand.Int31n(chunk.SectionSize)
+					y := sy*chunk.SectionSize + ctx.Rand.Int31n(chunk.SectionSize)
+					z := sz*chunk.SectionSize + ctx.Rand.Int31n(chunk.SectionSize)
+
+					v := c.GetVoxel(x, y, z)
+					if handler, exists := s.randomHandlers[v.Type]; exists {
+						handler(ctx, origin.Add(voxel.NewVoxelPosition(x, y, z)), v)
+					}
+				}
+			}
+		}
+	}
+}
+
+func lessChunkPosition(a, b chunk.ChunkPosition) bool {
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.Z < b.Z
+}
@@ -0,0 +1,172 @@
+package tick
+
+import (
+	"bytes"
+	"testing"
+
+	"Ceres/internal/testworld"
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// newGrassWorld creates a dirt floor at y = 0 with a single grass block in the middle
+func newGrassWorld() *chunk.ChunkManager {
+	cm := chunk.NewChunkManager()
+	testworld.Floor(cm, 0, 0, 15, voxel.VoxelTypeDirt)
+	cm.SetVoxel(voxel.NewVoxelPosition(8, 0, 8), voxel.NewVoxel(voxel.VoxelTypeGrass))
+	return cm
+}
+
+func countType(cm *chunk.ChunkManager, voxelType voxel.VoxelType) int {
+	count := 0
+	for _, c := range cm.GetLoadedChunks() {
+		for x := int32(0); x < chunk.ChunkSize; x++ {
+			for y := int32(0); y < chunk.ChunkSize; y++ {
+				for z := int32(0); z < chunk.ChunkSize; z++ {
+					if c.GetVoxel(x, y, z).Type == voxelType {
+						count++
+					}
+				}
+			}
+		}
+	}
+	return count
+}
+
+func TestScheduledTickFiresWhenDue(t *testing.T) {
+	cm := chunk.NewChunkManager()
+	pos := voxel.NewVoxelPosition(1, 2, 3)
+	cm.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelTypeSand))
+
+	s := NewScheduler(cm, 1)
+	var firedAt []uint64
+	s.RegisterScheduled(voxel.VoxelTypeSand, func(ctx *Context, p voxel.VoxelPosition, v voxel.Voxel) {
+		if p != pos {
+			t.Errorf("Expected update at %v, got %v", pos, p)
+		}
+		firedAt = append(firedAt, ctx.Tick)
+	})
+
+	if !s.Schedule(pos, 3) {
+		t.Fatal("Expected the update to be scheduled")
+	}
+	if s.Schedule(voxel.NewVoxelPosition(100, 0, 0), 1) {
+		t.Error("Expected scheduling in an unloaded chunk to fail")
+	}
+
+	for i := 0; i < 6; i++ {
+		s.Tick()
+	}
+
+	if len(firedAt) != 1 || firedAt[0] != 3 {
+		t.Errorf("Expected one update at tick 3, got %v", firedAt)
+	}
+}
+
+func TestScheduledTicksPersistWithChunk(t *testing.T) {
+	cm := chunk.NewChunkManager()
+	pos := voxel.NewVoxelPosition(-5, 7, 40)
+	cm.SetVoxel(pos, voxel.NewVoxel(voxel.VoxelTypeSand))
+
+	s := NewScheduler(cm, 1)
+	s.SetTick(1000)
+	s.Schedule(pos, 10)
+
+	var buf bytes.Buffer
+	if err := cm.GetChunk(chunk.VoxelToChunkPosition(pos)).Encode(&buf); err != nil {
+		t.Fatalf("Failed to encode chunk: %v", err)
+	}
+
+	loaded, err := chunk.DecodeChunk(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode chunk: %v", err)
+	}
+	restored := chunk.NewChunkManager()
+	restored.InsertChunk(loaded)
+
+	if restored.GetVoxel(pos).Type != voxel.VoxelTypeSand {
+		t.Error("Expected voxels to survive encoding")
+	}
+
+	rs := NewScheduler(restored, 1)
+	rs.SetTick(1005)
+	fired := 0
+	rs.RegisterScheduled(voxel.VoxelTypeSand, func(ctx *Context, p voxel.VoxelPosition, v voxel.Voxel) {
+		if ctx.Tick != 1010 {
+			t.Errorf("Expected the restored update at tick 1010, got %d", ctx.Tick)
+		}
+		fired++
+	})
+	for i := 0; i < 10; i++ {
+		rs.Tick()
+	}
+
+	if fired != 1 {
+		t.Errorf("Expected the restored update to fire once, got %d", fired)
+	}
+}
+
+func TestRandomTicksAreDeterministic(t *testing.T) {
+	run := func(seed int64) *chunk.ChunkManager {
+		cm := newGrassWorld()
+		s := NewScheduler(cm, seed)
+		s.RandomTicksPerSection = 256
+		RegisterDefaultHandlers(s)
+		for i := 0; i < 100; i++ {
+			s.Tick()
+		}
+		return cm
+	}
+
+	a, b := run(42), run(42)
+	for x := int32(0); x < 16; x++ {
+		for z := int32(0); z < 16; z++ {
+			pos := voxel.NewVoxelPosition(x, 0, z)
+			if a.GetVoxel(pos) != b.GetVoxel(pos) {
+				t.Fatalf("Expected identical runs, differ at %v", pos)
+			}
+		}
+	}
+
+	if grass := countType(a, voxel.VoxelTypeGrass); grass <= 1 {
+		t.Errorf("Expected grass to spread, got %d grass blocks", grass)
+	}
+}
+
+func TestGrassDiesUnderOpaqueBlocks(t *testing.T) {
+	cm := newGrassWorld()
+	cm.SetVoxel(voxel.NewVoxelPosition(8, 1, 8), voxel.NewVoxel(voxel.VoxelTypeStone))
+
+	s := NewScheduler(cm, 3)
+	s.RandomTicksPerSection = 512
+	s.RegisterRandom(voxel.VoxelTypeGrass, GrassTick)
+	for i := 0; i < 2000 && cm.GetVoxel(voxel.NewVoxelPosition(8, 0, 8)).Type == voxel.VoxelTypeGrass; i++ {
+		s.Tick()
+	}
+
+	if cm.GetVoxel(voxel.NewVoxelPosition(8, 0, 8)).Type != voxel.VoxelTypeDirt {
+		t.Error("Expected covered grass to turn into dirt")
+	}
+}
+
+func TestLeavesDecayWithoutWood(t *testing.T) {
+	cm := chunk.NewChunkManager()
+	for x := int32(4); x < 12; x++ {
+		cm.SetVoxel(voxel.NewVoxelPosition(x, 10, 10), voxel.NewVoxel(voxel.VoxelTypeLeaves))
+	}
+	cm.SetVoxel(voxel.NewVoxelPosition(4, 9, 10), voxel.NewVoxel(voxel.VoxelTypeWood))
+
+	s := NewScheduler(cm, 9)
+	s.RandomTicksPerSection = 512
+	s.RegisterRandom(voxel.VoxelTypeLeaves, LeavesTick)
+	for i := 0; i < 200; i++ {
+		s.Tick()
+	}
+
+	for x := int32(4); x < 12; x++ {
+		leaves := cm.GetVoxel(voxel.NewVoxelPosition(x, 10, 10)).Type == voxel.VoxelTypeLeaves
+		if supported := x-4 <= LeafSupportDistance; leaves != supported {
+			t.Errorf("Expected leaves at x=%d to remain: %v, got %v", x, supported, leaves)
+		}
+	}
+}
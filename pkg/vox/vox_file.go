@@ -0,0 +1,264 @@
+package vox
+
+// MaxModelSize is the largest model edge length MagicaVoxel supports
+const MaxModelSize = 256
+
+// VoxColor is an RGBA palette entry
+type VoxColor struct {
+	R, G, B, A uint8
+}
+
+// VoxVoxel is a single voxel inside a model, in model-local coordinates (Z up)
+type VoxVoxel struct {
+	X, Y, Z    uint8
+	ColorIndex uint8
+}
+
+// VoxModel is one SIZE/XYZI pair of a .vox file
+type VoxModel struct {
+	SizeX, SizeY, SizeZ int32
+	Voxels              []VoxVoxel
+}
+
+// VoxNodeType identifies the kind of a scene graph node
+type VoxNodeType int
+
+const (
+	VoxNodeTransform VoxNodeType = iota
+	VoxNodeGroup
+	VoxNodeShape
+)
+
+// VoxNode is a node of the scene graph (nTRN, nGRP or nSHP chunk)
+type VoxNode struct {
+	ID         int32
+	Type       VoxNodeType
+	Attributes map[string]string
+
+	// Transform nodes
+	Child       int32
+	LayerID     int32
+	Rotation    uint8
+	Translation [3]int32
+
+	// Group nodes
+	Children []int32
+
+	// Shape nodes
+	Models []int32
+}
+
+// VoxFile is the in-memory representation of a MagicaVoxel .vox file
+type VoxFile struct {
+	Version int32
+	Models  []VoxModel
+
+	// Palette[i] is the colour of voxels with ColorIndex i; entry 0 is unused
+	Palette [256]VoxColor
+
+	// Nodes holds the scene graph keyed by node ID; it is empty for files
+	// without one, in which case every model is placed at the origin
+	Nodes map[int32]*VoxNode
+}
+
+// NewVoxFile creates an empty file using the default MagicaVoxel palette
+func NewVoxFile() *VoxFile {
+	return &VoxFile{
+		Version: 200,
+		Palette: DefaultPalette(),
+		Nodes:   make(map[int32]*VoxNode),
+	}
+}
+
+// DefaultPalette returns the palette MagicaVoxel uses when a file has no RGBA chunk
+func DefaultPalette() [256]VoxColor {
+	var palette [256]VoxColor
+
+	steps := []uint8{0xff, 0xcc, 0x99, 0x66, 0x33, 0x00}
+	index := 1
+	for _, r := range steps {
+		for _, g := range steps {
+			for _, b := range steps {
+				if r == 0 && g == 0 && b == 0 {
+					continue
+				}
+				palette[index] = VoxColor{R: r, G: g, B: b, A: 0xff}
+				index++
+			}
+		}
+	}
+
+	ramp := []uint8{0xee, 0xdd, 0xbb, 0xaa, 0x88, 0x77, 0x55, 0x44, 0x22, 0x11}
+	for _, v := range ramp {
+		palette[index] = VoxColor{R: v, A: 0xff}
+		index++
+	}
+	for _, v := range ramp {
+		palette[index] = VoxColor{G: v, A: 0xff}
+		index++
+	}
+	for _, v := range ramp {
+		palette[index] = VoxColor{B: v, A: 0xff}
+		index++
+	}
+	for _, v := range ramp {
+		palette[index] = VoxColor{R: v, G: v, B: v, A: 0xff}
+		index++
+	}
+
+	return palette
+}
+
+// VoxRotation is a 3x3 rotation matrix whose rows each hold a single ±1 entry
+type VoxRotation [3][3]int32
+
+// IdentityRotation is the rotation byte MagicaVoxel writes for no rotation
+const IdentityRotation uint8 = 1 << 2
+
+// DecodeRotation expands a packed MagicaVoxel rotation byte into a matrix
+func DecodeRotation(packed uint8) VoxRotation {
+	var rotation VoxRotation
+
+	first := int(packed & 3)
+	second := int((packed >> 2) & 3)
+	third := 3 - first - second
+	if first > 2 || second > 2 || first == second {
+		return identityMatrix()
+	}
+
+	columns := [3]int{first, second, third}
+	for row, column := range columns {
+		value := int32(1)
+		if packed&(1<<(4+uint(row))) != 0 {
+			value = -1
+		}
+		rotation[row][column] = value
+	}
+
+	return rotation
+}
+
+// EncodeRotation packs a rotation matrix into the MagicaVoxel byte format
+func EncodeRotation(rotation VoxRotation) uint8 {
+	var packed uint8
+	for row := 0; row < 3; row++ {
+		for column := 0; column < 3; column++ {
+			if rotation[row][column] == 0 {
+				continue
+			}
+			if row < 2 {
+				packed |= uint8(column) << (2 * uint(row))
+			}
+			if rotation[row][column] < 0 {
+				packed |= 1 << (4 + uint(row))
+			}
+		}
+	}
+	return packed
+}
+
+// Apply rotates an integer vector
+func (r VoxRotation) Apply(v [3]int32) [3]int32 {
+	return [3]int32{
+		r[0][0]*v[0] + r[0][1]*v[1] + r[0][2]*v[2],
+		r[1][0]*v[0] + r[1][1]*v[1] + r[1][2]*v[2],
+		r[2][0]*v[0] + r[2][1]*v[1] + r[2][2]*v[2],
+	}
+}
+
+// Mul returns the rotation r * other
+func (r VoxRotation) Mul(other VoxRotation) VoxRotation {
+	var result VoxRotation
+	for row := 0; row < 3; row++ {
+		for column := 0; column < 3; column++ {
+			for k := 0; k < 3; k++ {
+				result[row][column] += r[row][k] * other[k][column]
+			}
+		}
+	}
+	return result
+}
+
+func identityMatrix() VoxRotation {
+	return VoxRotation{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+}
+
+// VoxInstance is a model placed in the scene with its accumulated transform
+type VoxInstance struct {
+	Model       int
+	Rotation    VoxRotation
+	Translation [3]int32
+}
+
+// Instances flattens the scene graph into a list of placed models.
+// Files without a scene graph yield one untransformed instance per model.
+func (f *VoxFile) Instances() []VoxInstance {
+	root, hasRoot := f.Nodes[0]
+	if !hasRoot {
+		instances := make([]VoxInstance, len(f.Models))
+		for i := range f.Models {
+			instances[i] = VoxInstance{Model: i, Rotation: identityMatrix()}
+		}
+		return instances
+	}
+
+	instances := make([]VoxInstance, 0, len(f.Models))
+	visited := make(map[int32]bool)
+	f.collectInstances(root, identityMatrix(), [3]int32{}, visited, &instances)
+	return instances
+}
+
+func (f *VoxFile) collectInstances(node *VoxNode, rotation VoxRotation, translation [3]int32,
+	visited map[int32]bool, instances *[]VoxInstance) {
+
+	if node == nil || visited[node.ID] {
+		return
+	}
+	visited[node.ID] = true
+	defer delete(visited, node.ID)
+
+	switch node.Type {
+	case VoxNodeTransform:
+		if node.Attributes["_hidden"] == "1" {
+			return
+		}
+		offset := rotation.Apply(node.Translation)
+		childTranslation := [3]int32{
+			translation[0] + offset[0],
+			translation[1] + offset[1],
+			translation[2] + offset[2],
+		}
+		childRotation := rotation.Mul(DecodeRotation(node.Rotation))
+		f.collectInstances(f.Nodes[node.Child], childRotation, childTranslation, visited, instances)
+	case VoxNodeGroup:
+		for _, child := range node.Children {
+			f.collectInstances(f.Nodes[child], rotation, translation, visited, instances)
+		}
+	case VoxNodeShape:
+		for _, model := range node.Models {
+			if model < 0 || int(model) >= len(f.Models) {
+				continue
+			}
+			*instances = append(*instances, VoxInstance{
+				Model:       int(model),
+				Rotation:    rotation,
+				Translation: translation,
+			})
+		}
+	}
+}
+
+// Place returns the scene-space position of a model voxel under this instance
+func (inst VoxInstance) Place(model *VoxModel, v VoxVoxel) [3]int32 {
+	local := [3]int32{
+		int32(v.X) - model.SizeX/2,
+		int32(v.Y) - model.SizeY/2,
+		int32(v.Z) - model.SizeZ/2,
+	}
+	rotated := inst.Rotation.Apply(local)
+	return [3]int32{
+		rotated[0] + inst.Translation[0],
+		rotated[1] + inst.Translation[1],
+		rotated[2] + inst.Translation[2],
+	}
+}
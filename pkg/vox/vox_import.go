@@ -0,0 +1,237 @@
+package vox
+
+import (
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// ImportOptions controls how a .vox file is placed into a world
+type ImportOptions struct {
+	// Origin is the world position of the minimum corner of the scene
+	Origin voxel.VoxelPosition
+
+	// Mapping overrides the voxel type used for individual palette indices.
+	// Indices without an entry map to the registered type with the nearest colour.
+	Mapping map[uint8]voxel.VoxelType
+}
+
+// Import places every model of the file into the chunk manager and returns the
+// number of voxels written. MagicaVoxel is Z-up, so model Z becomes world Y.
+func Import(cm *chunk.ChunkManager, file *VoxFile, opts ImportOptions) int {
+	instances := file.Instances()
+	if len(instances) == 0 {
+		return 0
+	}
+
+	min, max := sceneBounds(file, instances)
+
+	paletteTypes := make(map[uint8]voxel.VoxelType)
+	resolveType := func(index uint8) voxel.VoxelType {
+		if voxelType, ok := opts.Mapping[index]; ok {
+			return voxelType
+		}
+		if voxelType, ok := paletteTypes[index]; ok {
+			return voxelType
+		}
+		voxelType := NearestVoxelType(file.Palette[index])
+		paletteTypes[index] = voxelType
+		return voxelType
+	}
+
+	written := 0
+	for _, inst := range instances {
+		model := &file.Models[inst.Model]
+		for _, v := range model.Voxels {
+			voxelType := resolveType(v.ColorIndex)
+			if voxelType == voxel.VoxelTypeAir {
+				continue
+			}
+
+			p := inst.Place(model, v)
+			worldPos := opts.Origin.Add(voxel.NewVoxelPosition(
+				p[0]-min[0],
+				p[2]-min[2],
+				max[1]-p[1],
+			))
+			cm.SetVoxel(worldPos, voxel.NewVoxel(voxelType))
+			written++
+		}
+	}
+
+	return written
+}
+
+// NearestVoxelType returns the registered cube-shaped voxel type, other than
+// air, whose colour is closest to the given palette colour
+func NearestVoxelType(c VoxColor) voxel.VoxelType {
+	best := voxel.VoxelTypeAir
+	bestDistance := float32(-1)
+
+	for _, voxelType := range voxel.RegisteredVoxelTypes() {
+		if voxelType == voxel.VoxelTypeAir {
+			continue
+		}
+		props, _ := voxel.GetVoxelProperties(voxelType)
+		if props.Shape != voxel.ShapeCube {
+			continue
+		}
+
+		dr := props.Color[0]*255 - float32(c.R)
+		dg := props.Color[1]*255 - float32(c.G)
+		db := props.Color[2]*255 - float32(c.B)
+		distance := dr*dr + dg*dg + db*db
+
+		if bestDistance < 0 || distance < bestDistance {
+			best = voxelType
+			bestDistance = distance
+		}
+	}
+
+	return best
+}
+
+// ExportMapping returns the palette index to voxel type mapping used by
+// Export. Passing it as ImportOptions.Mapping restores the exported types
+// exactly, including shapes and colours that colour matching would merge.
+func ExportMapping() map[uint8]voxel.VoxelType {
+	mapping := make(map[uint8]voxel.VoxelType)
+	for _, voxelType := range voxel.RegisteredVoxelTypes() {
+		if voxelType != voxel.VoxelTypeAir {
+			mapping[uint8(voxelType)] = voxelType
+		}
+	}
+	return mapping
+}
+
+// Export writes the inclusive world region [min, max] into a new VoxFile.
+// Regions larger than MaxModelSize are split into several models that are
+// positioned with scene graph transforms. Each voxel type is stored at the
+// palette index equal to its type value, coloured with the type's colour.
+// Voxel state such as fluid levels is not stored. Importing with
+// ExportMapping restores the types; without it every index maps to the
+// nearest cube-shaped colour, so slabs, plants and types sharing a colour
+// come back as a different type.
+func Export(cm *chunk.ChunkManager, min, max voxel.VoxelPosition) *VoxFile {
+	file := NewVoxFile()
+
+	for _, voxelType := range voxel.RegisteredVoxelTypes() {
+		if voxelType == voxel.VoxelTypeAir {
+			continue
+		}
+		props, _ := voxel.GetVoxelProperties(voxelType)
+		file.Palette[voxelType] = VoxColor{
+			R: uint8(props.Color[0]*255 + 0.5),
+			G: uint8(props.Color[1]*255 + 0.5),
+			B: uint8(props.Color[2]*255 + 0.5),
+			A: 0xff,
+		}
+	}
+
+	// Scene-space extents: X stays X, world Z becomes -Y and world Y becomes Z
+	sizeX := max.X - min.X + 1
+	sizeY := max.Z - min.Z + 1
+	sizeZ := max.Y - min.Y + 1
+	if sizeX <= 0 || sizeY <= 0 || sizeZ <= 0 {
+		return file
+	}
+
+	file.Nodes[0] = &VoxNode{ID: 0, Type: VoxNodeTransform, Child: 1, Rotation: IdentityRotation}
+	group := &VoxNode{ID: 1, Type: VoxNodeGroup}
+	file.Nodes[1] = group
+	nextID := int32(2)
+
+	for tx := int32(0); tx < sizeX; tx += MaxModelSize {
+		for ty := int32(0); ty < sizeY; ty += MaxModelSize {
+			for tz := int32(0); tz < sizeZ; tz += MaxModelSize {
+				model := VoxModel{
+					SizeX: minInt32(MaxModelSize, sizeX-tx),
+					SizeY: minInt32(MaxModelSize, sizeY-ty),
+					SizeZ: minInt32(MaxModelSize, sizeZ-tz),
+				}
+
+				for x := int32(0); x < model.SizeX; x++ {
+					for y := int32(0); y < model.SizeY; y++ {
+						for z := int32(0); z < model.SizeZ; z++ {
+							worldPos := voxel.NewVoxelPosition(
+								min.X+tx+x,
+								min.Y+tz+z,
+								max.Z-(ty+y),
+							)
+							v := getVoxelIfLoaded(cm, worldPos)
+							if v.IsAir() {
+								continue
+							}
+							model.Voxels = append(model.Voxels, VoxVoxel{
+								X: uint8(x), Y: uint8(y), Z: uint8(z),
+								ColorIndex: uint8(v.Type),
+							})
+						}
+					}
+				}
+
+				modelIndex := int32(len(file.Models))
+				file.Models = append(file.Models, model)
+
+				transformID, shapeID := nextID, nextID+1
+				nextID += 2
+				file.Nodes[transformID] = &VoxNode{
+					ID:       transformID,
+					Type:     VoxNodeTransform,
+					Child:    shapeID,
+					Rotation: IdentityRotation,
+					Translation: [3]int32{
+						tx + model.SizeX/2,
+						ty + model.SizeY/2,
+						tz + model.SizeZ/2,
+					},
+				}
+				file.Nodes[shapeID] = &VoxNode{ID: shapeID, Type: VoxNodeShape, Models: []int32{modelIndex}}
+				group.Children = append(group.Children, transformID)
+			}
+		}
+	}
+
+	return file
+}
+
+func getVoxelIfLoaded(cm *chunk.ChunkManager, pos voxel.VoxelPosition) voxel.Voxel {
+	c := cm.GetChunkIfExists(chunk.VoxelToChunkPosition(pos))
+	if c == nil {
+		return voxel.NewVoxel(voxel.VoxelTypeAir)
+	}
+
+	x, y, z := chunk.VoxelToLocalPosition(pos)
+	return c.GetVoxel(x, y, z)
+}
+
+// sceneBounds returns the inclusive scene-space bounding box of all instances
+func sceneBounds(file *VoxFile, instances []VoxInstance) (min, max [3]int32) {
+	first := true
+	for _, inst := range instances {
+		model := &file.Models[inst.Model]
+		corners := [2]VoxVoxel{
+			{},
+			{X: uint8(model.SizeX - 1), Y: uint8(model.SizeY - 1), Z: uint8(model.SizeZ - 1)},
+		}
+		for _, corner := range corners {
+			p := inst.Place(model, corner)
+			for axis := 0; axis < 3; axis++ {
+				if first || p[axis] < min[axis] {
+					min[axis] = p[axis]
+				}
+				if first || p[axis] > max[axis] {
+					max[axis] = p[axis]
+				}
+			}
+			first = false
+		}
+	}
+	return
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
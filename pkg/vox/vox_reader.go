@@ -0,0 +1,329 @@
+package vox
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type voxParser struct {
+	data   []byte
+	offset int
+}
+
+// Read parses a MagicaVoxel .vox file
+func Read(r io.Reader) (*VoxFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vox data: %w", err)
+	}
+
+	p := &voxParser{data: data}
+
+	magic, err := p.readID()
+	if err != nil {
+		return nil, err
+	}
+	if magic != "VOX " {
+		return nil, fmt.Errorf("invalid vox magic %q", magic)
+	}
+
+	version, err := p.readInt32()
+	if err != nil {
+		return nil, err
+	}
+
+	id, _, children, err := p.readChunk()
+	if err != nil {
+		return nil, err
+	}
+	if id != "MAIN" {
+		return nil, fmt.Errorf("expected MAIN chunk, got %q", id)
+	}
+
+	file := &VoxFile{
+		Version: version,
+		Palette: DefaultPalette(),
+		Nodes:   make(map[int32]*VoxNode),
+	}
+
+	if err := file.parseChildren(children); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// LoadFile reads a .vox file from disk
+func LoadFile(path string) (*VoxFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vox file: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+func (file *VoxFile) parseChildren(data []byte) error {
+	p := &voxParser{data: data}
+	var pendingSize *VoxModel
+
+	for p.remaining() > 0 {
+		id, content, _, err := p.readChunk()
+		if err != nil {
+			return err
+		}
+		c := &voxParser{data: content}
+
+		switch id {
+		case "SIZE":
+			x, errX := c.readInt32()
+			y, errY := c.readInt32()
+			z, errZ := c.readInt32()
+			if errX != nil || errY != nil || errZ != nil {
+				return fmt.Errorf("truncated SIZE chunk")
+			}
+			if x <= 0 || y <= 0 || z <= 0 || x > MaxModelSize || y > MaxModelSize || z > MaxModelSize {
+				return fmt.Errorf("invalid model size %dx%dx%d", x, y, z)
+			}
+			pendingSize = &VoxModel{SizeX: x, SizeY: y, SizeZ: z}
+		case "XYZI":
+			if pendingSize == nil {
+				return fmt.Errorf("XYZI chunk without preceding SIZE chunk")
+			}
+			count, err := c.readInt32()
+			if err != nil {
+				return fmt.Errorf("truncated XYZI chunk")
+			}
+			if count < 0 || int(count)*4 > c.remaining() {
+				return fmt.Errorf("XYZI voxel count %d exceeds chunk size", count)
+			}
+			model := *pendingSize
+			model.Voxels = make([]VoxVoxel, count)
+			for i := range model.Voxels {
+				b := c.data[c.offset : c.offset+4]
+				model.Voxels[i] = VoxVoxel{X: b[0], Y: b[1], Z: b[2], ColorIndex: b[3]}
+				c.offset += 4
+			}
+			file.Models = append(file.Models, model)
+			pendingSize = nil
+		case "RGBA":
+			if c.remaining() < 256*4 {
+				return fmt.Errorf("truncated RGBA chunk")
+			}
+			for i := 0; i < 255; i++ {
+				b := c.data[i*4 : i*4+4]
+				file.Palette[i+1] = VoxColor{R: b[0], G: b[1], B: b[2], A: b[3]}
+			}
+		case "nTRN":
+			node, err := c.readTransformNode()
+			if err != nil {
+				return err
+			}
+			file.Nodes[node.ID] = node
+		case "nGRP":
+			node, err := c.readGroupNode()
+			if err != nil {
+				return err
+			}
+			file.Nodes[node.ID] = node
+		case "nSHP":
+			node, err := c.readShapeNode()
+			if err != nil {
+				return err
+			}
+			file.Nodes[node.ID] = node
+		}
+	}
+
+	return nil
+}
+
+func (p *voxParser) readTransformNode() (*VoxNode, error) {
+	node := &VoxNode{Type: VoxNodeTransform, Rotation: IdentityRotation}
+
+	var err error
+	if node.ID, err = p.readInt32(); err != nil {
+		return nil, fmt.Errorf("truncated nTRN chunk")
+	}
+	if node.Attributes, err = p.readDict(); err != nil {
+		return nil, err
+	}
+	if node.Child, err = p.readInt32(); err != nil {
+		return nil, fmt.Errorf("truncated nTRN chunk")
+	}
+	if _, err = p.readInt32(); err != nil { // reserved id
+		return nil, fmt.Errorf("truncated nTRN chunk")
+	}
+	if node.LayerID, err = p.readInt32(); err != nil {
+		return nil, fmt.Errorf("truncated nTRN chunk")
+	}
+	frames, err := p.readInt32()
+	if err != nil {
+		return nil, fmt.Errorf("truncated nTRN chunk")
+	}
+
+	for i := int32(0); i < frames; i++ {
+		frame, err := p.readDict()
+		if err != nil {
+			return nil, err
+		}
+		// Only the first animation frame is used for placement
+		if i != 0 {
+			continue
+		}
+		if r, ok := frame["_r"]; ok {
+			value, err := strconv.Atoi(r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rotation %q: %w", r, err)
+			}
+			node.Rotation = uint8(value)
+		}
+		if t, ok := frame["_t"]; ok {
+			fields := strings.Fields(t)
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("invalid translation %q", t)
+			}
+			for axis, field := range fields {
+				value, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("invalid translation %q: %w", t, err)
+				}
+				node.Translation[axis] = int32(value)
+			}
+		}
+	}
+
+	return node, nil
+}
+
+func (p *voxParser) readGroupNode() (*VoxNode, error) {
+	node := &VoxNode{Type: VoxNodeGroup}
+
+	var err error
+	if node.ID, err = p.readInt32(); err != nil {
+		return nil, fmt.Errorf("truncated nGRP chunk")
+	}
+	if node.Attributes, err = p.readDict(); err != nil {
+		return nil, err
+	}
+	count, err := p.readInt32()
+	if err != nil || count < 0 || int(count)*4 > p.remaining() {
+		return nil, fmt.Errorf("invalid nGRP child count")
+	}
+	node.Children = make([]int32, count)
+	for i := range node.Children {
+		node.Children[i], _ = p.readInt32()
+	}
+
+	return node, nil
+}
+
+func (p *voxParser) readShapeNode() (*VoxNode, error) {
+	node := &VoxNode{Type: VoxNodeShape}
+
+	var err error
+	if node.ID, err = p.readInt32(); err != nil {
+		return nil, fmt.Errorf("truncated nSHP chunk")
+	}
+	if node.Attributes, err = p.readDict(); err != nil {
+		return nil, err
+	}
+	count, err := p.readInt32()
+	if err != nil || count < 0 || int(count)*4 > p.remaining() {
+		return nil, fmt.Errorf("invalid nSHP model count")
+	}
+	node.Models = make([]int32, count)
+	for i := range node.Models {
+		if node.Models[i], err = p.readInt32(); err != nil {
+			return nil, fmt.Errorf("truncated nSHP chunk")
+		}
+		if _, err = p.readDict(); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+func (p *voxParser) readChunk() (id string, content, children []byte, err error) {
+	if id, err = p.readID(); err != nil {
+		return
+	}
+	contentSize, err := p.readInt32()
+	if err != nil {
+		return
+	}
+	childrenSize, err := p.readInt32()
+	if err != nil {
+		return
+	}
+	if contentSize < 0 || childrenSize < 0 || int(contentSize)+int(childrenSize) > p.remaining() {
+		err = fmt.Errorf("chunk %q exceeds file size", id)
+		return
+	}
+
+	content = p.data[p.offset : p.offset+int(contentSize)]
+	p.offset += int(contentSize)
+	children = p.data[p.offset : p.offset+int(childrenSize)]
+	p.offset += int(childrenSize)
+	return
+}
+
+func (p *voxParser) readDict() (map[string]string, error) {
+	count, err := p.readInt32()
+	if err != nil || count < 0 || int(count)*8 > p.remaining() {
+		return nil, fmt.Errorf("invalid DICT entry count")
+	}
+
+	dict := make(map[string]string, count)
+	for i := int32(0); i < count; i++ {
+		key, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		dict[key] = value
+	}
+
+	return dict, nil
+}
+
+func (p *voxParser) readString() (string, error) {
+	length, err := p.readInt32()
+	if err != nil || length < 0 || int(length) > p.remaining() {
+		return "", fmt.Errorf("invalid string length")
+	}
+	s := string(p.data[p.offset : p.offset+int(length)])
+	p.offset += int(length)
+	return s, nil
+}
+
+func (p *voxParser) readID() (string, error) {
+	if p.remaining() < 4 {
+		return "", io.ErrUnexpectedEOF
+	}
+	id := string(p.data[p.offset : p.offset+4])
+	p.offset += 4
+	return id, nil
+}
+
+func (p *voxParser) readInt32() (int32, error) {
+	if p.remaining() < 4 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	value := int32(binary.LittleEndian.Uint32(p.data[p.offset:]))
+	p.offset += 4
+	return value, nil
+}
+
+func (p *voxParser) remaining() int {
+	return len(p.data) - p.offset
+}
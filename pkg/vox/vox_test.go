@@ -0,0 +1,225 @@
+package vox
+
+import (
+	"bytes"
+	"testing"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+func TestDefaultPalette(t *testing.T) {
+	palette := DefaultPalette()
+
+	if palette[0] != (VoxColor{}) {
+		t.Errorf("Expected palette entry 0 to be empty, got %v", palette[0])
+	}
+	if palette[1] != (VoxColor{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("Expected palette entry 1 to be white, got %v", palette[1])
+	}
+	if palette[255] != (VoxColor{0x11, 0x11, 0x11, 0xff}) {
+		t.Errorf("Expected palette entry 255 to be dark gray, got %v", palette[255])
+	}
+}
+
+func TestRotationRoundTrip(t *testing.T) {
+	for packed := 0; packed < 128; packed++ {
+		first := packed & 3
+		second := (packed >> 2) & 3
+		if first > 2 || second > 2 || first == second {
+			continue
+		}
+
+		rotation := DecodeRotation(uint8(packed))
+		if encoded := EncodeRotation(rotation); encoded != uint8(packed) {
+			t.Errorf("Rotation %d: expected re-encoding to match, got %d", packed, encoded)
+		}
+	}
+
+	identity := DecodeRotation(IdentityRotation)
+	if identity != identityMatrix() {
+		t.Errorf("Expected identity rotation, got %v", identity)
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	file := NewVoxFile()
+	file.Models = []VoxModel{
+		{SizeX: 2, SizeY: 3, SizeZ: 4, Voxels: []VoxVoxel{{0, 0, 0, 1}, {1, 2, 3, 7}}},
+		{SizeX: 1, SizeY: 1, SizeZ: 1, Voxels: []VoxVoxel{{0, 0, 0, 42}}},
+	}
+	file.Palette[7] = VoxColor{R: 10, G: 20, B: 30, A: 255}
+	file.Nodes[0] = &VoxNode{ID: 0, Type: VoxNodeTransform, Child: 1, Rotation: IdentityRotation}
+	file.Nodes[1] = &VoxNode{ID: 1, Type: VoxNodeGroup, Children: []int32{2, 4}}
+	file.Nodes[2] = &VoxNode{ID: 2, Type: VoxNodeTransform, Child: 3, Rotation: IdentityRotation}
+	file.Nodes[3] = &VoxNode{ID: 3, Type: VoxNodeShape, Models: []int32{0}}
+	file.Nodes[4] = &VoxNode{ID: 4, Type: VoxNodeTransform, Child: 5, Rotation: 0x11, Translation: [3]int32{5, -6, 7}}
+	file.Nodes[5] = &VoxNode{ID: 5, Type: VoxNodeShape, Models: []int32{1}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, file); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if len(read.Models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(read.Models))
+	}
+	if read.Models[0].SizeX != 2 || read.Models[0].SizeY != 3 || read.Models[0].SizeZ != 4 {
+		t.Errorf("Expected model size 2x3x4, got %dx%dx%d",
+			read.Models[0].SizeX, read.Models[0].SizeY, read.Models[0].SizeZ)
+	}
+	if len(read.Models[0].Voxels) != 2 || read.Models[0].Voxels[1] != (VoxVoxel{1, 2, 3, 7}) {
+		t.Errorf("Expected voxels to round trip, got %v", read.Models[0].Voxels)
+	}
+	if read.Palette[7] != file.Palette[7] {
+		t.Errorf("Expected palette entry %v, got %v", file.Palette[7], read.Palette[7])
+	}
+
+	node := read.Nodes[4]
+	if node == nil || node.Rotation != 0x11 || node.Translation != [3]int32{5, -6, 7} {
+		t.Errorf("Expected transform node to round trip, got %+v", node)
+	}
+
+	instances := read.Instances()
+	if len(instances) != 2 {
+		t.Fatalf("Expected 2 instances, got %d", len(instances))
+	}
+	if instances[1].Translation != [3]int32{5, -6, 7} {
+		t.Errorf("Expected instance translation (5, -6, 7), got %v", instances[1].Translation)
+	}
+}
+
+func TestReadRejectsInvalidData(t *testing.T) {
+	tests := [][]byte{
+		[]byte("NOPE"),
+		[]byte("VOX \xc8\x00\x00\x00MAIN\x00\x00\x00\x00\xff\x00\x00\x00"),
+	}
+
+	for i, data := range tests {
+		if _, err := Read(bytes.NewReader(data)); err == nil {
+			t.Errorf("Case %d: expected an error for invalid data", i)
+		}
+	}
+}
+
+func TestNearestVoxelType(t *testing.T) {
+	if got := NearestVoxelType(VoxColor{R: 128, G: 128, B: 128, A: 255}); got != voxel.VoxelTypeStone {
+		t.Errorf("Expected gray to map to Stone, got %v", got)
+	}
+	if got := NearestVoxelType(VoxColor{R: 50, G: 200, B: 50, A: 255}); got != voxel.VoxelTypeGrass {
+		t.Errorf("Expected green to map to Grass, got %v", got)
+	}
+}
+
+func TestImportMappingAndOrigin(t *testing.T) {
+	file := NewVoxFile()
+	file.Models = []VoxModel{
+		{SizeX: 2, SizeY: 2, SizeZ: 2, Voxels: []VoxVoxel{{0, 1, 0, 3}, {1, 0, 1, 4}}},
+	}
+
+	cm := chunk.NewChunkManager()
+	origin := voxel.NewVoxelPosition(-1, 10, 31)
+	written := Import(cm, file, ImportOptions{
+		Origin:  origin,
+		Mapping: map[uint8]voxel.VoxelType{3: voxel.VoxelTypeBrick, 4: voxel.VoxelTypeSand},
+	})
+
+	if written != 2 {
+		t.Fatalf("Expected 2 voxels written, got %d", written)
+	}
+
+	// Model (x, y, z) lands at origin + (x, z, maxY - y)
+	if v := cm.GetVoxel(origin.Add(voxel.NewVoxelPosition(0, 0, 0))); v.Type != voxel.VoxelTypeBrick {
+		t.Errorf("Expected Brick at origin, got %s", v.GetName())
+	}
+	if v := cm.GetVoxel(origin.Add(voxel.NewVoxelPosition(1, 1, 1))); v.Type != voxel.VoxelTypeSand {
+		t.Errorf("Expected Sand at origin+(1,1,1), got %s", v.GetName())
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source := chunk.NewChunkManager()
+	min := voxel.NewVoxelPosition(-20, -3, 250)
+	max := voxel.NewVoxelPosition(270, 4, 262)
+
+	placed := map[voxel.VoxelPosition]voxel.VoxelType{
+		min:                                  voxel.VoxelTypeStone,
+		max:                                  voxel.VoxelTypeBrick,
+		voxel.NewVoxelPosition(0, 0, 256):    voxel.VoxelTypeGrass,
+		voxel.NewVoxelPosition(240, 2, 255):  voxel.VoxelTypeWater,
+		voxel.NewVoxelPosition(-20, 4, 262):  voxel.VoxelTypeGlass,
+		voxel.NewVoxelPosition(236, -3, 250): voxel.VoxelTypeLeaves,
+	}
+	for pos, voxelType := range placed {
+		source.SetVoxel(pos, voxel.NewVoxel(voxelType))
+	}
+
+	file := Export(source, min, max)
+	if len(file.Models) != 2 {
+		t.Fatalf("Expected region to be split into 2 models, got %d", len(file.Models))
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, file); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	target := chunk.NewChunkManager()
+	written := Import(target, read, ImportOptions{Origin: min})
+	if written != len(placed) {
+		t.Errorf("Expected %d voxels imported, got %d", len(placed), written)
+	}
+
+	for pos, voxelType := range placed {
+		if v := target.GetVoxel(pos); v.Type != voxelType {
+			t.Errorf("At %v: expected %v, got %v", pos, voxelType, v.Type)
+		}
+	}
+}
+
+func TestExportImportLosesShapesWithoutMapping(t *testing.T) {
+	source := chunk.NewChunkManager()
+	placed := map[voxel.VoxelPosition]voxel.Voxel{
+		voxel.NewVoxelPosition(0, 0, 0): voxel.NewVoxel(voxel.VoxelTypeStoneSlab),
+		voxel.NewVoxelPosition(1, 0, 0): voxel.NewVoxel(voxel.VoxelTypeTallGrass),
+		voxel.NewVoxelPosition(2, 0, 0): voxel.NewVoxel(voxel.VoxelTypeFlower),
+		voxel.NewVoxelPosition(3, 0, 0): voxel.NewVoxel(voxel.VoxelTypeWoodFence),
+		voxel.NewVoxelPosition(4, 0, 0): {Type: voxel.VoxelTypeWater, State: 5},
+	}
+	for pos, v := range placed {
+		source.SetVoxel(pos, v)
+	}
+	file := Export(source, voxel.NewVoxelPosition(0, 0, 0), voxel.NewVoxelPosition(4, 0, 0))
+
+	// Colour matching only picks cube shapes
+	matched := chunk.NewChunkManager()
+	Import(matched, file, ImportOptions{})
+	if v := matched.GetVoxel(voxel.NewVoxelPosition(0, 0, 0)); v.Type != voxel.VoxelTypeStone {
+		t.Errorf("Expected a slab without mapping to import as Stone, got %s", v.GetName())
+	}
+	if v := matched.GetVoxel(voxel.NewVoxelPosition(1, 0, 0)); v.Type != voxel.VoxelTypeGrass {
+		t.Errorf("Expected tall grass without mapping to import as Grass, got %s", v.GetName())
+	}
+
+	// The export mapping restores every type but not the state
+	mapped := chunk.NewChunkManager()
+	Import(mapped, file, ImportOptions{Mapping: ExportMapping()})
+	for pos, v := range placed {
+		got := mapped.GetVoxel(pos)
+		if got.Type != v.Type {
+			t.Errorf("At %v: expected %s, got %s", pos, v.GetName(), got.GetName())
+		}
+		if got.State != 0 {
+			t.Errorf("At %v: expected state to be dropped, got %d", pos, got.State)
+		}
+	}
+}
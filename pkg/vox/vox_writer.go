@@ -0,0 +1,148 @@
+package vox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Write encodes a VoxFile in the MagicaVoxel .vox format
+func Write(w io.Writer, file *VoxFile) error {
+	var children bytes.Buffer
+
+	for i, model := range file.Models {
+		if model.SizeX <= 0 || model.SizeY <= 0 || model.SizeZ <= 0 ||
+			model.SizeX > MaxModelSize || model.SizeY > MaxModelSize || model.SizeZ > MaxModelSize {
+			return fmt.Errorf("model %d has invalid size %dx%dx%d", i, model.SizeX, model.SizeY, model.SizeZ)
+		}
+
+		var size bytes.Buffer
+		writeInt32(&size, model.SizeX)
+		writeInt32(&size, model.SizeY)
+		writeInt32(&size, model.SizeZ)
+		writeChunk(&children, "SIZE", size.Bytes(), nil)
+
+		var xyzi bytes.Buffer
+		writeInt32(&xyzi, int32(len(model.Voxels)))
+		for _, v := range model.Voxels {
+			xyzi.Write([]byte{v.X, v.Y, v.Z, v.ColorIndex})
+		}
+		writeChunk(&children, "XYZI", xyzi.Bytes(), nil)
+	}
+
+	ids := make([]int32, 0, len(file.Nodes))
+	for id := range file.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		node := file.Nodes[id]
+		var content bytes.Buffer
+		writeInt32(&content, node.ID)
+		writeDict(&content, node.Attributes)
+
+		switch node.Type {
+		case VoxNodeTransform:
+			writeInt32(&content, node.Child)
+			writeInt32(&content, -1)
+			writeInt32(&content, node.LayerID)
+			writeInt32(&content, 1)
+			frame := map[string]string{
+				"_r": strconv.Itoa(int(node.Rotation)),
+				"_t": fmt.Sprintf("%d %d %d", node.Translation[0], node.Translation[1], node.Translation[2]),
+			}
+			writeDict(&content, frame)
+			writeChunk(&children, "nTRN", content.Bytes(), nil)
+		case VoxNodeGroup:
+			writeInt32(&content, int32(len(node.Children)))
+			for _, child := range node.Children {
+				writeInt32(&content, child)
+			}
+			writeChunk(&children, "nGRP", content.Bytes(), nil)
+		case VoxNodeShape:
+			writeInt32(&content, int32(len(node.Models)))
+			for _, model := range node.Models {
+				writeInt32(&content, model)
+				writeDict(&content, nil)
+			}
+			writeChunk(&children, "nSHP", content.Bytes(), nil)
+		}
+	}
+
+	var rgba bytes.Buffer
+	for i := 1; i <= 256; i++ {
+		var c VoxColor
+		if i < 256 {
+			c = file.Palette[i]
+		}
+		rgba.Write([]byte{c.R, c.G, c.B, c.A})
+	}
+	writeChunk(&children, "RGBA", rgba.Bytes(), nil)
+
+	var out bytes.Buffer
+	out.WriteString("VOX ")
+	version := file.Version
+	if version == 0 {
+		version = 200
+	}
+	writeInt32(&out, version)
+	writeChunk(&out, "MAIN", nil, children.Bytes())
+
+	if _, err := w.Write(out.Bytes()); err != nil {
+		return fmt.Errorf("failed to write vox data: %w", err)
+	}
+	return nil
+}
+
+// SaveFile writes a .vox file to disk
+func SaveFile(path string, file *VoxFile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create vox file: %w", err)
+	}
+
+	if err := Write(f, file); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func writeChunk(buf *bytes.Buffer, id string, content, children []byte) {
+	buf.WriteString(id)
+	writeInt32(buf, int32(len(content)))
+	writeInt32(buf, int32(len(children)))
+	buf.Write(content)
+	buf.Write(children)
+}
+
+func writeDict(buf *bytes.Buffer, dict map[string]string) {
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	writeInt32(buf, int32(len(keys)))
+	for _, key := range keys {
+		writeString(buf, key)
+		writeString(buf, dict[key])
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeInt32(buf, int32(len(s)))
+	buf.WriteString(s)
+}
+
+func writeInt32(buf *bytes.Buffer, value int32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(value))
+	buf.Write(b[:])
+}
@@ -18,11 +18,22 @@ const (
 	VoxelTypeLeaves
 	VoxelTypeGlass
 	VoxelTypeBrick
+	VoxelTypeCoalOre
+	VoxelTypeIronOre
+	VoxelTypeGoldOre
+	VoxelTypeStoneSlab
+	VoxelTypeStoneStairs
+	VoxelTypeWoodFence
+	VoxelTypeFlower
+	VoxelTypeTallGrass
 )
 
 // Voxel represents a single voxel in the world
 type Voxel struct {
 	Type VoxelType
+
+	// State holds per-voxel data whose meaning depends on the type, such as fluid levels
+	State uint8
 }
 
 // NewVoxel creates a new voxel with the specified type
@@ -44,40 +55,20 @@ func (v Voxel) IsSolid() bool {
 
 // IsTransparent checks if the voxel is transparent
 func (v Voxel) IsTransparent() bool {
-	return v.Type == VoxelTypeAir || v.Type == VoxelTypeWater || v.Type == VoxelTypeGlass
+	return CurrentPropertyTable().IsTransparent(v)
 }
 
-// IsOpaque checks if the voxel is opaque (blocks light and visibility)
+// IsOpaque checks if the voxel is an opaque cube (blocks light and visibility)
 func (v Voxel) IsOpaque() bool {
-	return !v.IsTransparent()
+	return CurrentPropertyTable().IsOpaque(v)
 }
 
 // GetName returns the name of the voxel type
 func (v Voxel) GetName() string {
-	switch v.Type {
-	case VoxelTypeAir:
-		return "Air"
-	case VoxelTypeStone:
-		return "Stone"
-	case VoxelTypeDirt:
-		return "Dirt"
-	case VoxelTypeGrass:
-		return "Grass"
-	case VoxelTypeSand:
-		return "Sand"
-	case VoxelTypeWater:
-		return "Water"
-	case VoxelTypeWood:
-		return "Wood"
-	case VoxelTypeLeaves:
-		return "Leaves"
-	case VoxelTypeGlass:
-		return "Glass"
-	case VoxelTypeBrick:
-		return "Brick"
-	default:
-		return "Unknown"
+	if props, exists := CurrentPropertyTable().Get(v.Type); exists {
+		return props.Name
 	}
+	return "Unknown"
 }
 
 // VoxelPosition represents a 3D position in voxel space (integer coordinates)
@@ -0,0 +1,51 @@
+package voxel
+
+// Fluid voxels store their distance from the nearest source in the low bits
+// of State and whether they are falling in FluidFallingBit. A distance of
+// zero without the falling bit is a source, so fluid placed with NewVoxel
+// (or generated as static water) is always a source.
+const (
+	FluidDistanceMask uint8 = 0x07
+	FluidFallingBit   uint8 = 0x08
+
+	// FluidMaxDistance is the furthest a flowing fluid can be from its source
+	FluidMaxDistance uint8 = 7
+)
+
+// NewFluidVoxel creates a flowing fluid voxel at the given distance from its source
+func NewFluidVoxel(voxelType VoxelType, distance uint8, falling bool) Voxel {
+	state := distance & FluidDistanceMask
+	if falling {
+		state |= FluidFallingBit
+	}
+	return Voxel{Type: voxelType, State: state}
+}
+
+// IsFluid checks if the voxel's type is registered as a fluid
+func (v Voxel) IsFluid() bool {
+	return CurrentPropertyTable().IsFluid(v)
+}
+
+// FluidDistance returns how far a flowing fluid is from its source
+func (v Voxel) FluidDistance() uint8 {
+	return v.State & FluidDistanceMask
+}
+
+// IsFluidFalling checks if a fluid voxel is fed from above
+func (v Voxel) IsFluidFalling() bool {
+	return v.State&FluidFallingBit != 0
+}
+
+// IsFluidSource checks if a fluid voxel is a source block
+func (v Voxel) IsFluidSource() bool {
+	return v.State == 0
+}
+
+// FluidHeight returns the surface height of a fluid voxel in [0, 1]. Falling
+// fluid fills the voxel; sources and flowing fluid get lower with distance.
+func (v Voxel) FluidHeight() float32 {
+	if v.IsFluidFalling() {
+		return 1
+	}
+	return float32(FluidMaxDistance+1-v.FluidDistance()) / float32(FluidMaxDistance+2)
+}
@@ -0,0 +1,189 @@
+package voxel
+
+// BlockShape selects the model a voxel type is drawn and collides with
+type BlockShape uint8
+
+const (
+	// ShapeCube fills the whole voxel
+	ShapeCube BlockShape = iota
+	// ShapeSlab fills the lower half of the voxel, or the upper half with SlabTopBit
+	ShapeSlab
+	// ShapeStairs is a slab with a step on the side given by StairsFacingMask
+	ShapeStairs
+	// ShapeFence is a post with rails towards neighbouring fences and full blocks
+	ShapeFence
+	// ShapeCross is two quads crossing along the voxel's diagonals, for plants.
+	// Crosses have no boxes, so nothing collides with them.
+	ShapeCross
+)
+
+// State bits of shaped blocks
+const (
+	// SlabTopBit places a slab in the upper half of its voxel
+	SlabTopBit uint8 = 0x01
+	// StairsFacingMask holds the index into HorizontalFaces of the side the
+	// step is on
+	StairsFacingMask uint8 = 0x03
+	// StairsUpsideDownBit hangs the stairs from the top of the voxel
+	StairsUpsideDownBit uint8 = 0x04
+)
+
+// HorizontalFaces are the four side faces, in the order StairsFacingMask uses
+var HorizontalFaces = [4]VoxelFace{VoxelFaceBack, VoxelFaceRight, VoxelFaceFront, VoxelFaceLeft}
+
+// Box is an axis-aligned box inside a voxel, from 0 to 1 on each axis
+type Box struct {
+	Min, Max [3]float32
+}
+
+// NewBox creates a box from its minimum and maximum corners
+func NewBox(minX, minY, minZ, maxX, maxY, maxZ float32) Box {
+	return Box{Min: [3]float32{minX, minY, minZ}, Max: [3]float32{maxX, maxY, maxZ}}
+}
+
+// FullBox fills the whole voxel
+var FullBox = NewBox(0, 0, 0, 1, 1, 1)
+
+// crossSelectionBox is what rays hit on a cross-shaped plant
+var crossSelectionBox = NewBox(0.125, 0, 0.125, 0.875, 0.875, 0.875)
+
+// Fence parts, in sixteenths of a voxel
+const (
+	fencePostMin = 6.0 / 16
+	fencePostMax = 10.0 / 16
+	fenceRailMin = 7.0 / 16
+	fenceRailMax = 9.0 / 16
+)
+
+// fenceRailHeights are the bottom and top of each fence rail
+var fenceRailHeights = [2][2]float32{{6.0 / 16, 9.0 / 16}, {12.0 / 16, 15.0 / 16}}
+
+// NeighborFunc returns the voxel next to a block through one of its faces
+type NeighborFunc func(face VoxelFace) Voxel
+
+// Shape returns the shape of a voxel's type; unregistered types are cubes
+func (t *PropertyTable) Shape(v Voxel) BlockShape {
+	return t.props[v.Type].Shape
+}
+
+// Boxes returns the boxes a voxel is drawn and collides with. Air, fluids and
+// crosses have none. Fences look up their side neighbours to place rails;
+// other shapes never call neighbor, which may then be nil.
+func (t *PropertyTable) Boxes(v Voxel, neighbor NeighborFunc) []Box {
+	if v.IsAir() || t.IsFluid(v) {
+		return nil
+	}
+
+	switch t.Shape(v) {
+	case ShapeSlab:
+		if v.State&SlabTopBit != 0 {
+			return []Box{NewBox(0, 0.5, 0, 1, 1, 1)}
+		}
+		return []Box{NewBox(0, 0, 0, 1, 0.5, 1)}
+	case ShapeStairs:
+		return stairsBoxes(v.State)
+	case ShapeFence:
+		return t.fenceBoxes(neighbor)
+	case ShapeCross:
+		return nil
+	default:
+		return []Box{FullBox}
+	}
+}
+
+// SelectionBoxes returns the boxes rays hit: the model's boxes, or a box
+// around the plant for crosses
+func (t *PropertyTable) SelectionBoxes(v Voxel, neighbor NeighborFunc) []Box {
+	if !v.IsAir() && t.Shape(v) == ShapeCross {
+		return []Box{crossSelectionBox}
+	}
+	return t.Boxes(v, neighbor)
+}
+
+// FullFaces returns a bit per face the voxel's model covers completely, which
+// hides the neighbouring face unless the voxel is transparent
+func (t *PropertyTable) FullFaces(v Voxel) uint8 {
+	if v.IsAir() || t.IsFluid(v) {
+		return 0
+	}
+
+	switch t.Shape(v) {
+	case ShapeSlab:
+		if v.State&SlabTopBit != 0 {
+			return 1 << VoxelFaceTop
+		}
+		return 1 << VoxelFaceBottom
+	case ShapeStairs:
+		faces := uint8(1) << HorizontalFaces[v.State&StairsFacingMask]
+		if v.State&StairsUpsideDownBit != 0 {
+			return faces | 1<<VoxelFaceTop
+		}
+		return faces | 1<<VoxelFaceBottom
+	case ShapeFence, ShapeCross:
+		return 0
+	default:
+		return 1<<6 - 1
+	}
+}
+
+// Hides reports whether a voxel hides the face of its neighbour that touches
+// it through the given face of the voxel
+func (t *PropertyTable) Hides(v Voxel, face VoxelFace) bool {
+	return !t.IsTransparent(v) && t.FullFaces(v)&(1<<face) != 0
+}
+
+// stairsBoxes returns a slab and the half-height step on the facing side
+func stairsBoxes(state uint8) []Box {
+	slab := NewBox(0, 0, 0, 1, 0.5, 1)
+	step := NewBox(0, 0.5, 0, 1, 1, 1)
+	if state&StairsUpsideDownBit != 0 {
+		slab, step = step, slab
+	}
+
+	switch HorizontalFaces[state&StairsFacingMask] {
+	case VoxelFaceBack:
+		step.Max[2] = 0.5
+	case VoxelFaceRight:
+		step.Min[0] = 0.5
+	case VoxelFaceFront:
+		step.Min[2] = 0.5
+	case VoxelFaceLeft:
+		step.Max[0] = 0.5
+	}
+	return []Box{slab, step}
+}
+
+// fenceBoxes returns a post and two rails towards each neighbour the fence
+// connects to
+func (t *PropertyTable) fenceBoxes(neighbor NeighborFunc) []Box {
+	boxes := []Box{NewBox(fencePostMin, 0, fencePostMin, fencePostMax, 1, fencePostMax)}
+	if neighbor == nil {
+		return boxes
+	}
+
+	for _, face := range HorizontalFaces {
+		if !t.fenceConnects(neighbor(face)) {
+			continue
+		}
+		for _, rail := range fenceRailHeights {
+			box := NewBox(fenceRailMin, rail[0], fenceRailMin, fenceRailMax, rail[1], fenceRailMax)
+			switch face {
+			case VoxelFaceBack:
+				box.Min[2], box.Max[2] = 0, fencePostMin
+			case VoxelFaceRight:
+				box.Min[0], box.Max[0] = fencePostMax, 1
+			case VoxelFaceFront:
+				box.Min[2], box.Max[2] = fencePostMax, 1
+			case VoxelFaceLeft:
+				box.Min[0], box.Max[0] = 0, fencePostMin
+			}
+			boxes = append(boxes, box)
+		}
+	}
+	return boxes
+}
+
+// fenceConnects reports whether a fence reaches out to a neighbour
+func (t *PropertyTable) fenceConnects(neighbor Voxel) bool {
+	return t.Shape(neighbor) == ShapeFence || t.IsOpaque(neighbor)
+}
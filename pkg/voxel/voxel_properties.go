@@ -0,0 +1,145 @@
+package voxel
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// VoxelProperties describes how a voxel type looks and behaves
+type VoxelProperties struct {
+	Name        string
+	Color       [3]float32
+	Transparent bool
+
+	// Tinted types have their colour multiplied by a per-position tint, e.g. biome grass colour
+	Tinted bool
+
+	// Fluid types flow; see voxel_fluid.go for how their level is stored in State
+	Fluid bool
+	// FluidSpread is how many voxels a fluid flows sideways from a source (at most FluidMaxDistance)
+	FluidSpread uint8
+	// FluidTickDelay is the number of ticks between fluid updates
+	FluidTickDelay uint32
+	// InfiniteSource fluids turn into a source between two or more sources
+	InfiniteSource bool
+
+	// Gravity types fall when nothing supports them from below
+	Gravity bool
+
+	// BlastResistance weakens explosion rays passing through the block
+	BlastResistance float32
+
+	// Shape is the model the type is drawn and collides with, see voxel_model.go
+	Shape BlockShape
+}
+
+var (
+	voxelProperties = map[VoxelType]VoxelProperties{
+		VoxelTypeAir:   {Name: "Air", Color: [3]float32{1.0, 1.0, 1.0}, Transparent: true},
+		VoxelTypeStone: {Name: "Stone", Color: [3]float32{0.5, 0.5, 0.5}, BlastResistance: 6},
+		VoxelTypeDirt:  {Name: "Dirt", Color: [3]float32{0.55, 0.35, 0.2}, BlastResistance: 0.5},
+		VoxelTypeGrass: {Name: "Grass", Color: [3]float32{0.2, 0.8, 0.2}, Tinted: true, BlastResistance: 0.6},
+		VoxelTypeSand:  {Name: "Sand", Color: [3]float32{0.95, 0.9, 0.6}, Gravity: true, BlastResistance: 0.5},
+		VoxelTypeWater: {
+			Name: "Water", Color: [3]float32{0.2, 0.4, 0.9}, Transparent: true,
+			Fluid: true, FluidSpread: 7, FluidTickDelay: 5, InfiniteSource: true,
+			BlastResistance: 100,
+		},
+		VoxelTypeWood:   {Name: "Wood", Color: [3]float32{0.6, 0.4, 0.2}, BlastResistance: 2},
+		VoxelTypeLeaves: {Name: "Leaves", Color: [3]float32{0.15, 0.6, 0.15}, Tinted: true, BlastResistance: 0.2},
+		VoxelTypeGlass:  {Name: "Glass", Color: [3]float32{0.7, 0.9, 1.0}, Transparent: true, BlastResistance: 0.3},
+		VoxelTypeBrick:  {Name: "Brick", Color: [3]float32{0.7, 0.3, 0.2}, BlastResistance: 6},
+
+		VoxelTypeCoalOre: {Name: "Coal Ore", Color: [3]float32{0.2, 0.2, 0.2}, BlastResistance: 3},
+		VoxelTypeIronOre: {Name: "Iron Ore", Color: [3]float32{0.75, 0.6, 0.5}, BlastResistance: 3},
+		VoxelTypeGoldOre: {Name: "Gold Ore", Color: [3]float32{0.95, 0.8, 0.2}, BlastResistance: 3},
+
+		VoxelTypeStoneSlab:   {Name: "Stone Slab", Color: [3]float32{0.5, 0.5, 0.5}, BlastResistance: 6, Shape: ShapeSlab},
+		VoxelTypeStoneStairs: {Name: "Stone Stairs", Color: [3]float32{0.5, 0.5, 0.5}, BlastResistance: 6, Shape: ShapeStairs},
+		VoxelTypeWoodFence:   {Name: "Wood Fence", Color: [3]float32{0.6, 0.4, 0.2}, BlastResistance: 2, Shape: ShapeFence},
+		VoxelTypeFlower:      {Name: "Flower", Color: [3]float32{0.9, 0.2, 0.3}, Transparent: true, Shape: ShapeCross},
+		VoxelTypeTallGrass:   {Name: "Tall Grass", Color: [3]float32{0.2, 0.8, 0.2}, Transparent: true, Tinted: true, Shape: ShapeCross},
+	}
+	// voxelPropertiesMutex serialises registrations; lookups read
+	// propertyTable instead
+	voxelPropertiesMutex sync.Mutex
+
+	// propertyTable is the registry as a PropertyTable, replaced on every
+	// registration
+	propertyTable atomic.Pointer[PropertyTable]
+)
+
+func init() {
+	propertyTable.Store(newPropertyTable())
+}
+
+// RegisterVoxelType registers or replaces the properties of a voxel type
+func RegisterVoxelType(voxelType VoxelType, props VoxelProperties) {
+	voxelPropertiesMutex.Lock()
+	defer voxelPropertiesMutex.Unlock()
+
+	voxelProperties[voxelType] = props
+	propertyTable.Store(newPropertyTable())
+}
+
+// GetVoxelProperties returns the properties of a voxel type and whether it is registered
+func GetVoxelProperties(voxelType VoxelType) (VoxelProperties, bool) {
+	return CurrentPropertyTable().Get(voxelType)
+}
+
+// RegisteredVoxelTypes returns every registered voxel type in ascending order
+func RegisteredVoxelTypes() []VoxelType {
+	table := CurrentPropertyTable()
+
+	var types []VoxelType
+	for voxelType := range table.registered {
+		if table.registered[voxelType] {
+			types = append(types, VoxelType(voxelType))
+		}
+	}
+	return types
+}
+
+// PropertyTable is an immutable copy of the registry indexed by type, for hot
+// loops such as meshing that would otherwise lock the registry per voxel
+type PropertyTable struct {
+	props      [256]VoxelProperties
+	registered [256]bool
+}
+
+// CurrentPropertyTable returns a table of the registered properties without
+// locking. The table is shared and is not updated by later registrations.
+func CurrentPropertyTable() *PropertyTable {
+	return propertyTable.Load()
+}
+
+// newPropertyTable copies the registry. The caller holds
+// voxelPropertiesMutex, or is init.
+func newPropertyTable() *PropertyTable {
+	table := &PropertyTable{}
+	for voxelType, props := range voxelProperties {
+		table.props[voxelType] = props
+		table.registered[voxelType] = true
+	}
+	return table
+}
+
+// Get returns the properties of a voxel type and whether it is registered
+func (t *PropertyTable) Get(voxelType VoxelType) (VoxelProperties, bool) {
+	return t.props[voxelType], t.registered[voxelType]
+}
+
+// IsTransparent is Voxel.IsTransparent using the table
+func (t *PropertyTable) IsTransparent(v Voxel) bool {
+	return v.Type == VoxelTypeAir || (t.registered[v.Type] && t.props[v.Type].Transparent)
+}
+
+// IsOpaque is Voxel.IsOpaque using the table
+func (t *PropertyTable) IsOpaque(v Voxel) bool {
+	return !t.IsTransparent(v) && t.props[v.Type].Shape == ShapeCube
+}
+
+// IsFluid is Voxel.IsFluid using the table
+func (t *PropertyTable) IsFluid(v Voxel) bool {
+	return t.registered[v.Type] && t.props[v.Type].Fluid
+}
@@ -1,6 +1,7 @@
 package voxel
 
 import (
+	"slices"
 	"testing"
 
 	ceresmath "Ceres/pkg/math"
@@ -76,6 +77,9 @@ func TestVoxelGetName(t *testing.T) {
 		{VoxelTypeLeaves, "Leaves"},
 		{VoxelTypeGlass, "Glass"},
 		{VoxelTypeBrick, "Brick"},
+		{VoxelTypeCoalOre, "Coal Ore"},
+		{VoxelTypeIronOre, "Iron Ore"},
+		{VoxelTypeGoldOre, "Gold Ore"},
 	}
 
 	for _, tt := range tests {
@@ -206,3 +210,165 @@ func TestGetFaceOffset(t *testing.T) {
 		}
 	}
 }
+
+func TestFluidState(t *testing.T) {
+	source := NewVoxel(VoxelTypeWater)
+	if !source.IsFluid() || !source.IsFluidSource() {
+		t.Error("Plain water should be a fluid source")
+	}
+
+	flowing := NewFluidVoxel(VoxelTypeWater, 3, false)
+	if flowing.IsFluidSource() || flowing.FluidDistance() != 3 {
+		t.Errorf("Expected flowing water at distance 3, got state %d", flowing.State)
+	}
+	if flowing.FluidHeight() >= source.FluidHeight() {
+		t.Error("Flowing water should be lower than its source")
+	}
+
+	falling := NewFluidVoxel(VoxelTypeWater, 0, true)
+	if falling.IsFluidSource() || !falling.IsFluidFalling() || falling.FluidHeight() != 1 {
+		t.Error("Falling water should fill the voxel and not be a source")
+	}
+
+	if NewVoxel(VoxelTypeStone).IsFluid() {
+		t.Error("Stone should not be a fluid")
+	}
+}
+
+// restoreRegistryAfter unregisters voxelType when the test ends, restoring
+// any properties it had, so later tests see the registry unchanged
+func restoreRegistryAfter(t *testing.T, voxelType VoxelType) {
+	previous, existed := GetVoxelProperties(voxelType)
+	t.Cleanup(func() {
+		voxelPropertiesMutex.Lock()
+		defer voxelPropertiesMutex.Unlock()
+
+		if existed {
+			voxelProperties[voxelType] = previous
+		} else {
+			delete(voxelProperties, voxelType)
+		}
+		propertyTable.Store(newPropertyTable())
+	})
+}
+
+func TestPropertyTable(t *testing.T) {
+	table := CurrentPropertyTable()
+	for _, v := range []Voxel{NewVoxel(VoxelTypeAir), NewVoxel(VoxelTypeStone), NewVoxel(VoxelTypeWater), NewVoxel(VoxelTypeGlass), NewVoxel(250)} {
+		if table.IsOpaque(v) != v.IsOpaque() || table.IsTransparent(v) != v.IsTransparent() || table.IsFluid(v) != v.IsFluid() {
+			t.Errorf("Expected the table to agree with the registry for type %d", v.Type)
+		}
+	}
+	if CurrentPropertyTable() != table {
+		t.Error("Expected the table to be shared until a type is registered")
+	}
+
+	restoreRegistryAfter(t, 251)
+	RegisterVoxelType(251, VoxelProperties{Name: "Mist", Transparent: true})
+	if table.IsTransparent(NewVoxel(251)) {
+		t.Error("Expected an existing table not to change")
+	}
+	if updated := CurrentPropertyTable(); !updated.IsTransparent(NewVoxel(251)) {
+		t.Error("Expected a new table after registering a type")
+	}
+	if mist := NewVoxel(251); !mist.IsTransparent() || mist.GetName() != "Mist" {
+		t.Errorf("Expected voxels to see the registration, got %s", mist.GetName())
+	}
+	if types := RegisteredVoxelTypes(); !slices.Contains(types, 251) || !slices.IsSorted(types) {
+		t.Errorf("Expected the registered type among the sorted types, got %v", types)
+	}
+}
+
+func TestRegistrationDuringLookups(t *testing.T) {
+	restoreRegistryAfter(t, 252)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			RegisterVoxelType(252, VoxelProperties{Name: "Haze", Transparent: i%2 == 0})
+		}
+	}()
+
+	v := NewVoxel(252)
+	for i := 0; i < 1000; i++ {
+		v.IsTransparent()
+		v.GetName()
+	}
+	<-done
+
+	if v.GetName() != "Haze" || v.IsTransparent() {
+		t.Errorf("Expected the last registration to win, got %s transparent %t", v.GetName(), v.IsTransparent())
+	}
+}
+
+func TestRegistryRestoredAfterTests(t *testing.T) {
+	t.Run("register", func(t *testing.T) {
+		restoreRegistryAfter(t, 253)
+		RegisterVoxelType(253, VoxelProperties{Name: "Smoke"})
+	})
+	if _, ok := GetVoxelProperties(253); ok {
+		t.Error("Expected the test's type to be unregistered afterwards")
+	}
+}
+
+func TestBlockShapes(t *testing.T) {
+	table := CurrentPropertyTable()
+
+	slab := NewVoxel(VoxelTypeStoneSlab)
+	if boxes := table.Boxes(slab, nil); len(boxes) != 1 || boxes[0].Max[1] != 0.5 {
+		t.Errorf("Expected a bottom slab to fill the lower half, got %v", boxes)
+	}
+	top := Voxel{Type: VoxelTypeStoneSlab, State: SlabTopBit}
+	if boxes := table.Boxes(top, nil); len(boxes) != 1 || boxes[0].Min[1] != 0.5 {
+		t.Errorf("Expected a top slab to fill the upper half, got %v", boxes)
+	}
+	if slab.IsOpaque() || !NewVoxel(VoxelTypeStone).IsOpaque() {
+		t.Error("Expected only full cubes to be opaque")
+	}
+
+	// A slab hides the face below it but not the side faces next to it
+	if !table.Hides(slab, VoxelFaceBottom) || table.Hides(slab, VoxelFaceTop) || table.Hides(slab, VoxelFaceLeft) {
+		t.Errorf("Expected a bottom slab to hide only through its bottom face, got faces %06b", table.FullFaces(slab))
+	}
+	if table.Hides(NewVoxel(VoxelTypeGlass), VoxelFaceTop) {
+		t.Error("Expected transparent cubes not to hide their neighbours")
+	}
+
+	// Stairs facing right have their step in the right half
+	stairs := Voxel{Type: VoxelTypeStoneStairs, State: 1}
+	boxes := table.Boxes(stairs, nil)
+	if len(boxes) != 2 || boxes[1].Min[0] != 0.5 || boxes[1].Min[1] != 0.5 {
+		t.Errorf("Expected a slab and a step on the right, got %v", boxes)
+	}
+	if !table.Hides(stairs, VoxelFaceRight) || table.Hides(stairs, VoxelFaceLeft) {
+		t.Error("Expected stairs to hide only through the side of their step")
+	}
+
+	// Fences reach out to fences and full blocks only
+	neighbors := map[VoxelFace]Voxel{
+		VoxelFaceRight: NewVoxel(VoxelTypeWoodFence),
+		VoxelFaceBack:  NewVoxel(VoxelTypeStone),
+		VoxelFaceLeft:  slab,
+	}
+	neighbor := func(face VoxelFace) Voxel {
+		if v, ok := neighbors[face]; ok {
+			return v
+		}
+		return NewVoxel(VoxelTypeAir)
+	}
+	if boxes := table.Boxes(NewVoxel(VoxelTypeWoodFence), neighbor); len(boxes) != 5 {
+		t.Errorf("Expected a post and two rails to each of two neighbours, got %d boxes", len(boxes))
+	}
+	if table.FullFaces(NewVoxel(VoxelTypeWoodFence)) != 0 {
+		t.Error("Expected fences to hide no faces")
+	}
+
+	// Plants have nothing to collide with but can still be selected
+	flower := NewVoxel(VoxelTypeFlower)
+	if boxes := table.Boxes(flower, nil); len(boxes) != 0 {
+		t.Errorf("Expected plants to have no collision boxes, got %v", boxes)
+	}
+	if boxes := table.SelectionBoxes(flower, nil); len(boxes) != 1 {
+		t.Errorf("Expected plants to have a selection box, got %v", boxes)
+	}
+}
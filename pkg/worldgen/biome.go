@@ -0,0 +1,182 @@
+package worldgen
+
+import (
+	"math"
+
+	"Ceres/pkg/voxel"
+)
+
+// Biome describes the climate a biome occupies and how its terrain looks
+type Biome struct {
+	Name string
+
+	// Temperature and Humidity are the biome's centre in climate space ([-1, 1])
+	Temperature float64
+	Humidity    float64
+
+	SurfaceType voxel.VoxelType
+	FillerType  voxel.VoxelType
+	FillerDepth int32
+
+	BaseHeight      float64
+	HeightVariation float64
+
+	// DecorationDensity is the chance per surface column of placing a feature
+	DecorationDensity float64
+
+	// Tint multiplies the colour of tinted voxel types such as grass and leaves
+	Tint [3]float32
+}
+
+// DefaultBiomes returns the built-in biome set
+func DefaultBiomes() []*Biome {
+	return []*Biome{
+		{
+			Name: "Plains", Temperature: 0.1, Humidity: 0.0,
+			SurfaceType: voxel.VoxelTypeGrass, FillerType: voxel.VoxelTypeDirt, FillerDepth: 3,
+			BaseHeight: 32, HeightVariation: 6, DecorationDensity: 0.004,
+			Tint: [3]float32{1.0, 1.0, 1.0},
+		},
+		{
+			Name: "Forest", Temperature: 0.2, Humidity: 0.6,
+			SurfaceType: voxel.VoxelTypeGrass, FillerType: voxel.VoxelTypeDirt, FillerDepth: 4,
+			BaseHeight: 34, HeightVariation: 10, DecorationDensity: 0.03,
+			Tint: [3]float32{0.75, 0.9, 0.7},
+		},
+		{
+			Name: "Desert", Temperature: 0.8, Humidity: -0.7,
+			SurfaceType: voxel.VoxelTypeSand, FillerType: voxel.VoxelTypeSand, FillerDepth: 5,
+			BaseHeight: 31, HeightVariation: 5, DecorationDensity: 0.002,
+			Tint: [3]float32{1.25, 1.05, 0.6},
+		},
+		{
+			Name: "Swamp", Temperature: 0.5, Humidity: 0.8,
+			SurfaceType: voxel.VoxelTypeGrass, FillerType: voxel.VoxelTypeDirt, FillerDepth: 3,
+			BaseHeight: 28, HeightVariation: 2, DecorationDensity: 0.01,
+			Tint: [3]float32{0.6, 0.7, 0.45},
+		},
+		{
+			Name: "Tundra", Temperature: -0.8, Humidity: -0.2,
+			SurfaceType: voxel.VoxelTypeGrass, FillerType: voxel.VoxelTypeDirt, FillerDepth: 2,
+			BaseHeight: 33, HeightVariation: 8, DecorationDensity: 0.002,
+			Tint: [3]float32{0.8, 0.9, 1.05},
+		},
+		{
+			Name: "Mountains", Temperature: -0.4, Humidity: 0.4,
+			SurfaceType: voxel.VoxelTypeStone, FillerType: voxel.VoxelTypeStone, FillerDepth: 1,
+			BaseHeight: 52, HeightVariation: 36, DecorationDensity: 0.001,
+			Tint: [3]float32{0.85, 0.95, 0.9},
+		},
+	}
+}
+
+// BiomeMap selects biomes from low-frequency temperature and humidity noise
+type BiomeMap struct {
+	Biomes []*Biome
+
+	// ClimateScale is the frequency of the temperature and humidity noise
+	ClimateScale float64
+
+	// BlendWidth controls how far apart in climate space two biomes still
+	// influence each other's height and tint; larger values blend more
+	BlendWidth float64
+
+	temperature *Noise
+	humidity    *Noise
+}
+
+// NewBiomeMap creates a biome map for the seed. With no biomes given the
+// default set is used.
+func NewBiomeMap(seed int64, biomes ...*Biome) *BiomeMap {
+	if len(biomes) == 0 {
+		biomes = DefaultBiomes()
+	}
+
+	return &BiomeMap{
+		Biomes:       biomes,
+		ClimateScale: 1.0 / 600.0,
+		BlendWidth:   0.12,
+		temperature:  NewNoise(seed ^ 0x74656d70),
+		humidity:     NewNoise(seed ^ 0x68756d69),
+	}
+}
+
+// Climate returns the temperature and humidity of a world column
+func (bm *BiomeMap) Climate(x, z int32) (temperature, humidity float64) {
+	fx := float64(x) * bm.ClimateScale
+	fz := float64(z) * bm.ClimateScale
+	temperature = clampUnit(bm.temperature.Fractal2D(fx, fz, 3, 2.0, 0.5) * 1.6)
+	humidity = clampUnit(bm.humidity.Fractal2D(fx, fz, 3, 2.0, 0.5) * 1.6)
+	return
+}
+
+// BiomeAt returns the biome whose climate is closest to that of the column
+// containing pos
+func (bm *BiomeMap) BiomeAt(pos voxel.VoxelPosition) *Biome {
+	temperature, humidity := bm.Climate(pos.X, pos.Z)
+
+	var best *Biome
+	bestDistance := math.MaxFloat64
+	for _, biome := range bm.Biomes {
+		if d := climateDistanceSq(biome, temperature, humidity); d < bestDistance {
+			best = biome
+			bestDistance = d
+		}
+	}
+
+	return best
+}
+
+// HeightProfile returns the base height and height variation of a column,
+// blended across every biome by climate distance so borders stay continuous
+func (bm *BiomeMap) HeightProfile(x, z int32) (baseHeight, variation float64) {
+	total := 0.0
+	bm.forEachWeight(x, z, func(biome *Biome, weight float64) {
+		baseHeight += biome.BaseHeight * weight
+		variation += biome.HeightVariation * weight
+		total += weight
+	})
+
+	return baseHeight / total, variation / total
+}
+
+// TintAt returns the blended biome tint for a column. It satisfies
+// chunk.TintSource so meshers can colour grass and leaves per biome.
+func (bm *BiomeMap) TintAt(pos voxel.VoxelPosition) [3]float32 {
+	var tint [3]float64
+	total := 0.0
+	bm.forEachWeight(pos.X, pos.Z, func(biome *Biome, weight float64) {
+		for i := range tint {
+			tint[i] += float64(biome.Tint[i]) * weight
+		}
+		total += weight
+	})
+
+	return [3]float32{float32(tint[0] / total), float32(tint[1] / total), float32(tint[2] / total)}
+}
+
+func (bm *BiomeMap) forEachWeight(x, z int32, fn func(biome *Biome, weight float64)) {
+	temperature, humidity := bm.Climate(x, z)
+
+	// Weights are relative to the nearest biome so they never all underflow
+	nearest := math.MaxFloat64
+	for _, biome := range bm.Biomes {
+		nearest = math.Min(nearest, climateDistanceSq(biome, temperature, humidity))
+	}
+
+	width := 2 * bm.BlendWidth * bm.BlendWidth
+	for _, biome := range bm.Biomes {
+		d := climateDistanceSq(biome, temperature, humidity) - nearest
+		fn(biome, math.Exp(-d/width))
+	}
+}
+
+func climateDistanceSq(biome *Biome, temperature, humidity float64) float64 {
+	dt := biome.Temperature - temperature
+	dh := biome.Humidity - humidity
+	return dt*dt + dh*dh
+}
+
+func clampUnit(v float64) float64 {
+	return math.Max(-1, math.Min(1, v))
+}
@@ -0,0 +1,190 @@
+package worldgen
+
+import (
+	"math"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// NoiseCaveStage carves open caverns wherever 3D noise exceeds a threshold
+type NoiseCaveStage struct {
+	Scale     float64
+	Octaves   int
+	Threshold float64
+	MinY      int32
+	MaxY      int32
+}
+
+// NewNoiseCaveStage creates a cave stage producing sparse caverns below the surface
+func NewNoiseCaveStage() *NoiseCaveStage {
+	return &NoiseCaveStage{
+		Scale:     1.0 / 48.0,
+		Octaves:   3,
+		Threshold: 0.42,
+		MinY:      -256,
+		MaxY:      24,
+	}
+}
+
+func (ncs *NoiseCaveStage) Generate(c *chunk.Chunk, seed int64) {
+	origin := c.GetWorldPosition()
+	if origin.Y > ncs.MaxY || origin.Y+chunk.ChunkSize <= ncs.MinY || c.IsEmpty() {
+		return
+	}
+
+	noise := NewNoise(seed ^ 0x63617665)
+
+	forEachLocal(c, func(x, y, z int32, world voxel.VoxelPosition) {
+		if world.Y < ncs.MinY || world.Y > ncs.MaxY {
+			return
+		}
+		if !isCarvable(c.GetVoxel(x, y, z)) {
+			return
+		}
+
+		n := noise.Fractal3D(
+			float64(world.X)*ncs.Scale,
+			float64(world.Y)*ncs.Scale*1.5,
+			float64(world.Z)*ncs.Scale,
+			ncs.Octaves, 2.0, 0.5,
+		)
+		if n > ncs.Threshold {
+			c.SetVoxel(x, y, z, voxel.NewVoxel(voxel.VoxelTypeAir))
+		}
+	})
+}
+
+// WormCaveStage carves winding tunnels. Each worm starts in an origin chunk
+// and its whole path is derived from the seed and that chunk's position, so
+// a chunk can carve the parts of worms that started in its neighbours and the
+// tunnels line up however the chunks are loaded.
+type WormCaveStage struct {
+	WormsPerChunk float64
+	MinLength     int
+	MaxLength     int
+	MinRadius     float64
+	MaxRadius     float64
+	MinY          int32
+	MaxY          int32
+}
+
+// NewWormCaveStage creates a stage with occasional long tunnels
+func NewWormCaveStage() *WormCaveStage {
+	return &WormCaveStage{
+		WormsPerChunk: 0.15,
+		MinLength:     40,
+		MaxLength:     120,
+		MinRadius:     1.5,
+		MaxRadius:     3.5,
+		MinY:          -256,
+		MaxY:          40,
+	}
+}
+
+func (wcs *WormCaveStage) Generate(c *chunk.Chunk, seed int64) {
+	if c.IsEmpty() {
+		return
+	}
+
+	reach := int32(math.Ceil((float64(wcs.MaxLength) + wcs.MaxRadius) / chunk.ChunkSize))
+	for dx := -reach; dx <= reach; dx++ {
+		for dy := -reach; dy <= reach; dy++ {
+			for dz := -reach; dz <= reach; dz++ {
+				origin := c.Position.Add(chunk.NewChunkPosition(dx, dy, dz))
+				wcs.carveWormsFrom(c, origin, seed)
+			}
+		}
+	}
+}
+
+func (wcs *WormCaveStage) carveWormsFrom(c *chunk.Chunk, origin chunk.ChunkPosition, seed int64) {
+	rng := chunkRandom(seed, origin, saltWorms)
+
+	count := int(wcs.WormsPerChunk)
+	if rng.Float64() < wcs.WormsPerChunk-float64(count) {
+		count++
+	}
+
+	originWorld := origin.GetWorldPosition()
+	chunkMin := c.GetWorldPosition()
+
+	for worm := 0; worm < count; worm++ {
+		// Draw every random value up front so skipped worms do not shift the stream
+		x := float64(originWorld.X) + rng.Float64()*chunk.ChunkSize
+		y := float64(originWorld.Y) + rng.Float64()*chunk.ChunkSize
+		z := float64(originWorld.Z) + rng.Float64()*chunk.ChunkSize
+		yaw := rng.Float64() * 2 * math.Pi
+		pitch := (rng.Float64() - 0.5) * 0.5
+		length := wcs.MinLength
+		if wcs.MaxLength > wcs.MinLength {
+			length += rng.Intn(wcs.MaxLength - wcs.MinLength + 1)
+		}
+		pathSeed := rng.Int63()
+
+		if int32(y) < wcs.MinY || int32(y) > wcs.MaxY {
+			continue
+		}
+
+		path := chunkRandom(pathSeed, origin, saltWorms)
+		for step := 0; step < length; step++ {
+			t := float64(step) / float64(length)
+			radius := wcs.MinRadius + (wcs.MaxRadius-wcs.MinRadius)*math.Sin(math.Pi*t)
+
+			carveSphere(c, chunkMin, x, y, z, radius)
+
+			x += math.Cos(yaw) * math.Cos(pitch)
+			y += math.Sin(pitch)
+			z += math.Sin(yaw) * math.Cos(pitch)
+			yaw += (path.Float64() - 0.5) * 0.4
+			pitch = pitch*0.8 + (path.Float64()-0.5)*0.3
+		}
+	}
+}
+
+// carveSphere clears the carvable voxels of chunk c within radius of a world point
+func carveSphere(c *chunk.Chunk, chunkMin voxel.VoxelPosition, cx, cy, cz, radius float64) {
+	minX := int32(math.Floor(cx-radius)) - chunkMin.X
+	maxX := int32(math.Ceil(cx+radius)) - chunkMin.X
+	minY := int32(math.Floor(cy-radius)) - chunkMin.Y
+	maxY := int32(math.Ceil(cy+radius)) - chunkMin.Y
+	minZ := int32(math.Floor(cz-radius)) - chunkMin.Z
+	maxZ := int32(math.Ceil(cz+radius)) - chunkMin.Z
+
+	if maxX < 0 || maxY < 0 || maxZ < 0 || minX >= chunk.ChunkSize || minY >= chunk.ChunkSize || minZ >= chunk.ChunkSize {
+		return
+	}
+
+	radiusSq := radius * radius
+	for x := clampLocal(minX); x <= clampLocal(maxX); x++ {
+		for y := clampLocal(minY); y <= clampLocal(maxY); y++ {
+			for z := clampLocal(minZ); z <= clampLocal(maxZ); z++ {
+				dx := float64(chunkMin.X+x) + 0.5 - cx
+				dy := float64(chunkMin.Y+y) + 0.5 - cy
+				dz := float64(chunkMin.Z+z) + 0.5 - cz
+				if dx*dx+dy*dy+dz*dz > radiusSq {
+					continue
+				}
+				if isCarvable(c.GetVoxel(x, y, z)) {
+					c.SetVoxel(x, y, z, voxel.NewVoxel(voxel.VoxelTypeAir))
+				}
+			}
+		}
+	}
+}
+
+// isCarvable reports whether caves may remove the voxel. Water is kept so
+// caves never drain oceans.
+func isCarvable(v voxel.Voxel) bool {
+	return !v.IsAir() && v.Type != voxel.VoxelTypeWater
+}
+
+func clampLocal(v int32) int32 {
+	if v < 0 {
+		return 0
+	}
+	if v >= chunk.ChunkSize {
+		return chunk.ChunkSize - 1
+	}
+	return v
+}
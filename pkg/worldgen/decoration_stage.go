@@ -0,0 +1,207 @@
+package worldgen
+
+import (
+	"math/rand"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// Feature is a structure placed on the surface, such as a tree or a boulder
+type Feature interface {
+	// Place writes the feature standing on the air voxel at pos
+	Place(w VoxelWriter, pos voxel.VoxelPosition, rng *rand.Rand)
+}
+
+// Decoration describes where and how often a feature is placed
+type Decoration struct {
+	Feature Feature
+
+	// Ground lists the block types the feature may stand on; empty means any opaque block
+	Ground []voxel.VoxelType
+
+	// Biomes restricts the decoration to the named biomes; empty means every biome
+	Biomes []string
+
+	// Density is the chance per suitable surface column. With a biome map it
+	// is scaled by the biome's DecorationDensity.
+	Density float64
+}
+
+// DefaultDecorations returns trees for the grassy biomes and scattered boulders
+func DefaultDecorations() []Decoration {
+	return []Decoration{
+		{
+			Feature: NewOakTreeFeature(),
+			Ground:  []voxel.VoxelType{voxel.VoxelTypeGrass, voxel.VoxelTypeDirt},
+			Biomes:  []string{"Plains", "Forest", "Swamp", "Tundra"},
+			Density: 1,
+		},
+		{
+			Feature: NewBoulderFeature(),
+			Ground:  []voxel.VoxelType{voxel.VoxelTypeGrass, voxel.VoxelTypeStone, voxel.VoxelTypeSand},
+			Density: 0.15,
+		},
+	}
+}
+
+// DecorationStage places features on the surface columns of each chunk.
+// Features may reach into neighbouring chunks; those writes are queued until
+// the neighbour is generated, so the result does not depend on load order.
+type DecorationStage struct {
+	Biomes      *BiomeMap
+	Decorations []Decoration
+}
+
+// NewDecorationStage creates a decoration stage. biomes may be nil.
+func NewDecorationStage(biomes *BiomeMap, decorations ...Decoration) *DecorationStage {
+	return &DecorationStage{
+		Biomes:      biomes,
+		Decorations: decorations,
+	}
+}
+
+func (ds *DecorationStage) Decorate(c *chunk.Chunk, seed int64, w VoxelWriter) {
+	if c.IsEmpty() {
+		return
+	}
+
+	rng := rand.New(rand.NewSource(chunkRandom(seed, c.Position, saltDecoration).Int63()))
+	origin := c.GetWorldPosition()
+
+	for x := int32(0); x < chunk.ChunkSize; x++ {
+		for z := int32(0); z < chunk.ChunkSize; z++ {
+			y, ground, ok := surfaceInChunk(c, x, z)
+			if !ok {
+				continue
+			}
+
+			pos := origin.Add(voxel.NewVoxelPosition(x, y+1, z))
+
+			var biome *Biome
+			if ds.Biomes != nil {
+				biome = ds.Biomes.BiomeAt(pos)
+			}
+
+			for _, decoration := range ds.Decorations {
+				chance := decoration.Density
+				if biome != nil {
+					chance *= biome.DecorationDensity
+				}
+				if rng.Float64() >= chance || !decoration.allows(ground, biome) {
+					continue
+				}
+
+				decoration.Feature.Place(w, pos, rng)
+				break
+			}
+		}
+	}
+}
+
+func (d *Decoration) allows(ground voxel.VoxelType, biome *Biome) bool {
+	if len(d.Ground) > 0 && !containsType(d.Ground, ground) {
+		return false
+	}
+	if len(d.Biomes) == 0 || biome == nil {
+		return true
+	}
+	for _, name := range d.Biomes {
+		if name == biome.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// surfaceInChunk returns the highest opaque voxel of a column with air directly
+// above it inside the same chunk
+func surfaceInChunk(c *chunk.Chunk, x, z int32) (int32, voxel.VoxelType, bool) {
+	for y := int32(chunk.ChunkSize - 2); y >= 0; y-- {
+		ground := c.GetVoxel(x, y, z)
+		if ground.IsTransparent() {
+			continue
+		}
+		if c.GetVoxel(x, y+1, z).IsAir() {
+			return y, ground.Type, true
+		}
+	}
+	return 0, voxel.VoxelTypeAir, false
+}
+
+func containsType(types []voxel.VoxelType, voxelType voxel.VoxelType) bool {
+	for _, t := range types {
+		if t == voxelType {
+			return true
+		}
+	}
+	return false
+}
+
+// decorationRank orders what features may overwrite, see outranks. The
+// natural blocks the terrain stages place share the top rank, so features
+// never cut into terrain, and trunks win over leaves whichever tree is placed
+// first.
+func decorationRank(voxelType voxel.VoxelType) int {
+	switch voxelType {
+	case voxel.VoxelTypeAir:
+		return 0
+	case voxel.VoxelTypeLeaves:
+		return 1
+	case voxel.VoxelTypeWood:
+		return 2
+	case voxel.VoxelTypeStone, voxel.VoxelTypeDirt, voxel.VoxelTypeGrass, voxel.VoxelTypeSand, voxel.VoxelTypeWater,
+		voxel.VoxelTypeCoalOre, voxel.VoxelTypeIronOre, voxel.VoxelTypeGoldOre:
+		return 4
+	default:
+		return 3
+	}
+}
+
+// outranks reports whether a feature may replace existing with voxelType: a
+// voxel is only replaced by one of higher rank. Other blocks features place
+// tie at the same rank and the higher type wins, so which feature, and so
+// which chunk, is placed first does not matter. Natural blocks are never
+// replaced, so of two features placing different natural blocks in the same
+// voxel the first is kept.
+func outranks(voxelType, existing voxel.VoxelType) bool {
+	rank, existingRank := decorationRank(voxelType), decorationRank(existing)
+	return rank > existingRank || (rank == 3 && existingRank == 3 && voxelType > existing)
+}
+
+// placeDecoration writes a feature voxel into a chunk if it outranks the
+// voxel already there
+func placeDecoration(c *chunk.Chunk, x, y, z int32, v voxel.Voxel) {
+	if outranks(v.Type, c.GetVoxel(x, y, z).Type) {
+		c.SetVoxel(x, y, z, v)
+	}
+}
+
+// decorationWriter routes feature writes: into the chunk being generated
+// directly, into ungenerated chunks through the pending queue, and into
+// already generated chunks through the manager so their meshes are refreshed
+type decorationWriter struct {
+	cm      *chunk.ChunkManager
+	current *chunk.Chunk
+}
+
+func (dw *decorationWriter) SetVoxel(pos voxel.VoxelPosition, v voxel.Voxel) {
+	chunkPos := chunk.VoxelToChunkPosition(pos)
+	if chunkPos == dw.current.Position {
+		x, y, z := chunk.VoxelToLocalPosition(pos)
+		placeDecoration(dw.current, x, y, z, v)
+		return
+	}
+
+	if dw.cm.QueueWrite(pos, v) {
+		return
+	}
+
+	// Generated chunks may have been unloaded since; never recreate them empty
+	if dw.cm.GetChunkIfExists(chunkPos) == nil {
+		return
+	}
+	if outranks(v.Type, dw.cm.GetVoxel(pos).Type) {
+		dw.cm.SetVoxel(pos, v)
+	}
+}
@@ -0,0 +1,179 @@
+package worldgen
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"Ceres/pkg/voxel"
+)
+
+// TreeFeature grows a tree from an L-system. The expanded string is drawn by
+// a 3D turtle starting at the base of the trunk and pointing up:
+//
+//	F  move Step voxels forward, placing trunk
+//	L  place a leaf ball of LeafRadius
+//	+- turn left/right by Angle
+//	&^ pitch down/up by Angle
+//	*  turn by a random angle
+//	[] push/pop the turtle state
+type TreeFeature struct {
+	Axiom      string
+	Rules      map[byte]string
+	Iterations int
+
+	// Angle is the turn angle in degrees
+	Angle      float64
+	Step       int32
+	LeafRadius int32
+
+	TrunkType voxel.VoxelType
+	LeafType  voxel.VoxelType
+}
+
+// NewOakTreeFeature creates a small branching broadleaf tree
+func NewOakTreeFeature() *TreeFeature {
+	return &TreeFeature{
+		Axiom: "FFFA",
+		Rules: map[byte]string{
+			'A': "[&FFB]*[&FFB]*[&FFB]FFL",
+			'B': "FL",
+		},
+		Iterations: 2,
+		Angle:      35,
+		Step:       1,
+		LeafRadius: 2,
+		TrunkType:  voxel.VoxelTypeWood,
+		LeafType:   voxel.VoxelTypeLeaves,
+	}
+}
+
+// Expand returns the L-system string after all iterations
+func (tf *TreeFeature) Expand() string {
+	current := tf.Axiom
+	for i := 0; i < tf.Iterations; i++ {
+		var next strings.Builder
+		for j := 0; j < len(current); j++ {
+			if rule, ok := tf.Rules[current[j]]; ok {
+				next.WriteString(rule)
+			} else {
+				next.WriteByte(current[j])
+			}
+		}
+		current = next.String()
+	}
+
+	return current
+}
+
+type turtle struct {
+	x, y, z    float64
+	yaw, pitch float64
+}
+
+func (t *turtle) direction() (float64, float64, float64) {
+	return math.Sin(t.pitch) * math.Cos(t.yaw), math.Cos(t.pitch), math.Sin(t.pitch) * math.Sin(t.yaw)
+}
+
+func (t *turtle) voxel() voxel.VoxelPosition {
+	return voxel.NewVoxelPosition(
+		int32(math.Floor(t.x+0.5)),
+		int32(math.Floor(t.y+0.5)),
+		int32(math.Floor(t.z+0.5)),
+	)
+}
+
+func (tf *TreeFeature) Place(w VoxelWriter, pos voxel.VoxelPosition, rng *rand.Rand) {
+	angle := tf.Angle * math.Pi / 180
+	state := turtle{x: float64(pos.X), y: float64(pos.Y), z: float64(pos.Z), yaw: rng.Float64() * 2 * math.Pi}
+	var stack []turtle
+
+	// The base is placed first so trees always have a trunk at their root
+	w.SetVoxel(pos, voxel.NewVoxel(tf.TrunkType))
+
+	program := tf.Expand()
+	for i := 0; i < len(program); i++ {
+		switch program[i] {
+		case 'F':
+			dx, dy, dz := state.direction()
+			for s := int32(0); s < tf.Step; s++ {
+				state.x, state.y, state.z = state.x+dx, state.y+dy, state.z+dz
+				w.SetVoxel(state.voxel(), voxel.NewVoxel(tf.TrunkType))
+			}
+		case 'L':
+			placeBall(w, state.voxel(), float64(tf.LeafRadius)+0.5, tf.LeafType)
+		case '+':
+			state.yaw += angle
+		case '-':
+			state.yaw -= angle
+		case '&':
+			state.pitch += angle
+		case '^':
+			state.pitch -= angle
+		case '*':
+			state.yaw += (0.5 + rng.Float64()) * 2 * math.Pi / 3
+		case '[':
+			stack = append(stack, state)
+		case ']':
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// BoulderFeature places a rough ball of stone half sunk into the ground
+type BoulderFeature struct {
+	Type      voxel.VoxelType
+	MinRadius float64
+	MaxRadius float64
+}
+
+// NewBoulderFeature creates a stone boulder feature
+func NewBoulderFeature() *BoulderFeature {
+	return &BoulderFeature{
+		Type:      voxel.VoxelTypeStone,
+		MinRadius: 1.2,
+		MaxRadius: 2.5,
+	}
+}
+
+func (bf *BoulderFeature) Place(w VoxelWriter, pos voxel.VoxelPosition, rng *rand.Rand) {
+	radius := bf.MinRadius + rng.Float64()*(bf.MaxRadius-bf.MinRadius)
+	placeBall(w, pos, radius, bf.Type)
+}
+
+// TemplateFeature places a saved template centred on the surface column,
+// rotated by a random multiple of 90 degrees around the Y axis
+type TemplateFeature struct {
+	Template *Template
+
+	// Sink lowers the template into the ground, e.g. for foundations
+	Sink int32
+}
+
+func (tf *TemplateFeature) Place(w VoxelWriter, pos voxel.VoxelPosition, rng *rand.Rand) {
+	rotation := rng.Intn(4)
+
+	size := tf.Template.RotatedSize(rotation)
+	origin := pos.Add(voxel.NewVoxelPosition(-size.X/2, -tf.Sink, -size.Z/2))
+	tf.Template.Place(w, origin, rotation)
+}
+
+// placeBall writes every voxel within radius of center
+func placeBall(w VoxelWriter, center voxel.VoxelPosition, radius float64, voxelType voxel.VoxelType) {
+	r := int32(math.Ceil(radius))
+	radiusSq := radius * radius
+
+	for dx := -r; dx <= r; dx++ {
+		for dy := -r; dy <= r; dy++ {
+			for dz := -r; dz <= r; dz++ {
+				if float64(dx*dx+dy*dy+dz*dz) > radiusSq {
+					continue
+				}
+				w.SetVoxel(center.Add(voxel.NewVoxelPosition(dx, dy, dz)), voxel.NewVoxel(voxelType))
+			}
+		}
+	}
+}
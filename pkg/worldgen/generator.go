@@ -0,0 +1,117 @@
+package worldgen
+
+import (
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// Stage is one step of the world generation pipeline. A stage must only
+// depend on the seed and the chunk's position (plus what earlier stages wrote
+// into the same chunk), so chunks can be generated in any order.
+type Stage interface {
+	Generate(c *chunk.Chunk, seed int64)
+}
+
+// Decorator places features that may extend into neighbouring chunks.
+// Decorators run after every Stage and must base their placement only on the
+// chunk's own contents; writes go through the VoxelWriter, which queues those
+// aimed at chunks that have not been generated yet.
+type Decorator interface {
+	Decorate(c *chunk.Chunk, seed int64, w VoxelWriter)
+}
+
+// VoxelWriter receives voxels placed by features
+type VoxelWriter interface {
+	SetVoxel(pos voxel.VoxelPosition, v voxel.Voxel)
+}
+
+// Generator runs a fixed list of stages over newly created chunks
+type Generator struct {
+	Seed       int64
+	Stages     []Stage
+	Decorators []Decorator
+
+	// Biomes is the biome map used by the stages, if any
+	Biomes *BiomeMap
+}
+
+// NewGenerator creates a generator that runs the given stages in order
+func NewGenerator(seed int64, stages ...Stage) *Generator {
+	return &Generator{
+		Seed:   seed,
+		Stages: stages,
+	}
+}
+
+// NewDefaultGenerator creates a generator with biome terrain, ores, caves,
+// trees and boulders
+func NewDefaultGenerator(seed int64) *Generator {
+	biomes := NewBiomeMap(seed)
+
+	g := NewGenerator(seed,
+		NewBiomeTerrainStage(biomes),
+		NewOreStage(DefaultOreConfigs()...),
+		NewNoiseCaveStage(),
+		NewWormCaveStage(),
+	)
+	g.Decorators = []Decorator{NewDecorationStage(biomes, DefaultDecorations()...)}
+	g.Biomes = biomes
+
+	return g
+}
+
+// GenerateChunk creates the chunk at pos in the manager, runs every stage and
+// decorator on it and then applies the writes neighbours queued for it
+func (g *Generator) GenerateChunk(cm *chunk.ChunkManager, pos chunk.ChunkPosition) *chunk.Chunk {
+	c := cm.CreateChunk(pos)
+
+	for _, stage := range g.Stages {
+		stage.Generate(c, g.Seed)
+	}
+
+	writer := &decorationWriter{cm: cm, current: c}
+	for _, decorator := range g.Decorators {
+		decorator.Decorate(c, g.Seed, writer)
+	}
+
+	for _, write := range cm.FinishGeneration(pos) {
+		x, y, z := chunk.VoxelToLocalPosition(write.Position)
+		placeDecoration(c, x, y, z, write.Voxel)
+	}
+
+	return c
+}
+
+// InsertLoadedChunk adds a chunk loaded from disk to the manager and applies
+// the writes neighbours queued for it, the same way GenerateChunk does
+func (g *Generator) InsertLoadedChunk(cm *chunk.ChunkManager, c *chunk.Chunk) {
+	cm.InsertChunk(c)
+
+	for _, write := range cm.FinishGeneration(c.Position) {
+		x, y, z := chunk.VoxelToLocalPosition(write.Position)
+		placeDecoration(c, x, y, z, write.Voxel)
+	}
+}
+
+// GenerateRegion generates every chunk between min and max (inclusive)
+func (g *Generator) GenerateRegion(cm *chunk.ChunkManager, min, max chunk.ChunkPosition) {
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			for z := min.Z; z <= max.Z; z++ {
+				g.GenerateChunk(cm, chunk.NewChunkPosition(x, y, z))
+			}
+		}
+	}
+}
+
+// forEachLocal calls fn for every local coordinate of a chunk with its world position
+func forEachLocal(c *chunk.Chunk, fn func(x, y, z int32, world voxel.VoxelPosition)) {
+	origin := c.GetWorldPosition()
+	for z := int32(0); z < chunk.ChunkSize; z++ {
+		for y := int32(0); y < chunk.ChunkSize; y++ {
+			for x := int32(0); x < chunk.ChunkSize; x++ {
+				fn(x, y, z, origin.Add(voxel.NewVoxelPosition(x, y, z)))
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package worldgen
+
+import (
+	"math"
+)
+
+// Noise is a seeded gradient noise source. Gradients are derived from a hash
+// of the seed and lattice coordinates, so no permutation table is needed and
+// any two Noise values with the same seed produce identical output.
+type Noise struct {
+	seed int64
+}
+
+// NewNoise creates a noise source for the given seed
+func NewNoise(seed int64) *Noise {
+	return &Noise{seed: seed}
+}
+
+var gradients3D = [12][3]float64{
+	{1, 1, 0}, {-1, 1, 0}, {1, -1, 0}, {-1, -1, 0},
+	{1, 0, 1}, {-1, 0, 1}, {1, 0, -1}, {-1, 0, -1},
+	{0, 1, 1}, {0, -1, 1}, {0, 1, -1}, {0, -1, -1},
+}
+
+var gradients2D = [8][2]float64{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{math.Sqrt2 / 2, math.Sqrt2 / 2}, {-math.Sqrt2 / 2, math.Sqrt2 / 2},
+	{math.Sqrt2 / 2, -math.Sqrt2 / 2}, {-math.Sqrt2 / 2, -math.Sqrt2 / 2},
+}
+
+// Noise2D returns gradient noise in roughly [-1, 1]
+func (n *Noise) Noise2D(x, z float64) float64 {
+	x0 := math.Floor(x)
+	z0 := math.Floor(z)
+	fx := x - x0
+	fz := z - z0
+	ix := int64(x0)
+	iz := int64(z0)
+
+	dot := func(cx, cz int64, dx, dz float64) float64 {
+		g := gradients2D[hashCoords(n.seed, cx, 0, cz)&7]
+		return g[0]*dx + g[1]*dz
+	}
+
+	u := fade(fx)
+	v := fade(fz)
+
+	a := lerp(dot(ix, iz, fx, fz), dot(ix+1, iz, fx-1, fz), u)
+	b := lerp(dot(ix, iz+1, fx, fz-1), dot(ix+1, iz+1, fx-1, fz-1), u)
+
+	return lerp(a, b, v) * math.Sqrt2
+}
+
+// Noise3D returns gradient noise in roughly [-1, 1]
+func (n *Noise) Noise3D(x, y, z float64) float64 {
+	x0 := math.Floor(x)
+	y0 := math.Floor(y)
+	z0 := math.Floor(z)
+	fx := x - x0
+	fy := y - y0
+	fz := z - z0
+	ix := int64(x0)
+	iy := int64(y0)
+	iz := int64(z0)
+
+	dot := func(cx, cy, cz int64, dx, dy, dz float64) float64 {
+		g := gradients3D[hashCoords(n.seed, cx, cy, cz)%12]
+		return g[0]*dx + g[1]*dy + g[2]*dz
+	}
+
+	u := fade(fx)
+	v := fade(fy)
+	w := fade(fz)
+
+	x00 := lerp(dot(ix, iy, iz, fx, fy, fz), dot(ix+1, iy, iz, fx-1, fy, fz), u)
+	x10 := lerp(dot(ix, iy+1, iz, fx, fy-1, fz), dot(ix+1, iy+1, iz, fx-1, fy-1, fz), u)
+	x01 := lerp(dot(ix, iy, iz+1, fx, fy, fz-1), dot(ix+1, iy, iz+1, fx-1, fy, fz-1), u)
+	x11 := lerp(dot(ix, iy+1, iz+1, fx, fy-1, fz-1), dot(ix+1, iy+1, iz+1, fx-1, fy-1, fz-1), u)
+
+	return lerp(lerp(x00, x10, v), lerp(x01, x11, v), w)
+}
+
+// Fractal2D sums octaves of 2D noise and normalises the result to roughly [-1, 1]
+func (n *Noise) Fractal2D(x, z float64, octaves int, lacunarity, persistence float64) float64 {
+	sum, amplitude, frequency, total := 0.0, 1.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		sum += n.Noise2D(x*frequency+float64(i)*31.7, z*frequency-float64(i)*17.3) * amplitude
+		total += amplitude
+		amplitude *= persistence
+		frequency *= lacunarity
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// Fractal3D sums octaves of 3D noise and normalises the result to roughly [-1, 1]
+func (n *Noise) Fractal3D(x, y, z float64, octaves int, lacunarity, persistence float64) float64 {
+	sum, amplitude, frequency, total := 0.0, 1.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		offset := float64(i) * 23.9
+		sum += n.Noise3D(x*frequency+offset, y*frequency-offset, z*frequency+offset) * amplitude
+		total += amplitude
+		amplitude *= persistence
+		frequency *= lacunarity
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
@@ -0,0 +1,107 @@
+package worldgen
+
+import (
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// OreConfig describes one kind of ore vein
+type OreConfig struct {
+	Type voxel.VoxelType
+
+	// Replace is the only voxel type a vein may overwrite
+	Replace voxel.VoxelType
+
+	// MinY and MaxY bound the world heights veins start at
+	MinY int32
+	MaxY int32
+
+	// VeinSize is the number of random-walk steps per vein (at most ChunkSize)
+	VeinSize int
+
+	// Frequency is the average number of veins started per chunk
+	Frequency float64
+}
+
+// DefaultOreConfigs returns coal, iron and gold veins at increasing depths
+func DefaultOreConfigs() []OreConfig {
+	return []OreConfig{
+		{Type: voxel.VoxelTypeCoalOre, Replace: voxel.VoxelTypeStone, MinY: -64, MaxY: 48, VeinSize: 12, Frequency: 6},
+		{Type: voxel.VoxelTypeIronOre, Replace: voxel.VoxelTypeStone, MinY: -96, MaxY: 24, VeinSize: 8, Frequency: 4},
+		{Type: voxel.VoxelTypeGoldOre, Replace: voxel.VoxelTypeStone, MinY: -160, MaxY: -16, VeinSize: 6, Frequency: 1.5},
+	}
+}
+
+// OreStage scatters ore veins. Veins are random walks that start in an origin
+// chunk and may spill into its neighbours; each chunk replays the veins of
+// all adjacent origin chunks and keeps the voxels that fall inside it.
+type OreStage struct {
+	Ores []OreConfig
+}
+
+// NewOreStage creates an ore stage for the given vein configurations. The
+// configurations are copied, so clamping VeinSize leaves the caller's intact.
+func NewOreStage(ores ...OreConfig) *OreStage {
+	ores = append([]OreConfig(nil), ores...)
+	for i := range ores {
+		if ores[i].VeinSize > chunk.ChunkSize {
+			ores[i].VeinSize = chunk.ChunkSize
+		}
+	}
+	return &OreStage{Ores: ores}
+}
+
+func (ost *OreStage) Generate(c *chunk.Chunk, seed int64) {
+	if c.IsEmpty() {
+		return
+	}
+
+	chunkMin := c.GetWorldPosition()
+
+	for dx := int32(-1); dx <= 1; dx++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for dz := int32(-1); dz <= 1; dz++ {
+				origin := c.Position.Add(chunk.NewChunkPosition(dx, dy, dz))
+				ost.placeVeinsFrom(c, chunkMin, origin, seed)
+			}
+		}
+	}
+}
+
+func (ost *OreStage) placeVeinsFrom(c *chunk.Chunk, chunkMin voxel.VoxelPosition, origin chunk.ChunkPosition, seed int64) {
+	rng := chunkRandom(seed, origin, saltOres)
+	originWorld := origin.GetWorldPosition()
+
+	for _, ore := range ost.Ores {
+		count := int(ore.Frequency)
+		if rng.Float64() < ore.Frequency-float64(count) {
+			count++
+		}
+
+		for vein := 0; vein < count; vein++ {
+			x := originWorld.X + rng.Int31n(chunk.ChunkSize)
+			y := originWorld.Y + rng.Int31n(chunk.ChunkSize)
+			z := originWorld.Z + rng.Int31n(chunk.ChunkSize)
+			walkSeed := rng.Int63()
+
+			if y < ore.MinY || y > ore.MaxY {
+				continue
+			}
+
+			walk := chunkRandom(walkSeed, origin, saltOres)
+			for step := 0; step < ore.VeinSize; step++ {
+				lx, ly, lz := x-chunkMin.X, y-chunkMin.Y, z-chunkMin.Z
+				if lx >= 0 && ly >= 0 && lz >= 0 && lx < chunk.ChunkSize && ly < chunk.ChunkSize && lz < chunk.ChunkSize {
+					if c.GetVoxel(lx, ly, lz).Type == ore.Replace {
+						c.SetVoxel(lx, ly, lz, voxel.NewVoxel(ore.Type))
+					}
+				}
+
+				offset := voxel.GetFaceOffset(voxel.VoxelFace(walk.Intn(6)))
+				x += offset.X
+				y += offset.Y
+				z += offset.Z
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package worldgen
+
+import (
+	"Ceres/pkg/chunk"
+)
+
+// Salts keep the random streams of different stages independent
+const (
+	saltWorms int64 = iota + 1
+	saltOres
+	saltDecoration
+)
+
+// mix64 is the SplitMix64 finaliser
+func mix64(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
+
+// hashCoords hashes a seed and integer lattice coordinates to a uint64
+func hashCoords(seed, x, y, z int64) uint64 {
+	h := mix64(uint64(seed))
+	h = mix64(h ^ uint64(x)*0x9e3779b97f4a7c15)
+	h = mix64(h ^ uint64(y)*0xc2b2ae3d27d4eb4f)
+	h = mix64(h ^ uint64(z)*0x165667b19e3779f9)
+	return h
+}
+
+// random is a small SplitMix64 generator. Generation stages create many
+// short-lived streams, which would be costly with math/rand sources.
+type random struct {
+	state uint64
+}
+
+// chunkRandom returns a random stream that depends only on the world seed,
+// a chunk position and a stage salt. Stages use it so that the content a
+// chunk contributes never depends on the order chunks are generated in.
+func chunkRandom(seed int64, pos chunk.ChunkPosition, salt int64) *random {
+	return &random{state: hashCoords(seed^salt*0x5851f42d4c957f2d, int64(pos.X), int64(pos.Y), int64(pos.Z))}
+}
+
+func (r *random) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	return mix64(r.state)
+}
+
+// Int63 returns a non-negative pseudo-random int64
+func (r *random) Int63() int64 {
+	return int64(r.Uint64() >> 1)
+}
+
+// Intn returns a pseudo-random int in [0, n); n must be positive
+func (r *random) Intn(n int) int {
+	return int(r.Uint64() % uint64(n))
+}
+
+// Int31n returns a pseudo-random int32 in [0, n); n must be positive
+func (r *random) Int31n(n int32) int32 {
+	return int32(r.Uint64() % uint64(n))
+}
+
+// Float64 returns a pseudo-random float64 in [0, 1)
+func (r *random) Float64() float64 {
+	return float64(r.Uint64()>>11) / (1 << 53)
+}
@@ -0,0 +1,132 @@
+package worldgen
+
+import (
+	"fmt"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/vox"
+	"Ceres/pkg/voxel"
+)
+
+// TemplateVoxel is one solid voxel of a template, relative to its minimum corner
+type TemplateVoxel struct {
+	Offset voxel.VoxelPosition
+	Type   voxel.VoxelType
+}
+
+// Template is a saved structure that features can stamp into the world.
+// Air is not stored, so placing a template never clears existing blocks.
+type Template struct {
+	Size   voxel.VoxelPosition
+	Voxels []TemplateVoxel
+}
+
+// CaptureTemplate copies the solid voxels of the inclusive region [min, max].
+// Chunks that are not loaded count as air.
+func CaptureTemplate(cm *chunk.ChunkManager, min, max voxel.VoxelPosition) *Template {
+	t := &Template{Size: max.Sub(min).Add(voxel.NewVoxelPosition(1, 1, 1))}
+
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			for z := min.Z; z <= max.Z; z++ {
+				pos := voxel.NewVoxelPosition(x, y, z)
+				c := cm.GetChunkIfExists(chunk.VoxelToChunkPosition(pos))
+				if c == nil {
+					continue
+				}
+
+				lx, ly, lz := chunk.VoxelToLocalPosition(pos)
+				if v := c.GetVoxel(lx, ly, lz); !v.IsAir() {
+					t.Voxels = append(t.Voxels, TemplateVoxel{Offset: pos.Sub(min), Type: v.Type})
+				}
+			}
+		}
+	}
+
+	return t
+}
+
+// RotatedSize returns the template's size after rotating it by rotation
+// quarter turns around the Y axis
+func (t *Template) RotatedSize(rotation int) voxel.VoxelPosition {
+	if rotation%2 != 0 {
+		return voxel.NewVoxelPosition(t.Size.Z, t.Size.Y, t.Size.X)
+	}
+	return t.Size
+}
+
+// rotateOffset rotates an offset by quarter turns around the Y axis, keeping
+// it inside the rotated bounds
+func (t *Template) rotateOffset(offset voxel.VoxelPosition, rotation int) voxel.VoxelPosition {
+	switch rotation & 3 {
+	case 1:
+		return voxel.NewVoxelPosition(t.Size.Z-1-offset.Z, offset.Y, offset.X)
+	case 2:
+		return voxel.NewVoxelPosition(t.Size.X-1-offset.X, offset.Y, t.Size.Z-1-offset.Z)
+	case 3:
+		return voxel.NewVoxelPosition(offset.Z, offset.Y, t.Size.X-1-offset.X)
+	default:
+		return offset
+	}
+}
+
+// Place writes the template with its minimum corner at origin, rotated by
+// rotation quarter turns around the Y axis
+func (t *Template) Place(w VoxelWriter, origin voxel.VoxelPosition, rotation int) {
+	for _, tv := range t.Voxels {
+		w.SetVoxel(origin.Add(t.rotateOffset(tv.Offset, rotation)), voxel.NewVoxel(tv.Type))
+	}
+}
+
+// TemplateFromVox converts a MagicaVoxel scene into a template
+func TemplateFromVox(file *vox.VoxFile) *Template {
+	cm := chunk.NewChunkManager()
+	if vox.Import(cm, file, vox.ImportOptions{}) == 0 {
+		return &Template{}
+	}
+
+	var lo, hi voxel.VoxelPosition
+	first := true
+	for _, c := range cm.GetLoadedChunks() {
+		forEachLocal(c, func(x, y, z int32, world voxel.VoxelPosition) {
+			if c.GetVoxel(x, y, z).IsAir() {
+				return
+			}
+			if first {
+				lo, hi, first = world, world, false
+				return
+			}
+			lo = voxel.NewVoxelPosition(min(lo.X, world.X), min(lo.Y, world.Y), min(lo.Z, world.Z))
+			hi = voxel.NewVoxelPosition(max(hi.X, world.X), max(hi.Y, world.Y), max(hi.Z, world.Z))
+		})
+	}
+
+	return CaptureTemplate(cm, lo, hi)
+}
+
+// ToVox converts the template into a MagicaVoxel scene
+func (t *Template) ToVox() *vox.VoxFile {
+	cm := chunk.NewChunkManager()
+	t.Place(cm, voxel.NewVoxelPosition(0, 0, 0), 0)
+
+	return vox.Export(cm, voxel.NewVoxelPosition(0, 0, 0), t.Size.Sub(voxel.NewVoxelPosition(1, 1, 1)))
+}
+
+// LoadTemplate loads a template saved as a .vox file
+func LoadTemplate(path string) (*Template, error) {
+	file, err := vox.LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+
+	return TemplateFromVox(file), nil
+}
+
+// SaveTemplate saves a template as a .vox file
+func SaveTemplate(path string, t *Template) error {
+	if err := vox.SaveFile(path, t.ToVox()); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package worldgen
+
+import (
+	"math"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+// TerrainStage fills chunks from a 2D noise height map with stone, a dirt
+// layer, a grass surface and water up to sea level. When Biomes is set, the
+// height profile and surface blocks come from the biome map instead.
+type TerrainStage struct {
+	BaseHeight  float64
+	Amplitude   float64
+	Scale       float64
+	Octaves     int
+	DirtDepth   int32
+	WaterLevel  int32
+	SurfaceType voxel.VoxelType
+
+	Biomes *BiomeMap
+}
+
+// NewTerrainStage creates a terrain stage with gentle rolling hills
+func NewTerrainStage() *TerrainStage {
+	return &TerrainStage{
+		BaseHeight:  32,
+		Amplitude:   16,
+		Scale:       1.0 / 96.0,
+		Octaves:     4,
+		DirtDepth:   3,
+		WaterLevel:  28,
+		SurfaceType: voxel.VoxelTypeGrass,
+	}
+}
+
+// NewBiomeTerrainStage creates a terrain stage driven by a biome map
+func NewBiomeTerrainStage(biomes *BiomeMap) *TerrainStage {
+	ts := NewTerrainStage()
+	ts.Biomes = biomes
+	return ts
+}
+
+// HeightAt returns the terrain surface height for a world column
+func (ts *TerrainStage) HeightAt(seed int64, x, z int32) int32 {
+	noise := NewNoise(seed)
+	h := noise.Fractal2D(float64(x)*ts.Scale, float64(z)*ts.Scale, ts.Octaves, 2.0, 0.5)
+
+	baseHeight, amplitude := ts.BaseHeight, ts.Amplitude
+	if ts.Biomes != nil {
+		baseHeight, amplitude = ts.Biomes.HeightProfile(x, z)
+	}
+
+	return int32(math.Floor(baseHeight + h*amplitude))
+}
+
+// surfaceAt returns the surface block, filler block and filler depth of a column
+func (ts *TerrainStage) surfaceAt(x, z int32) (surface, filler voxel.VoxelType, depth int32) {
+	if ts.Biomes == nil {
+		return ts.SurfaceType, voxel.VoxelTypeDirt, ts.DirtDepth
+	}
+
+	biome := ts.Biomes.BiomeAt(voxel.NewVoxelPosition(x, 0, z))
+	return biome.SurfaceType, biome.FillerType, biome.FillerDepth
+}
+
+func (ts *TerrainStage) Generate(c *chunk.Chunk, seed int64) {
+	origin := c.GetWorldPosition()
+
+	for x := int32(0); x < chunk.ChunkSize; x++ {
+		for z := int32(0); z < chunk.ChunkSize; z++ {
+			height := ts.HeightAt(seed, origin.X+x, origin.Z+z)
+			surface, filler, depth := ts.surfaceAt(origin.X+x, origin.Z+z)
+
+			for y := int32(0); y < chunk.ChunkSize; y++ {
+				worldY := origin.Y + y
+
+				var voxelType voxel.VoxelType
+				switch {
+				case worldY < height-depth:
+					voxelType = voxel.VoxelTypeStone
+				case worldY < height:
+					voxelType = filler
+				case worldY == height:
+					voxelType = surface
+					if height < ts.WaterLevel {
+						voxelType = voxel.VoxelTypeSand
+					}
+				case worldY <= ts.WaterLevel:
+					voxelType = voxel.VoxelTypeWater
+				default:
+					continue
+				}
+
+				c.SetVoxel(x, y, z, voxel.NewVoxel(voxelType))
+			}
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package worldgen
+
+import (
+	"testing"
+
+	"Ceres/pkg/chunk"
+	"Ceres/pkg/voxel"
+)
+
+type fillStage struct {
+	voxelType voxel.VoxelType
+}
+
+func (fs fillStage) Generate(c *chunk.Chunk, seed int64) {
+	c.Fill(fs.voxelType)
+}
+
+func sameChunks(t *testing.T, a, b *chunk.ChunkManager, min, max chunk.ChunkPosition) {
+	t.Helper()
+	for cx := min.X; cx <= max.X; cx++ {
+		for cy := min.Y; cy <= max.Y; cy++ {
+			for cz := min.Z; cz <= max.Z; cz++ {
+				pos := chunk.NewChunkPosition(cx, cy, cz)
+				ca, cb := a.GetChunkIfExists(pos), b.GetChunkIfExists(pos)
+				for i := int32(0); i < chunk.ChunkSize*chunk.ChunkSize*chunk.ChunkSize; i++ {
+					x, y, z := i%chunk.ChunkSize, (i/chunk.ChunkSize)%chunk.ChunkSize, i/(chunk.ChunkSize*chunk.ChunkSize)
+					if va, vb := ca.GetVoxel(x, y, z), cb.GetVoxel(x, y, z); va != vb {
+						t.Fatalf("Chunk %v differs at (%d, %d, %d): %s vs %s", pos, x, y, z, va.GetName(), vb.GetName())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestNoiseDeterministicAndBounded(t *testing.T) {
+	a := NewNoise(42)
+	b := NewNoise(42)
+	c := NewNoise(43)
+
+	differs := false
+	for i := 0; i < 1000; i++ {
+		x, y, z := float64(i)*0.37, float64(i)*-0.21, float64(i)*0.113
+		na := a.Noise3D(x, y, z)
+		if na != b.Noise3D(x, y, z) {
+			t.Fatal("Expected identical noise for identical seeds")
+		}
+		if na < -1.01 || na > 1.01 {
+			t.Fatalf("Noise value %f out of range", na)
+		}
+		if n2 := a.Noise2D(x, z); n2 < -1.01 || n2 > 1.01 {
+			t.Fatalf("2D noise value %f out of range", n2)
+		}
+		if na != c.Noise3D(x, y, z) {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("Expected different seeds to produce different noise")
+	}
+}
+
+func TestGenerationIsOrderIndependent(t *testing.T) {
+	worms := NewWormCaveStage()
+	worms.WormsPerChunk = 2
+	gen := NewGenerator(1234, NewTerrainStage(), NewOreStage(DefaultOreConfigs()...), NewNoiseCaveStage(), worms)
+
+	min := chunk.NewChunkPosition(-1, 0, -1)
+	max := chunk.NewChunkPosition(1, 1, 1)
+
+	forward := chunk.NewChunkManager()
+	gen.GenerateRegion(forward, min, max)
+
+	reverse := chunk.NewChunkManager()
+	for x := max.X; x >= min.X; x-- {
+		for y := max.Y; y >= min.Y; y-- {
+			for z := max.Z; z >= min.Z; z-- {
+				gen.GenerateChunk(reverse, chunk.NewChunkPosition(x, y, z))
+			}
+		}
+	}
+
+	sameChunks(t, forward, reverse, min, max)
+}
+
+func TestWormTunnelsCrossChunkBoundaries(t *testing.T) {
+	worms := NewWormCaveStage()
+	worms.WormsPerChunk = 3
+	gen := NewGenerator(99, fillStage{voxel.VoxelTypeStone}, worms)
+
+	cm := chunk.NewChunkManager()
+	left := gen.GenerateChunk(cm, chunk.NewChunkPosition(0, 0, 0))
+	right := gen.GenerateChunk(cm, chunk.NewChunkPosition(1, 0, 0))
+
+	crossings := 0
+	for y := int32(0); y < chunk.ChunkSize; y++ {
+		for z := int32(0); z < chunk.ChunkSize; z++ {
+			if left.GetVoxel(chunk.ChunkSize-1, y, z).IsAir() && right.GetVoxel(0, y, z).IsAir() {
+				crossings++
+			}
+		}
+	}
+
+	if crossings == 0 {
+		t.Error("Expected at least one tunnel to continue across the chunk boundary")
+	}
+}
+
+func TestOreStageRespectsDepthRange(t *testing.T) {
+	ore := OreConfig{
+		Type:      voxel.VoxelTypeGoldOre,
+		Replace:   voxel.VoxelTypeStone,
+		MinY:      -40,
+		MaxY:      -20,
+		VeinSize:  5,
+		Frequency: 20,
+	}
+	gen := NewGenerator(7, fillStage{voxel.VoxelTypeStone}, NewOreStage(ore))
+
+	cm := chunk.NewChunkManager()
+	gen.GenerateRegion(cm, chunk.NewChunkPosition(0, -3, 0), chunk.NewChunkPosition(0, 0, 0))
+
+	found := 0
+	for y := int32(-96); y < 32; y++ {
+		for x := int32(0); x < chunk.ChunkSize; x++ {
+			for z := int32(0); z < chunk.ChunkSize; z++ {
+				if cm.GetVoxel(voxel.NewVoxelPosition(x, y, z)).Type != ore.Type {
+					continue
+				}
+				found++
+				if y < ore.MinY-int32(ore.VeinSize) || y > ore.MaxY+int32(ore.VeinSize) {
+					t.Fatalf("Ore at y=%d outside its depth range", y)
+				}
+			}
+		}
+	}
+
+	if found == 0 {
+		t.Error("Expected ore veins to be placed")
+	}
+}
+
+func TestNewOreStageLeavesConfigsIntact(t *testing.T) {
+	ores := []OreConfig{{Type: voxel.VoxelTypeCoalOre, Replace: voxel.VoxelTypeStone, VeinSize: chunk.ChunkSize * 2}}
+	stage := NewOreStage(ores...)
+
+	if stage.Ores[0].VeinSize != chunk.ChunkSize {
+		t.Errorf("Expected VeinSize to be clamped to %d, got %d", chunk.ChunkSize, stage.Ores[0].VeinSize)
+	}
+	if ores[0].VeinSize != chunk.ChunkSize*2 {
+		t.Errorf("Expected the caller's VeinSize to stay %d, got %d", chunk.ChunkSize*2, ores[0].VeinSize)
+	}
+}
+
+func TestNoiseCaveThreshold(t *testing.T) {
+	carveAll := NewNoiseCaveStage()
+	carveAll.Threshold = -2
+	carveAll.MinY, carveAll.MaxY = 0, 15
+
+	cm := chunk.NewChunkManager()
+	c := NewGenerator(5, fillStage{voxel.VoxelTypeStone}, carveAll).GenerateChunk(cm, chunk.NewChunkPosition(0, 0, 0))
+
+	if !c.GetVoxel(3, 15, 3).IsAir() {
+		t.Error("Expected voxels within the cave range to be carved")
+	}
+	if c.GetVoxel(3, 16, 3).Type != voxel.VoxelTypeStone {
+		t.Error("Expected voxels above MaxY to stay solid")
+	}
+
+	carveNone := NewNoiseCaveStage()
+	carveNone.Threshold = 2
+	c = NewGenerator(5, fillStage{voxel.VoxelTypeStone}, carveNone).GenerateChunk(chunk.NewChunkManager(), chunk.NewChunkPosition(0, 0, 0))
+	if c.GetVoxel(3, 3, 3).IsAir() {
+		t.Error("Expected no voxels to be carved above the noise range")
+	}
+}
+
+func TestBiomeMapSelectsSeveralBiomes(t *testing.T) {
+	a := NewBiomeMap(321)
+	b := NewBiomeMap(321)
+
+	seen := make(map[string]bool)
+	for x := int32(-20000); x <= 20000; x += 97 {
+		pos := voxel.NewVoxelPosition(x, 0, x/3)
+		biome := a.BiomeAt(pos)
+		if biome.Name != b.BiomeAt(pos).Name {
+			t.Fatal("Expected identical biomes for identical seeds")
+		}
+		seen[biome.Name] = true
+	}
+
+	if len(seen) < 3 {
+		t.Errorf("Expected at least 3 biomes, got %d", len(seen))
+	}
+}
+
+func TestBiomeHeightIsContinuous(t *testing.T) {
+	terrain := NewBiomeTerrainStage(NewBiomeMap(77))
+
+	previous := terrain.HeightAt(77, -3000, 500)
+	for x := int32(-2999); x <= 3000; x++ {
+		height := terrain.HeightAt(77, x, 500)
+		if diff := height - previous; diff > 4 || diff < -4 {
+			t.Fatalf("Expected smooth terrain, got a step of %d at x=%d", diff, x)
+		}
+		previous = height
+	}
+}
+
+type constantTint [3]float32
+
+func (ct constantTint) TintAt(pos voxel.VoxelPosition) [3]float32 {
+	return ct
+}
+
+func TestTintSourceColoursTintedTypes(t *testing.T) {
+	cm := chunk.NewChunkManager()
+	cm.SetTintSource(constantTint{0.5, 1.0, 0.5})
+	cm.SetVoxel(voxel.NewVoxelPosition(0, 0, 0), voxel.NewVoxel(voxel.VoxelTypeGrass))
+	cm.SetVoxel(voxel.NewVoxelPosition(5, 0, 0), voxel.NewVoxel(voxel.VoxelTypeStone))
+
+	grass, _ := voxel.GetVoxelProperties(voxel.VoxelTypeGrass)
+	stone, _ := voxel.GetVoxelProperties(voxel.VoxelTypeStone)
+
+	for _, greedy := range []bool{false, true} {
+		mesh := cm.GetChunk(chunk.NewChunkPosition(0, 0, 0)).BuildMesh(greedy)
+		for i := 0; i < len(mesh.Vertices); i += 11 {
+			color := [3]float32{mesh.Vertices[i+8], mesh.Vertices[i+9], mesh.Vertices[i+10]}
+			expected := stone.Color
+			if mesh.Vertices[i] < 2 {
+				expected = [3]float32{grass.Color[0] * 0.5, grass.Color[1], grass.Color[2] * 0.5}
+			}
+			if color != expected {
+				t.Fatalf("Expected colour %v, got %v (greedy=%v)", expected, color, greedy)
+			}
+		}
+	}
+}
+
+// columnDecorator places a single tall column at a fixed position of one chunk
+type columnDecorator struct {
+	origin voxel.VoxelPosition
+	height int32
+}
+
+func (cd columnDecorator) Decorate(c *chunk.Chunk, seed int64, w VoxelWriter) {
+	if chunk.VoxelToChunkPosition(cd.origin) != c.Position {
+		return
+	}
+	for y := int32(0); y < cd.height; y++ {
+		w.SetVoxel(cd.origin.Add(voxel.NewVoxelPosition(0, y, 0)), voxel.NewVoxel(voxel.VoxelTypeWood))
+	}
+}
+
+func TestDecorationWritesArePendingUntilGenerated(t *testing.T) {
+	gen := NewGenerator(1)
+	gen.Decorators = []Decorator{columnDecorator{origin: voxel.NewVoxelPosition(4, 28, 4), height: 10}}
+
+	cm := chunk.NewChunkManager()
+	gen.GenerateChunk(cm, chunk.NewChunkPosition(0, 0, 0))
+
+	above := chunk.NewChunkPosition(0, 1, 0)
+	if cm.GetChunkIfExists(above) != nil {
+		t.Fatal("Expected the chunk above not to be created by the decoration")
+	}
+	if cm.PendingWriteCount() != 6 {
+		t.Errorf("Expected 6 pending writes, got %d", cm.PendingWriteCount())
+	}
+
+	gen.GenerateChunk(cm, above)
+	if cm.PendingWriteCount() != 0 {
+		t.Errorf("Expected no pending writes, got %d", cm.PendingWriteCount())
+	}
+	if cm.GetVoxel(voxel.NewVoxelPosition(4, 37, 4)).Type != voxel.VoxelTypeWood {
+		t.Error("Expected the pending write to be applied on generation")
+	}
+}
+
+func TestDecorationIsOrderIndependent(t *testing.T) {
+	terrain := NewTerrainStage()
+	terrain.BaseHeight = 20
+	terrain.Amplitude = 4
+	terrain.WaterLevel = -100
+
+	templateWorld := chunk.NewChunkManager()
+	templateWorld.SetVoxel(voxel.NewVoxelPosition(0, 0, 0), voxel.NewVoxel(voxel.VoxelTypeBrick))
+	templateWorld.SetVoxel(voxel.NewVoxelPosition(2, 1, 0), voxel.NewVoxel(voxel.VoxelTypeGlass))
+
+	gen := NewGenerator(2024, terrain)
+	gen.Decorators = []Decorator{NewDecorationStage(nil,
+		Decoration{Feature: NewOakTreeFeature(), Ground: []voxel.VoxelType{voxel.VoxelTypeGrass}, Density: 0.02},
+		Decoration{Feature: NewBoulderFeature(), Density: 0.01},
+		Decoration{Feature: &TemplateFeature{Template: CaptureTemplate(templateWorld, voxel.NewVoxelPosition(0, 0, 0), voxel.NewVoxelPosition(2, 1, 0))}, Density: 0.005},
+	)}
+
+	min := chunk.NewChunkPosition(-1, 0, -1)
+	max := chunk.NewChunkPosition(1, 1, 1)
+
+	forward := chunk.NewChunkManager()
+	gen.GenerateRegion(forward, min, max)
+
+	reverse := chunk.NewChunkManager()
+	for x := max.X; x >= min.X; x-- {
+		for y := max.Y; y >= min.Y; y-- {
+			for z := max.Z; z >= min.Z; z-- {
+				gen.GenerateChunk(reverse, chunk.NewChunkPosition(x, y, z))
+			}
+		}
+	}
+
+	sameChunks(t, forward, reverse, min, max)
+
+	leaves := 0
+	for _, c := range forward.GetLoadedChunks() {
+		forEachLocal(c, func(x, y, z int32, world voxel.VoxelPosition) {
+			if c.GetVoxel(x, y, z).Type == voxel.VoxelTypeLeaves {
+				leaves++
+			}
+		})
+	}
+	if leaves == 0 {
+		t.Error("Expected trees to be placed")
+	}
+}
+
+func TestTemplateRoundTripAndRotation(t *testing.T) {
+	cm := chunk.NewChunkManager()
+	cm.SetVoxel(voxel.NewVoxelPosition(10, 5, 10), voxel.NewVoxel(voxel.VoxelTypeBrick))
+	cm.SetVoxel(voxel.NewVoxelPosition(13, 6, 11), voxel.NewVoxel(voxel.VoxelTypeGlass))
+
+	template := CaptureTemplate(cm, voxel.NewVoxelPosition(10, 5, 10), voxel.NewVoxelPosition(13, 6, 11))
+	if template.Size != voxel.NewVoxelPosition(4, 2, 2) || len(template.Voxels) != 2 {
+		t.Fatalf("Expected a 4x2x2 template with 2 voxels, got %v with %d", template.Size, len(template.Voxels))
+	}
+
+	path := t.TempDir() + "/template.vox"
+	if err := SaveTemplate(path, template); err != nil {
+		t.Fatalf("Failed to save template: %v", err)
+	}
+	loaded, err := LoadTemplate(path)
+	if err != nil {
+		t.Fatalf("Failed to load template: %v", err)
+	}
+	if loaded.Size != template.Size || len(loaded.Voxels) != len(template.Voxels) {
+		t.Errorf("Expected %v with %d voxels, got %v with %d", template.Size, len(template.Voxels), loaded.Size, len(loaded.Voxels))
+	}
+
+	for rotation := 0; rotation < 4; rotation++ {
+		world := chunk.NewChunkManager()
+		template.Place(world, voxel.NewVoxelPosition(0, 0, 0), rotation)
+
+		size := template.RotatedSize(rotation)
+		placed := CaptureTemplate(world, voxel.NewVoxelPosition(0, 0, 0), size.Sub(voxel.NewVoxelPosition(1, 1, 1)))
+		if len(placed.Voxels) != 2 {
+			t.Errorf("Expected rotation %d to stay within %v, got %d voxels inside", rotation, size, len(placed.Voxels))
+		}
+	}
+}
+
+// pointDecorator writes a voxel from the chunk source, wherever it lands
+type pointDecorator struct {
+	source chunk.ChunkPosition
+	pos    voxel.VoxelPosition
+	v      voxel.Voxel
+}
+
+func (pd pointDecorator) Decorate(c *chunk.Chunk, seed int64, w VoxelWriter) {
+	if c.Position == pd.source {
+		w.SetVoxel(pd.pos, pd.v)
+	}
+}
+
+func TestDecorationTiesIgnoreGenerationOrder(t *testing.T) {
+	a, b, target := chunk.NewChunkPosition(0, 0, 0), chunk.NewChunkPosition(2, 0, 0), chunk.NewChunkPosition(1, 0, 0)
+	pos := voxel.NewVoxelPosition(chunk.ChunkSize+4, 5, 4)
+
+	gen := NewGenerator(1)
+	gen.Decorators = []Decorator{
+		pointDecorator{source: a, pos: pos, v: voxel.NewVoxel(voxel.VoxelTypeGlass)},
+		pointDecorator{source: b, pos: pos, v: voxel.NewVoxel(voxel.VoxelTypeBrick)},
+	}
+
+	for _, order := range [][]chunk.ChunkPosition{{a, b, target}, {b, a, target}, {target, a, b}, {target, b, a}, {a, target, b}} {
+		cm := chunk.NewChunkManager()
+		for _, chunkPos := range order {
+			gen.GenerateChunk(cm, chunkPos)
+		}
+		if got := cm.GetVoxel(pos).Type; got != voxel.VoxelTypeBrick {
+			t.Errorf("Expected the higher type to win generating %v, got %s", order, cm.GetVoxel(pos).GetName())
+		}
+	}
+
+	// Natural blocks are never replaced by other features
+	gen.Decorators = []Decorator{
+		pointDecorator{source: a, pos: pos, v: voxel.NewVoxel(voxel.VoxelTypeStone)},
+		pointDecorator{source: b, pos: pos, v: voxel.NewVoxel(voxel.VoxelTypeBrick)},
+	}
+	for _, order := range [][]chunk.ChunkPosition{{a, b, target}, {b, a, target}} {
+		cm := chunk.NewChunkManager()
+		for _, chunkPos := range order {
+			gen.GenerateChunk(cm, chunkPos)
+		}
+		if got := cm.GetVoxel(pos).Type; got != voxel.VoxelTypeStone {
+			t.Errorf("Expected stone to stay generating %v, got %s", order, cm.GetVoxel(pos).GetName())
+		}
+	}
+}